@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/config"
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+)
+
+// migrate moves data written by older versions of the API into its current shape.
+// Every step is idempotent, so it is safe to run on each deploy.
+func main() {
+	viperConfig, err := config.NewViper()
+	if err != nil {
+		log.Fatalf("Failed to initialize viper config: %v", err)
+	}
+	logger := config.NewLogger(viperConfig)
+	mongo_1 := config.NewMongoDatabase(viperConfig, "MONGODB_URI_1")
+	validate := config.NewValidator()
+	ctx := context.Background()
+
+	userRepository := repository.NewUserRepository(mongo_1)
+	scoreRepository := repository.NewScoreRepository(mongo_1)
+	submissionRepository := repository.NewSubmissionRepository(mongo_1)
+	assignmentRepository := repository.NewAssignmentRepository(mongo_1)
+	scoreLedgerRepository := repository.NewScoreLedgerRepository(mongo_1)
+	extensionRepository := repository.NewExtensionRepository(mongo_1)
+	cohortRepository := repository.NewCohortRepository(mongo_1)
+	cohortMemberRepository := repository.NewCohortMemberRepository(mongo_1)
+	if err = scoreRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create score indexes: %v", err)
+	}
+	if err = submissionRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create submission indexes: %v", err)
+	}
+	if err = scoreLedgerRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create score ledger indexes: %v", err)
+	}
+	if err = cohortRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create cohort indexes: %v", err)
+	}
+	if err = cohortMemberRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatalf("Failed to create cohort member indexes: %v", err)
+	}
+
+	scoreUseCase := usecase.NewScoreUseCase(logger, validate, scoreRepository, scoreLedgerRepository, submissionRepository, assignmentRepository, nil, nil, nil, nil, nil, nil, userRepository, nil, nil)
+	migrated, err := scoreUseCase.MigrateLegacyScores(ctx)
+	if err != nil {
+		log.Fatalf("Failed to migrate legacy scores: %v", err)
+	}
+	log.Printf("Migrated %d legacy scores", migrated)
+
+	rawScores, err := submissionRepository.BackfillRawScores(ctx)
+	if err != nil {
+		log.Fatalf("Failed to backfill raw submission scores: %v", err)
+	}
+	log.Printf("Set raw score on %d existing submissions", rawScores)
+
+	backfilled, err := scoreUseCase.BackfillLedger(ctx)
+	if err != nil {
+		log.Fatalf("Failed to backfill score ledger: %v", err)
+	}
+	log.Printf("Opened ledger for %d existing scores", backfilled)
+
+	if err = scoreLedgerRepository.RebuildProjections(ctx); err != nil {
+		log.Fatalf("Failed to rebuild score projections: %v", err)
+	}
+	if err = scoreRepository.RebuildTotals(ctx); err != nil {
+		log.Fatalf("Failed to rebuild leaderboard totals: %v", err)
+	}
+
+	cohortUseCase := usecase.NewCohortUseCase(logger, validate, cohortRepository, cohortMemberRepository, nil, nil, extensionRepository, userRepository, nil)
+	members, err := cohortUseCase.MigrateLegacyCohorts(ctx)
+	if err != nil {
+		log.Fatalf("Failed to migrate legacy cohorts: %v", err)
+	}
+	log.Printf("Added %d users to cohorts from their legacy cohort name", members)
+
+	// Roles are otherwise only given by admins, so the first one comes from here.
+	if email := viperConfig.GetString("ADMIN_EMAIL"); email != "" {
+		user, err := userRepository.FindByEmail(ctx, email)
+		if err != nil {
+			log.Fatalf("Failed to find admin user: %v", err)
+		}
+		if user == nil {
+			log.Fatalf("ADMIN_EMAIL %s has no account; register it first", email)
+		}
+		if user.GetRole() != entity.RoleAdmin {
+			if err = userRepository.UpdateRole(ctx, user.ID, entity.RoleAdmin); err != nil {
+				log.Fatalf("Failed to make %s an admin: %v", email, err)
+			}
+			log.Printf("Made %s an admin", email)
+		}
+	}
+}
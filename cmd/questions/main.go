@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/config"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+)
+
+// questions imports GIFT or QTI files into a question bank and exports banks
+// back out:
+//
+//	questions import -bank <id> -email <staff email> [-format gift|qti] <file>
+//	questions export -bank <id> [-format gift|qti] [-out <file>]
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "import" && os.Args[1] != "export") {
+		log.Fatalf("Usage: questions import|export -bank <id> ...")
+	}
+	flags := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	bankID := flags.String("bank", "", "question bank id")
+	email := flags.String("email", "", "email of the staff member the imported questions are created by")
+	format := flags.String("format", "", "gift or qti; import works it out from the file when empty")
+	out := flags.String("out", "", "file to export to instead of standard output")
+	_ = flags.Parse(os.Args[2:])
+
+	viperConfig, err := config.NewViper()
+	if err != nil {
+		log.Fatalf("Failed to initialize viper config: %v", err)
+	}
+	logger := config.NewLogger(viperConfig)
+	mongo_1 := config.NewMongoDatabase(viperConfig, "MONGODB_URI_1")
+	validate := config.NewValidator()
+	ctx := context.Background()
+
+	questionBankRepository := repository.NewQuestionBankRepository(mongo_1)
+	questionRepository := repository.NewQuestionRepository(mongo_1)
+	userRepository := repository.NewUserRepository(mongo_1)
+	quizUseCase := usecase.NewQuizUseCase(logger, validate, questionBankRepository, questionRepository, nil, nil, nil, nil, nil, nil, nil, nil, nil, userRepository, nil)
+
+	if os.Args[1] == "export" {
+		if *format == "" {
+			*format = "gift"
+		}
+		export, err := quizUseCase.ExportQuestions(ctx, &model.ExportQuestionsRequest{BankID: *bankID, Format: *format})
+		if err != nil {
+			log.Fatalf("Failed to export questions: %v", err)
+		}
+		var w io.Writer = os.Stdout
+		if *out != "" {
+			file, err := os.Create(*out)
+			if err != nil {
+				log.Fatalf("Failed to create %s: %v", *out, err)
+			}
+			defer file.Close()
+			w = file
+		}
+		if err = export.Write(w); err != nil {
+			log.Fatalf("Failed to write questions: %v", err)
+		}
+		log.Printf("Exported bank %s, skipped %d questions the format cannot hold", *bankID, export.Skipped)
+		return
+	}
+
+	if flags.NArg() != 1 {
+		log.Fatalf("Usage: questions import -bank <id> -email <staff email> [-format gift|qti] <file>")
+	}
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", flags.Arg(0), err)
+	}
+	response, err := quizUseCase.ImportQuestions(ctx, &model.ImportQuestionsRequest{
+		ActorEmail: *email,
+		BankID:     *bankID,
+		Format:     *format,
+		Data:       data,
+	})
+	if response != nil {
+		for _, question := range response.Questions {
+			if question.Status != model.QuestionImportImported {
+				fmt.Printf("#%d %s (%s): %s: %s\n", question.Index, question.Title, question.Kind, question.Status, question.Problem)
+			}
+		}
+	}
+	if err != nil {
+		log.Fatalf("Failed to import questions: %v", err)
+	}
+	log.Printf("Imported %d of %d %s questions, %d unsupported", response.Imported, response.Total, response.Format, response.Unsupported)
+}
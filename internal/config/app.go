@@ -1,10 +1,16 @@
 package config
 
 import (
+	"context"
+	"time"
+
 	"github.com/Erwanph/be-wan-central-lab/internal/delivery/http"
 	"github.com/Erwanph/be-wan-central-lab/internal/delivery/http/middleware"
 	"github.com/Erwanph/be-wan-central-lab/internal/delivery/http/route"
+	"github.com/Erwanph/be-wan-central-lab/internal/delivery/job"
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
 	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/runner"
 	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -23,20 +29,185 @@ type BootstrapConfig struct {
 
 func Bootstrap(config *BootstrapConfig) {
 	userRepository := repository.NewUserRepository(config.MongoDB1)
+	notificationRepository := repository.NewNotificationRepository(config.MongoDB1)
+	mailEventRepository := repository.NewMailEventRepository(config.MongoDB1)
+	assignmentRepository := repository.NewAssignmentRepository(config.MongoDB1)
+	scoreRepository := repository.NewScoreRepository(config.MongoDB1)
+	submissionRepository := repository.NewSubmissionRepository(config.MongoDB1)
+	scoreLedgerRepository := repository.NewScoreLedgerRepository(config.MongoDB1)
+	courseRepository := repository.NewCourseRepository(config.MongoDB1)
+	moduleRepository := repository.NewModuleRepository(config.MongoDB1)
+	enrollmentRepository := repository.NewEnrollmentRepository(config.MongoDB1)
+	progressRepository := repository.NewProgressRepository(config.MongoDB1)
+	extensionRepository := repository.NewExtensionRepository(config.MongoDB1)
+	cohortRepository := repository.NewCohortRepository(config.MongoDB1)
+	cohortMemberRepository := repository.NewCohortMemberRepository(config.MongoDB1)
+	questionBankRepository := repository.NewQuestionBankRepository(config.MongoDB1)
+	questionRepository := repository.NewQuestionRepository(config.MongoDB1)
+	quizRepository := repository.NewQuizRepository(config.MongoDB1)
+	quizAttemptRepository := repository.NewQuizAttemptRepository(config.MongoDB1)
+	examEventRepository := repository.NewExamEventRepository(config.MongoDB1)
+	codeGraderRepository := repository.NewCodeGraderRepository(config.MongoDB1)
+	codeSubmissionRepository := repository.NewCodeSubmissionRepository(config.MongoDB1)
+	clientKeyRepository := repository.NewClientKeyRepository(config.MongoDB1)
+	scoreNonceRepository := repository.NewScoreNonceRepository(config.MongoDB1)
+	rubricRepository := repository.NewRubricRepository(config.MongoDB1)
+	rubricGradeRepository := repository.NewRubricGradeRepository(config.MongoDB1)
+	regradeRequestRepository := repository.NewRegradeRequestRepository(config.MongoDB1)
+	peerReviewRepository := repository.NewPeerReviewRepository(config.MongoDB1)
+	peerSubmissionRepository := repository.NewPeerSubmissionRepository(config.MongoDB1)
+	peerReviewTaskRepository := repository.NewPeerReviewTaskRepository(config.MongoDB1)
+	peerResultRepository := repository.NewPeerResultRepository(config.MongoDB1)
+	similarityReportRepository := repository.NewSimilarityReportRepository(config.MongoDB1)
+	groupRepository := repository.NewGroupRepository(config.MongoDB1)
+	groupMemberRepository := repository.NewGroupMemberRepository(config.MongoDB1)
+	gradingSchemeRepository := repository.NewGradingSchemeRepository(config.MongoDB1)
+	courseGradeRepository := repository.NewCourseGradeRepository(config.MongoDB1)
+
+	// setup indexes
+	indexes := map[string]func(context.Context) error{
+		"users":              userRepository.EnsureIndexes,
+		"scores":             scoreRepository.EnsureIndexes,
+		"submissions":        submissionRepository.EnsureIndexes,
+		"score_ledger":       scoreLedgerRepository.EnsureIndexes,
+		"courses":            courseRepository.EnsureIndexes,
+		"modules":            moduleRepository.EnsureIndexes,
+		"enrollments":        enrollmentRepository.EnsureIndexes,
+		"progress":           progressRepository.EnsureIndexes,
+		"extensions":         extensionRepository.EnsureIndexes,
+		"cohorts":            cohortRepository.EnsureIndexes,
+		"cohort_members":     cohortMemberRepository.EnsureIndexes,
+		"questions":          questionRepository.EnsureIndexes,
+		"quizzes":            quizRepository.EnsureIndexes,
+		"quiz_attempts":      quizAttemptRepository.EnsureIndexes,
+		"exam_events":        examEventRepository.EnsureIndexes,
+		"code_graders":       codeGraderRepository.EnsureIndexes,
+		"code_submissions":   codeSubmissionRepository.EnsureIndexes,
+		"client_keys":        clientKeyRepository.EnsureIndexes,
+		"score_nonces":       scoreNonceRepository.EnsureIndexes,
+		"rubric_grades":      rubricGradeRepository.EnsureIndexes,
+		"regrade_requests":   regradeRequestRepository.EnsureIndexes,
+		"peer_reviews":       peerReviewRepository.EnsureIndexes,
+		"peer_submissions":   peerSubmissionRepository.EnsureIndexes,
+		"peer_review_tasks":  peerReviewTaskRepository.EnsureIndexes,
+		"peer_results":       peerResultRepository.EnsureIndexes,
+		"similarity_reports": similarityReportRepository.EnsureIndexes,
+		"groups":             groupRepository.EnsureIndexes,
+		"group_members":      groupMemberRepository.EnsureIndexes,
+		"grading_schemes":    gradingSchemeRepository.EnsureIndexes,
+		"course_grades":      courseGradeRepository.EnsureIndexes,
+	}
+	for collection, ensure := range indexes {
+		if err := ensure(context.Background()); err != nil {
+			config.Log.WithField("collection", collection).WithError(err).Error("Failed to create indexes")
+		}
+	}
 
 	// setup use cases
-	userUseCase := usecase.NewUserUseCase(config.Log, config.Validate, userRepository, config.Config)
+	mailUseCase := usecase.NewMailUseCase(config.Log, config.Validate, mailEventRepository, userRepository, config.Config)
+	notificationUseCase := usecase.NewNotificationUseCase(config.Log, config.Validate, notificationRepository, userRepository, mailUseCase, config.Config)
+	userUseCase := usecase.NewUserUseCase(config.Log, config.Validate, userRepository, mailEventRepository, scoreRepository, courseGradeRepository, cohortRepository, cohortMemberRepository, notificationUseCase, config.Config)
+	assignmentUseCase := usecase.NewAssignmentUseCase(config.Log, config.Validate, assignmentRepository, courseRepository, moduleRepository, extensionRepository, enrollmentRepository, cohortRepository, cohortMemberRepository, scoreRepository, userRepository, notificationUseCase)
+	leaderboardUseCase := usecase.NewLeaderboardUseCase(config.Log, config.Validate, scoreRepository, enrollmentRepository, cohortMemberRepository, userRepository)
+	progressUseCase := usecase.NewProgressUseCase(config.Log, config.Validate, progressRepository, courseRepository, moduleRepository, assignmentRepository, enrollmentRepository, cohortMemberRepository, userRepository)
+	scoreUseCase := usecase.NewScoreUseCase(config.Log, config.Validate, scoreRepository, scoreLedgerRepository, submissionRepository, assignmentRepository, courseRepository, extensionRepository, enrollmentRepository, cohortMemberRepository, groupRepository, groupMemberRepository, userRepository, notificationUseCase, progressUseCase)
+	courseUseCase := usecase.NewCourseUseCase(config.Log, config.Validate, courseRepository, moduleRepository, enrollmentRepository, cohortRepository, cohortMemberRepository, assignmentRepository, scoreRepository, userRepository, notificationUseCase)
+	gradebookUseCase := usecase.NewGradebookUseCase(config.Log, config.Validate, courseRepository, moduleRepository, assignmentRepository, enrollmentRepository, cohortMemberRepository, userRepository)
+	quizUseCase := usecase.NewQuizUseCase(config.Log, config.Validate, questionBankRepository, questionRepository, quizRepository, quizAttemptRepository, examEventRepository, assignmentRepository, courseRepository, enrollmentRepository, submissionRepository, cohortRepository, cohortMemberRepository, userRepository, scoreUseCase)
+	codeGradingUseCase := usecase.NewCodeGradingUseCase(config.Log, config.Validate, codeGraderRepository, codeSubmissionRepository, assignmentRepository, courseRepository, enrollmentRepository, submissionRepository, cohortRepository, cohortMemberRepository, userRepository, scoreUseCase, map[string]runner.Runner{
+		entity.CodeRunnerLocal: runner.NewLocalRunner(config.Config.GetString("GRADER_WORKDIR"), config.Config.GetString("GRADER_CGROUP_PARENT"), config.Config.GetInt("GRADER_UID"), config.Config.GetBool("GRADER_ALLOW_NETWORK")),
+	})
+	labClientUseCase := usecase.NewLabClientUseCase(config.Log, config.Validate, clientKeyRepository, scoreNonceRepository, assignmentRepository, courseRepository, cohortRepository, cohortMemberRepository, userRepository, scoreUseCase)
+	rubricUseCase := usecase.NewRubricUseCase(config.Log, config.Validate, rubricRepository, rubricGradeRepository, assignmentRepository, courseRepository, cohortRepository, cohortMemberRepository, userRepository, scoreUseCase)
+	regradeUseCase := usecase.NewRegradeUseCase(config.Log, config.Validate, regradeRequestRepository, assignmentRepository, scoreRepository, scoreLedgerRepository, cohortMemberRepository, userRepository, scoreUseCase, notificationUseCase)
+	peerReviewUseCase := usecase.NewPeerReviewUseCase(config.Log, config.Validate, peerReviewRepository, peerSubmissionRepository, peerReviewTaskRepository, peerResultRepository, rubricRepository, assignmentRepository, courseRepository, enrollmentRepository, cohortRepository, cohortMemberRepository, userRepository, scoreUseCase, notificationUseCase)
+	gradeUseCase := usecase.NewGradeUseCase(config.Log, config.Validate, gradingSchemeRepository, courseGradeRepository, courseRepository, assignmentRepository, enrollmentRepository, scoreRepository, extensionRepository, cohortRepository, cohortMemberRepository, userRepository)
+	groupUseCase := usecase.NewGroupUseCase(config.Log, config.Validate, groupRepository, groupMemberRepository, cohortRepository, cohortMemberRepository, userRepository, notificationUseCase)
+	similarityUseCase := usecase.NewSimilarityUseCase(config.Log, config.Validate, similarityReportRepository, codeSubmissionRepository, assignmentRepository, courseRepository, cohortRepository, cohortMemberRepository, userRepository)
+	cohortUseCase := usecase.NewCohortUseCase(config.Log, config.Validate, cohortRepository, cohortMemberRepository, courseRepository, scoreRepository, extensionRepository, userRepository, notificationUseCase)
 
 	// setup controller
 	userController := http.NewUserController(userUseCase, config.Log, config.Config)
+	notificationController := http.NewNotificationController(notificationUseCase, config.Log)
+	mailController := http.NewMailController(mailUseCase, config.Log)
+	assignmentController := http.NewAssignmentController(assignmentUseCase, scoreUseCase, config.Log)
+	leaderboardController := http.NewLeaderboardController(leaderboardUseCase, config.Log)
+	scoreController := http.NewScoreController(scoreUseCase, config.Log)
+	courseController := http.NewCourseController(courseUseCase, config.Log)
+	progressController := http.NewProgressController(progressUseCase, config.Log)
+	cohortController := http.NewCohortController(cohortUseCase, config.Log)
+	gradebookController := http.NewGradebookController(gradebookUseCase, scoreUseCase, config.Log)
+	quizController := http.NewQuizController(quizUseCase, config.Log)
+	codeGradingController := http.NewCodeGradingController(codeGradingUseCase, config.Log)
+	labClientController := http.NewLabClientController(labClientUseCase, config.Log)
+	rubricController := http.NewRubricController(rubricUseCase, config.Log)
+	regradeController := http.NewRegradeController(regradeUseCase, config.Log)
+	peerReviewController := http.NewPeerReviewController(peerReviewUseCase, config.Log)
+	similarityController := http.NewSimilarityController(similarityUseCase, config.Log)
+	groupController := http.NewGroupController(groupUseCase, config.Log)
+	gradeController := http.NewGradeController(gradeUseCase, config.Log)
 
 	// setup middleware
-	authMiddleware := middleware.NewAuthMiddleware(config.Log, userUseCase, config.Config)
+	authMiddleware := middleware.NewAuthMiddleware(config.Log, userUseCase, cohortUseCase, config.Config)
 	// config.App.Use(authMiddleware.Handle)
 	routeConfig := route.RouteConfig{
-		App:            config.App,
-		UserController: userController,
-		AuthMiddleware: authMiddleware,
+		App:                    config.App,
+		UserController:         userController,
+		NotificationController: notificationController,
+		MailController:         mailController,
+		AssignmentController:   assignmentController,
+		LeaderboardController:  leaderboardController,
+		ScoreController:        scoreController,
+		CourseController:       courseController,
+		ProgressController:     progressController,
+		CohortController:       cohortController,
+		GradebookController:    gradebookController,
+		QuizController:         quizController,
+		CodeGradingController:  codeGradingController,
+		LabClientController:    labClientController,
+		RubricController:       rubricController,
+		RegradeController:      regradeController,
+		PeerReviewController:   peerReviewController,
+		SimilarityController:   similarityController,
+		GroupController:        groupController,
+		GradeController:        gradeController,
+		AuthMiddleware:         authMiddleware,
 	}
 	routeConfig.Setup()
+
+	// setup background jobs
+	if config.Config.GetBool("JOB_ENABLED") {
+		scheduler := job.NewScheduler(config.Log)
+		scheduler.Every(15*time.Minute, "notification-digest", func(ctx context.Context) error {
+			_, err := notificationUseCase.SendDailyDigests(ctx)
+			return err
+		})
+		scheduler.Every(time.Minute, "quiz-expiry", func(ctx context.Context) error {
+			_, err := quizUseCase.FinishExpired(ctx)
+			return err
+		})
+		scheduler.Every(5*time.Second, "code-grading", func(ctx context.Context) error {
+			_, err := codeGradingUseCase.GradeQueued(ctx, 50)
+			return err
+		})
+		scheduler.Every(time.Minute, "similarity", func(ctx context.Context) error {
+			_, err := similarityUseCase.CheckQueued(ctx, 5)
+			return err
+		})
+		scheduler.Every(time.Minute, "peer-review", func(ctx context.Context) error {
+			_, err := peerReviewUseCase.Advance(ctx)
+			return err
+		})
+		scheduler.Every(time.Minute, "course-grades", func(ctx context.Context) error {
+			_, err := gradeUseCase.RecomputeChanged(ctx)
+			return err
+		})
+		if bounceDir := config.Config.GetString("MAIL_BOUNCE_DIR"); bounceDir != "" {
+			scheduler.Every(5*time.Minute, "mail-bounce-mailbox", func(ctx context.Context) error {
+				_, err := mailUseCase.IngestMailbox(ctx, bounceDir)
+				return err
+			})
+		}
+		scheduler.Start(context.Background())
+	}
 }
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type AssignmentController struct {
+	Log          *logrus.Logger
+	UseCase      *usecase.AssignmentUseCase
+	ScoreUseCase *usecase.ScoreUseCase
+}
+
+func NewAssignmentController(useCase *usecase.AssignmentUseCase, scoreUseCase *usecase.ScoreUseCase, logger *logrus.Logger) *AssignmentController {
+	return &AssignmentController{
+		Log:          logger,
+		UseCase:      useCase,
+		ScoreUseCase: scoreUseCase,
+	}
+}
+
+func (c *AssignmentController) List(ctx *fiber.Ctx) error {
+	request := &model.ListAssignmentRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		Page:       ctx.QueryInt("page", 1),
+		Limit:      ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get assignments", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting assignments", nil, response))
+}
+
+func (c *AssignmentController) Get(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.Get(ctx.UserContext(), ctx.Locals("user").(string), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get assignment", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting assignment", nil, response))
+}
+
+func (c *AssignmentController) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateAssignmentRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to parse create assignment request")
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create assignment", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Assignment has been created", nil, response))
+}
+
+func (c *AssignmentController) Update(ctx *fiber.Ctx) error {
+	request := new(model.UpdateAssignmentRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to parse update assignment request")
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update assignment", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Assignment has been updated", nil, response))
+}
+
+func (c *AssignmentController) ListSubmissions(ctx *fiber.Ctx) error {
+	request := &model.ListSubmissionRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		ActorRole:    ctx.Locals("role").(string),
+		StudentEmail: ctx.Query("email"),
+		AssignmentID: ctx.Params("id"),
+	}
+	response, err := c.ScoreUseCase.ListSubmissions(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get submissions", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting submissions", nil, response))
+}
+
+func (c *AssignmentController) CreateExtension(ctx *fiber.Ctx) error {
+	request := new(model.CreateExtensionRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.CreateExtension(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create extension", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Extension has been granted", nil, response))
+}
+
+func (c *AssignmentController) ListExtensions(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.ListExtensions(ctx.UserContext(), ctx.Locals("user").(string), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get extensions", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting extensions", nil, response))
+}
+
+func (c *AssignmentController) DeleteExtension(ctx *fiber.Ctx) error {
+	if err := c.UseCase.DeleteExtension(ctx.UserContext(), ctx.Locals("user").(string), ctx.Params("id"), ctx.Params("extensionId")); err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to delete extension", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Extension has been removed", nil, nil))
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"io"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type CodeGradingController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.CodeGradingUseCase
+}
+
+func NewCodeGradingController(useCase *usecase.CodeGradingUseCase, logger *logrus.Logger) *CodeGradingController {
+	return &CodeGradingController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *CodeGradingController) GetGrader(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.GetGrader(ctx.UserContext(), ctx.Locals("user").(string), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get code grader", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting code grader", nil, response))
+}
+
+func (c *CodeGradingController) SaveGrader(ctx *fiber.Ctx) error {
+	request := new(model.SaveCodeGraderRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.SaveGrader(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to save code grader", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Code grader has been saved", nil, response))
+}
+
+// Submit takes the source from a multipart "file" field or, failing that, the
+// raw request body.
+func (c *CodeGradingController) Submit(ctx *fiber.Ctx) error {
+	request := &model.SubmitCodeRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+		Source:       ctx.Body(),
+		SourceIP:     ctx.IP(),
+	}
+	if header, err := ctx.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err == nil {
+			request.Source, err = io.ReadAll(file)
+			_ = file.Close()
+		}
+		if err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"assignment_id": request.AssignmentID,
+				util.LogError:   err,
+			}).Error("Failed to read source file")
+			ctx.Status(fiber.StatusBadRequest)
+			return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+		}
+	}
+
+	response, err := c.UseCase.Submit(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to submit code", err, nil))
+	}
+	ctx.Status(fiber.StatusAccepted)
+	return ctx.JSON(model.NewWebResponse("Code has been queued for grading", nil, response))
+}
+
+func (c *CodeGradingController) ListSubmissions(ctx *fiber.Ctx) error {
+	request := &model.ListCodeSubmissionRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+		Email:        ctx.Query("email"),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListSubmissions(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get code submissions", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting code submissions", nil, response))
+}
+
+func (c *CodeGradingController) GetSubmission(ctx *fiber.Ctx) error {
+	request := &model.GetCodeSubmissionRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ID:         ctx.Params("id"),
+	}
+	response, err := c.UseCase.GetSubmission(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get code submission", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting code submission", nil, response))
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type CohortController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.CohortUseCase
+}
+
+func NewCohortController(useCase *usecase.CohortUseCase, logger *logrus.Logger) *CohortController {
+	return &CohortController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *CohortController) List(ctx *fiber.Ctx) error {
+	request := &model.ListCohortRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ActorRole:  ctx.Locals("role").(string),
+		Page:       ctx.QueryInt("page", 1),
+		Limit:      ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get cohorts", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting cohorts", nil, response))
+}
+
+func (c *CohortController) Get(ctx *fiber.Ctx) error {
+	request := &model.GetCohortRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ID:         ctx.Params("id"),
+	}
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get cohort", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting cohort", nil, response))
+}
+
+func (c *CohortController) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateCohortRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create cohort", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Cohort has been created", nil, response))
+}
+
+func (c *CohortController) Update(ctx *fiber.Ctx) error {
+	request := new(model.UpdateCohortRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update cohort", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Cohort has been updated", nil, response))
+}
+
+func (c *CohortController) ListMembers(ctx *fiber.Ctx) error {
+	request := &model.ListCohortMemberRequest{
+		CohortID: ctx.Params("id"),
+		Role:     ctx.Query("role"),
+		Page:     ctx.QueryInt("page", 1),
+		Limit:    ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListMembers(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get cohort members", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting cohort members", nil, response))
+}
+
+func (c *CohortController) AddMembers(ctx *fiber.Ctx) error {
+	request := new(model.AddCohortMembersRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.CohortID = ctx.Params("id")
+
+	response, err := c.UseCase.AddMembers(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to add cohort members", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Cohort members have been processed", nil, response))
+}
+
+func (c *CohortController) RemoveMember(ctx *fiber.Ctx) error {
+	request := &model.RemoveCohortMemberRequest{
+		CohortID: ctx.Params("id"),
+		UserID:   ctx.Params("userId"),
+	}
+	if err := c.UseCase.RemoveMember(ctx.UserContext(), request); err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to remove cohort member", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Cohort member has been removed", nil, nil))
+}
+
+func (c *CohortController) MoveStudents(ctx *fiber.Ctx) error {
+	request := new(model.MoveCohortStudentsRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ActorRole = ctx.Locals("role").(string)
+
+	response, err := c.UseCase.MoveStudents(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to move students", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Students have been moved", nil, response))
+}
+
+func (c *CohortController) Scores(ctx *fiber.Ctx) error {
+	request := &model.CohortScoresRequest{
+		CohortID: ctx.Params("id"),
+		CourseID: ctx.Query("course_id"),
+		Page:     ctx.QueryInt("page", 1),
+		Limit:    ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.Scores(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get cohort scores", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting cohort scores", nil, response))
+}
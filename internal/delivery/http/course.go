@@ -0,0 +1,196 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type CourseController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.CourseUseCase
+}
+
+func NewCourseController(useCase *usecase.CourseUseCase, logger *logrus.Logger) *CourseController {
+	return &CourseController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *CourseController) List(ctx *fiber.Ctx) error {
+	request := &model.ListCourseRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ActorRole:  ctx.Locals("role").(string),
+		Page:       ctx.QueryInt("page", 1),
+		Limit:      ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get courses", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting courses", nil, response))
+}
+
+func (c *CourseController) Get(ctx *fiber.Ctx) error {
+	request := &model.GetCourseRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ActorRole:  ctx.Locals("role").(string),
+		ID:         ctx.Params("id"),
+	}
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get course", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting course", nil, response))
+}
+
+func (c *CourseController) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateCourseRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create course", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Course has been created", nil, response))
+}
+
+func (c *CourseController) Update(ctx *fiber.Ctx) error {
+	request := new(model.UpdateCourseRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update course", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Course has been updated", nil, response))
+}
+
+func (c *CourseController) RotateJoinCode(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.RotateJoinCode(ctx.UserContext(), ctx.Locals("user").(string), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to rotate join code", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Join code has been rotated", nil, response))
+}
+
+func (c *CourseController) CreateModule(ctx *fiber.Ctx) error {
+	request := new(model.CreateModuleRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.CourseID = ctx.Params("id")
+
+	response, err := c.UseCase.CreateModule(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create module", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Module has been created", nil, response))
+}
+
+func (c *CourseController) UpdateModule(ctx *fiber.Ctx) error {
+	request := new(model.UpdateModuleRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.CourseID = ctx.Params("id")
+	request.ID = ctx.Params("moduleId")
+
+	response, err := c.UseCase.UpdateModule(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update module", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Module has been updated", nil, response))
+}
+
+func (c *CourseController) Join(ctx *fiber.Ctx) error {
+	request := new(model.JoinCourseRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+
+	response, err := c.UseCase.Join(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to join course", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Enrolled in course", nil, response))
+}
+
+func (c *CourseController) Enroll(ctx *fiber.Ctx) error {
+	request := new(model.EnrollRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.CourseID = ctx.Params("id")
+
+	response, err := c.UseCase.Enroll(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to enroll user", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("User has been enrolled", nil, response))
+}
+
+func (c *CourseController) ListEnrollments(ctx *fiber.Ctx) error {
+	request := &model.ListEnrollmentRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		CourseID:   ctx.Params("id"),
+		Status:     ctx.Query("status"),
+		Page:       ctx.QueryInt("page", 1),
+		Limit:      ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListEnrollments(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get enrollments", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting enrollments", nil, response))
+}
+
+func (c *CourseController) UpdateEnrollment(ctx *fiber.Ctx) error {
+	request := new(model.UpdateEnrollmentRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.CourseID = ctx.Params("id")
+	request.UserID = ctx.Params("userId")
+
+	response, err := c.UseCase.UpdateEnrollment(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update enrollment", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Enrollment has been updated", nil, response))
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type GradeController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.GradeUseCase
+}
+
+func NewGradeController(useCase *usecase.GradeUseCase, logger *logrus.Logger) *GradeController {
+	return &GradeController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *GradeController) GetScheme(ctx *fiber.Ctx) error {
+	request := &model.GetGradingSchemeRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ActorRole:  ctx.Locals("role").(string),
+		CourseID:   ctx.Params("id"),
+	}
+	response, err := c.UseCase.GetScheme(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get grading scheme", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting grading scheme", nil, response))
+}
+
+func (c *GradeController) SaveScheme(ctx *fiber.Ctx) error {
+	request := new(model.SaveGradingSchemeRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.CourseID = ctx.Params("id")
+
+	response, err := c.UseCase.SaveScheme(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to save grading scheme", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Grading scheme has been saved", nil, response))
+}
+
+func (c *GradeController) Get(ctx *fiber.Ctx) error {
+	request := &model.CourseGradeRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		ActorRole:    ctx.Locals("role").(string),
+		CourseID:     ctx.Params("id"),
+		StudentEmail: ctx.Query("email"),
+	}
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get course grade", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting course grade", nil, response))
+}
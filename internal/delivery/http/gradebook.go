@@ -0,0 +1,93 @@
+package http
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type GradebookController struct {
+	Log          *logrus.Logger
+	UseCase      *usecase.GradebookUseCase
+	ScoreUseCase *usecase.ScoreUseCase
+}
+
+func NewGradebookController(useCase *usecase.GradebookUseCase, scoreUseCase *usecase.ScoreUseCase, logger *logrus.Logger) *GradebookController {
+	return &GradebookController{
+		Log:          logger,
+		UseCase:      useCase,
+		ScoreUseCase: scoreUseCase,
+	}
+}
+
+func (c *GradebookController) Export(ctx *fiber.Ctx) error {
+	request := &model.GradebookExportRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		CourseID:   ctx.Params("id"),
+		Format:     ctx.Query("format", "csv"),
+		Status:     ctx.Query("status"),
+	}
+	export, err := c.UseCase.Export(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to export gradebook", err, nil))
+	}
+
+	ctx.Attachment(export.Filename)
+	ctx.Set(fiber.HeaderContentType, export.ContentType)
+	userContext := ctx.UserContext()
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// Headers are already sent, so a failure here can only cut the file short.
+		if err := export.Write(userContext, w); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				util.LogError:   err,
+			}).Error("Failed to stream gradebook")
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
+
+// Import takes the CSV either as the "file" field of a multipart form or as the
+// raw request body. Nothing is written unless confirm=true.
+func (c *GradebookController) Import(ctx *fiber.Ctx) error {
+	request := &model.GradeImportRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		SourceIP:   ctx.IP(),
+		CourseID:   ctx.Params("id"),
+		Confirm:    ctx.QueryBool("confirm"),
+		CSV:        ctx.Body(),
+	}
+	if header, err := ctx.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err == nil {
+			request.CSV, err = io.ReadAll(file)
+			_ = file.Close()
+		}
+		if err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"course_id":   request.CourseID,
+				util.LogError: err,
+			}).Error("Failed to read grade import file")
+			ctx.Status(fiber.StatusBadRequest)
+			return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+		}
+	}
+
+	response, err := c.ScoreUseCase.ImportGrades(ctx.UserContext(), request)
+	if err != nil {
+		// A rejected import still carries the report of what is wrong with it.
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to import grades", err, response))
+	}
+	if response.Applied {
+		return ctx.JSON(model.NewWebResponse("Grades have been imported", nil, response))
+	}
+	return ctx.JSON(model.NewWebResponse("Grade import checked, nothing was applied", nil, response))
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type GroupController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.GroupUseCase
+}
+
+func NewGroupController(useCase *usecase.GroupUseCase, logger *logrus.Logger) *GroupController {
+	return &GroupController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *GroupController) List(ctx *fiber.Ctx) error {
+	request := &model.ListGroupRequest{
+		CohortID: ctx.Params("id"),
+	}
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get groups", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting groups", nil, response))
+}
+
+func (c *GroupController) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateGroupRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.CohortID = ctx.Params("id")
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create group", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Group has been created", nil, response))
+}
+
+func (c *GroupController) Update(ctx *fiber.Ctx) error {
+	request := new(model.UpdateGroupRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.CohortID = ctx.Params("id")
+	request.ID = ctx.Params("groupId")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update group", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Group has been updated", nil, response))
+}
+
+func (c *GroupController) Delete(ctx *fiber.Ctx) error {
+	request := &model.GroupRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		CohortID:   ctx.Params("id"),
+		ID:         ctx.Params("groupId"),
+	}
+	if err := c.UseCase.Delete(ctx.UserContext(), request); err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to delete group", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Group has been deleted", nil, nil))
+}
+
+func (c *GroupController) AddMembers(ctx *fiber.Ctx) error {
+	request := new(model.AddGroupMembersRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.CohortID = ctx.Params("id")
+	request.ID = ctx.Params("groupId")
+
+	response, err := c.UseCase.AddMembers(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to add group members", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Group members have been processed", nil, response))
+}
+
+func (c *GroupController) RemoveMember(ctx *fiber.Ctx) error {
+	request := &model.RemoveGroupMemberRequest{
+		CohortID: ctx.Params("id"),
+		ID:       ctx.Params("groupId"),
+		UserID:   ctx.Params("userId"),
+	}
+	if err := c.UseCase.RemoveMember(ctx.UserContext(), request); err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to remove group member", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Group member has been removed", nil, nil))
+}
+
+func (c *GroupController) Join(ctx *fiber.Ctx) error {
+	request := &model.GroupRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		CohortID:   ctx.Params("id"),
+		ID:         ctx.Params("groupId"),
+	}
+	response, err := c.UseCase.Join(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to join group", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Group has been joined", nil, response))
+}
+
+func (c *GroupController) Leave(ctx *fiber.Ctx) error {
+	request := &model.GroupRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		CohortID:   ctx.Params("id"),
+		ID:         ctx.Params("groupId"),
+	}
+	if err := c.UseCase.Leave(ctx.UserContext(), request); err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to leave group", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Group has been left", nil, nil))
+}
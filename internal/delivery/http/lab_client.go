@@ -0,0 +1,75 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type LabClientController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.LabClientUseCase
+}
+
+func NewLabClientController(useCase *usecase.LabClientUseCase, logger *logrus.Logger) *LabClientController {
+	return &LabClientController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// UpdateScore takes a score signed by the assignment's lab client.
+func (c *LabClientController) UpdateScore(ctx *fiber.Ctx) error {
+	request := new(model.UpdateScoreRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.UserEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+	request.SourceIP = ctx.IP()
+
+	response, err := c.UseCase.SubmitScore(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update score", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Score updated successfully", nil, response))
+}
+
+func (c *LabClientController) CreateKey(ctx *fiber.Ctx) error {
+	request := new(model.CreateClientKeyRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.CreateKey(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create client key", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Client key has been created", nil, response))
+}
+
+func (c *LabClientController) ListKeys(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.ListKeys(ctx.UserContext(), ctx.Locals("user").(string), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get client keys", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting client keys", nil, response))
+}
+
+func (c *LabClientController) RevokeKey(ctx *fiber.Ctx) error {
+	if err := c.UseCase.RevokeKey(ctx.UserContext(), ctx.Locals("user").(string), ctx.Params("id"), ctx.Params("keyId")); err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to revoke client key", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Client key has been revoked", nil, nil))
+}
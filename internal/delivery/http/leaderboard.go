@@ -0,0 +1,94 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type LeaderboardController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.LeaderboardUseCase
+}
+
+func NewLeaderboardController(useCase *usecase.LeaderboardUseCase, logger *logrus.Logger) *LeaderboardController {
+	return &LeaderboardController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *LeaderboardController) List(ctx *fiber.Ctx) error {
+	request := &model.LeaderboardRequest{
+		UserEmail:    ctx.Locals("user").(string),
+		ActorRole:    ctx.Locals("role").(string),
+		CourseID:     ctx.Params("id", ctx.Query("course_id")),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+		AssignmentID: ctx.Query("assignment_id"),
+		CohortID:     ctx.Query("cohort_id"),
+	}
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get leaderboard", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting leaderboard", nil, response))
+}
+
+func (c *LeaderboardController) Me(ctx *fiber.Ctx) error {
+	request := &model.LeaderboardMeRequest{
+		UserEmail:    ctx.Locals("user").(string),
+		ActorRole:    ctx.Locals("role").(string),
+		CourseID:     ctx.Params("id", ctx.Query("course_id")),
+		AssignmentID: ctx.Query("assignment_id"),
+		CohortID:     ctx.Query("cohort_id"),
+		Neighbours:   ctx.QueryInt("neighbours", 2),
+	}
+	response, err := c.UseCase.Me(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get leaderboard position", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting leaderboard position", nil, response))
+}
+
+// Cohort ranks the students of the cohort in the path, optionally within a course.
+func (c *LeaderboardController) Cohort(ctx *fiber.Ctx) error {
+	request := &model.LeaderboardRequest{
+		UserEmail:    ctx.Locals("user").(string),
+		ActorRole:    ctx.Locals("role").(string),
+		CohortID:     ctx.Params("id"),
+		CourseID:     ctx.Query("course_id"),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+		AssignmentID: ctx.Query("assignment_id"),
+	}
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get leaderboard", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting leaderboard", nil, response))
+}
+
+func (c *LeaderboardController) UpdateVisibility(ctx *fiber.Ctx) error {
+	request := new(model.UpdateLeaderboardVisibilityRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogError: err,
+		}).Error("Failed to parse leaderboard visibility request")
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.UserEmail = ctx.Locals("user").(string)
+
+	response, err := c.UseCase.UpdateVisibility(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update leaderboard visibility", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Leaderboard visibility updated", nil, response))
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type MailController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.MailUseCase
+}
+
+func NewMailController(useCase *usecase.MailUseCase, logger *logrus.Logger) *MailController {
+	return &MailController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+// ReceiveEvents accepts either a JSON batch of bounce/complaint events from the
+// mail provider, or a raw DSN/ARF message posted as message/rfc822.
+func (c *MailController) ReceiveEvents(ctx *fiber.Ctx) error {
+	if !c.UseCase.VerifyWebhookSecret(ctx.Get("X-Webhook-Secret")) {
+		ctx.Status(fiber.StatusUnauthorized)
+		return ctx.JSON(model.NewWebResponse("Failed to receive mail events", util.ErrInvalidWebhookSignature, nil))
+	}
+
+	var response *model.MailWebhookResponse
+	var err error
+	if strings.HasPrefix(ctx.Get(fiber.HeaderContentType), "message/rfc822") {
+		response, err = c.UseCase.HandleRawReport(ctx.UserContext(), bytes.NewReader(ctx.Body()), entity.MailEventSourceWebhook)
+	} else {
+		request := new(model.MailWebhookRequest)
+		if err := ctx.BodyParser(request); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogError: err,
+			}).Error("Failed to parse mail webhook request")
+			ctx.Status(fiber.StatusBadRequest)
+			return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+		}
+		response, err = c.UseCase.HandleWebhook(ctx.UserContext(), request)
+	}
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to receive mail events", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Mail events recorded", nil, response))
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
 	"github.com/Erwanph/be-wan-central-lab/internal/model"
 	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
 	"github.com/Erwanph/be-wan-central-lab/internal/util"
@@ -16,16 +17,18 @@ import (
 )
 
 type AuthMiddleware struct {
-	Log     *logrus.Logger
-	UseCase *usecase.UserUseCase
-	Config  *viper.Viper
+	Log           *logrus.Logger
+	UseCase       *usecase.UserUseCase
+	CohortUseCase *usecase.CohortUseCase
+	Config        *viper.Viper
 }
 
-func NewAuthMiddleware(log *logrus.Logger, useCase *usecase.UserUseCase, config *viper.Viper) *AuthMiddleware {
+func NewAuthMiddleware(log *logrus.Logger, useCase *usecase.UserUseCase, cohortUseCase *usecase.CohortUseCase, config *viper.Viper) *AuthMiddleware {
 	return &AuthMiddleware{
-		Log:     log,
-		UseCase: useCase,
-		Config:  config,
+		Log:           log,
+		UseCase:       useCase,
+		CohortUseCase: cohortUseCase,
+		Config:        config,
 	}
 }
 
@@ -73,7 +76,37 @@ func (m *AuthMiddleware) CheckSession(ctx *fiber.Ctx) error {
 	}
 
 	ctx.Locals("user", user.Email)
+	ctx.Locals("user_id", user.ID)
+	ctx.Locals("role", user.Role)
 
 	return ctx.Next()
 }
 
+// RequireRole must run after CheckSession; it rejects callers whose role is not listed.
+func (m *AuthMiddleware) RequireRole(roles ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		role, _ := ctx.Locals("role").(string)
+		if !util.Contain(roles, role) {
+			ctx.Status(http.StatusForbidden)
+			return ctx.JSON(model.NewWebResponse("Authorization failed", util.ErrPermissionDenied, nil))
+		}
+		return ctx.Next()
+	}
+}
+
+// RequireCohortRole must run after CheckSession on routes with a cohort :id; it
+// rejects callers who are not in that cohort with one of the listed cohort roles.
+// Admins manage every cohort and always pass.
+func (m *AuthMiddleware) RequireCohortRole(roles ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if role, _ := ctx.Locals("role").(string); role == entity.RoleAdmin {
+			return ctx.Next()
+		}
+		userID, _ := ctx.Locals("user_id").(string)
+		if err := m.CohortUseCase.CheckMember(ctx.UserContext(), ctx.Params("id"), userID, roles...); err != nil {
+			ctx.Status(util.StatusCode(err))
+			return ctx.JSON(model.NewWebResponse("Authorization failed", err, nil))
+		}
+		return ctx.Next()
+	}
+}
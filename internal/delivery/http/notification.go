@@ -0,0 +1,87 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type NotificationController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.NotificationUseCase
+}
+
+func NewNotificationController(useCase *usecase.NotificationUseCase, logger *logrus.Logger) *NotificationController {
+	return &NotificationController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *NotificationController) List(ctx *fiber.Ctx) error {
+	request := &model.ListNotificationRequest{
+		UserEmail: ctx.Locals("user").(string),
+		Page:      ctx.QueryInt("page", 1),
+		Limit:     ctx.QueryInt("limit", 20),
+	}
+
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get notifications", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting notifications", nil, response))
+}
+
+func (c *NotificationController) MarkRead(ctx *fiber.Ctx) error {
+	request := &model.MarkNotificationReadRequest{
+		UserEmail:      ctx.Locals("user").(string),
+		NotificationID: ctx.Params("id"),
+	}
+
+	if err := c.UseCase.MarkRead(ctx.UserContext(), request); err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to mark notification as read", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Notification marked as read", nil, nil))
+}
+
+func (c *NotificationController) MarkAllRead(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.MarkAllRead(ctx.UserContext(), ctx.Locals("user").(string))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to mark notifications as read", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("All notifications marked as read", nil, response))
+}
+
+func (c *NotificationController) GetPreferences(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.GetPreferences(ctx.UserContext(), ctx.Locals("user").(string))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get notification preferences", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting notification preferences", nil, response))
+}
+
+func (c *NotificationController) UpdatePreferences(ctx *fiber.Ctx) error {
+	request := new(model.UpdateNotificationPreferencesRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to parse notification preferences request")
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.UserEmail = ctx.Locals("user").(string)
+
+	response, err := c.UseCase.UpdatePreferences(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update notification preferences", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Notification preferences updated", nil, response))
+}
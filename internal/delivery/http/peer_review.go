@@ -0,0 +1,208 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type PeerReviewController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.PeerReviewUseCase
+}
+
+func NewPeerReviewController(useCase *usecase.PeerReviewUseCase, logger *logrus.Logger) *PeerReviewController {
+	return &PeerReviewController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *PeerReviewController) Save(ctx *fiber.Ctx) error {
+	request := new(model.SavePeerReviewRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.Save(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to save peer review", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Peer review has been saved", nil, response))
+}
+
+func (c *PeerReviewController) Get(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get peer review", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting peer review", nil, response))
+}
+
+func (c *PeerReviewController) Submit(ctx *fiber.Ctx) error {
+	request := new(model.SavePeerSubmissionRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.Submit(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to submit work", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Work has been submitted", nil, response))
+}
+
+func (c *PeerReviewController) GetSubmission(ctx *fiber.Ctx) error {
+	request := &model.PeerReviewActionRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+	}
+	response, err := c.UseCase.GetSubmission(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get submitted work", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting submitted work", nil, response))
+}
+
+func (c *PeerReviewController) Allocate(ctx *fiber.Ctx) error {
+	request := &model.PeerReviewActionRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+	}
+	response, err := c.UseCase.Allocate(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to hand out peer reviews", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Peer reviews have been handed out", nil, response))
+}
+
+func (c *PeerReviewController) Finalize(ctx *fiber.Ctx) error {
+	request := &model.PeerReviewActionRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+	}
+	response, err := c.UseCase.Finalize(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to finalize peer review", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Peer review scores have been recorded", nil, response))
+}
+
+func (c *PeerReviewController) Override(ctx *fiber.Ctx) error {
+	request := new(model.OverridePeerScoreRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.SourceIP = ctx.IP()
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.Override(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to override peer review score", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Peer review score has been overridden", nil, response))
+}
+
+func (c *PeerReviewController) ListResults(ctx *fiber.Ctx) error {
+	request := &model.ListAssignmentPeerReviewRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListResults(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get peer review results", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting peer review results", nil, response))
+}
+
+func (c *PeerReviewController) ListReviews(ctx *fiber.Ctx) error {
+	request := &model.ListAssignmentPeerReviewRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListReviews(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get peer reviews", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting peer reviews", nil, response))
+}
+
+func (c *PeerReviewController) Received(ctx *fiber.Ctx) error {
+	request := &model.ReceivedPeerReviewRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+	}
+	response, err := c.UseCase.Received(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get received peer reviews", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting received peer reviews", nil, response))
+}
+
+func (c *PeerReviewController) ListTasks(ctx *fiber.Ctx) error {
+	request := &model.ListPeerReviewTaskRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		Page:       ctx.QueryInt("page", 1),
+		Limit:      ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListTasks(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get peer reviews", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting peer reviews", nil, response))
+}
+
+func (c *PeerReviewController) GetTask(ctx *fiber.Ctx) error {
+	request := &model.GetPeerReviewTaskRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ID:         ctx.Params("id"),
+	}
+	response, err := c.UseCase.GetTask(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get peer review", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting peer review", nil, response))
+}
+
+func (c *PeerReviewController) SubmitReview(ctx *fiber.Ctx) error {
+	request := new(model.SubmitPeerReviewRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.SubmitReview(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to submit peer review", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Peer review has been submitted", nil, response))
+}
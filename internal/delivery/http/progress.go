@@ -0,0 +1,65 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type ProgressController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.ProgressUseCase
+}
+
+func NewProgressController(useCase *usecase.ProgressUseCase, logger *logrus.Logger) *ProgressController {
+	return &ProgressController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *ProgressController) Start(ctx *fiber.Ctx) error {
+	request := &model.StartAssignmentRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+	}
+	response, err := c.UseCase.Start(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to start assignment", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Assignment started", nil, response))
+}
+
+func (c *ProgressController) Course(ctx *fiber.Ctx) error {
+	request := &model.CourseProgressRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		ActorRole:    ctx.Locals("role").(string),
+		CourseID:     ctx.Params("id"),
+		StudentEmail: ctx.Query("email"),
+	}
+	response, err := c.UseCase.Course(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get progress", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting progress", nil, response))
+}
+
+func (c *ProgressController) Matrix(ctx *fiber.Ctx) error {
+	request := &model.ProgressMatrixRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		CourseID:   ctx.Params("id"),
+		Status:     ctx.Query("status", "active"),
+		Page:       ctx.QueryInt("page", 1),
+		Limit:      ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.Matrix(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get progress matrix", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting progress matrix", nil, response))
+}
@@ -0,0 +1,375 @@
+package http
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type QuizController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.QuizUseCase
+}
+
+func NewQuizController(useCase *usecase.QuizUseCase, logger *logrus.Logger) *QuizController {
+	return &QuizController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *QuizController) ListBanks(ctx *fiber.Ctx) error {
+	request := &model.ListQuestionBankRequest{
+		CourseID: ctx.Query("course_id"),
+		Page:     ctx.QueryInt("page", 1),
+		Limit:    ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListBanks(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get question banks", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting question banks", nil, response))
+}
+
+func (c *QuizController) GetBank(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.GetBank(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get question bank", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting question bank", nil, response))
+}
+
+func (c *QuizController) CreateBank(ctx *fiber.Ctx) error {
+	request := new(model.CreateQuestionBankRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+
+	response, err := c.UseCase.CreateBank(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create question bank", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Question bank has been created", nil, response))
+}
+
+func (c *QuizController) UpdateBank(ctx *fiber.Ctx) error {
+	request := new(model.UpdateQuestionBankRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.UpdateBank(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update question bank", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Question bank has been updated", nil, response))
+}
+
+func (c *QuizController) ListQuestions(ctx *fiber.Ctx) error {
+	request := &model.ListQuestionRequest{
+		BankID: ctx.Params("id"),
+		Page:   ctx.QueryInt("page", 1),
+		Limit:  ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListQuestions(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get questions", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting questions", nil, response))
+}
+
+func (c *QuizController) CreateQuestion(ctx *fiber.Ctx) error {
+	request := new(model.SaveQuestionRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.BankID = ctx.Params("id")
+
+	response, err := c.UseCase.CreateQuestion(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create question", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Question has been created", nil, response))
+}
+
+func (c *QuizController) UpdateQuestion(ctx *fiber.Ctx) error {
+	request := new(model.SaveQuestionRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.BankID = ctx.Params("id")
+	request.ID = ctx.Params("questionId")
+
+	response, err := c.UseCase.UpdateQuestion(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update question", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Question has been updated", nil, response))
+}
+
+func (c *QuizController) PreviewQuestion(ctx *fiber.Ctx) error {
+	request := &model.PreviewQuestionRequest{
+		BankID: ctx.Params("id"),
+		ID:     ctx.Params("questionId"),
+		Seed:   int64(ctx.QueryInt("seed", 0)),
+	}
+	response, err := c.UseCase.PreviewQuestion(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to preview question", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success previewing question", nil, response))
+}
+
+func (c *QuizController) DeleteQuestion(ctx *fiber.Ctx) error {
+	if err := c.UseCase.DeleteQuestion(ctx.UserContext(), ctx.Params("id"), ctx.Params("questionId")); err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to delete question", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Question has been deleted", nil, nil))
+}
+
+// ImportQuestions takes the file either as the "file" field of a multipart form
+// or as the raw request body.
+func (c *QuizController) ImportQuestions(ctx *fiber.Ctx) error {
+	request := &model.ImportQuestionsRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		BankID:     ctx.Params("id"),
+		Format:     ctx.Query("format"),
+		Data:       ctx.Body(),
+	}
+	if header, err := ctx.FormFile("file"); err == nil {
+		file, err := header.Open()
+		if err == nil {
+			request.Data, err = io.ReadAll(file)
+			_ = file.Close()
+		}
+		if err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"bank_id":     request.BankID,
+				util.LogError: err,
+			}).Error("Failed to read question file")
+			ctx.Status(fiber.StatusBadRequest)
+			return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+		}
+	}
+
+	response, err := c.UseCase.ImportQuestions(ctx.UserContext(), request)
+	if err != nil {
+		// A rejected file still carries the report of what is wrong with it.
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to import questions", err, response))
+	}
+	return ctx.JSON(model.NewWebResponse("Questions have been imported", nil, response))
+}
+
+func (c *QuizController) ExportQuestions(ctx *fiber.Ctx) error {
+	request := &model.ExportQuestionsRequest{
+		BankID: ctx.Params("id"),
+		Format: ctx.Query("format", "gift"),
+	}
+	export, err := c.UseCase.ExportQuestions(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to export questions", err, nil))
+	}
+
+	ctx.Attachment(export.Filename)
+	ctx.Set(fiber.HeaderContentType, export.ContentType)
+	ctx.Set("X-Skipped-Questions", strconv.Itoa(export.Skipped))
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := export.Write(w); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				util.LogError:   err,
+			}).Error("Failed to stream question export")
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
+
+func (c *QuizController) GetQuiz(ctx *fiber.Ctx) error {
+	staff := entity.IsStaffRole(ctx.Locals("role").(string))
+	response, err := c.UseCase.GetQuiz(ctx.UserContext(), ctx.Params("id"), staff)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get quiz", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting quiz", nil, response))
+}
+
+func (c *QuizController) SaveQuiz(ctx *fiber.Ctx) error {
+	request := new(model.SaveQuizRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.SaveQuiz(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to save quiz", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Quiz has been saved", nil, response))
+}
+
+func (c *QuizController) StartAttempt(ctx *fiber.Ctx) error {
+	request := new(model.StartQuizAttemptRequest)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(request); err != nil {
+			ctx.Status(fiber.StatusBadRequest)
+			return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+		}
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+	request.SourceIP = ctx.IP()
+
+	response, err := c.UseCase.StartAttempt(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to start quiz", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Quiz has been started", nil, response))
+}
+
+func (c *QuizController) ListAttempts(ctx *fiber.Ctx) error {
+	request := &model.ListQuizAttemptRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+		Email:        ctx.Query("email"),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListAttempts(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get quiz attempts", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting quiz attempts", nil, response))
+}
+
+func (c *QuizController) GetAttempt(ctx *fiber.Ctx) error {
+	request := &model.GetQuizAttemptRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ID:         ctx.Params("id"),
+	}
+	response, err := c.UseCase.GetAttempt(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get quiz attempt", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting quiz attempt", nil, response))
+}
+
+func (c *QuizController) SaveAnswers(ctx *fiber.Ctx) error {
+	request := new(model.SaveQuizAnswersRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ID = ctx.Params("id")
+	request.SourceIP = ctx.IP()
+
+	response, err := c.UseCase.SaveAnswers(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to save answers", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Answers have been saved", nil, response))
+}
+
+func (c *QuizController) Submit(ctx *fiber.Ctx) error {
+	request := new(model.SaveQuizAnswersRequest)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(request); err != nil {
+			ctx.Status(fiber.StatusBadRequest)
+			return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+		}
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ID = ctx.Params("id")
+	request.SourceIP = ctx.IP()
+
+	response, err := c.UseCase.Submit(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to submit quiz", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Quiz has been submitted", nil, response))
+}
+
+func (c *QuizController) RecordEvents(ctx *fiber.Ctx) error {
+	request := new(model.RecordExamEventsRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ID = ctx.Params("id")
+	request.SourceIP = ctx.IP()
+
+	response, err := c.UseCase.RecordEvents(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to record exam events", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Exam events have been recorded", nil, response))
+}
+
+func (c *QuizController) ListEvents(ctx *fiber.Ctx) error {
+	request := &model.GetQuizAttemptRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ID:         ctx.Params("id"),
+	}
+	response, err := c.UseCase.ListEvents(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get exam events", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting exam events", nil, response))
+}
+
+func (c *QuizController) ExamSummary(ctx *fiber.Ctx) error {
+	request := &model.ListQuizAttemptRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+		Email:        ctx.Query("email"),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ExamSummary(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get exam summary", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting exam summary", nil, response))
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type RegradeController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.RegradeUseCase
+}
+
+func NewRegradeController(useCase *usecase.RegradeUseCase, logger *logrus.Logger) *RegradeController {
+	return &RegradeController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *RegradeController) Create(ctx *fiber.Ctx) error {
+	request := new(model.CreateRegradeRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to request regrade", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Regrade request has been opened", nil, response))
+}
+
+func (c *RegradeController) List(ctx *fiber.Ctx) error {
+	request := &model.ListRegradeRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		Status:       ctx.Query("status"),
+		AssignmentID: ctx.Query("assignment_id"),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get regrade requests", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting regrade requests", nil, response))
+}
+
+func (c *RegradeController) Get(ctx *fiber.Ctx) error {
+	request := &model.GetRegradeRequest{
+		ActorEmail: ctx.Locals("user").(string),
+		ID:         ctx.Params("id"),
+	}
+	response, err := c.UseCase.Get(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get regrade request", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting regrade request", nil, response))
+}
+
+func (c *RegradeController) Accept(ctx *fiber.Ctx) error {
+	request := new(model.ResolveRegradeRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.SourceIP = ctx.IP()
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.Accept(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to accept regrade request", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Regrade request has been accepted", nil, response))
+}
+
+func (c *RegradeController) Reject(ctx *fiber.Ctx) error {
+	request := new(model.ResolveRegradeRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.SourceIP = ctx.IP()
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.Reject(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to reject regrade request", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Regrade request has been rejected", nil, response))
+}
+
+func (c *RegradeController) Escalate(ctx *fiber.Ctx) error {
+	request := new(model.EscalateRegradeRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.Escalate(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to escalate regrade request", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Regrade request has been escalated", nil, response))
+}
@@ -2,6 +2,7 @@ package route
 
 import (
 	"github.com/Erwanph/be-wan-central-lab/internal/delivery/http"
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
 
 	"github.com/Erwanph/be-wan-central-lab/internal/delivery/http/middleware"
 	"github.com/gofiber/fiber/v2"
@@ -9,9 +10,27 @@ import (
 )
 
 type RouteConfig struct {
-	App            *fiber.App
-	UserController *http.UserController
-	AuthMiddleware *middleware.AuthMiddleware
+	App                    *fiber.App
+	UserController         *http.UserController
+	NotificationController *http.NotificationController
+	MailController         *http.MailController
+	AssignmentController   *http.AssignmentController
+	LeaderboardController  *http.LeaderboardController
+	ScoreController        *http.ScoreController
+	CourseController       *http.CourseController
+	ProgressController     *http.ProgressController
+	CohortController       *http.CohortController
+	GradebookController    *http.GradebookController
+	QuizController         *http.QuizController
+	CodeGradingController  *http.CodeGradingController
+	LabClientController    *http.LabClientController
+	RubricController       *http.RubricController
+	RegradeController      *http.RegradeController
+	PeerReviewController   *http.PeerReviewController
+	SimilarityController   *http.SimilarityController
+	GroupController        *http.GroupController
+	GradeController        *http.GradeController
+	AuthMiddleware         *middleware.AuthMiddleware
 }
 
 func (c *RouteConfig) Setup() {
@@ -33,6 +52,18 @@ func (c *RouteConfig) SetupV1Route(api fiber.Router) {
 	api = api.Group("v1")
 	c.SetupAuthRoute(api)
 	c.SetupProfileRoute(api)
+	c.SetupNotificationRoute(api)
+	c.SetupCourseRoute(api)
+	c.SetupCohortRoute(api)
+	c.SetupAssignmentRoute(api)
+	c.SetupQuizRoute(api)
+	c.SetupCodeSubmissionRoute(api)
+	c.SetupRubricRoute(api)
+	c.SetupRegradeRoute(api)
+	c.SetupPeerReviewRoute(api)
+	c.SetupLeaderboardRoute(api)
+	c.SetupWebhookRoute(api)
+	c.SetupAdminRoute(api)
 }
 
 func (c *RouteConfig) SetupAuthRoute(api fiber.Router) {
@@ -47,5 +78,199 @@ func (c *RouteConfig) SetupProfileRoute(api fiber.Router) {
 	profiles.Use(c.AuthMiddleware.CheckSession)
 	profiles.Get("/", c.UserController.GetProfiles)
 	profiles.Patch("/", c.UserController.UpdateProfiles)
-	profiles.Patch("/score", c.UserController.UpdateScore)
+	profiles.Get("/notification-preferences", c.NotificationController.GetPreferences)
+	profiles.Put("/notification-preferences", c.NotificationController.UpdatePreferences)
+	profiles.Put("/leaderboard-visibility", c.LeaderboardController.UpdateVisibility)
+}
+
+func (c *RouteConfig) SetupNotificationRoute(api fiber.Router) {
+	notifications := api.Group("notifications")
+	notifications.Use(c.AuthMiddleware.CheckSession)
+	notifications.Get("/", c.NotificationController.List)
+	notifications.Patch("/read-all", c.NotificationController.MarkAllRead)
+	notifications.Patch("/:id/read", c.NotificationController.MarkRead)
+}
+
+func (c *RouteConfig) SetupCourseRoute(api fiber.Router) {
+	staff := c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleAdmin)
+	graders := c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleTA, entity.RoleAdmin)
+	courses := api.Group("courses")
+	courses.Use(c.AuthMiddleware.CheckSession)
+	courses.Get("/", c.CourseController.List)
+	courses.Post("/", staff, c.CourseController.Create)
+	courses.Post("/join", c.CourseController.Join)
+	courses.Get("/:id", c.CourseController.Get)
+	courses.Put("/:id", staff, c.CourseController.Update)
+	courses.Post("/:id/join-code", staff, c.CourseController.RotateJoinCode)
+	courses.Post("/:id/modules", staff, c.CourseController.CreateModule)
+	courses.Put("/:id/modules/:moduleId", staff, c.CourseController.UpdateModule)
+	courses.Get("/:id/enrollments", graders, c.CourseController.ListEnrollments)
+	courses.Post("/:id/enrollments", staff, c.CourseController.Enroll)
+	courses.Put("/:id/enrollments/:userId", staff, c.CourseController.UpdateEnrollment)
+	courses.Get("/:id/progress", c.ProgressController.Course)
+	courses.Get("/:id/progress/matrix", graders, c.ProgressController.Matrix)
+	courses.Get("/:id/gradebook", graders, c.GradebookController.Export)
+	courses.Post("/:id/gradebook/import", staff, c.GradebookController.Import)
+	courses.Get("/:id/grading-scheme", c.GradeController.GetScheme)
+	courses.Put("/:id/grading-scheme", staff, c.GradeController.SaveScheme)
+	courses.Get("/:id/grade", c.GradeController.Get)
+	courses.Get("/:id/leaderboard", c.LeaderboardController.List)
+	courses.Get("/:id/leaderboard/me", c.LeaderboardController.Me)
+}
+
+func (c *RouteConfig) SetupCohortRoute(api fiber.Router) {
+	staff := c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleAdmin)
+	members := c.AuthMiddleware.RequireCohortRole(entity.CohortRoleStudent, entity.CohortRoleInstructor, entity.CohortRoleTA)
+	teachers := c.AuthMiddleware.RequireCohortRole(entity.CohortRoleInstructor, entity.CohortRoleTA)
+	instructors := c.AuthMiddleware.RequireCohortRole(entity.CohortRoleInstructor)
+	cohorts := api.Group("cohorts")
+	cohorts.Use(c.AuthMiddleware.CheckSession)
+	cohorts.Get("/", c.CohortController.List)
+	cohorts.Post("/", staff, c.CohortController.Create)
+	cohorts.Post("/moves", staff, c.CohortController.MoveStudents)
+	cohorts.Get("/:id", members, c.CohortController.Get)
+	cohorts.Put("/:id", staff, instructors, c.CohortController.Update)
+	cohorts.Get("/:id/members", teachers, c.CohortController.ListMembers)
+	cohorts.Post("/:id/members", staff, instructors, c.CohortController.AddMembers)
+	cohorts.Delete("/:id/members/:userId", staff, instructors, c.CohortController.RemoveMember)
+	cohorts.Get("/:id/scores", teachers, c.CohortController.Scores)
+	cohorts.Get("/:id/leaderboard", members, c.LeaderboardController.Cohort)
+	cohorts.Get("/:id/groups", members, c.GroupController.List)
+	cohorts.Post("/:id/groups", staff, instructors, c.GroupController.Create)
+	cohorts.Put("/:id/groups/:groupId", staff, instructors, c.GroupController.Update)
+	cohorts.Delete("/:id/groups/:groupId", staff, instructors, c.GroupController.Delete)
+	cohorts.Post("/:id/groups/:groupId/members", staff, instructors, c.GroupController.AddMembers)
+	cohorts.Delete("/:id/groups/:groupId/members/:userId", staff, instructors, c.GroupController.RemoveMember)
+	cohorts.Post("/:id/groups/:groupId/join", members, c.GroupController.Join)
+	cohorts.Post("/:id/groups/:groupId/leave", members, c.GroupController.Leave)
+}
+
+func (c *RouteConfig) SetupAssignmentRoute(api fiber.Router) {
+	staff := c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleAdmin)
+	graders := c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleTA, entity.RoleAdmin)
+	assignments := api.Group("assignments")
+	assignments.Use(c.AuthMiddleware.CheckSession)
+	assignments.Get("/", c.AssignmentController.List)
+	assignments.Post("/", staff, c.AssignmentController.Create)
+	assignments.Get("/:id", c.AssignmentController.Get)
+	assignments.Put("/:id", staff, c.AssignmentController.Update)
+	assignments.Post("/:id/start", c.ProgressController.Start)
+	assignments.Patch("/:id/score", c.LabClientController.UpdateScore)
+	assignments.Get("/:id/client-keys", staff, c.LabClientController.ListKeys)
+	assignments.Post("/:id/client-keys", staff, c.LabClientController.CreateKey)
+	assignments.Delete("/:id/client-keys/:keyId", staff, c.LabClientController.RevokeKey)
+	assignments.Get("/:id/quiz", c.QuizController.GetQuiz)
+	assignments.Put("/:id/quiz", staff, c.QuizController.SaveQuiz)
+	assignments.Post("/:id/quiz/attempts", c.QuizController.StartAttempt)
+	assignments.Get("/:id/quiz/attempts", graders, c.QuizController.ListAttempts)
+	assignments.Get("/:id/quiz/exam-summary", graders, c.QuizController.ExamSummary)
+	assignments.Get("/:id/code-grader", staff, c.CodeGradingController.GetGrader)
+	assignments.Put("/:id/code-grader", staff, c.CodeGradingController.SaveGrader)
+	assignments.Post("/:id/code-submissions", c.CodeGradingController.Submit)
+	assignments.Get("/:id/code-submissions", graders, c.CodeGradingController.ListSubmissions)
+	assignments.Get("/:id/similarity", staff, c.SimilarityController.GetReport)
+	assignments.Post("/:id/similarity", staff, c.SimilarityController.Check)
+	assignments.Put("/:id/similarity/starter-code", staff, c.SimilarityController.SaveAllowList)
+	assignments.Get("/:id/rubric", c.RubricController.GetForAssignment)
+	assignments.Put("/:id/rubric", staff, c.RubricController.Attach)
+	assignments.Get("/:id/rubric-grade", c.RubricController.GetGrade)
+	assignments.Get("/:id/rubric-grades", graders, c.RubricController.ListGrades)
+	assignments.Put("/:id/rubric-grades", graders, c.RubricController.Grade)
+	assignments.Post("/:id/regrade-requests", c.RegradeController.Create)
+	assignments.Get("/:id/peer-review", c.PeerReviewController.Get)
+	assignments.Put("/:id/peer-review", staff, c.PeerReviewController.Save)
+	assignments.Get("/:id/peer-review/submission", c.PeerReviewController.GetSubmission)
+	assignments.Put("/:id/peer-review/submission", c.PeerReviewController.Submit)
+	assignments.Post("/:id/peer-review/allocate", staff, c.PeerReviewController.Allocate)
+	assignments.Post("/:id/peer-review/finalize", staff, c.PeerReviewController.Finalize)
+	assignments.Put("/:id/peer-review/overrides", staff, c.PeerReviewController.Override)
+	assignments.Get("/:id/peer-review/results", graders, c.PeerReviewController.ListResults)
+	assignments.Get("/:id/peer-reviews", graders, c.PeerReviewController.ListReviews)
+	assignments.Get("/:id/peer-reviews/received", c.PeerReviewController.Received)
+	assignments.Get("/:id/submissions", c.AssignmentController.ListSubmissions)
+	assignments.Get("/:id/extensions", staff, c.AssignmentController.ListExtensions)
+	assignments.Post("/:id/extensions", staff, c.AssignmentController.CreateExtension)
+	assignments.Delete("/:id/extensions/:extensionId", staff, c.AssignmentController.DeleteExtension)
+}
+
+func (c *RouteConfig) SetupQuizRoute(api fiber.Router) {
+	staff := c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleAdmin)
+	graders := c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleTA, entity.RoleAdmin)
+	quizzes := api.Group("quizzes")
+	quizzes.Use(c.AuthMiddleware.CheckSession)
+	quizzes.Get("/banks", staff, c.QuizController.ListBanks)
+	quizzes.Post("/banks", staff, c.QuizController.CreateBank)
+	quizzes.Get("/banks/:id", staff, c.QuizController.GetBank)
+	quizzes.Put("/banks/:id", staff, c.QuizController.UpdateBank)
+	quizzes.Get("/banks/:id/questions", staff, c.QuizController.ListQuestions)
+	quizzes.Post("/banks/:id/import", staff, c.QuizController.ImportQuestions)
+	quizzes.Get("/banks/:id/export", staff, c.QuizController.ExportQuestions)
+	quizzes.Post("/banks/:id/questions", staff, c.QuizController.CreateQuestion)
+	quizzes.Put("/banks/:id/questions/:questionId", staff, c.QuizController.UpdateQuestion)
+	quizzes.Delete("/banks/:id/questions/:questionId", staff, c.QuizController.DeleteQuestion)
+	quizzes.Get("/banks/:id/questions/:questionId/preview", staff, c.QuizController.PreviewQuestion)
+	quizzes.Get("/attempts/:id", c.QuizController.GetAttempt)
+	quizzes.Put("/attempts/:id/answers", c.QuizController.SaveAnswers)
+	quizzes.Post("/attempts/:id/submit", c.QuizController.Submit)
+	quizzes.Post("/attempts/:id/events", c.QuizController.RecordEvents)
+	quizzes.Get("/attempts/:id/events", graders, c.QuizController.ListEvents)
+}
+
+func (c *RouteConfig) SetupCodeSubmissionRoute(api fiber.Router) {
+	submissions := api.Group("code-submissions")
+	submissions.Use(c.AuthMiddleware.CheckSession)
+	submissions.Get("/:id", c.CodeGradingController.GetSubmission)
+}
+
+func (c *RouteConfig) SetupRubricRoute(api fiber.Router) {
+	staff := c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleAdmin)
+	rubrics := api.Group("rubrics")
+	rubrics.Use(c.AuthMiddleware.CheckSession)
+	rubrics.Get("/", staff, c.RubricController.List)
+	rubrics.Post("/", staff, c.RubricController.Create)
+	rubrics.Get("/:id", staff, c.RubricController.Get)
+	rubrics.Put("/:id", staff, c.RubricController.Update)
+}
+
+func (c *RouteConfig) SetupRegradeRoute(api fiber.Router) {
+	graders := c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleTA, entity.RoleAdmin)
+	regrades := api.Group("regrade-requests")
+	regrades.Use(c.AuthMiddleware.CheckSession)
+	regrades.Get("/", c.RegradeController.List)
+	regrades.Get("/:id", c.RegradeController.Get)
+	regrades.Post("/:id/accept", graders, c.RegradeController.Accept)
+	regrades.Post("/:id/reject", graders, c.RegradeController.Reject)
+	regrades.Post("/:id/escalate", graders, c.RegradeController.Escalate)
+}
+
+func (c *RouteConfig) SetupPeerReviewRoute(api fiber.Router) {
+	reviews := api.Group("peer-reviews")
+	reviews.Use(c.AuthMiddleware.CheckSession)
+	reviews.Get("/", c.PeerReviewController.ListTasks)
+	reviews.Get("/:id", c.PeerReviewController.GetTask)
+	reviews.Put("/:id", c.PeerReviewController.SubmitReview)
+}
+
+func (c *RouteConfig) SetupLeaderboardRoute(api fiber.Router) {
+	leaderboard := api.Group("leaderboard")
+	leaderboard.Use(c.AuthMiddleware.CheckSession)
+	leaderboard.Get("/", c.LeaderboardController.List)
+	leaderboard.Get("/me", c.LeaderboardController.Me)
+}
+
+func (c *RouteConfig) SetupWebhookRoute(api fiber.Router) {
+	webhooks := api.Group("webhooks")
+	webhooks.Post("/mail/events", c.MailController.ReceiveEvents)
+}
+
+func (c *RouteConfig) SetupAdminRoute(api fiber.Router) {
+	admin := api.Group("admin")
+	admin.Use(c.AuthMiddleware.CheckSession)
+	admin.Use(c.AuthMiddleware.RequireRole(entity.RoleInstructor, entity.RoleTA, entity.RoleAdmin))
+	admin.Get("/users", c.UserController.AdminListUsers)
+	admin.Get("/users/:id", c.UserController.AdminGetUser)
+	admin.Post("/users/:id/email-status/reset", c.UserController.AdminResetEmailStatus)
+	admin.Put("/users/:id/role", c.AuthMiddleware.RequireRole(entity.RoleAdmin), c.UserController.AdminSetRole)
+	admin.Get("/scores/history", c.ScoreController.History)
+	admin.Post("/scores/corrections", c.ScoreController.Correct)
 }
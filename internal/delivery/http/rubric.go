@@ -0,0 +1,151 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type RubricController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.RubricUseCase
+}
+
+func NewRubricController(useCase *usecase.RubricUseCase, logger *logrus.Logger) *RubricController {
+	return &RubricController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *RubricController) List(ctx *fiber.Ctx) error {
+	request := &model.ListRubricRequest{
+		CourseID: ctx.Query("course_id"),
+		Page:     ctx.QueryInt("page", 1),
+		Limit:    ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.List(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get rubrics", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting rubrics", nil, response))
+}
+
+func (c *RubricController) Get(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.Get(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get rubric", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting rubric", nil, response))
+}
+
+func (c *RubricController) Create(ctx *fiber.Ctx) error {
+	request := new(model.SaveRubricRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+
+	response, err := c.UseCase.Create(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to create rubric", err, nil))
+	}
+	ctx.Status(fiber.StatusCreated)
+	return ctx.JSON(model.NewWebResponse("Rubric has been created", nil, response))
+}
+
+func (c *RubricController) Update(ctx *fiber.Ctx) error {
+	request := new(model.SaveRubricRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.Update(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update rubric", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Rubric has been updated", nil, response))
+}
+
+func (c *RubricController) GetForAssignment(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.GetForAssignment(ctx.UserContext(), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get rubric", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting rubric", nil, response))
+}
+
+func (c *RubricController) Attach(ctx *fiber.Ctx) error {
+	request := new(model.AttachRubricRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.Attach(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to attach rubric", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Rubric has been attached", nil, response))
+}
+
+func (c *RubricController) Grade(ctx *fiber.Ctx) error {
+	request := new(model.GradeRubricRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+	request.SourceIP = ctx.IP()
+
+	response, err := c.UseCase.Grade(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to grade", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Grade has been recorded", nil, response))
+}
+
+func (c *RubricController) GetGrade(ctx *fiber.Ctx) error {
+	request := &model.GetRubricGradeRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+		Email:        ctx.Query("email"),
+	}
+	response, err := c.UseCase.GetGrade(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get rubric grade", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting rubric grade", nil, response))
+}
+
+func (c *RubricController) ListGrades(ctx *fiber.Ctx) error {
+	request := &model.ListRubricGradeRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.ListGrades(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get rubric grades", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting rubric grades", nil, response))
+}
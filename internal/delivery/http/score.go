@@ -0,0 +1,54 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type ScoreController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.ScoreUseCase
+}
+
+func NewScoreController(useCase *usecase.ScoreUseCase, logger *logrus.Logger) *ScoreController {
+	return &ScoreController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *ScoreController) History(ctx *fiber.Ctx) error {
+	request := &model.ScoreHistoryRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		UserEmail:    ctx.Query("email"),
+		AssignmentID: ctx.Query("assignment_id"),
+		Page:         ctx.QueryInt("page", 1),
+		Limit:        ctx.QueryInt("limit", 20),
+	}
+	response, err := c.UseCase.History(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get score history", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting score history", nil, response))
+}
+
+func (c *ScoreController) Correct(ctx *fiber.Ctx) error {
+	request := new(model.ScoreCorrectionRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.SourceIP = ctx.IP()
+
+	response, err := c.UseCase.Correct(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to correct score", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Score has been corrected", nil, response))
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/usecase"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type SimilarityController struct {
+	Log     *logrus.Logger
+	UseCase *usecase.SimilarityUseCase
+}
+
+func NewSimilarityController(useCase *usecase.SimilarityUseCase, logger *logrus.Logger) *SimilarityController {
+	return &SimilarityController{
+		Log:     logger,
+		UseCase: useCase,
+	}
+}
+
+func (c *SimilarityController) GetReport(ctx *fiber.Ctx) error {
+	request := &model.GetSimilarityReportRequest{
+		ActorEmail:   ctx.Locals("user").(string),
+		AssignmentID: ctx.Params("id"),
+	}
+	response, err := c.UseCase.GetReport(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get similarity report", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting similarity report", nil, response))
+}
+
+func (c *SimilarityController) Check(ctx *fiber.Ctx) error {
+	request := new(model.CheckSimilarityRequest)
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(request); err != nil {
+			ctx.Status(fiber.StatusBadRequest)
+			return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+		}
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.Check(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to check similarity", err, nil))
+	}
+	ctx.Status(fiber.StatusAccepted)
+	return ctx.JSON(model.NewWebResponse("Similarity check has been queued", nil, response))
+}
+
+func (c *SimilarityController) SaveAllowList(ctx *fiber.Ctx) error {
+	request := new(model.SaveAllowListRequest)
+	if err := ctx.BodyParser(request); err != nil {
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.AssignmentID = ctx.Params("id")
+
+	response, err := c.UseCase.SaveAllowList(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to save starter code", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Starter code has been saved", nil, response))
+}
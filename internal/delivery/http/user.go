@@ -166,29 +166,53 @@ func (c *UserController) UpdateProfiles(ctx *fiber.Ctx) error {
 	return ctx.JSON(model.NewWebResponse("Profiles has been updated", nil, updatedUser))
 }
 
-func (c *UserController) UpdateScore(ctx *fiber.Ctx) error {
-	userEmail := ctx.Locals("user").(string)
-	request := new(model.UpdateScoreRequest)
+func (c *UserController) AdminListUsers(ctx *fiber.Ctx) error {
+	request := &model.AdminListUserRequest{
+		ActorEmail:    ctx.Locals("user").(string),
+		Page:          ctx.QueryInt("page", 1),
+		Limit:         ctx.QueryInt("limit", 20),
+		Search:        ctx.Query("search"),
+		Undeliverable: ctx.QueryBool("undeliverable", false),
+	}
+	response, err := c.UseCase.AdminListUsers(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get users", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting users", nil, response))
+}
+
+func (c *UserController) AdminGetUser(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.AdminGetUser(ctx.UserContext(), ctx.Locals("user").(string), ctx.Params("id"))
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to get user", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Success getting user", nil, response))
+}
+
+func (c *UserController) AdminSetRole(ctx *fiber.Ctx) error {
+	request := new(model.AdminSetRoleRequest)
 	if err := ctx.BodyParser(request); err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(model.NewWebResponse(
-			"Failed to parse request",
-			err,
-			nil,
-		))
+		ctx.Status(fiber.StatusBadRequest)
+		return ctx.JSON(model.NewWebResponse("Failed to parse request", util.ErrInvalidFormatRequest, nil))
+	}
+	request.ActorEmail = ctx.Locals("user").(string)
+	request.ID = ctx.Params("id")
+
+	response, err := c.UseCase.AdminSetRole(ctx.UserContext(), request)
+	if err != nil {
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to update role", err, nil))
 	}
+	return ctx.JSON(model.NewWebResponse("Role has been updated", nil, response))
+}
 
-	response, err := c.UseCase.UpdateScore(ctx.Context(), userEmail, request.Score)
+func (c *UserController) AdminResetEmailStatus(ctx *fiber.Ctx) error {
+	response, err := c.UseCase.AdminResetEmailStatus(ctx.UserContext(), ctx.Locals("user").(string), ctx.Params("id"))
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(model.NewWebResponse(
-			"Failed to update score",
-			err,
-			nil,
-		))
-	}
-
-	return ctx.JSON(model.NewWebResponse(
-		"Score updated successfully",
-		nil,
-		response,
-	))
+		ctx.Status(util.StatusCode(err))
+		return ctx.JSON(model.NewWebResponse("Failed to reset email status", err, nil))
+	}
+	return ctx.JSON(model.NewWebResponse("Email status has been reset", nil, response))
 }
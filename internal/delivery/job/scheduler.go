@@ -0,0 +1,75 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/sirupsen/logrus"
+)
+
+type Task struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs background tasks on fixed intervals inside the API process.
+// Each task runs in its own goroutine, so a slow task never delays another one.
+type Scheduler struct {
+	Log   *logrus.Logger
+	Tasks []Task
+}
+
+func NewScheduler(log *logrus.Logger) *Scheduler {
+	return &Scheduler{
+		Log: log,
+	}
+}
+
+func (s *Scheduler) Every(interval time.Duration, name string, run func(ctx context.Context) error) {
+	s.Tasks = append(s.Tasks, Task{Name: name, Interval: interval, Run: run})
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, task := range s.Tasks {
+		go s.loop(ctx, task)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, task Task) {
+	ticker := time.NewTicker(task.Interval)
+	defer ticker.Stop()
+	for {
+		s.run(ctx, task)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, task Task) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.Log.WithFields(logrus.Fields{
+				"job":         task.Name,
+				util.LogError: r,
+			}).Error("Job panicked")
+		}
+	}()
+
+	started := time.Now()
+	if err := task.Run(ctx); err != nil {
+		s.Log.WithFields(logrus.Fields{
+			"job":         task.Name,
+			util.LogError: err,
+		}).Error("Job failed")
+		return
+	}
+	s.Log.WithFields(logrus.Fields{
+		"job":      task.Name,
+		"duration": time.Since(started).String(),
+	}).Debug("Job finished")
+}
@@ -0,0 +1,191 @@
+package entity
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ScorePolicyBest    = "best"
+	ScorePolicyLatest  = "latest"
+	ScorePolicyAverage = "average"
+)
+
+const (
+	LatePolicyNone          = "none"
+	LatePolicyHardClose     = "hard_close"
+	LatePolicyPercentPerDay = "percent_per_day"
+	LatePolicyLinearDecay   = "linear_decay"
+)
+
+// Assignments are graded manually, with scores reported by the student or
+// recorded by staff, unless an auto-grader, a rubric or peer review is
+// attached. Students cannot report their own scores for any of those.
+const (
+	GradingManual     = "manual"
+	GradingQuiz       = "quiz"
+	GradingCode       = "code"
+	GradingRubric     = "rubric"
+	GradingPeerReview = "peer_review"
+)
+
+// LatePolicy decides what happens to work submitted after the due date.
+//   - none: late work counts in full.
+//   - hard_close: nothing is accepted after the due date.
+//   - percent_per_day: Percent is taken off for every started day late.
+//   - linear_decay: the score shrinks linearly from 100% at the due date to
+//     Floor percent after Days days, and stays at Floor afterwards.
+type LatePolicy struct {
+	Type    string  `bson:"type"`
+	Percent float64 `bson:"percent,omitempty"`
+	Days    float64 `bson:"days,omitempty"`
+	Floor   float64 `bson:"floor,omitempty"`
+}
+
+type Assignment struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty"`
+	Title       string              `bson:"title"`
+	Description string              `bson:"description"`
+	CourseID    *primitive.ObjectID `bson:"course_id,omitempty"`
+	ModuleID    *primitive.ObjectID `bson:"module_id,omitempty"`
+	Position    int                 `bson:"position"`
+	MaxScore    int                 `bson:"max_score"`
+	OpensAt     *time.Time          `bson:"opens_at"`
+	DueAt       *time.Time          `bson:"due_at"`
+	ClosesAt    *time.Time          `bson:"closes_at"`
+	LatePolicy  LatePolicy          `bson:"late_policy"`
+	MaxAttempts int                 `bson:"max_attempts"` // 0 means unlimited
+	ScorePolicy string              `bson:"score_policy"`
+	Grading     string              `bson:"grading,omitempty"`
+	RubricID    *primitive.ObjectID `bson:"rubric_id,omitempty"`
+	RegradeDays int                 `bson:"regrade_days,omitempty"` // 0 means DefaultRegradeWindow
+	GroupMode   bool                `bson:"group_mode,omitempty"`   // one submission and score for the whole group
+	Category    string              `bson:"category,omitempty"`     // key of a category of the course's grading scheme
+	Legacy      bool                `bson:"legacy,omitempty"`
+	CreatedBy   primitive.ObjectID  `bson:"created_by,omitempty"`
+	CreatedAt   time.Time           `bson:"created_at"`
+	UpdatedAt   *time.Time          `bson:"updated_at"`
+}
+
+// IsAutoGraded reports whether the assignment's scores come from an auto-grader
+// or a rubric rather than from the student.
+func (a *Assignment) IsAutoGraded() bool {
+	return a.Grading != "" && a.Grading != GradingManual
+}
+
+// IsOpen reports whether submissions are accepted at the given time.
+func (a *Assignment) IsOpen(now time.Time) bool {
+	_, closesAt := a.DeadlinesFor(nil)
+	return a.IsOpenUntil(now, closesAt)
+}
+
+// IsOpenUntil is IsOpen with the closing time already adjusted by DeadlinesFor.
+func (a *Assignment) IsOpenUntil(now time.Time, closesAt *time.Time) bool {
+	if a.OpensAt != nil && now.Before(*a.OpensAt) {
+		return false
+	}
+	if closesAt != nil && now.After(*closesAt) {
+		return false
+	}
+	return true
+}
+
+// DeadlinesFor returns the due and closing times that apply with the given
+// extension, which may be nil. A hard close policy closes at the due date, and
+// an extension moves the closing time out as far as its own due date.
+func (a *Assignment) DeadlinesFor(extension *Extension) (dueAt, closesAt *time.Time) {
+	dueAt, closesAt = a.DueAt, a.ClosesAt
+	if extension != nil {
+		due := extension.DueAt
+		dueAt = &due
+		if closesAt != nil && closesAt.Before(due) {
+			closesAt = &due
+		}
+	}
+	if a.LatePolicy.Type == LatePolicyHardClose && dueAt != nil && (closesAt == nil || dueAt.Before(*closesAt)) {
+		closesAt = dueAt
+	}
+	return dueAt, closesAt
+}
+
+// ApplyLatePolicy returns the score after the late penalty and the number of
+// days late. Days are counted as calendar days in loc, with anything late on the
+// due date itself counting as one day.
+func (a *Assignment) ApplyLatePolicy(raw int, dueAt *time.Time, submittedAt time.Time, loc *time.Location) (int, int) {
+	if dueAt == nil || !submittedAt.After(*dueAt) {
+		return raw, 0
+	}
+	due := dueAt.In(loc)
+	submitted := submittedAt.In(loc)
+	dueDate := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, loc)
+	submittedDate := time.Date(submitted.Year(), submitted.Month(), submitted.Day(), 0, 0, 0, 0, loc)
+	daysLate := max(int(math.Round(submittedDate.Sub(dueDate).Hours()/24)), 1)
+
+	factor := 1.0
+	switch a.LatePolicy.Type {
+	case LatePolicyPercentPerDay:
+		factor = 1 - a.LatePolicy.Percent*float64(daysLate)/100
+	case LatePolicyLinearDecay:
+		floor := a.LatePolicy.Floor / 100
+		elapsed := submittedAt.Sub(*dueAt).Hours() / 24
+		factor = max(1-(1-floor)*elapsed/a.LatePolicy.Days, floor)
+	}
+	return int(math.Round(float64(raw) * max(factor, 0))), daysLate
+}
+
+// AttemptsLeft returns -1 when the assignment allows unlimited attempts.
+func (a *Assignment) AttemptsLeft(used int) int {
+	if a.MaxAttempts == 0 {
+		return -1
+	}
+	return max(a.MaxAttempts-used, 0)
+}
+
+// EffectiveScore applies the assignment's score policy to the submissions and
+// returns the score that counts plus the attempt it came from. The attempt is 0
+// for the average policy, where no single attempt counts.
+func (a *Assignment) EffectiveScore(submissions []Submission) (int, int) {
+	if len(submissions) == 0 {
+		return 0, 0
+	}
+	switch a.ScorePolicy {
+	case ScorePolicyLatest:
+		latest := submissions[0]
+		for _, submission := range submissions[1:] {
+			if submission.Attempt > latest.Attempt {
+				latest = submission
+			}
+		}
+		return latest.Score, latest.Attempt
+	case ScorePolicyAverage:
+		sum := 0
+		for _, submission := range submissions {
+			sum += submission.Score
+		}
+		return int(math.Round(float64(sum) / float64(len(submissions)))), 0
+	default:
+		best := submissions[0]
+		for _, submission := range submissions[1:] {
+			if submission.Score > best.Score {
+				best = submission
+			}
+		}
+		return best.Score, best.Attempt
+	}
+}
+
+// CountedDaysLate returns how many days late the counted attempt was submitted.
+// Under the average policy every attempt counts, so the most days late of any
+// attempt is returned.
+func (a *Assignment) CountedDaysLate(submissions []Submission) int {
+	_, attempt := a.EffectiveScore(submissions)
+	daysLate := 0
+	for _, submission := range submissions {
+		if attempt == 0 || submission.Attempt == attempt {
+			daysLate = max(daysLate, submission.DaysLate)
+		}
+	}
+	return daysLate
+}
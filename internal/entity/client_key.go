@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Algorithms a lab client can sign score submissions with.
+const (
+	ClientKeyHMAC    = "hmac-sha256"
+	ClientKeyEd25519 = "ed25519"
+)
+
+// ClientKey is the key lab client builds for one assignment sign their score
+// submissions with. Key holds the shared secret for HMAC keys and the public
+// key for Ed25519 keys, whose private half the server does not keep.
+type ClientKey struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	AssignmentID primitive.ObjectID `bson:"assignment_id"`
+	Algorithm    string             `bson:"algorithm"`
+	Key          []byte             `bson:"key"`
+	Label        string             `bson:"label,omitempty"`
+	CreatedBy    primitive.ObjectID `bson:"created_by"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	RevokedAt    *time.Time         `bson:"revoked_at"`
+}
+
+// Verify reports whether signature is the key's signature of message.
+func (k *ClientKey) Verify(message, signature []byte) bool {
+	switch k.Algorithm {
+	case ClientKeyHMAC:
+		mac := hmac.New(sha256.New, k.Key)
+		mac.Write(message)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case ClientKeyEd25519:
+		return len(k.Key) == ed25519.PublicKeySize && ed25519.Verify(k.Key, message, signature)
+	}
+	return false
+}
+
+// ScoreNonce marks a nonce as used by a signed score submission. It is kept
+// until the submission's timestamp would be rejected anyway.
+type ScoreNonce struct {
+	KeyID     primitive.ObjectID `bson:"key_id"`
+	Nonce     string             `bson:"nonce"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+}
@@ -0,0 +1,185 @@
+package entity
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Languages a code grader can be set up for. Each but custom comes with the
+// file the upload is saved as and the commands that build and run it; custom
+// graders spell those out themselves.
+const (
+	CodeLanguagePython = "python"
+	CodeLanguageC      = "c"
+	CodeLanguageCPP    = "cpp"
+	CodeLanguageCustom = "custom"
+)
+
+type CodeLanguage struct {
+	Filename string
+	Build    []string
+	Run      []string
+}
+
+var CodeLanguages = map[string]CodeLanguage{
+	CodeLanguagePython: {Filename: "main.py", Run: []string{"python3", "main.py"}},
+	CodeLanguageC: {
+		Filename: "main.c",
+		Build:    []string{"gcc", "-O2", "-std=c17", "-o", "main", "main.c", "-lm"},
+		Run:      []string{"./main"},
+	},
+	CodeLanguageCPP: {
+		Filename: "main.cpp",
+		Build:    []string{"g++", "-O2", "-std=c++17", "-o", "main", "main.cpp"},
+		Run:      []string{"./main"},
+	},
+}
+
+// How a test's output is compared with what it expects.
+//   - exact: byte for byte.
+//   - trimmed: trailing whitespace on each line and trailing blank lines are
+//     ignored.
+//   - tokens: only the whitespace separated words have to match.
+const (
+	CodeCompareExact   = "exact"
+	CodeCompareTrimmed = "trimmed"
+	CodeCompareTokens  = "tokens"
+)
+
+const CodeRunnerLocal = "local"
+
+// CodeTest is one test case: the program is run with Input on stdin and passes
+// when its output matches Expected. Hidden tests only show students whether
+// they passed.
+type CodeTest struct {
+	Name     string `bson:"name"`
+	Input    string `bson:"input"`
+	Expected string `bson:"expected"`
+	Weight   int    `bson:"weight"`
+	Hidden   bool   `bson:"hidden"`
+}
+
+// CodeGrader turns an assignment into one graded by running the uploaded
+// program against test cases. TimeLimit is in milliseconds per test and
+// MemoryLimit in megabytes.
+type CodeGrader struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	AssignmentID primitive.ObjectID `bson:"assignment_id"`
+	Runner       string             `bson:"runner"`
+	Language     string             `bson:"language"`
+	Filename     string             `bson:"filename"`
+	Build        []string           `bson:"build"`
+	Run          []string           `bson:"run"`
+	TimeLimit    int                `bson:"time_limit"`
+	MemoryLimit  int                `bson:"memory_limit"`
+	Compare      string             `bson:"compare"`
+	Tests        []CodeTest         `bson:"tests"`
+	CreatedBy    primitive.ObjectID `bson:"created_by,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at"`
+	UpdatedAt    *time.Time         `bson:"updated_at"`
+}
+
+// Matches compares a test's output with what it expects.
+func (g *CodeGrader) Matches(expected, actual string) bool {
+	switch g.Compare {
+	case CodeCompareExact:
+		return expected == actual
+	case CodeCompareTokens:
+		return strings.Join(strings.Fields(expected), " ") == strings.Join(strings.Fields(actual), " ")
+	default:
+		return trimOutput(expected) == trimOutput(actual)
+	}
+}
+
+func trimOutput(output string) string {
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// PossibleWeight is the total weight of the tests.
+func (g *CodeGrader) PossibleWeight() int {
+	total := 0
+	for _, test := range g.Tests {
+		total += test.Weight
+	}
+	return total
+}
+
+const (
+	CodeSubmissionQueued  = "queued"
+	CodeSubmissionRunning = "running"
+	CodeSubmissionGraded  = "graded"
+	CodeSubmissionFailed  = "failed"
+)
+
+// Test result statuses. compile_error is given to every test when the build
+// fails.
+const (
+	CodeTestPassed       = "passed"
+	CodeTestWrongAnswer  = "wrong_answer"
+	CodeTestTimeout      = "timeout"
+	CodeTestMemoryLimit  = "memory_limit"
+	CodeTestOutputLimit  = "output_limit"
+	CodeTestRuntimeError = "runtime_error"
+	CodeTestCompileError = "compile_error"
+)
+
+// CodeTestResult is how the program did on one test. Stdout and Stderr are
+// kept cut short, for the report.
+type CodeTestResult struct {
+	Name     string `bson:"name"`
+	Status   string `bson:"status"`
+	Weight   int    `bson:"weight"`
+	Hidden   bool   `bson:"hidden"`
+	TimeMs   int64  `bson:"time_ms"`
+	ExitCode int    `bson:"exit_code"`
+	Stdout   string `bson:"stdout,omitempty"`
+	Stderr   string `bson:"stderr,omitempty"`
+}
+
+// CodeSubmission is an uploaded program and its place in the grading queue.
+// A worker claims a queued submission by setting LockedUntil; one whose
+// worker died is claimed again once the lock runs out, up to a few Tries.
+type CodeSubmission struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	AssignmentID primitive.ObjectID `bson:"assignment_id"`
+	UserID       primitive.ObjectID `bson:"user_id"`
+	Language     string             `bson:"language"`
+	Filename     string             `bson:"filename"`
+	Source       string             `bson:"source"`
+	Status       string             `bson:"status"`
+	BuildOutput  string             `bson:"build_output,omitempty"`
+	Results      []CodeTestResult   `bson:"results,omitempty"`
+	Earned       int                `bson:"earned"`
+	Possible     int                `bson:"possible"`
+	Score        int                `bson:"score"`             // scaled to the assignment, before any late penalty
+	Attempt      int                `bson:"attempt,omitempty"` // the submission attempt the score was recorded as
+	Error        string             `bson:"error,omitempty"`
+	Tries        int                `bson:"tries"`
+	SourceIP     string             `bson:"source_ip,omitempty"`
+	SubmittedAt  time.Time          `bson:"submitted_at"`
+	StartedAt    *time.Time         `bson:"started_at"`
+	FinishedAt   *time.Time         `bson:"finished_at"`
+	LockedUntil  *time.Time         `bson:"locked_until,omitempty"`
+}
+
+// Grade totals the weights of the passed tests and scales them to maxScore.
+func (s *CodeSubmission) Grade(maxScore int) {
+	s.Earned, s.Possible = 0, 0
+	for _, result := range s.Results {
+		s.Possible += result.Weight
+		if result.Status == CodeTestPassed {
+			s.Earned += result.Weight
+		}
+	}
+	s.Score = 0
+	if s.Possible > 0 {
+		s.Score = int(math.Round(float64(s.Earned) / float64(s.Possible) * float64(maxScore)))
+	}
+}
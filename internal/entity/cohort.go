@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	CohortRoleStudent    = "student"
+	CohortRoleInstructor = "instructor"
+	CohortRoleTA         = "ta"
+)
+
+// Cohort groups students, e.g. "2026 Semester 1 - Class A", under the
+// instructors and TAs who teach them. Staff only see and grade students of the
+// cohorts they belong to.
+type Cohort struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty"`
+	Name      string              `bson:"name"`
+	CourseID  *primitive.ObjectID `bson:"course_id,omitempty"`
+	CreatedBy primitive.ObjectID  `bson:"created_by,omitempty"`
+	CreatedAt time.Time           `bson:"created_at"`
+	UpdatedAt *time.Time          `bson:"updated_at"`
+}
+
+// CohortMember places a user in a cohort. A student belongs to at most one
+// cohort; staff may teach any number of them.
+type CohortMember struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	CohortID primitive.ObjectID `bson:"cohort_id"`
+	UserID   primitive.ObjectID `bson:"user_id"`
+	Role     string             `bson:"role"`
+	AddedBy  primitive.ObjectID `bson:"added_by,omitempty"`
+	AddedAt  time.Time          `bson:"added_at"`
+}
+
+// IsCohortStaffRole reports whether the cohort role teaches the cohort's students.
+func IsCohortStaffRole(role string) bool {
+	return role == CohortRoleInstructor || role == CohortRoleTA
+}
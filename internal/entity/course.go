@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Course struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Code        string             `bson:"code"`
+	Title       string             `bson:"title"`
+	Description string             `bson:"description"`
+	JoinCode    string             `bson:"join_code"`
+	JoinEnabled bool               `bson:"join_enabled"`
+	Timezone    string             `bson:"timezone"` // deadlines and late days are evaluated here
+	CreatedBy   primitive.ObjectID `bson:"created_by,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	UpdatedAt   *time.Time         `bson:"updated_at"`
+}
+
+// Module is one lab module of a course. Modules are listed by Position, then by
+// creation time when positions tie.
+type Module struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	CourseID    primitive.ObjectID `bson:"course_id"`
+	Title       string             `bson:"title"`
+	Description string             `bson:"description"`
+	Position    int                `bson:"position"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	UpdatedAt   *time.Time         `bson:"updated_at"`
+}
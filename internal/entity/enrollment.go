@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	EnrollmentActive    = "active"
+	EnrollmentDropped   = "dropped"
+	EnrollmentCompleted = "completed"
+
+	EnrollmentMethodJoinCode   = "join_code"
+	EnrollmentMethodInstructor = "instructor"
+)
+
+type Enrollment struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	CourseID   primitive.ObjectID `bson:"course_id"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	Status     string             `bson:"status"`
+	Method     string             `bson:"method"`
+	EnrolledBy primitive.ObjectID `bson:"enrolled_by,omitempty"`
+	EnrolledAt time.Time          `bson:"enrolled_at"`
+	UpdatedAt  *time.Time         `bson:"updated_at"`
+}
+
+// CanAccess reports whether the student may still see the course content.
+// Completed students keep read access; dropped students lose it.
+func (e *Enrollment) CanAccess() bool {
+	return e.Status == EnrollmentActive || e.Status == EnrollmentCompleted
+}
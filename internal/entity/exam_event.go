@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Integrity events reported by the exam client, and ip_changed, which the
+// server records when an exam attempt is used from another address than the
+// one it was started from.
+const (
+	ExamEventFocusLost       = "focus_lost"
+	ExamEventFocusGained     = "focus_gained"
+	ExamEventPaste           = "paste"
+	ExamEventCopy            = "copy"
+	ExamEventFullscreenExit  = "fullscreen_exit"
+	ExamEventFullscreenEnter = "fullscreen_enter"
+	ExamEventIPChanged       = "ip_changed"
+)
+
+// ExamEvent is one entry of an exam attempt's integrity timeline. ClientAt is
+// when the client says it happened, ReceivedAt when the server got it.
+type ExamEvent struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	AttemptID    primitive.ObjectID `bson:"attempt_id"`
+	AssignmentID primitive.ObjectID `bson:"assignment_id"`
+	UserID       primitive.ObjectID `bson:"user_id"`
+	Type         string             `bson:"type"`
+	Detail       string             `bson:"detail,omitempty"`
+	IP           string             `bson:"ip"`
+	ClientAt     *time.Time         `bson:"client_at"`
+	ReceivedAt   time.Time          `bson:"received_at"`
+}
+
+// ExamEventCounts is how many events of each type an attempt has.
+type ExamEventCounts struct {
+	AttemptID primitive.ObjectID `bson:"_id"`
+	Counts    map[string]int     `bson:"counts"`
+	Total     int                `bson:"total"`
+}
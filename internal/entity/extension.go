@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Extension moves an assignment's due date for one student or for a whole
+// cohort, e.g. as an accommodation. Exactly one of UserID and CohortID is set.
+type Extension struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty"`
+	AssignmentID primitive.ObjectID  `bson:"assignment_id"`
+	UserID       *primitive.ObjectID `bson:"user_id,omitempty"`
+	CohortID     *primitive.ObjectID `bson:"cohort_id,omitempty"`
+	DueAt        time.Time           `bson:"due_at"`
+	Reason       string              `bson:"reason"`
+	CreatedBy    primitive.ObjectID  `bson:"created_by"`
+	CreatedAt    time.Time           `bson:"created_at"`
+}
+
+// PickExtension chooses the extension that applies to a student: their own
+// extension wins over any cohort extension, and among cohort extensions the
+// latest due date wins.
+func PickExtension(extensions []Extension, userID primitive.ObjectID) *Extension {
+	var picked *Extension
+	for i := range extensions {
+		extension := &extensions[i]
+		if extension.UserID != nil {
+			if *extension.UserID == userID {
+				return extension
+			}
+			continue
+		}
+		if picked == nil || extension.DueAt.After(picked.DueAt) {
+			picked = extension
+		}
+	}
+	return picked
+}
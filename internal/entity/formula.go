@@ -0,0 +1,350 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	errFormulaSyntax   = errors.New("formula syntax error")
+	errFormulaVariable = errors.New("formula uses an unknown variable")
+)
+
+// Formula is an arithmetic expression over named parameters, such as
+// "sqrt(a^2 + b^2)". It knows + - * / ^, parentheses, the constants pi and e,
+// and the functions in formulaFunctions.
+type Formula struct {
+	root formulaNode
+}
+
+type formulaNode interface {
+	eval(values map[string]float64) (float64, error)
+	variables(names map[string]bool)
+}
+
+var formulaFunctions = map[string]struct {
+	args int
+	fn   func(args ...float64) float64
+}{
+	"abs":   {1, func(x ...float64) float64 { return math.Abs(x[0]) }},
+	"sqrt":  {1, func(x ...float64) float64 { return math.Sqrt(x[0]) }},
+	"exp":   {1, func(x ...float64) float64 { return math.Exp(x[0]) }},
+	"ln":    {1, func(x ...float64) float64 { return math.Log(x[0]) }},
+	"log":   {1, func(x ...float64) float64 { return math.Log10(x[0]) }},
+	"sin":   {1, func(x ...float64) float64 { return math.Sin(x[0]) }},
+	"cos":   {1, func(x ...float64) float64 { return math.Cos(x[0]) }},
+	"tan":   {1, func(x ...float64) float64 { return math.Tan(x[0]) }},
+	"floor": {1, func(x ...float64) float64 { return math.Floor(x[0]) }},
+	"ceil":  {1, func(x ...float64) float64 { return math.Ceil(x[0]) }},
+	"round": {1, func(x ...float64) float64 { return math.Round(x[0]) }},
+	"min":   {2, func(x ...float64) float64 { return math.Min(x[0], x[1]) }},
+	"max":   {2, func(x ...float64) float64 { return math.Max(x[0], x[1]) }},
+	"pow":   {2, func(x ...float64) float64 { return math.Pow(x[0], x[1]) }},
+}
+
+var formulaConstants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// IsFormulaName reports whether the name is already taken by a constant or
+// function and so cannot name a parameter.
+func IsFormulaName(name string) bool {
+	name = strings.ToLower(name)
+	_, constant := formulaConstants[name]
+	_, function := formulaFunctions[name]
+	return constant || function
+}
+
+// ParseFormula parses the expression without evaluating it.
+func ParseFormula(text string) (*Formula, error) {
+	p := &formulaParser{tokens: tokenizeFormula(text)}
+	root, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != "" {
+		return nil, fmt.Errorf("%w: unexpected %q", errFormulaSyntax, p.peek())
+	}
+	return &Formula{root: root}, nil
+}
+
+// Variables returns the names the formula needs a value for.
+func (f *Formula) Variables() map[string]bool {
+	names := map[string]bool{}
+	f.root.variables(names)
+	return names
+}
+
+// Eval computes the formula. A result that is not a finite number, e.g. from
+// dividing by zero, is an error.
+func (f *Formula) Eval(values map[string]float64) (float64, error) {
+	result, err := f.root.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, errors.New("formula result is not a finite number")
+	}
+	return result, nil
+}
+
+// tokenizeFormula splits the text into numbers, names and single character
+// operators. Anything else becomes its own token and fails to parse.
+func tokenizeFormula(text string) []string {
+	tokens := []string{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			// Exponents as in 6.02e23 or 1e-3.
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					for i = j; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+					}
+				}
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
+
+type formulaParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *formulaParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *formulaParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+// expression := term (("+" | "-") term)*
+func (p *formulaParser) expression() (formulaNode, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "+" || p.peek() == "-" {
+		op := p.next()
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &formulaBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// term := unary (("*" | "/") unary)*
+func (p *formulaParser) term() (formulaNode, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "*" || p.peek() == "/" {
+		op := p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &formulaBinary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+// unary := ("+" | "-") unary | power
+func (p *formulaParser) unary() (formulaNode, error) {
+	if p.peek() == "-" || p.peek() == "+" {
+		op := p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			return operand, nil
+		}
+		return &formulaBinary{op: "-", left: formulaNumber(0), right: operand}, nil
+	}
+	return p.power()
+}
+
+// power := primary ("^" unary)?, so that 2^-1 and 2^3^2 read as usual.
+func (p *formulaParser) power() (formulaNode, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != "^" {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &formulaBinary{op: "^", left: base, right: exponent}, nil
+}
+
+// primary := number | name | name "(" args ")" | "(" expression ")"
+func (p *formulaParser) primary() (formulaNode, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("%w: unexpected end", errFormulaSyntax)
+	case token == "(":
+		inner, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("%w: missing )", errFormulaSyntax)
+		}
+		return inner, nil
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad number %q", errFormulaSyntax, token)
+		}
+		return formulaNumber(value), nil
+	case unicode.IsLetter(rune(token[0])) || token[0] == '_':
+		if p.peek() != "(" {
+			if value, ok := formulaConstants[strings.ToLower(token)]; ok {
+				return formulaNumber(value), nil
+			}
+			return formulaVariable(token), nil
+		}
+		function, ok := formulaFunctions[strings.ToLower(token)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown function %q", errFormulaSyntax, token)
+		}
+		p.next()
+		call := &formulaCall{fn: function.fn}
+		for {
+			arg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.peek() != "," {
+				break
+			}
+			p.next()
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("%w: missing )", errFormulaSyntax)
+		}
+		if len(call.args) != function.args {
+			return nil, fmt.Errorf("%w: %s takes %d arguments", errFormulaSyntax, token, function.args)
+		}
+		return call, nil
+	}
+	return nil, fmt.Errorf("%w: unexpected %q", errFormulaSyntax, token)
+}
+
+type formulaNumber float64
+
+func (n formulaNumber) eval(map[string]float64) (float64, error) { return float64(n), nil }
+func (n formulaNumber) variables(map[string]bool)                {}
+
+type formulaVariable string
+
+func (v formulaVariable) eval(values map[string]float64) (float64, error) {
+	value, ok := values[string(v)]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", errFormulaVariable, string(v))
+	}
+	return value, nil
+}
+
+func (v formulaVariable) variables(names map[string]bool) { names[string(v)] = true }
+
+type formulaBinary struct {
+	op          string
+	left, right formulaNode
+}
+
+func (b *formulaBinary) eval(values map[string]float64) (float64, error) {
+	left, err := b.left.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	right, err := b.right.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		return left / right, nil
+	case "^":
+		return math.Pow(left, right), nil
+	}
+	return 0, fmt.Errorf("%w: unknown operator %q", errFormulaSyntax, b.op)
+}
+
+func (b *formulaBinary) variables(names map[string]bool) {
+	b.left.variables(names)
+	b.right.variables(names)
+}
+
+type formulaCall struct {
+	fn   func(args ...float64) float64
+	args []formulaNode
+}
+
+func (c *formulaCall) eval(values map[string]float64) (float64, error) {
+	args := make([]float64, 0, len(c.args))
+	for _, arg := range c.args {
+		value, err := arg.eval(values)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, value)
+	}
+	return c.fn(args...), nil
+}
+
+func (c *formulaCall) variables(names map[string]bool) {
+	for _, arg := range c.args {
+		arg.variables(names)
+	}
+}
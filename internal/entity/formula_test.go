@@ -0,0 +1,109 @@
+package entity
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestFormulaEval(t *testing.T) {
+	values := map[string]float64{"a": 3, "b": 4, "x_1": 0.5}
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"12 / 4 / 3", 1},
+		{"2^3^2", 512},
+		{"2^-1", 0.5},
+		{"-2^2", -4},
+		{"--3", 3},
+		{"+a", 3},
+		{"sqrt(a^2 + b^2)", 5},
+		{"SQRT(a^2 + b^2)", 5},
+		{"max(a, b) - min(a, b)", 1},
+		{"pow(2, 10)", 1024},
+		{"round(2.5) + floor(-0.5) + ceil(0.1)", 3},
+		{"6.02e23 / 1e23", 6.02},
+		{"1E-3 * 1000", 1},
+		{".5 + x_1", 1},
+		{"2 * pi", 2 * math.Pi},
+		{"ln(e)", 1},
+		{"log(1000)", 3},
+	}
+	for _, tt := range tests {
+		formula, err := ParseFormula(tt.text)
+		if err != nil {
+			t.Errorf("ParseFormula(%q) error = %v", tt.text, err)
+			continue
+		}
+		got, err := formula.Eval(values)
+		if err != nil {
+			t.Errorf("Eval(%q) error = %v", tt.text, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Eval(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseFormulaErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"1 + 2)",
+		"2 3",
+		"1..2",
+		"sqrt()",
+		"max(1)",
+		"max(1, 2, 3)",
+		"max(1,)",
+		"nope(1)",
+		"1 $ 2",
+		"sqrt(4",
+	}
+	for _, text := range tests {
+		if _, err := ParseFormula(text); !errors.Is(err, errFormulaSyntax) {
+			t.Errorf("ParseFormula(%q) error = %v, want a syntax error", text, err)
+		}
+	}
+}
+
+func TestFormulaEvalErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want error
+	}{
+		{"a + 1", errFormulaVariable},
+		{"1 / 0", nil},
+		{"ln(0)", nil},
+		{"sqrt(-1)", nil},
+	}
+	for _, tt := range tests {
+		formula, err := ParseFormula(tt.text)
+		if err != nil {
+			t.Fatalf("ParseFormula(%q) error = %v", tt.text, err)
+		}
+		_, err = formula.Eval(map[string]float64{})
+		if err == nil {
+			t.Errorf("Eval(%q) succeeded, want an error", tt.text)
+		} else if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("Eval(%q) error = %v, want %v", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestFormulaVariables(t *testing.T) {
+	formula, err := ParseFormula("a * sin(b) + pi - a / C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := formula.Variables()
+	if len(got) != 3 || !got["a"] || !got["b"] || !got["C"] {
+		t.Errorf("Variables() = %v, want a, b and C", got)
+	}
+}
@@ -0,0 +1,192 @@
+package entity
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	GradeCurveNone   = "none"
+	GradeCurveLinear = "linear"
+	GradeCurveCap    = "cap"
+)
+
+// GradeCategory is a weighted part of the course grade, e.g. labs worth 40%.
+// Assignments belong to it through their Category key. The DropLowest lowest
+// scores of the category, by percentage, do not count, as long as one is left.
+type GradeCategory struct {
+	Key        string  `bson:"key"`
+	Name       string  `bson:"name"`
+	Weight     float64 `bson:"weight"` // percent of the course grade
+	DropLowest int     `bson:"drop_lowest,omitempty"`
+}
+
+// LetterGrade is earned by a course grade of at least Min percent.
+type LetterGrade struct {
+	Letter string  `bson:"letter"`
+	Min    float64 `bson:"min"`
+}
+
+// GradeCurve adjusts every student's course grade once the whole course's
+// grades are known. A curve never lowers a grade nor raises one above 100.
+//   - none: grades stay as they are.
+//   - linear: grades are scaled by the same factor so their mean becomes
+//     TargetMean.
+//   - cap: grades are scaled so that the best one becomes Cap, 100 when unset.
+type GradeCurve struct {
+	Type       string  `bson:"type"`
+	TargetMean float64 `bson:"target_mean,omitempty"`
+	Cap        float64 `bson:"cap,omitempty"`
+}
+
+// GradingScheme is how a course's final grade is worked out from its
+// assignments' scores. Assignments outside every category do not count.
+type GradingScheme struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	CourseID   primitive.ObjectID `bson:"course_id"`
+	Categories []GradeCategory    `bson:"categories"`
+	Letters    []LetterGrade      `bson:"letters"` // highest Min first
+	Curve      GradeCurve         `bson:"curve"`
+	UpdatedBy  primitive.ObjectID `bson:"updated_by"`
+	UpdatedAt  time.Time          `bson:"updated_at"`
+	ComputedAt *time.Time         `bson:"computed_at"` // when the course grades were last worked out
+}
+
+// GradeItem is one assignment's score for a student. A missing Score is left
+// out of the grade until Due, and counts as zero afterwards.
+type GradeItem struct {
+	AssignmentID primitive.ObjectID
+	Category     string
+	Score        *int
+	MaxScore     int
+	Due          bool
+}
+
+// CategoryGrade is a student's standing in one category: the points earned
+// out of those possible over the scores that count, and which were dropped.
+type CategoryGrade struct {
+	Key      string               `bson:"key"`
+	Name     string               `bson:"name"`
+	Weight   float64              `bson:"weight"`
+	Earned   int                  `bson:"earned"`
+	Possible int                  `bson:"possible"`
+	Percent  *float64             `bson:"percent"`
+	Dropped  []primitive.ObjectID `bson:"dropped,omitempty"`
+}
+
+// CourseGrade is a student's final grade for a course, as last computed.
+// Percent is the weighted grade and Curved the grade after the course's curve.
+type CourseGrade struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	CourseID   primitive.ObjectID `bson:"course_id"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	Categories []CategoryGrade    `bson:"categories"`
+	Percent    *float64           `bson:"percent"`
+	Curved     *float64           `bson:"curved"`
+	Letter     string             `bson:"letter"`
+	ComputedAt time.Time          `bson:"computed_at"`
+}
+
+// Grade works out a student's category grades and weighted percentage. The
+// weights of categories with nothing to count yet are shared out among the
+// others; the percentage is nil while no category has anything to count.
+func (s *GradingScheme) Grade(items []GradeItem) ([]CategoryGrade, *float64) {
+	categories := make([]CategoryGrade, 0, len(s.Categories))
+	weighted, weights := 0.0, 0.0
+	for _, category := range s.Categories {
+		type counted struct {
+			id         primitive.ObjectID
+			score, max int
+			percent    float64
+		}
+		scores := []counted{}
+		for _, item := range items {
+			if item.Category != category.Key || item.MaxScore <= 0 || item.Score == nil && !item.Due {
+				continue
+			}
+			score := 0
+			if item.Score != nil {
+				score = *item.Score
+			}
+			scores = append(scores, counted{item.AssignmentID, score, item.MaxScore, float64(score) / float64(item.MaxScore)})
+		}
+		sort.SliceStable(scores, func(i, j int) bool { return scores[i].percent < scores[j].percent })
+
+		grade := CategoryGrade{Key: category.Key, Name: category.Name, Weight: category.Weight}
+		drop := min(category.DropLowest, max(len(scores)-1, 0))
+		for i, score := range scores {
+			if i < drop {
+				grade.Dropped = append(grade.Dropped, score.id)
+				continue
+			}
+			grade.Earned += score.score
+			grade.Possible += score.max
+		}
+		if grade.Possible > 0 {
+			percent := RoundPercent(float64(grade.Earned) * 100 / float64(grade.Possible))
+			grade.Percent = &percent
+			weighted += category.Weight * percent
+			weights += category.Weight
+		}
+		categories = append(categories, grade)
+	}
+	if weights == 0 {
+		return categories, nil
+	}
+	percent := RoundPercent(weighted / weights)
+	return categories, &percent
+}
+
+// CurveFactor is what the curve multiplies every grade of the course by.
+func (c GradeCurve) CurveFactor(percents []float64) float64 {
+	if len(percents) == 0 {
+		return 1
+	}
+	factor := 1.0
+	switch c.Type {
+	case GradeCurveLinear:
+		sum := 0.0
+		for _, percent := range percents {
+			sum += percent
+		}
+		if mean := sum / float64(len(percents)); mean > 0 {
+			factor = c.TargetMean / mean
+		}
+	case GradeCurveCap:
+		target := c.Cap
+		if target == 0 {
+			target = 100
+		}
+		best := 0.0
+		for _, percent := range percents {
+			best = max(best, percent)
+		}
+		if best > 0 {
+			factor = target / best
+		}
+	}
+	return max(factor, 1)
+}
+
+// ApplyCurve is the grade after the curve's factor, at most 100.
+func ApplyCurve(percent, factor float64) float64 {
+	return RoundPercent(min(percent*factor, max(percent, 100)))
+}
+
+// Letter is the letter a grade earns, or "" when it is below every boundary.
+func (s *GradingScheme) Letter(percent float64) string {
+	for _, letter := range s.Letters {
+		if percent >= letter.Min {
+			return letter.Letter
+		}
+	}
+	return ""
+}
+
+// RoundPercent rounds a percentage to two decimals.
+func RoundPercent(percent float64) float64 {
+	return math.Round(percent*100) / 100
+}
@@ -0,0 +1,175 @@
+package entity
+
+import (
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGradingSchemeGrade(t *testing.T) {
+	ids := make([]primitive.ObjectID, 5)
+	for i := range ids {
+		ids[i] = primitive.NewObjectID()
+	}
+	score := func(n int) *int { return &n }
+	scheme := &GradingScheme{Categories: []GradeCategory{
+		{Key: "labs", Weight: 60, DropLowest: 1},
+		{Key: "exams", Weight: 40},
+	}}
+	tests := []struct {
+		name    string
+		items   []GradeItem
+		labs    *float64 // nil when nothing counts
+		dropped []primitive.ObjectID
+		percent *float64
+	}{
+		{
+			name: "drops the lowest percentage, not the lowest points",
+			items: []GradeItem{
+				{AssignmentID: ids[0], Category: "labs", Score: score(5), MaxScore: 10},
+				{AssignmentID: ids[1], Category: "labs", Score: score(8), MaxScore: 40},
+				{AssignmentID: ids[2], Category: "exams", Score: score(30), MaxScore: 40},
+			},
+			labs:    ptr(50),
+			dropped: []primitive.ObjectID{ids[1]},
+			percent: ptr(60),
+		},
+		{
+			name: "drops the first of tied scores",
+			items: []GradeItem{
+				{AssignmentID: ids[0], Category: "labs", Score: score(5), MaxScore: 10},
+				{AssignmentID: ids[1], Category: "labs", Score: score(5), MaxScore: 10},
+				{AssignmentID: ids[2], Category: "labs", Score: score(9), MaxScore: 10},
+			},
+			labs:    ptr(70),
+			dropped: []primitive.ObjectID{ids[0]},
+			percent: ptr(70),
+		},
+		{
+			name: "keeps the only score even when dropping one",
+			items: []GradeItem{
+				{AssignmentID: ids[0], Category: "labs", Score: score(3), MaxScore: 10},
+			},
+			labs:    ptr(30),
+			percent: ptr(30),
+		},
+		{
+			name: "counts a missing score as zero only once due",
+			items: []GradeItem{
+				{AssignmentID: ids[0], Category: "labs", Score: score(10), MaxScore: 10},
+				{AssignmentID: ids[1], Category: "labs", Score: score(6), MaxScore: 10},
+				{AssignmentID: ids[2], Category: "labs", MaxScore: 10, Due: true},
+				{AssignmentID: ids[3], Category: "labs", MaxScore: 10},
+			},
+			labs:    ptr(80),
+			dropped: []primitive.ObjectID{ids[2]},
+			percent: ptr(80),
+		},
+		{
+			name: "leaves out items outside the scheme and without a maximum",
+			items: []GradeItem{
+				{AssignmentID: ids[0], Category: "labs", Score: score(1), MaxScore: 0},
+				{AssignmentID: ids[1], Category: "bonus", Score: score(10), MaxScore: 10},
+				{AssignmentID: ids[2], Category: "exams", Score: score(20), MaxScore: 40},
+			},
+			percent: ptr(50),
+		},
+		{
+			name: "has no percentage while nothing counts",
+			items: []GradeItem{
+				{AssignmentID: ids[0], Category: "labs", MaxScore: 10},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			categories, percent := scheme.Grade(tt.items)
+			if len(categories) != 2 {
+				t.Fatalf("got %d categories, want 2", len(categories))
+			}
+			labs := categories[0]
+			if !equalPercent(labs.Percent, tt.labs) {
+				t.Errorf("labs percent = %v, want %v", show(labs.Percent), show(tt.labs))
+			}
+			if !slices.Equal(labs.Dropped, tt.dropped) {
+				t.Errorf("labs dropped = %v, want %v", labs.Dropped, tt.dropped)
+			}
+			if !equalPercent(percent, tt.percent) {
+				t.Errorf("percent = %v, want %v", show(percent), show(tt.percent))
+			}
+		})
+	}
+}
+
+func TestGradeCurve(t *testing.T) {
+	tests := []struct {
+		name     string
+		curve    GradeCurve
+		percents []float64
+		factor   float64
+	}{
+		{"none", GradeCurve{Type: GradeCurveNone}, []float64{50, 70}, 1},
+		{"linear raises the mean", GradeCurve{Type: GradeCurveLinear, TargetMean: 75}, []float64{50, 70}, 1.25},
+		{"linear never lowers", GradeCurve{Type: GradeCurveLinear, TargetMean: 50}, []float64{60, 80}, 1},
+		{"linear with a zero mean", GradeCurve{Type: GradeCurveLinear, TargetMean: 70}, []float64{0, 0}, 1},
+		{"cap defaults to 100", GradeCurve{Type: GradeCurveCap}, []float64{40, 80}, 1.25},
+		{"cap to a set best", GradeCurve{Type: GradeCurveCap, Cap: 90}, []float64{45, 60}, 1.5},
+		{"no grades", GradeCurve{Type: GradeCurveCap}, nil, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.curve.CurveFactor(tt.percents); got != tt.factor {
+				t.Errorf("CurveFactor() = %v, want %v", got, tt.factor)
+			}
+		})
+	}
+}
+
+func TestApplyCurve(t *testing.T) {
+	tests := []struct {
+		percent, factor, want float64
+	}{
+		{60, 1.25, 75},
+		{90, 1.25, 100},
+		{105, 1.25, 105},
+		{33.333, 1, 33.33},
+	}
+	for _, tt := range tests {
+		if got := ApplyCurve(tt.percent, tt.factor); got != tt.want {
+			t.Errorf("ApplyCurve(%v, %v) = %v, want %v", tt.percent, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestGradingSchemeLetter(t *testing.T) {
+	scheme := &GradingScheme{Letters: []LetterGrade{{"A", 85}, {"B", 70}, {"C", 55}}}
+	tests := []struct {
+		percent float64
+		want    string
+	}{
+		{100, "A"},
+		{85, "A"},
+		{84.99, "B"},
+		{55, "C"},
+		{54.99, ""},
+	}
+	for _, tt := range tests {
+		if got := scheme.Letter(tt.percent); got != tt.want {
+			t.Errorf("Letter(%v) = %q, want %q", tt.percent, got, tt.want)
+		}
+	}
+}
+
+func ptr(percent float64) *float64 { return &percent }
+
+func equalPercent(a, b *float64) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func show(percent *float64) any {
+	if percent == nil {
+		return nil
+	}
+	return *percent
+}
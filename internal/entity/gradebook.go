@@ -0,0 +1,17 @@
+package entity
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// GradebookRow is one enrolled student's line of a course gradebook with their
+// scores and submissions for the course's assignments.
+type GradebookRow struct {
+	UserID      primitive.ObjectID `bson:"_id"`
+	Email       string             `bson:"email"`
+	Name        string             `bson:"name"`
+	Cohort      string             `bson:"cohort"`
+	Group       string             `bson:"group"` // the student's group in their cohort, if any
+	Status      string             `bson:"status"`
+	Grade       *CourseGrade       `bson:"grade"` // nil while the course has no grading scheme
+	Scores      []Score            `bson:"scores"`
+	Submissions []Submission       `bson:"submissions"`
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Group is a team of students within a cohort, e.g. the pairs a lab is done
+// in. Instructors place students in groups; groups open for self-signup also
+// let students join and leave them, up to MaxSize members (0 means no limit).
+// A student belongs to at most one group of their cohort.
+type Group struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	CohortID   primitive.ObjectID `bson:"cohort_id"`
+	Name       string             `bson:"name"`
+	MaxSize    int                `bson:"max_size"`
+	SelfSignup bool               `bson:"self_signup"`
+	Size       int                `bson:"size"`
+	CreatedBy  primitive.ObjectID `bson:"created_by,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at"`
+	UpdatedAt  *time.Time         `bson:"updated_at"`
+}
+
+// IsFull reports whether the group has no room for another member.
+func (g *Group) IsFull() bool {
+	return g.MaxSize > 0 && g.Size >= g.MaxSize
+}
+
+type GroupMember struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	GroupID  primitive.ObjectID `bson:"group_id"`
+	CohortID primitive.ObjectID `bson:"cohort_id"`
+	UserID   primitive.ObjectID `bson:"user_id"`
+	AddedBy  primitive.ObjectID `bson:"added_by,omitempty"`
+	AddedAt  time.Time          `bson:"added_at"`
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MailEventHardBounce = "hard_bounce"
+	MailEventSoftBounce = "soft_bounce"
+	MailEventComplaint  = "complaint"
+
+	MailEventSourceWebhook = "webhook"
+	MailEventSourceMailbox = "mailbox"
+)
+
+type MailEvent struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Email      string             `bson:"email"`
+	Type       string             `bson:"type"`
+	Source     string             `bson:"source"`
+	Status     string             `bson:"status"`
+	Diagnostic string             `bson:"diagnostic"`
+	OccurredAt time.Time          `bson:"occurred_at"`
+	CreatedAt  time.Time          `bson:"created_at"`
+}
+
+// Suppresses reports whether the event means mail to the address must stop.
+func (e *MailEvent) Suppresses() bool {
+	return e.Type == MailEventHardBounce || e.Type == MailEventComplaint
+}
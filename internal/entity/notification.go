@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	NotificationCategoryScore      = "score"
+	NotificationCategoryAssignment = "assignment"
+	NotificationCategorySecurity   = "security"
+	NotificationCategoryCourse     = "course"
+)
+
+const (
+	NotificationDeliveryInstantEmail = "instant_email"
+	NotificationDeliveryDailyDigest  = "daily_digest"
+	NotificationDeliveryInApp        = "in_app"
+	NotificationDeliveryOff          = "off"
+)
+
+var NotificationCategories = []string{
+	NotificationCategoryScore,
+	NotificationCategoryAssignment,
+	NotificationCategorySecurity,
+	NotificationCategoryCourse,
+}
+
+var NotificationDeliveries = []string{
+	NotificationDeliveryInstantEmail,
+	NotificationDeliveryDailyDigest,
+	NotificationDeliveryInApp,
+	NotificationDeliveryOff,
+}
+
+func DefaultNotificationDelivery(category string) string {
+	if category == NotificationCategorySecurity {
+		return NotificationDeliveryInstantEmail
+	}
+	return NotificationDeliveryInApp
+}
+
+type Notification struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Category  string             `bson:"category"`
+	Title     string             `bson:"title"`
+	Body      string             `bson:"body"`
+	Delivery  string             `bson:"delivery"`
+	ReadAt    *time.Time         `bson:"read_at"`
+	EmailedAt *time.Time         `bson:"emailed_at"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
@@ -0,0 +1,124 @@
+package entity
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// How the scores of a submission's peer reviews are combined.
+//   - median: the middle score, or the mean of the middle two.
+//   - trimmed_mean: the mean after dropping the lowest and highest score, when
+//     there are at least three.
+const (
+	PeerAggregationMedian      = "median"
+	PeerAggregationTrimmedMean = "trimmed_mean"
+)
+
+// PeerReview makes an assignment graded by the students themselves. Once the
+// assignment is due every submission is handed to Reviewers other students of
+// the author's cohort, who grade it with the rubric until ReviewDueAt. The
+// combined peer score then makes up the assignment's score, except for
+// CreditPercent of it, which students earn by completing their own reviews on
+// time.
+type PeerReview struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	AssignmentID  primitive.ObjectID `bson:"assignment_id"`
+	RubricID      primitive.ObjectID `bson:"rubric_id"`
+	Reviewers     int                `bson:"reviewers"`
+	Aggregation   string             `bson:"aggregation"`
+	CreditPercent int                `bson:"credit_percent"`
+	ReviewDueAt   time.Time          `bson:"review_due_at"`
+	AllocatedAt   *time.Time         `bson:"allocated_at"`
+	FinalizedAt   *time.Time         `bson:"finalized_at"`
+	CreatedBy     primitive.ObjectID `bson:"created_by,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	UpdatedAt     *time.Time         `bson:"updated_at"`
+}
+
+// Combine aggregates review scores, each a fraction of the rubric's points.
+func (p *PeerReview) Combine(scores []float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(scores)
+	slices.Sort(sorted)
+	if p.Aggregation == PeerAggregationTrimmedMean {
+		if len(sorted) >= 3 {
+			sorted = sorted[1 : len(sorted)-1]
+		}
+		total := 0.0
+		for _, score := range sorted {
+			total += score
+		}
+		return total / float64(len(sorted))
+	}
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// Score scales the peer score and the share of reviews done on time to the
+// assignment's max score.
+func (p *PeerReview) Score(peer float64, onTime, assigned int, maxScore int) int {
+	credit := 1.0
+	if assigned > 0 {
+		credit = float64(onTime) / float64(assigned)
+	}
+	weight := float64(p.CreditPercent) / 100
+	return int(math.Round(float64(maxScore) * ((1-weight)*peer + weight*credit)))
+}
+
+// PeerSubmission is the work a student hands in for peer review. It can be
+// replaced until the reviews are handed out.
+type PeerSubmission struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	AssignmentID primitive.ObjectID `bson:"assignment_id"`
+	UserID       primitive.ObjectID `bson:"user_id"`
+	Content      string             `bson:"content"`
+	SubmittedAt  time.Time          `bson:"submitted_at"`
+}
+
+// PeerReviewTask is one student's review of another's submission. Neither
+// sees who the other is.
+type PeerReviewTask struct {
+	ID           primitive.ObjectID     `bson:"_id,omitempty"`
+	AssignmentID primitive.ObjectID     `bson:"assignment_id"`
+	SubmissionID primitive.ObjectID     `bson:"submission_id"`
+	AuthorID     primitive.ObjectID     `bson:"author_id"`
+	ReviewerID   primitive.ObjectID     `bson:"reviewer_id"`
+	Criteria     []RubricGradeCriterion `bson:"criteria,omitempty"`
+	Comment      string                 `bson:"comment,omitempty"`
+	Earned       int                    `bson:"earned"`
+	Possible     int                    `bson:"possible"`
+	DueAt        time.Time              `bson:"due_at"`
+	SubmittedAt  *time.Time             `bson:"submitted_at"`
+	CreatedAt    time.Time              `bson:"created_at"`
+}
+
+// OnTime reports whether the review was handed in by its due date.
+func (t *PeerReviewTask) OnTime() bool {
+	return t.SubmittedAt != nil && !t.SubmittedAt.After(t.DueAt)
+}
+
+// PeerResult is how a student did on a peer reviewed assignment. PeerScore is
+// nil when nobody reviewed their submission; such students only get a score
+// once staff override it. An Override replaces the computed score.
+type PeerResult struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty"`
+	AssignmentID   primitive.ObjectID  `bson:"assignment_id"`
+	UserID         primitive.ObjectID  `bson:"user_id"`
+	Reviews        int                 `bson:"reviews"` // reviews received
+	PeerScore      *float64            `bson:"peer_score"`
+	Assigned       int                 `bson:"assigned"` // reviews to give
+	OnTime         int                 `bson:"on_time"`
+	Score          *int                `bson:"score"`
+	Override       *int                `bson:"override,omitempty"`
+	OverrideBy     *primitive.ObjectID `bson:"override_by,omitempty"`
+	OverrideReason string              `bson:"override_reason,omitempty"`
+	UpdatedAt      *time.Time          `bson:"updated_at"`
+}
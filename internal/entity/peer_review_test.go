@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPeerReviewCombine(t *testing.T) {
+	tests := []struct {
+		aggregation string
+		scores      []float64
+		want        float64
+	}{
+		{PeerAggregationMedian, nil, 0},
+		{PeerAggregationMedian, []float64{0.4}, 0.4},
+		{PeerAggregationMedian, []float64{0.9, 0.1, 0.5}, 0.5},
+		{PeerAggregationMedian, []float64{1, 0.2, 0.6, 0.4}, 0.5},
+		{PeerAggregationMedian, []float64{0.7, 0.7, 0.1}, 0.7},
+		{PeerAggregationTrimmedMean, nil, 0},
+		{PeerAggregationTrimmedMean, []float64{0.2, 0.6}, 0.4},
+		{PeerAggregationTrimmedMean, []float64{0, 0.5, 1}, 0.5},
+		{PeerAggregationTrimmedMean, []float64{1, 0.6, 0.4, 0}, 0.5},
+		{PeerAggregationTrimmedMean, []float64{0.8, 0.8, 0.8, 0.2}, 0.8},
+	}
+	for _, tt := range tests {
+		review := &PeerReview{Aggregation: tt.aggregation}
+		scores := slices.Clone(tt.scores)
+		if got := review.Combine(scores); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s Combine(%v) = %v, want %v", tt.aggregation, tt.scores, got, tt.want)
+		}
+		if !slices.Equal(scores, tt.scores) {
+			t.Errorf("%s Combine(%v) reordered its input to %v", tt.aggregation, tt.scores, scores)
+		}
+	}
+}
+
+func TestPeerReviewScore(t *testing.T) {
+	tests := []struct {
+		credit           int
+		peer             float64
+		onTime, assigned int
+		want             int
+	}{
+		{0, 0.75, 0, 3, 75},
+		{20, 1, 3, 3, 100},
+		{20, 1, 0, 3, 80},
+		{20, 0.5, 1, 2, 50},
+		{20, 0.5, 0, 0, 60},
+		{10, 0.555, 2, 3, 57},
+	}
+	for _, tt := range tests {
+		review := &PeerReview{CreditPercent: tt.credit}
+		if got := review.Score(tt.peer, tt.onTime, tt.assigned, 100); got != tt.want {
+			t.Errorf("Score(%v, %d, %d) with %d%% credit = %d, want %d", tt.peer, tt.onTime, tt.assigned, tt.credit, got, tt.want)
+		}
+	}
+}
+
+func TestPeerReviewTaskOnTime(t *testing.T) {
+	due := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		submitted := due.Add(d)
+		return &submitted
+	}
+	tests := []struct {
+		submittedAt *time.Time
+		want        bool
+	}{
+		{nil, false},
+		{at(-time.Hour), true},
+		{at(0), true},
+		{at(time.Second), false},
+	}
+	for _, tt := range tests {
+		task := &PeerReviewTask{DueAt: due, SubmittedAt: tt.submittedAt}
+		if got := task.OnTime(); got != tt.want {
+			t.Errorf("OnTime() with submission at %v = %v, want %v", tt.submittedAt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ProgressNotStarted = "not_started"
+	ProgressStarted    = "started"
+	ProgressCompleted  = "completed"
+	ProgressGraded     = "graded"
+)
+
+// progressLevels orders the statuses so an item never moves backwards, e.g. a
+// late "started" event cannot undo a grade.
+var progressLevels = map[string]int{
+	ProgressNotStarted: 0,
+	ProgressStarted:    1,
+	ProgressCompleted:  2,
+	ProgressGraded:     3,
+}
+
+func ProgressLevel(status string) int {
+	return progressLevels[status]
+}
+
+type ProgressItem struct {
+	Level       int        `bson:"level"`
+	Score       int        `bson:"score"`
+	StartedAt   *time.Time `bson:"started_at,omitempty"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty"`
+	GradedAt    *time.Time `bson:"graded_at,omitempty"`
+}
+
+func (p ProgressItem) Status() string {
+	for status, level := range progressLevels {
+		if level == p.Level {
+			return status
+		}
+	}
+	return ProgressNotStarted
+}
+
+// Done reports whether the item counts towards module completion.
+func (p ProgressItem) Done() bool {
+	return p.Level >= progressLevels[ProgressCompleted]
+}
+
+// ModuleProgress is one student's progress through one module. Items are keyed
+// by assignment id in hex.
+type ModuleProgress struct {
+	ID             primitive.ObjectID      `bson:"_id,omitempty"`
+	UserID         primitive.ObjectID      `bson:"user_id"`
+	CourseID       primitive.ObjectID      `bson:"course_id"`
+	ModuleID       primitive.ObjectID      `bson:"module_id"`
+	Items          map[string]ProgressItem `bson:"items"`
+	LastActivityAt time.Time               `bson:"last_activity_at"`
+}
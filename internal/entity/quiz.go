@@ -0,0 +1,352 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	QuestionMultipleChoice = "multiple_choice"
+	QuestionMultipleAnswer = "multiple_answer"
+	QuestionNumeric        = "numeric"
+	QuestionShortText      = "short_text"
+)
+
+const (
+	QuizAttemptInProgress = "in_progress"
+	QuizAttemptSubmitted  = "submitted"
+)
+
+// QuestionBank is a named pool of questions that quizzes draw from.
+type QuestionBank struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty"`
+	Name      string              `bson:"name"`
+	CourseID  *primitive.ObjectID `bson:"course_id,omitempty"`
+	CreatedBy primitive.ObjectID  `bson:"created_by,omitempty"`
+	CreatedAt time.Time           `bson:"created_at"`
+	UpdatedAt *time.Time          `bson:"updated_at"`
+}
+
+type QuestionChoice struct {
+	ID      string `bson:"id"`
+	Text    string `bson:"text"`
+	Correct bool   `bson:"correct"`
+}
+
+// QuestionParameter is a value drawn for each student from Min to Max in steps
+// of Step, so Min 1, Max 2 and Step 0.5 give 1, 1.5 or 2.
+type QuestionParameter struct {
+	Name string  `bson:"name"`
+	Min  float64 `bson:"min"`
+	Max  float64 `bson:"max"`
+	Step float64 `bson:"step"`
+}
+
+// questionPlaceholder matches the {{name}} placeholders parameter values are
+// written into.
+var questionPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Question is one bank question together with its answer key. Only the fields a
+// type uses are set: Choices for multiple choice and multiple answer, Answer and
+// Tolerance for numeric, and Patterns for short text. A parameterized numeric
+// question has Parameters and a Formula computing the answer from them instead
+// of a fixed Answer.
+type Question struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty"`
+	BankID        primitive.ObjectID  `bson:"bank_id"`
+	Type          string              `bson:"type"`
+	Prompt        string              `bson:"prompt"`
+	Points        int                 `bson:"points"`
+	Choices       []QuestionChoice    `bson:"choices,omitempty"`
+	Answer        float64             `bson:"answer,omitempty"`
+	Tolerance     float64             `bson:"tolerance,omitempty"`
+	Parameters    []QuestionParameter `bson:"parameters,omitempty"`
+	Formula       string              `bson:"formula,omitempty"`
+	Patterns      []string            `bson:"patterns,omitempty"`
+	CaseSensitive bool                `bson:"case_sensitive,omitempty"`
+	CreatedBy     primitive.ObjectID  `bson:"created_by,omitempty"`
+	CreatedAt     time.Time           `bson:"created_at"`
+	UpdatedAt     *time.Time          `bson:"updated_at"`
+}
+
+// AnswerPattern compiles a short text pattern so that it has to match the whole
+// answer, ignoring case unless the question is case sensitive.
+func (q *Question) AnswerPattern(pattern string) (*regexp.Regexp, error) {
+	flags := "(?i)"
+	if q.CaseSensitive {
+		flags = ""
+	}
+	return regexp.Compile(flags + `^(?:` + pattern + `)$`)
+}
+
+// Placeholders returns the parameter names used in the prompt and choices.
+func (q *Question) Placeholders() []string {
+	names := []string{}
+	texts := []string{q.Prompt}
+	for _, choice := range q.Choices {
+		texts = append(texts, choice.Text)
+	}
+	for _, text := range texts {
+		for _, match := range questionPlaceholder.FindAllStringSubmatch(text, -1) {
+			if !slices.Contains(names, match[1]) {
+				names = append(names, match[1])
+			}
+		}
+	}
+	return names
+}
+
+// Values draws the question's parameter values for a seed. The same seed and
+// question always give the same values.
+func (q *Question) Values(seed int64) map[string]float64 {
+	values := make(map[string]float64, len(q.Parameters))
+	if len(q.Parameters) == 0 {
+		return values
+	}
+	id := q.ID
+	rng := rand.New(rand.NewPCG(uint64(seed), binary.BigEndian.Uint64(id[4:])))
+	for _, parameter := range q.Parameters {
+		steps := 0
+		if parameter.Step > 0 && parameter.Max > parameter.Min {
+			steps = int(math.Floor((parameter.Max-parameter.Min)/parameter.Step + 1e-9))
+		}
+		value := parameter.Min + float64(rng.IntN(steps+1))*parameter.Step
+		// Rounding drops the floating point noise from adding up steps, so 0.1
+		// steps give 0.3 rather than 0.30000000000000004.
+		values[parameter.Name] = math.Round(value*1e9) / 1e9
+	}
+	return values
+}
+
+// Variant returns the question as asked with the seed: the parameter values are
+// written into the prompt and choices, and the answer is computed from the
+// formula. The values are returned too so staff can see what was asked. A
+// question without parameters is returned as it is.
+func (q *Question) Variant(seed int64) (Question, map[string]float64) {
+	variant := *q
+	if len(q.Parameters) == 0 {
+		return variant, nil
+	}
+	values := q.Values(seed)
+	fill := func(text string) string {
+		return questionPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+			name := questionPlaceholder.FindStringSubmatch(match)[1]
+			if value, ok := values[name]; ok {
+				return strconv.FormatFloat(value, 'f', -1, 64)
+			}
+			return match
+		})
+	}
+	variant.Prompt = fill(q.Prompt)
+	variant.Choices = make([]QuestionChoice, len(q.Choices))
+	for i, choice := range q.Choices {
+		choice.Text = fill(choice.Text)
+		variant.Choices[i] = choice
+	}
+	if q.Formula != "" {
+		// The formula was checked when the question was saved, so this only
+		// fails for values that make it undefined; nothing then matches NaN.
+		variant.Answer = math.NaN()
+		if formula, err := ParseFormula(q.Formula); err == nil {
+			if answer, err := formula.Eval(values); err == nil {
+				variant.Answer = answer
+			}
+		}
+	}
+	return variant, values
+}
+
+// VariantSeed derives the seed of a student's nth attempt of a quiz, so each
+// student and attempt gets its own variant and the same one can be worked out
+// again later.
+func VariantSeed(quizID, userID primitive.ObjectID, attempt int) int64 {
+	hash := sha256.New()
+	hash.Write(quizID[:])
+	hash.Write(userID[:])
+	binary.Write(hash, binary.BigEndian, int64(attempt))
+	return int64(binary.BigEndian.Uint64(hash.Sum(nil)))
+}
+
+// Grade returns the fraction of the question's points the answer earns, from 0
+// to 1. Multiple answer questions give credit for every correct choice picked
+// and take it away for every wrong one.
+func (q *Question) Grade(answer *QuizAnswer) float64 {
+	if answer == nil {
+		return 0
+	}
+	switch q.Type {
+	case QuestionMultipleChoice:
+		if len(answer.ChoiceIDs) != 1 {
+			return 0
+		}
+		for _, choice := range q.Choices {
+			if choice.ID == answer.ChoiceIDs[0] && choice.Correct {
+				return 1
+			}
+		}
+		return 0
+	case QuestionMultipleAnswer:
+		correct, picked := 0, 0
+		for _, choice := range q.Choices {
+			if choice.Correct {
+				correct++
+			}
+			if !slices.Contains(answer.ChoiceIDs, choice.ID) {
+				continue
+			}
+			if choice.Correct {
+				picked++
+			} else {
+				picked--
+			}
+		}
+		if correct == 0 {
+			return 0
+		}
+		return max(float64(picked)/float64(correct), 0)
+	case QuestionNumeric:
+		// The epsilon keeps answers exactly at the tolerance from failing on
+		// floating point error.
+		if answer.Number != nil && math.Abs(*answer.Number-q.Answer) <= q.Tolerance+1e-9 {
+			return 1
+		}
+		return 0
+	case QuestionShortText:
+		text := strings.TrimSpace(answer.Text)
+		for _, pattern := range q.Patterns {
+			if re, err := q.AnswerPattern(pattern); err == nil && re.MatchString(text) {
+				return 1
+			}
+		}
+		return 0
+	}
+	return 0
+}
+
+// QuizSection draws Count questions at random from a bank.
+type QuizSection struct {
+	BankID primitive.ObjectID `bson:"bank_id"`
+	Count  int                `bson:"count"`
+}
+
+// QuizExam makes a timed quiz an exam: its attempts end exactly at their
+// time limit, may need an access code and may only be taken from the given
+// networks, and the client reports integrity events while they run.
+type QuizExam struct {
+	AccessCode      string   `bson:"access_code,omitempty"`
+	AllowedNetworks []string `bson:"allowed_networks,omitempty"` // CIDR ranges; any network when empty
+}
+
+// Quiz turns an assignment into an auto-graded quiz. TimeLimit is in minutes,
+// and 0 means an attempt lasts until the assignment closes.
+type Quiz struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	AssignmentID   primitive.ObjectID `bson:"assignment_id"`
+	Sections       []QuizSection      `bson:"sections"`
+	TimeLimit      int                `bson:"time_limit"`
+	ShuffleChoices bool               `bson:"shuffle_choices"`
+	Exam           *QuizExam          `bson:"exam,omitempty"`
+	CreatedBy      primitive.ObjectID `bson:"created_by,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at"`
+	UpdatedAt      *time.Time         `bson:"updated_at"`
+}
+
+// QuizAnswer is a student's answer to one question. Choice questions use
+// ChoiceIDs, numeric questions Number and short text questions Text.
+type QuizAnswer struct {
+	QuestionID primitive.ObjectID `bson:"question_id"`
+	ChoiceIDs  []string           `bson:"choice_ids,omitempty"`
+	Number     *float64           `bson:"number,omitempty"`
+	Text       string             `bson:"text,omitempty"`
+}
+
+type QuizQuestionResult struct {
+	QuestionID primitive.ObjectID `bson:"question_id"`
+	Earned     float64            `bson:"earned"`
+	Correct    bool               `bson:"correct"`
+}
+
+// QuizAttempt is one sitting of a quiz. Questions is a copy of the questions
+// drawn when the attempt started, answer keys included, so later edits to the
+// bank do not change how the attempt is graded. Parameterized questions are
+// kept as templates and turned into the student's variant with Seed whenever
+// they are shown or graded.
+type QuizAttempt struct {
+	ID           primitive.ObjectID   `bson:"_id,omitempty"`
+	QuizID       primitive.ObjectID   `bson:"quiz_id"`
+	AssignmentID primitive.ObjectID   `bson:"assignment_id"`
+	UserID       primitive.ObjectID   `bson:"user_id"`
+	Status       string               `bson:"status"`
+	Questions    []Question           `bson:"questions"`
+	Seed         int64                `bson:"seed,omitempty"`
+	Answers      []QuizAnswer         `bson:"answers"`
+	Results      []QuizQuestionResult `bson:"results,omitempty"`
+	Earned       float64              `bson:"earned"`
+	Possible     int                  `bson:"possible"`
+	Score        int                  `bson:"score"`             // scaled to the assignment, before any late penalty
+	Attempt      int                  `bson:"attempt,omitempty"` // the submission attempt the score was recorded as
+	StartedAt    time.Time            `bson:"started_at"`
+	ExpiresAt    *time.Time           `bson:"expires_at"`
+	SubmittedAt  *time.Time           `bson:"submitted_at"`
+	Exam         *QuizAttemptExam     `bson:"exam,omitempty"`
+}
+
+// QuizAttemptExam is the exam session an attempt of an exam runs in: the
+// address it was started from, the one it was last used from and the networks
+// it may be taken from.
+type QuizAttemptExam struct {
+	IP              string   `bson:"ip"`
+	LastIP          string   `bson:"last_ip"`
+	AllowedNetworks []string `bson:"allowed_networks,omitempty"`
+}
+
+// Answer returns the answer given to the question, or nil.
+func (a *QuizAttempt) Answer(questionID primitive.ObjectID) *QuizAnswer {
+	for i := range a.Answers {
+		if a.Answers[i].QuestionID == questionID {
+			return &a.Answers[i]
+		}
+	}
+	return nil
+}
+
+// HasQuestion reports whether the question was drawn for this attempt.
+func (a *QuizAttempt) HasQuestion(questionID primitive.ObjectID) bool {
+	for _, question := range a.Questions {
+		if question.ID == questionID {
+			return true
+		}
+	}
+	return false
+}
+
+// Grade marks every question and scales the points earned to maxScore.
+func (a *QuizAttempt) Grade(maxScore int) {
+	a.Results = make([]QuizQuestionResult, 0, len(a.Questions))
+	a.Earned, a.Possible = 0, 0
+	for i := range a.Questions {
+		question, _ := a.Questions[i].Variant(a.Seed)
+		fraction := question.Grade(a.Answer(question.ID))
+		earned := math.Round(fraction*float64(question.Points)*100) / 100
+		a.Results = append(a.Results, QuizQuestionResult{
+			QuestionID: question.ID,
+			Earned:     earned,
+			Correct:    fraction == 1,
+		})
+		a.Earned += earned
+		a.Possible += question.Points
+	}
+	a.Score = 0
+	if a.Possible > 0 {
+		a.Score = int(math.Round(a.Earned / float64(a.Possible) * float64(maxScore)))
+	}
+}
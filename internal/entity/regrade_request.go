@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A regrade request starts open. Staff accept it, recording a new score, or
+// reject it with a reason; a TA who cannot decide escalates it to the
+// instructors, who then accept or reject it.
+const (
+	RegradeOpen      = "open"
+	RegradeEscalated = "escalated"
+	RegradeAccepted  = "accepted"
+	RegradeRejected  = "rejected"
+)
+
+// DefaultRegradeWindow is how long after a score was last changed a student may
+// ask for a regrade, for assignments that do not set their own window.
+const DefaultRegradeWindow = 7 * 24 * time.Hour
+
+// RegradeRequest is a student's appeal against their score on an assignment.
+// Pending is set while it is open or escalated, so a student has at most one
+// pending request per assignment.
+type RegradeRequest struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty"`
+	AssignmentID  primitive.ObjectID  `bson:"assignment_id"`
+	CourseID      *primitive.ObjectID `bson:"course_id,omitempty"`
+	UserID        primitive.ObjectID  `bson:"user_id"`
+	Justification string              `bson:"justification"`
+	Status        string              `bson:"status"`
+	Pending       bool                `bson:"pending,omitempty"`
+	Score         int                 `bson:"score"` // the score being appealed
+	NewScore      *int                `bson:"new_score,omitempty"`
+	Response      string              `bson:"response,omitempty"`
+	EscalatedBy   *primitive.ObjectID `bson:"escalated_by,omitempty"`
+	EscalatedAt   *time.Time          `bson:"escalated_at,omitempty"`
+	EscalateNote  string              `bson:"escalate_note,omitempty"`
+	ResolvedBy    *primitive.ObjectID `bson:"resolved_by,omitempty"`
+	ResolvedEmail string              `bson:"resolved_email,omitempty"`
+	ResolvedAt    *time.Time          `bson:"resolved_at,omitempty"`
+	CreatedAt     time.Time           `bson:"created_at"`
+	UpdatedAt     *time.Time          `bson:"updated_at"`
+}
+
+// RegradeWindow is how long after grading the assignment takes regrade
+// requests.
+func (a *Assignment) RegradeWindow() time.Duration {
+	if a.RegradeDays > 0 {
+		return time.Duration(a.RegradeDays) * 24 * time.Hour
+	}
+	return DefaultRegradeWindow
+}
@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rubric is a reusable marking scheme: criteria, each with performance levels
+// worth some points. Assignments graded with a rubric point at it, so it can
+// be shared across assignments and courses.
+type Rubric struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty"`
+	CourseID    *primitive.ObjectID `bson:"course_id,omitempty"`
+	Title       string              `bson:"title"`
+	Description string              `bson:"description"`
+	Criteria    []RubricCriterion   `bson:"criteria"`
+	CreatedBy   primitive.ObjectID  `bson:"created_by"`
+	CreatedAt   time.Time           `bson:"created_at"`
+	UpdatedAt   *time.Time          `bson:"updated_at"`
+}
+
+type RubricCriterion struct {
+	ID          string        `bson:"id"`
+	Title       string        `bson:"title"`
+	Description string        `bson:"description"`
+	Levels      []RubricLevel `bson:"levels"`
+}
+
+type RubricLevel struct {
+	ID          string `bson:"id"`
+	Title       string `bson:"title"`
+	Description string `bson:"description"`
+	Points      int    `bson:"points"`
+}
+
+// MaxPoints is the most points the criterion's levels give.
+func (c *RubricCriterion) MaxPoints() int {
+	points := 0
+	for i, level := range c.Levels {
+		if i == 0 || level.Points > points {
+			points = level.Points
+		}
+	}
+	return points
+}
+
+// Level returns the criterion's level with the id, or nil.
+func (c *RubricCriterion) Level(id string) *RubricLevel {
+	for i := range c.Levels {
+		if c.Levels[i].ID == id {
+			return &c.Levels[i]
+		}
+	}
+	return nil
+}
+
+// MaxPoints is the most points the rubric gives.
+func (r *Rubric) MaxPoints() int {
+	points := 0
+	for i := range r.Criteria {
+		points += r.Criteria[i].MaxPoints()
+	}
+	return points
+}
+
+// RubricGrade is a grader's marking of one student's work on an assignment.
+// Each criterion keeps the titles and points it was graded with, so the
+// breakdown stays as graded when the rubric is edited later. A student has one
+// rubric grade per assignment; grading again replaces it.
+type RubricGrade struct {
+	ID           primitive.ObjectID     `bson:"_id,omitempty"`
+	AssignmentID primitive.ObjectID     `bson:"assignment_id"`
+	RubricID     primitive.ObjectID     `bson:"rubric_id"`
+	UserID       primitive.ObjectID     `bson:"user_id"`
+	Criteria     []RubricGradeCriterion `bson:"criteria"`
+	Comment      string                 `bson:"comment,omitempty"`
+	Earned       int                    `bson:"earned"`
+	Possible     int                    `bson:"possible"`
+	Score        int                    `bson:"score"` // scaled to the assignment
+	GraderID     primitive.ObjectID     `bson:"grader_id"`
+	GraderEmail  string                 `bson:"grader_email"`
+	GradedAt     time.Time              `bson:"graded_at"`
+}
+
+type RubricGradeCriterion struct {
+	CriterionID string `bson:"criterion_id"`
+	Title       string `bson:"title"`
+	LevelID     string `bson:"level_id"`
+	Level       string `bson:"level"`
+	Points      int    `bson:"points"`
+	MaxPoints   int    `bson:"max_points"`
+	Comment     string `bson:"comment,omitempty"`
+}
@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Score is the current standing of a user on an assignment, projected from the
+// score ledger. SubmissionScore is what the score policy gives from submissions
+// and Adjustment the sum of manual corrections; Score is always their sum.
+type Score struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID          primitive.ObjectID  `bson:"user_id"`
+	AssignmentID    primitive.ObjectID  `bson:"assignment_id"`
+	CourseID        *primitive.ObjectID `bson:"course_id,omitempty"`
+	Score           int                 `bson:"score"`
+	SubmissionScore int                 `bson:"submission_score"`
+	Adjustment      int                 `bson:"adjustment"`
+	Attempts        int                 `bson:"attempts"`
+	CreatedAt       time.Time           `bson:"created_at"`
+	UpdatedAt       *time.Time          `bson:"updated_at"`
+}
+
+type LeaderboardFilter struct {
+	CourseID     *primitive.ObjectID
+	AssignmentID *primitive.ObjectID
+	// UserIDs limits the ranking to these users, e.g. the students of a cohort,
+	// when it is not nil.
+	UserIDs []primitive.ObjectID
+	// IncludeUserID keeps this user ranked even when they opted out, so they can
+	// still see their own standing.
+	IncludeUserID *primitive.ObjectID
+}
+
+type LeaderboardEntry struct {
+	UserID   primitive.ObjectID `bson:"_id"`
+	Name     string             `bson:"name"`
+	Total    int                `bson:"total"`
+	Rank     int                `bson:"rank"`
+	Position int                `bson:"position"`
+}
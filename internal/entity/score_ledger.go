@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ScoreChangeSubmission = "submission"
+	ScoreChangeCorrection = "correction"
+	ScoreChangeMigration  = "migration"
+	ScoreChangeGrade      = "grade"
+)
+
+// ScoreLedgerEntry is an immutable record of one change to a user's score for an
+// assignment. Entries are only ever inserted; Score documents are projections of
+// the sum of their deltas.
+type ScoreLedgerEntry struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID       primitive.ObjectID  `bson:"user_id"`
+	AssignmentID primitive.ObjectID  `bson:"assignment_id"`
+	Kind         string              `bson:"kind"`
+	Delta        int                 `bson:"delta"`
+	OldScore     int                 `bson:"old_score"`
+	NewScore     int                 `bson:"new_score"`
+	ActorID      primitive.ObjectID  `bson:"actor_id,omitempty"`
+	ActorEmail   string              `bson:"actor_email"`
+	Reason       string              `bson:"reason,omitempty"`
+	SourceIP     string              `bson:"source_ip,omitempty"`
+	SubmissionID *primitive.ObjectID `bson:"submission_id,omitempty"`
+	CreatedAt    time.Time           `bson:"created_at"`
+}
@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SimilarityQueued  = "queued"
+	SimilarityRunning = "running"
+	SimilarityDone    = "done"
+	SimilarityFailed  = "failed"
+)
+
+// DefaultSimilarityThreshold is the similarity, in percent, from which a pair
+// of submissions is reported when the check does not ask for another.
+const DefaultSimilarityThreshold = 50
+
+// StarterCode is code students were given, e.g. a template to fill in. Code
+// matching it is not counted as shared between submissions.
+type StarterCode struct {
+	Name   string `bson:"name"`
+	Source string `bson:"source"`
+}
+
+// SimilarityRegion is a run of code found in both submissions of a pair, as
+// line ranges of each.
+type SimilarityRegion struct {
+	StartA int `bson:"start_a"`
+	EndA   int `bson:"end_a"`
+	StartB int `bson:"start_b"`
+	EndB   int `bson:"end_b"`
+}
+
+// SimilarityPair is two students whose latest code submissions share more
+// than the check's threshold. Coverage is the fraction of each submission's
+// fingerprints found in the other; Similarity the larger of the two.
+type SimilarityPair struct {
+	UserA       primitive.ObjectID `bson:"user_a"`
+	UserB       primitive.ObjectID `bson:"user_b"`
+	SubmissionA primitive.ObjectID `bson:"submission_a"`
+	SubmissionB primitive.ObjectID `bson:"submission_b"`
+	Similarity  float64            `bson:"similarity"`
+	CoverageA   float64            `bson:"coverage_a"`
+	CoverageB   float64            `bson:"coverage_b"`
+	Regions     []SimilarityRegion `bson:"regions"`
+}
+
+// SimilarityReport is the latest similarity check of an assignment's code
+// submissions and the starter code it ignores. A check is queued and run by a
+// background worker, which claims it like a code submission.
+type SimilarityReport struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty"`
+	AssignmentID primitive.ObjectID  `bson:"assignment_id"`
+	AllowList    []StarterCode       `bson:"allow_list"`
+	Status       string              `bson:"status,omitempty"`
+	Threshold    int                 `bson:"threshold"`
+	Submissions  int                 `bson:"submissions"`
+	Compared     int                 `bson:"compared"`
+	Pairs        []SimilarityPair    `bson:"pairs"`
+	Error        string              `bson:"error,omitempty"`
+	Tries        int                 `bson:"tries"`
+	RequestedBy  *primitive.ObjectID `bson:"requested_by,omitempty"`
+	RequestedAt  *time.Time          `bson:"requested_at,omitempty"`
+	StartedAt    *time.Time          `bson:"started_at"`
+	FinishedAt   *time.Time          `bson:"finished_at"`
+	LockedUntil  *time.Time          `bson:"locked_until,omitempty"`
+	UpdatedAt    *time.Time          `bson:"updated_at"`
+}
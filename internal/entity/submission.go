@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Submission struct {
+	ID           primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID       primitive.ObjectID  `bson:"user_id"`
+	AssignmentID primitive.ObjectID  `bson:"assignment_id"`
+	Attempt      int                 `bson:"attempt"`
+	PayloadRef   string              `bson:"payload_ref,omitempty"`
+	RawScore     int                 `bson:"raw_score"`
+	Score        int                 `bson:"score"` // after the late penalty
+	DaysLate     int                 `bson:"days_late,omitempty"`
+	Grader       string              `bson:"grader"`
+	GroupID      *primitive.ObjectID `bson:"group_id,omitempty"` // set on every member's copy of a group submission
+	SubmittedAt  time.Time           `bson:"submitted_at"`
+}
@@ -6,16 +6,58 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	RoleStudent    = "student"
+	RoleInstructor = "instructor"
+	RoleTA         = "ta"
+	RoleAdmin      = "admin"
+)
+
 type User struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty"`
 	Name            string             `bson:"name"`
 	Email           string             `bson:"email"`
 	Password        string             `bson:"password"`
-	Score           int                `bson:"score"`
+	Score           int                `bson:"score"` // legacy single score, read only by the score migration
 	CreatedAt       time.Time          `bson:"created_at"`
 	UpdatedAt       *time.Time         `bson:"updated_at"`
 	SecretKey       string             `bson:"secret_key"`
 	OTP             string             `bson:"otp"`
 	OTPExpiresAt    time.Time          `bson:"otp_expires_at"`
 	IsEmailVerified bool               `bson:"is_email_verified"`
+	Role            string             `bson:"role,omitempty"`
+
+	Cohort            string `bson:"cohort,omitempty"` // legacy cohort name, read only by the cohort migration
+	LeaderboardOptOut bool   `bson:"leaderboard_opt_out,omitempty"`
+
+	EmailUndeliverable       bool       `bson:"email_undeliverable,omitempty"`
+	EmailUndeliverableReason string     `bson:"email_undeliverable_reason,omitempty"`
+	EmailUndeliverableAt     *time.Time `bson:"email_undeliverable_at,omitempty"`
+
+	Timezone                string            `bson:"timezone,omitempty"`
+	NotificationPreferences map[string]string `bson:"notification_preferences,omitempty"`
+	LastDigestAt            *time.Time        `bson:"last_digest_at,omitempty"`
+}
+
+// NotificationDelivery returns how the user wants notifications of the category
+// delivered, falling back to the category default when nothing is stored.
+func (u *User) NotificationDelivery(category string) string {
+	if delivery, ok := u.NotificationPreferences[category]; ok {
+		return delivery
+	}
+	return DefaultNotificationDelivery(category)
+}
+
+// GetRole treats users created before roles existed as students.
+func (u *User) GetRole() string {
+	if u.Role == "" {
+		return RoleStudent
+	}
+	return u.Role
+}
+
+// IsStaffRole reports whether the role may see and grade other users' work.
+// Only instructors and admins manage courses; TAs grade.
+func IsStaffRole(role string) bool {
+	return role == RoleInstructor || role == RoleTA || role == RoleAdmin
 }
@@ -0,0 +1,207 @@
+package model
+
+import "time"
+
+type CreateAssignmentRequest struct {
+	ActorEmail  string      `json:"-" validate:"required,email"`
+	Title       string      `json:"title" validate:"required"`
+	Description string      `json:"description"`
+	ModuleID    string      `json:"module_id"`
+	Position    *int        `json:"position" validate:"omitempty,min=0"`
+	MaxScore    int         `json:"max_score" validate:"required,min=1"`
+	OpensAt     *time.Time  `json:"opens_at"`
+	DueAt       *time.Time  `json:"due_at"`
+	ClosesAt    *time.Time  `json:"closes_at"`
+	LatePolicy  *LatePolicy `json:"late_policy" validate:"omitempty"`
+	MaxAttempts int         `json:"max_attempts" validate:"min=0"`
+	ScorePolicy string      `json:"score_policy" validate:"omitempty,oneof=best latest average"`
+	RegradeDays int         `json:"regrade_days" validate:"min=0,max=365"`
+	GroupMode   bool        `json:"group_mode"`
+	Category    string      `json:"category" validate:"max=50"`
+}
+
+type UpdateAssignmentRequest struct {
+	ActorEmail  string      `json:"-" validate:"required,email"`
+	ID          string      `json:"-" validate:"required"`
+	Title       string      `json:"title" validate:"required"`
+	Description string      `json:"description"`
+	ModuleID    string      `json:"module_id"`
+	Position    *int        `json:"position" validate:"omitempty,min=0"`
+	MaxScore    int         `json:"max_score" validate:"required,min=1"`
+	OpensAt     *time.Time  `json:"opens_at"`
+	DueAt       *time.Time  `json:"due_at"`
+	ClosesAt    *time.Time  `json:"closes_at"`
+	LatePolicy  *LatePolicy `json:"late_policy" validate:"omitempty"`
+	MaxAttempts int         `json:"max_attempts" validate:"min=0"`
+	ScorePolicy string      `json:"score_policy" validate:"omitempty,oneof=best latest average"`
+	RegradeDays int         `json:"regrade_days" validate:"min=0,max=365"`
+	GroupMode   bool        `json:"group_mode"`
+	Category    string      `json:"category" validate:"max=50"`
+}
+
+type LatePolicy struct {
+	Type    string  `json:"type" validate:"required,oneof=none hard_close percent_per_day linear_decay"`
+	Percent float64 `json:"percent,omitempty" validate:"min=0,max=100"`
+	Days    float64 `json:"days,omitempty" validate:"min=0"`
+	Floor   float64 `json:"floor,omitempty" validate:"min=0,max=100"`
+}
+
+type ListAssignmentRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	Page       int    `json:"page" validate:"min=1"`
+	Limit      int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type AssignmentResponse struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	CourseID    string     `json:"course_id,omitempty"`
+	ModuleID    string     `json:"module_id,omitempty"`
+	Position    int        `json:"position"`
+	MaxScore    int        `json:"max_score"`
+	OpensAt     *time.Time `json:"opens_at"`
+	DueAt       *time.Time `json:"due_at"`
+	ClosesAt    *time.Time `json:"closes_at"`
+	LatePolicy  LatePolicy `json:"late_policy"`
+	Timezone    string     `json:"timezone"`
+	MaxAttempts int        `json:"max_attempts"`
+	ScorePolicy string     `json:"score_policy"`
+	Grading     string     `json:"grading"`
+	RubricID    string     `json:"rubric_id,omitempty"`
+	RegradeDays int        `json:"regrade_days"`
+	GroupMode   bool       `json:"group_mode"`
+	Category    string     `json:"category"`
+	IsOpen      bool       `json:"is_open"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+type ListAssignmentResponse struct {
+	Assignments []AssignmentResponse `json:"assignments"`
+	Paging      *PaginationMetadata  `json:"paging"`
+}
+
+// UpdateScoreRequest is a score reported by a lab client, signed with one of
+// the assignment's client keys. Timestamp is in Unix seconds and Signature is
+// base64.
+type UpdateScoreRequest struct {
+	UserEmail    string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Score        int    `json:"score"`
+	PayloadRef   string `json:"payload_ref" validate:"max=200"`
+	KeyID        string `json:"key_id"`
+	Nonce        string `json:"nonce" validate:"min=16,max=128,printascii"`
+	Timestamp    int64  `json:"timestamp" validate:"required"`
+	Signature    string `json:"signature"`
+	SourceIP     string `json:"-"`
+}
+
+type RecordScoreRequest struct {
+	UserEmail    string `json:"email" validate:"required,email"`
+	AssignmentID string `json:"assignment_id" validate:"required"`
+	Score        int    `json:"score" validate:"min=0"`
+	PayloadRef   string `json:"payload_ref"`
+	Grader       string `json:"grader"`
+	ActorEmail   string `json:"-"`
+	Reason       string `json:"-"`
+	SourceIP     string `json:"-"`
+	// Set only by auto-graders, which may record scores for auto-graded
+	// assignments and give the time the work was handed in.
+	AutoGraded  bool       `json:"-"`
+	SubmittedAt *time.Time `json:"-"`
+}
+
+type UpdateScoreResponse struct {
+	Email        string `json:"email"`
+	AssignmentID string `json:"assignment_id"`
+	Attempt      int    `json:"attempt"`
+	AttemptsLeft int    `json:"attempts_left"`
+	RawScore     int    `json:"raw_score"`
+	DaysLate     int    `json:"days_late"`
+	Score        int    `json:"score"`
+	TotalScore   int    `json:"total_score"`
+	Group        string `json:"group,omitempty"`
+}
+
+type ListSubmissionRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	ActorRole    string `json:"-"`
+	StudentEmail string `json:"email" validate:"omitempty,email"`
+	AssignmentID string `json:"assignment_id" validate:"required"`
+}
+
+type SubmissionResponse struct {
+	Attempt     int       `json:"attempt"`
+	PayloadRef  string    `json:"payload_ref"`
+	RawScore    int       `json:"raw_score"`
+	DaysLate    int       `json:"days_late"`
+	Score       int       `json:"score"`
+	Grader      string    `json:"grader"`
+	Counted     bool      `json:"counted"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+type ListSubmissionResponse struct {
+	Email        string               `json:"email"`
+	AssignmentID string               `json:"assignment_id"`
+	ScorePolicy  string               `json:"score_policy"`
+	DueAt        *time.Time           `json:"due_at"`
+	ClosesAt     *time.Time           `json:"closes_at"`
+	Score        int                  `json:"score"`
+	AttemptsLeft int                  `json:"attempts_left"`
+	Submissions  []SubmissionResponse `json:"submissions"`
+	Group        *GroupSummary        `json:"group,omitempty"`
+}
+
+type CreateExtensionRequest struct {
+	ActorEmail   string    `json:"-" validate:"required,email"`
+	AssignmentID string    `json:"-" validate:"required"`
+	Email        string    `json:"email" validate:"required_without=CohortID,excluded_with=CohortID,omitempty,email"`
+	CohortID     string    `json:"cohort_id"`
+	DueAt        time.Time `json:"due_at" validate:"required"`
+	Reason       string    `json:"reason" validate:"max=500"`
+}
+
+type ExtensionResponse struct {
+	ID           string    `json:"id"`
+	AssignmentID string    `json:"assignment_id"`
+	Email        string    `json:"email,omitempty"`
+	CohortID     string    `json:"cohort_id,omitempty"`
+	DueAt        time.Time `json:"due_at"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// CreateClientKeyRequest issues a signing key for lab client builds. An Ed25519
+// key can be given as its base64 public key so the private key never leaves
+// the build machine; otherwise the server generates the key.
+type CreateClientKeyRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Algorithm    string `json:"algorithm" validate:"required,oneof=hmac-sha256 ed25519"`
+	PublicKey    string `json:"public_key" validate:"omitempty,base64"`
+	Label        string `json:"label" validate:"max=100"`
+}
+
+// ClientKeyResponse only carries Secret or PrivateKey when the key is issued;
+// they cannot be read back later.
+type ClientKeyResponse struct {
+	ID           string     `json:"id"`
+	AssignmentID string     `json:"assignment_id"`
+	Algorithm    string     `json:"algorithm"`
+	Label        string     `json:"label,omitempty"`
+	Secret       string     `json:"secret,omitempty"`
+	PrivateKey   string     `json:"private_key,omitempty"`
+	PublicKey    string     `json:"public_key,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+}
+
+type ListClientKeyResponse struct {
+	Keys []ClientKeyResponse `json:"keys"`
+}
+
+type ListExtensionResponse struct {
+	Extensions []ExtensionResponse `json:"extensions"`
+}
@@ -0,0 +1,101 @@
+package model
+
+import "time"
+
+type CodeTest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Input    string `json:"input" validate:"max=1048576"`
+	Expected string `json:"expected" validate:"max=1048576"`
+	Weight   int    `json:"weight" validate:"min=1,max=1000"`
+	Hidden   bool   `json:"hidden"`
+}
+
+// SaveCodeGraderRequest sets up an assignment's code grader. Build, Run and
+// Filename are only taken for the custom language; the others have their own.
+// TimeLimit is in milliseconds per test and MemoryLimit in megabytes.
+type SaveCodeGraderRequest struct {
+	ActorEmail   string     `json:"-" validate:"required,email"`
+	AssignmentID string     `json:"-" validate:"required"`
+	Runner       string     `json:"runner" validate:"omitempty,oneof=local"`
+	Language     string     `json:"language" validate:"required,oneof=python c cpp custom"`
+	Filename     string     `json:"filename" validate:"required_if=Language custom,max=100"`
+	Build        []string   `json:"build" validate:"max=50"`
+	Run          []string   `json:"run" validate:"required_if=Language custom,max=50"`
+	TimeLimit    int        `json:"time_limit" validate:"omitempty,min=100,max=30000"`
+	MemoryLimit  int        `json:"memory_limit" validate:"omitempty,min=16,max=4096"`
+	Compare      string     `json:"compare" validate:"omitempty,oneof=exact trimmed tokens"`
+	Tests        []CodeTest `json:"tests" validate:"required,min=1,max=200,dive"`
+}
+
+type CodeGraderResponse struct {
+	AssignmentID string     `json:"assignment_id"`
+	Runner       string     `json:"runner"`
+	Language     string     `json:"language"`
+	Filename     string     `json:"filename"`
+	Build        []string   `json:"build"`
+	Run          []string   `json:"run"`
+	TimeLimit    int        `json:"time_limit"`
+	MemoryLimit  int        `json:"memory_limit"`
+	Compare      string     `json:"compare"`
+	Tests        []CodeTest `json:"tests"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+type SubmitCodeRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Source       []byte `json:"-" validate:"required"`
+	SourceIP     string `json:"-"`
+}
+
+type GetCodeSubmissionRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ID         string `json:"-" validate:"required"`
+}
+
+type ListCodeSubmissionRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Email        string `json:"email" validate:"omitempty,email"`
+	Page         int    `json:"page" validate:"min=1"`
+	Limit        int    `json:"limit" validate:"min=1,max=100"`
+}
+
+// CodeTestResultResponse is how the program did on one test. Students are not
+// shown the output of hidden tests.
+type CodeTestResultResponse struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Weight   int    `json:"weight"`
+	Hidden   bool   `json:"hidden"`
+	TimeMs   int64  `json:"time_ms"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+}
+
+type CodeSubmissionResponse struct {
+	ID           string                   `json:"id"`
+	AssignmentID string                   `json:"assignment_id"`
+	Email        string                   `json:"email,omitempty"`
+	Language     string                   `json:"language"`
+	Filename     string                   `json:"filename"`
+	Source       string                   `json:"source,omitempty"`
+	Status       string                   `json:"status"`
+	BuildOutput  string                   `json:"build_output,omitempty"`
+	Results      []CodeTestResultResponse `json:"results,omitempty"`
+	Earned       *int                     `json:"earned,omitempty"`
+	Possible     int                      `json:"possible"`
+	Score        *int                     `json:"score,omitempty"`
+	Attempt      int                      `json:"attempt,omitempty"`
+	Error        string                   `json:"error,omitempty"`
+	SubmittedAt  time.Time                `json:"submitted_at"`
+	StartedAt    *time.Time               `json:"started_at"`
+	FinishedAt   *time.Time               `json:"finished_at"`
+}
+
+type ListCodeSubmissionResponse struct {
+	Submissions []CodeSubmissionResponse `json:"submissions"`
+	Paging      *PaginationMetadata      `json:"paging"`
+}
@@ -0,0 +1,135 @@
+package model
+
+import "time"
+
+// Outcome of adding one email to a cohort.
+const (
+	CohortMemberAdded         = "added"
+	CohortMemberAlreadyMember = "already_member"
+	CohortMemberInOtherCohort = "in_other_cohort"
+	CohortMemberInvalidRole   = "invalid_role"
+	CohortMemberNotFound      = "not_found"
+)
+
+type CreateCohortRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	Name       string `json:"name" validate:"required,max=100"`
+	CourseID   string `json:"course_id"`
+}
+
+type UpdateCohortRequest struct {
+	ID       string `json:"-" validate:"required"`
+	Name     string `json:"name" validate:"required,max=100"`
+	CourseID string `json:"course_id"`
+}
+
+type ListCohortRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ActorRole  string `json:"-"`
+	Page       int    `json:"page" validate:"min=1"`
+	Limit      int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type GetCohortRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ID         string `json:"-" validate:"required"`
+}
+
+type CohortResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	CourseID  string     `json:"course_id,omitempty"`
+	Role      string     `json:"role,omitempty"`
+	Students  int64      `json:"students"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+type ListCohortResponse struct {
+	Cohorts []CohortResponse    `json:"cohorts"`
+	Paging  *PaginationMetadata `json:"paging"`
+}
+
+type AddCohortMembersRequest struct {
+	ActorEmail string   `json:"-" validate:"required,email"`
+	CohortID   string   `json:"-" validate:"required"`
+	Emails     []string `json:"emails" validate:"required,min=1,max=200,dive,email"`
+	Role       string   `json:"role" validate:"required,oneof=student instructor ta"`
+}
+
+type CohortMemberResult struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+}
+
+type AddCohortMembersResponse struct {
+	Added   int                  `json:"added"`
+	Results []CohortMemberResult `json:"results"`
+}
+
+type ListCohortMemberRequest struct {
+	CohortID string `json:"-" validate:"required"`
+	Role     string `json:"role" validate:"omitempty,oneof=student instructor ta"`
+	Page     int    `json:"page" validate:"min=1"`
+	Limit    int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type CohortMemberResponse struct {
+	UserID  string    `json:"user_id"`
+	Email   string    `json:"email"`
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type ListCohortMemberResponse struct {
+	Members []CohortMemberResponse `json:"members"`
+	Paging  *PaginationMetadata    `json:"paging"`
+}
+
+type RemoveCohortMemberRequest struct {
+	CohortID string `json:"-" validate:"required"`
+	UserID   string `json:"-" validate:"required"`
+}
+
+// MoveCohortStudentsRequest moves the listed students, or every student when
+// Emails is empty, from one cohort to another.
+type MoveCohortStudentsRequest struct {
+	ActorEmail   string   `json:"-" validate:"required,email"`
+	ActorRole    string   `json:"-"`
+	FromCohortID string   `json:"from_cohort_id" validate:"required"`
+	ToCohortID   string   `json:"to_cohort_id" validate:"required"`
+	Emails       []string `json:"emails" validate:"max=500,dive,email"`
+}
+
+type MoveCohortStudentsResponse struct {
+	Moved    int64    `json:"moved"`
+	NotFound []string `json:"not_found"`
+}
+
+type CohortScoresRequest struct {
+	CohortID string `json:"-" validate:"required"`
+	CourseID string `json:"course_id"`
+	Page     int    `json:"page" validate:"min=1"`
+	Limit    int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type CohortScoreResponse struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Name   string `json:"name"`
+	Score  int    `json:"score"`
+}
+
+type CohortScoresResponse struct {
+	CohortID string                `json:"cohort_id"`
+	CourseID string                `json:"course_id,omitempty"`
+	Students []CohortScoreResponse `json:"students"`
+	Paging   *PaginationMetadata   `json:"paging"`
+}
+
+type UserCohortResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
@@ -0,0 +1,118 @@
+package converter
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+)
+
+// NewAssignmentResponse shows the assignment's times in loc, the timezone of
+// its course.
+func NewAssignmentResponse(assignment *entity.Assignment, loc *time.Location) *model.AssignmentResponse {
+	response := &model.AssignmentResponse{
+		ID:          assignment.ID.Hex(),
+		Title:       assignment.Title,
+		Description: assignment.Description,
+		Position:    assignment.Position,
+		MaxScore:    assignment.MaxScore,
+		OpensAt:     InLocation(assignment.OpensAt, loc),
+		DueAt:       InLocation(assignment.DueAt, loc),
+		ClosesAt:    InLocation(assignment.ClosesAt, loc),
+		LatePolicy: model.LatePolicy{
+			Type:    assignment.LatePolicy.Type,
+			Percent: assignment.LatePolicy.Percent,
+			Days:    assignment.LatePolicy.Days,
+			Floor:   assignment.LatePolicy.Floor,
+		},
+		Timezone:    loc.String(),
+		MaxAttempts: assignment.MaxAttempts,
+		ScorePolicy: assignment.ScorePolicy,
+		Grading:     assignment.Grading,
+		RegradeDays: assignment.RegradeDays,
+		GroupMode:   assignment.GroupMode,
+		Category:    assignment.Category,
+		IsOpen:      assignment.IsOpen(time.Now().In(loc)),
+		CreatedAt:   assignment.CreatedAt,
+		UpdatedAt:   assignment.UpdatedAt,
+	}
+	if response.Grading == "" {
+		response.Grading = entity.GradingManual
+	}
+	if response.LatePolicy.Type == "" {
+		response.LatePolicy.Type = entity.LatePolicyNone
+	}
+	if assignment.CourseID != nil {
+		response.CourseID = assignment.CourseID.Hex()
+	}
+	if assignment.ModuleID != nil {
+		response.ModuleID = assignment.ModuleID.Hex()
+	}
+	if assignment.RubricID != nil {
+		response.RubricID = assignment.RubricID.Hex()
+	}
+	return response
+}
+
+func NewSubmissionResponse(submission *entity.Submission, countedAttempt int) *model.SubmissionResponse {
+	return &model.SubmissionResponse{
+		Attempt:     submission.Attempt,
+		PayloadRef:  submission.PayloadRef,
+		RawScore:    submission.RawScore,
+		DaysLate:    submission.DaysLate,
+		Score:       submission.Score,
+		Grader:      submission.Grader,
+		Counted:     countedAttempt == 0 || submission.Attempt == countedAttempt,
+		SubmittedAt: submission.SubmittedAt,
+	}
+}
+
+func NewExtensionResponse(extension *entity.Extension, email string, loc *time.Location) *model.ExtensionResponse {
+	response := &model.ExtensionResponse{
+		ID:           extension.ID.Hex(),
+		AssignmentID: extension.AssignmentID.Hex(),
+		Email:        email,
+		DueAt:        extension.DueAt.In(loc),
+		Reason:       extension.Reason,
+		CreatedAt:    extension.CreatedAt,
+	}
+	if extension.CohortID != nil {
+		response.CohortID = extension.CohortID.Hex()
+	}
+	return response
+}
+
+// NewClientKeyResponse never includes an HMAC secret; only Ed25519 public keys
+// are shown.
+func NewClientKeyResponse(key *entity.ClientKey) *model.ClientKeyResponse {
+	response := &model.ClientKeyResponse{
+		ID:           key.ID.Hex(),
+		AssignmentID: key.AssignmentID.Hex(),
+		Algorithm:    key.Algorithm,
+		Label:        key.Label,
+		CreatedAt:    key.CreatedAt,
+		RevokedAt:    key.RevokedAt,
+	}
+	if key.Algorithm == entity.ClientKeyEd25519 && len(key.Key) > 0 {
+		response.PublicKey = base64.StdEncoding.EncodeToString(key.Key)
+	}
+	return response
+}
+
+func InLocation(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.In(loc)
+	return &local
+}
+
+// CourseLocation is the timezone deadlines of the course are evaluated in.
+func CourseLocation(course *entity.Course) *time.Location {
+	if course == nil {
+		return util.LoadLocation("")
+	}
+	return util.LoadLocation(course.Timezone)
+}
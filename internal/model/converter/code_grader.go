@@ -0,0 +1,78 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+// NewCodeGraderResponse includes the expected output of every test, so it must
+// only reach staff.
+func NewCodeGraderResponse(grader *entity.CodeGrader) *model.CodeGraderResponse {
+	response := &model.CodeGraderResponse{
+		AssignmentID: grader.AssignmentID.Hex(),
+		Runner:       grader.Runner,
+		Language:     grader.Language,
+		Filename:     grader.Filename,
+		Build:        grader.Build,
+		Run:          grader.Run,
+		TimeLimit:    grader.TimeLimit,
+		MemoryLimit:  grader.MemoryLimit,
+		Compare:      grader.Compare,
+		Tests:        make([]model.CodeTest, 0, len(grader.Tests)),
+		CreatedAt:    grader.CreatedAt,
+		UpdatedAt:    grader.UpdatedAt,
+	}
+	for _, test := range grader.Tests {
+		response.Tests = append(response.Tests, model.CodeTest{
+			Name:     test.Name,
+			Input:    test.Input,
+			Expected: test.Expected,
+			Weight:   test.Weight,
+			Hidden:   test.Hidden,
+		})
+	}
+	return response
+}
+
+// NewCodeSubmissionResponse shows the submission with its per-test report.
+// Unless withHidden is set for staff, hidden tests only show their status.
+func NewCodeSubmissionResponse(submission *entity.CodeSubmission, email string, withHidden bool) *model.CodeSubmissionResponse {
+	response := &model.CodeSubmissionResponse{
+		ID:           submission.ID.Hex(),
+		AssignmentID: submission.AssignmentID.Hex(),
+		Email:        email,
+		Language:     submission.Language,
+		Filename:     submission.Filename,
+		Source:       submission.Source,
+		Status:       submission.Status,
+		BuildOutput:  submission.BuildOutput,
+		Possible:     submission.Possible,
+		Attempt:      submission.Attempt,
+		Error:        submission.Error,
+		SubmittedAt:  submission.SubmittedAt,
+		StartedAt:    submission.StartedAt,
+		FinishedAt:   submission.FinishedAt,
+	}
+	if submission.Status == entity.CodeSubmissionGraded {
+		earned, score := submission.Earned, submission.Score
+		response.Earned = &earned
+		response.Score = &score
+	}
+	for _, result := range submission.Results {
+		item := model.CodeTestResultResponse{
+			Name:   result.Name,
+			Status: result.Status,
+			Weight: result.Weight,
+			Hidden: result.Hidden,
+		}
+		if !result.Hidden || withHidden {
+			exitCode := result.ExitCode
+			item.TimeMs = result.TimeMs
+			item.ExitCode = &exitCode
+			item.Stdout = result.Stdout
+			item.Stderr = result.Stderr
+		}
+		response.Results = append(response.Results, item)
+	}
+	return response
+}
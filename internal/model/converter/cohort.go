@@ -0,0 +1,45 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+// NewCohortResponse includes the caller's role in the cohort when they are a member.
+func NewCohortResponse(cohort *entity.Cohort, member *entity.CohortMember, students int64) *model.CohortResponse {
+	response := &model.CohortResponse{
+		ID:        cohort.ID.Hex(),
+		Name:      cohort.Name,
+		Students:  students,
+		CreatedAt: cohort.CreatedAt,
+		UpdatedAt: cohort.UpdatedAt,
+	}
+	if cohort.CourseID != nil {
+		response.CourseID = cohort.CourseID.Hex()
+	}
+	if member != nil {
+		response.Role = member.Role
+	}
+	return response
+}
+
+func NewCohortMemberResponse(member *entity.CohortMember, user *entity.User) *model.CohortMemberResponse {
+	response := &model.CohortMemberResponse{
+		UserID:  member.UserID.Hex(),
+		Role:    member.Role,
+		AddedAt: member.AddedAt,
+	}
+	if user != nil {
+		response.Email = user.Email
+		response.Name = user.Name
+	}
+	return response
+}
+
+func NewUserCohortResponse(cohort *entity.Cohort, member *entity.CohortMember) *model.UserCohortResponse {
+	return &model.UserCohortResponse{
+		ID:   cohort.ID.Hex(),
+		Name: cohort.Name,
+		Role: member.Role,
+	}
+}
@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+// NewCourseResponse leaves the join code out unless withJoinCode is set, which
+// callers only do for staff.
+func NewCourseResponse(course *entity.Course, enrollment *entity.Enrollment, withJoinCode bool) *model.CourseResponse {
+	response := &model.CourseResponse{
+		ID:          course.ID.Hex(),
+		Code:        course.Code,
+		Title:       course.Title,
+		Description: course.Description,
+		JoinEnabled: course.JoinEnabled,
+		Timezone:    CourseLocation(course).String(),
+		CreatedAt:   course.CreatedAt,
+		UpdatedAt:   course.UpdatedAt,
+	}
+	if withJoinCode {
+		response.JoinCode = course.JoinCode
+	}
+	if enrollment != nil {
+		response.EnrollmentStatus = enrollment.Status
+	}
+	return response
+}
+
+func NewModuleResponse(module *entity.Module, assignments []entity.Assignment, loc *time.Location) *model.ModuleResponse {
+	response := &model.ModuleResponse{
+		ID:          module.ID.Hex(),
+		Title:       module.Title,
+		Description: module.Description,
+		Position:    module.Position,
+		Assignments: make([]model.AssignmentResponse, 0, len(assignments)),
+	}
+	for i := range assignments {
+		response.Assignments = append(response.Assignments, *NewAssignmentResponse(&assignments[i], loc))
+	}
+	return response
+}
+
+func NewEnrollmentResponse(enrollment *entity.Enrollment, user *entity.User) *model.EnrollmentResponse {
+	response := &model.EnrollmentResponse{
+		CourseID:   enrollment.CourseID.Hex(),
+		UserID:     enrollment.UserID.Hex(),
+		Status:     enrollment.Status,
+		Method:     enrollment.Method,
+		EnrolledAt: enrollment.EnrolledAt,
+		UpdatedAt:  enrollment.UpdatedAt,
+	}
+	if user != nil {
+		response.Email = user.Email
+		response.Name = user.Name
+	}
+	return response
+}
@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+func NewGradingSchemeResponse(scheme *entity.GradingScheme) *model.GradingSchemeResponse {
+	response := &model.GradingSchemeResponse{
+		CourseID:   scheme.CourseID.Hex(),
+		Categories: make([]model.GradeCategory, 0, len(scheme.Categories)),
+		Letters:    make([]model.LetterGrade, 0, len(scheme.Letters)),
+		Curve: model.GradeCurve{
+			Type:       scheme.Curve.Type,
+			TargetMean: scheme.Curve.TargetMean,
+			Cap:        scheme.Curve.Cap,
+		},
+		UpdatedAt:  scheme.UpdatedAt,
+		ComputedAt: scheme.ComputedAt,
+	}
+	for _, category := range scheme.Categories {
+		response.Categories = append(response.Categories, model.GradeCategory{
+			Key:        category.Key,
+			Name:       category.Name,
+			Weight:     category.Weight,
+			DropLowest: category.DropLowest,
+		})
+	}
+	for _, letter := range scheme.Letters {
+		response.Letters = append(response.Letters, model.LetterGrade{Letter: letter.Letter, Min: letter.Min})
+	}
+	return response
+}
+
+func NewCourseGradeResponse(grade *entity.CourseGrade, email string) *model.CourseGradeResponse {
+	response := &model.CourseGradeResponse{
+		CourseID:   grade.CourseID.Hex(),
+		Email:      email,
+		Categories: make([]model.CategoryGradeResponse, 0, len(grade.Categories)),
+		Percent:    grade.Percent,
+		Curved:     grade.Curved,
+		Letter:     grade.Letter,
+		ComputedAt: grade.ComputedAt,
+	}
+	for _, category := range grade.Categories {
+		dropped := make([]string, 0, len(category.Dropped))
+		for _, id := range category.Dropped {
+			dropped = append(dropped, id.Hex())
+		}
+		response.Categories = append(response.Categories, model.CategoryGradeResponse{
+			Key:      category.Key,
+			Name:     category.Name,
+			Weight:   category.Weight,
+			Earned:   category.Earned,
+			Possible: category.Possible,
+			Percent:  category.Percent,
+			Dropped:  dropped,
+		})
+	}
+	return response
+}
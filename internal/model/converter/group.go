@@ -0,0 +1,34 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+// NewGroupResponse converts the group without its members, which the caller
+// adds.
+func NewGroupResponse(group *entity.Group) *model.GroupResponse {
+	return &model.GroupResponse{
+		ID:         group.ID.Hex(),
+		CohortID:   group.CohortID.Hex(),
+		Name:       group.Name,
+		MaxSize:    group.MaxSize,
+		SelfSignup: group.SelfSignup,
+		Size:       group.Size,
+		Members:    []model.GroupMemberResponse{},
+		CreatedAt:  group.CreatedAt,
+		UpdatedAt:  group.UpdatedAt,
+	}
+}
+
+func NewGroupMemberResponse(member *entity.GroupMember, user *entity.User) *model.GroupMemberResponse {
+	response := &model.GroupMemberResponse{
+		UserID:  member.UserID.Hex(),
+		AddedAt: member.AddedAt,
+	}
+	if user != nil {
+		response.Email = user.Email
+		response.Name = user.Name
+	}
+	return response
+}
@@ -0,0 +1,16 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func NewLeaderboardEntryResponse(entry *entity.LeaderboardEntry, me primitive.ObjectID) *model.LeaderboardEntryResponse {
+	return &model.LeaderboardEntryResponse{
+		Rank:  entry.Rank,
+		Name:  entry.Name,
+		Score: entry.Total,
+		IsMe:  entry.UserID == me,
+	}
+}
@@ -0,0 +1,16 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+func NewMailEventResponse(event *entity.MailEvent) *model.MailEventResponse {
+	return &model.MailEventResponse{
+		Type:       event.Type,
+		Source:     event.Source,
+		Status:     event.Status,
+		Diagnostic: event.Diagnostic,
+		OccurredAt: event.OccurredAt,
+	}
+}
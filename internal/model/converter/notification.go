@@ -0,0 +1,30 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+)
+
+func NewNotificationResponse(notification *entity.Notification) *model.NotificationResponse {
+	return &model.NotificationResponse{
+		ID:        notification.ID.Hex(),
+		Category:  notification.Category,
+		Title:     notification.Title,
+		Body:      notification.Body,
+		IsRead:    notification.ReadAt != nil,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+}
+
+func NewNotificationPreferencesResponse(user *entity.User) *model.NotificationPreferencesResponse {
+	preferences := make(map[string]string, len(entity.NotificationCategories))
+	for _, category := range entity.NotificationCategories {
+		preferences[category] = user.NotificationDelivery(category)
+	}
+	return &model.NotificationPreferencesResponse{
+		Timezone:    util.LoadLocation(user.Timezone).String(),
+		Preferences: preferences,
+	}
+}
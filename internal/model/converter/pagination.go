@@ -0,0 +1,16 @@
+package converter
+
+import "github.com/Erwanph/be-wan-central-lab/internal/model"
+
+func NewPaginationMetadata(page, limit int, total int64) *model.PaginationMetadata {
+	totalPage := int64(0)
+	if limit > 0 {
+		totalPage = (total + int64(limit) - 1) / int64(limit)
+	}
+	return &model.PaginationMetadata{
+		Page:      page,
+		Limit:     limit,
+		TotalItem: total,
+		TotalPage: totalPage,
+	}
+}
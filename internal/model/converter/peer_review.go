@@ -0,0 +1,72 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+func NewPeerReviewResponse(review *entity.PeerReview, rubric *entity.Rubric) *model.PeerReviewResponse {
+	response := &model.PeerReviewResponse{
+		AssignmentID:  review.AssignmentID.Hex(),
+		Reviewers:     review.Reviewers,
+		Aggregation:   review.Aggregation,
+		CreditPercent: review.CreditPercent,
+		ReviewDueAt:   review.ReviewDueAt,
+		AllocatedAt:   review.AllocatedAt,
+		FinalizedAt:   review.FinalizedAt,
+	}
+	if rubric != nil {
+		response.Rubric = NewRubricResponse(rubric)
+	}
+	return response
+}
+
+func NewPeerSubmissionResponse(submission *entity.PeerSubmission) *model.PeerSubmissionResponse {
+	return &model.PeerSubmissionResponse{
+		ID:           submission.ID.Hex(),
+		AssignmentID: submission.AssignmentID.Hex(),
+		Content:      submission.Content,
+		SubmittedAt:  submission.SubmittedAt,
+	}
+}
+
+// NewPeerReviewTaskResponse leaves the emails for the caller to fill in when
+// they may see them.
+func NewPeerReviewTaskResponse(task *entity.PeerReviewTask, content string) *model.PeerReviewTaskResponse {
+	response := &model.PeerReviewTaskResponse{
+		ID:           task.ID.Hex(),
+		AssignmentID: task.AssignmentID.Hex(),
+		Content:      content,
+		Criteria:     make([]model.RubricGradeCriterion, 0, len(task.Criteria)),
+		Comment:      task.Comment,
+		Earned:       task.Earned,
+		Possible:     task.Possible,
+		DueAt:        task.DueAt,
+		SubmittedAt:  task.SubmittedAt,
+	}
+	for _, criterion := range task.Criteria {
+		response.Criteria = append(response.Criteria, model.RubricGradeCriterion{
+			CriterionID: criterion.CriterionID,
+			Title:       criterion.Title,
+			LevelID:     criterion.LevelID,
+			Level:       criterion.Level,
+			Points:      criterion.Points,
+			MaxPoints:   criterion.MaxPoints,
+			Comment:     criterion.Comment,
+		})
+	}
+	return response
+}
+
+func NewPeerResultResponse(result *entity.PeerResult, email string) *model.PeerResultResponse {
+	return &model.PeerResultResponse{
+		Email:          email,
+		Reviews:        result.Reviews,
+		PeerScore:      result.PeerScore,
+		Assigned:       result.Assigned,
+		OnTime:         result.OnTime,
+		Score:          result.Score,
+		Override:       result.Override,
+		OverrideReason: result.OverrideReason,
+	}
+}
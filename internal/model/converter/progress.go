@@ -0,0 +1,61 @@
+package converter
+
+import (
+	"math"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+// NewModuleProgressResponse measures progress against the module's current
+// assignments, so adding an assignment lowers the percentage until it is done.
+// progress may be nil when the student has not touched the module yet.
+func NewModuleProgressResponse(module *entity.Module, assignments []entity.Assignment, progress *entity.ModuleProgress, withItems bool) *model.ModuleProgressResponse {
+	response := &model.ModuleProgressResponse{
+		ModuleID: module.ID.Hex(),
+		Title:    module.Title,
+		Total:    len(assignments),
+	}
+	if progress != nil {
+		lastActivityAt := progress.LastActivityAt
+		response.LastActivityAt = &lastActivityAt
+	}
+	for _, assignment := range assignments {
+		var item entity.ProgressItem
+		if progress != nil {
+			item = progress.Items[assignment.ID.Hex()]
+		}
+		if item.Done() {
+			response.Completed++
+		}
+		if withItems {
+			response.Items = append(response.Items, model.ProgressItemResponse{
+				AssignmentID: assignment.ID.Hex(),
+				Title:        assignment.Title,
+				Status:       item.Status(),
+				Score:        item.Score,
+				StartedAt:    item.StartedAt,
+				CompletedAt:  item.CompletedAt,
+				GradedAt:     item.GradedAt,
+			})
+		}
+	}
+	response.Percent = Percent(response.Completed, response.Total)
+	return response
+}
+
+// Percent rounds to one decimal place and treats an empty module as 0%.
+func Percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
+
+func LatestTime(a, b *time.Time) *time.Time {
+	if a == nil || (b != nil && b.After(*a)) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,192 @@
+package converter
+
+import (
+	"math"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+func NewQuestionBankResponse(bank *entity.QuestionBank, questions int64) *model.QuestionBankResponse {
+	response := &model.QuestionBankResponse{
+		ID:            bank.ID.Hex(),
+		Name:          bank.Name,
+		QuestionCount: questions,
+		CreatedAt:     bank.CreatedAt,
+		UpdatedAt:     bank.UpdatedAt,
+	}
+	if bank.CourseID != nil {
+		response.CourseID = bank.CourseID.Hex()
+	}
+	return response
+}
+
+// NewQuestionResponse includes the answer key, so it must only reach staff.
+func NewQuestionResponse(question *entity.Question) *model.QuestionResponse {
+	return newQuestionResponse(question, question, nil)
+}
+
+// NewQuestionVariantResponse shows a parameterized question as asked with the
+// seed, with the values drawn and the answer they give, next to its template.
+func NewQuestionVariantResponse(question *entity.Question, seed int64) *model.QuestionResponse {
+	variant, values := question.Variant(seed)
+	return newQuestionResponse(question, &variant, values)
+}
+
+func newQuestionResponse(template, question *entity.Question, values map[string]float64) *model.QuestionResponse {
+	response := &model.QuestionResponse{
+		ID:            question.ID.Hex(),
+		BankID:        question.BankID.Hex(),
+		Type:          question.Type,
+		Prompt:        question.Prompt,
+		Points:        question.Points,
+		Formula:       template.Formula,
+		Values:        values,
+		Patterns:      question.Patterns,
+		CaseSensitive: question.CaseSensitive,
+		CreatedAt:     question.CreatedAt,
+		UpdatedAt:     question.UpdatedAt,
+	}
+	for _, choice := range question.Choices {
+		response.Choices = append(response.Choices, model.QuestionChoiceResponse{
+			ID:      choice.ID,
+			Text:    choice.Text,
+			Correct: choice.Correct,
+		})
+	}
+	for _, parameter := range template.Parameters {
+		response.Parameters = append(response.Parameters, model.QuestionParameter{
+			Name: parameter.Name,
+			Min:  parameter.Min,
+			Max:  parameter.Max,
+			Step: parameter.Step,
+		})
+	}
+	if question.Type == entity.QuestionNumeric {
+		// A template's answer comes from its formula, and a variant whose
+		// values leave the formula undefined has none.
+		if !math.IsNaN(question.Answer) && (values != nil || template.Formula == "") {
+			answer := question.Answer
+			response.Answer = &answer
+		}
+		response.Tolerance = question.Tolerance
+	}
+	return response
+}
+
+// NewQuizResponse shows an exam's access code and networks only when staff is
+// set.
+func NewQuizResponse(quiz *entity.Quiz, staff bool) *model.QuizResponse {
+	response := &model.QuizResponse{
+		AssignmentID:   quiz.AssignmentID.Hex(),
+		Sections:       make([]model.QuizSection, 0, len(quiz.Sections)),
+		TimeLimit:      quiz.TimeLimit,
+		ShuffleChoices: quiz.ShuffleChoices,
+		CreatedAt:      quiz.CreatedAt,
+		UpdatedAt:      quiz.UpdatedAt,
+	}
+	for _, section := range quiz.Sections {
+		response.Sections = append(response.Sections, model.QuizSection{
+			BankID: section.BankID.Hex(),
+			Count:  section.Count,
+		})
+		response.QuestionCount += section.Count
+	}
+	if quiz.Exam != nil {
+		response.Exam = &model.QuizExamResponse{AccessCodeRequired: quiz.Exam.AccessCode != ""}
+		if staff {
+			response.Exam.AccessCode = quiz.Exam.AccessCode
+			response.Exam.AllowedNetworks = quiz.Exam.AllowedNetworks
+		}
+	}
+	return response
+}
+
+// NewQuizAttemptResponse shows the questions without their answers. Results are
+// only shown once the attempt is submitted, and the answer key only when
+// withKey is set for staff reviewing the attempt.
+func NewQuizAttemptResponse(attempt *entity.QuizAttempt, email string, withKey bool) *model.QuizAttemptResponse {
+	response := &model.QuizAttemptResponse{
+		ID:           attempt.ID.Hex(),
+		AssignmentID: attempt.AssignmentID.Hex(),
+		Email:        email,
+		Status:       attempt.Status,
+		Exam:         attempt.Exam != nil,
+		Possible:     attempt.Possible,
+		Attempt:      attempt.Attempt,
+		StartedAt:    attempt.StartedAt,
+		ExpiresAt:    attempt.ExpiresAt,
+		SubmittedAt:  attempt.SubmittedAt,
+	}
+	for _, template := range attempt.Questions {
+		question, _ := template.Variant(attempt.Seed)
+		item := model.QuizQuestionResponse{
+			ID:     question.ID.Hex(),
+			Type:   question.Type,
+			Prompt: question.Prompt,
+			Points: question.Points,
+		}
+		for _, choice := range question.Choices {
+			item.Choices = append(item.Choices, model.QuizChoiceResponse{ID: choice.ID, Text: choice.Text})
+		}
+		response.Questions = append(response.Questions, item)
+		if withKey {
+			response.AnswerKey = append(response.AnswerKey, *NewQuestionVariantResponse(&template, attempt.Seed))
+		}
+	}
+	if withKey && attempt.Seed != 0 {
+		seed := attempt.Seed
+		response.Seed = &seed
+	}
+	for _, answer := range attempt.Answers {
+		response.Answers = append(response.Answers, model.QuizAnswer{
+			QuestionID: answer.QuestionID.Hex(),
+			ChoiceIDs:  answer.ChoiceIDs,
+			Number:     answer.Number,
+			Text:       answer.Text,
+		})
+	}
+	if attempt.Status == entity.QuizAttemptSubmitted {
+		earned, score := attempt.Earned, attempt.Score
+		response.Earned = &earned
+		response.Score = &score
+		for _, result := range attempt.Results {
+			response.Results = append(response.Results, model.QuizQuestionResultResponse{
+				QuestionID: result.QuestionID.Hex(),
+				Earned:     result.Earned,
+				Correct:    result.Correct,
+			})
+		}
+	}
+	return response
+}
+
+func NewExamEventResponse(event *entity.ExamEvent) *model.ExamEventResponse {
+	return &model.ExamEventResponse{
+		Type:       event.Type,
+		Detail:     event.Detail,
+		IP:         event.IP,
+		ClientAt:   event.ClientAt,
+		ReceivedAt: event.ReceivedAt,
+	}
+}
+
+func NewExamAttemptSummary(attempt *entity.QuizAttempt, email string, counts entity.ExamEventCounts) *model.ExamAttemptSummary {
+	summary := &model.ExamAttemptSummary{
+		AttemptID:   attempt.ID.Hex(),
+		Email:       email,
+		Status:      attempt.Status,
+		Events:      counts.Counts,
+		TotalEvents: counts.Total,
+		StartedAt:   attempt.StartedAt,
+		ExpiresAt:   attempt.ExpiresAt,
+		SubmittedAt: attempt.SubmittedAt,
+	}
+	if summary.Events == nil {
+		summary.Events = map[string]int{}
+	}
+	if attempt.Exam != nil {
+		summary.IP = attempt.Exam.IP
+	}
+	return summary
+}
@@ -0,0 +1,30 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+// NewRegradeRequestResponse only shows staff the note left when the request was
+// escalated.
+func NewRegradeRequestResponse(request *entity.RegradeRequest, email string, staff bool) *model.RegradeRequestResponse {
+	response := &model.RegradeRequestResponse{
+		ID:            request.ID.Hex(),
+		AssignmentID:  request.AssignmentID.Hex(),
+		Email:         email,
+		Justification: request.Justification,
+		Status:        request.Status,
+		Score:         request.Score,
+		NewScore:      request.NewScore,
+		Response:      request.Response,
+		EscalatedAt:   request.EscalatedAt,
+		ResolvedBy:    request.ResolvedEmail,
+		ResolvedAt:    request.ResolvedAt,
+		CreatedAt:     request.CreatedAt,
+		UpdatedAt:     request.UpdatedAt,
+	}
+	if staff {
+		response.EscalateNote = request.EscalateNote
+	}
+	return response
+}
@@ -0,0 +1,68 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+func NewRubricResponse(rubric *entity.Rubric) *model.RubricResponse {
+	response := &model.RubricResponse{
+		ID:          rubric.ID.Hex(),
+		Title:       rubric.Title,
+		Description: rubric.Description,
+		Criteria:    make([]model.RubricCriterion, 0, len(rubric.Criteria)),
+		MaxPoints:   rubric.MaxPoints(),
+		CreatedAt:   rubric.CreatedAt,
+		UpdatedAt:   rubric.UpdatedAt,
+	}
+	if rubric.CourseID != nil {
+		response.CourseID = rubric.CourseID.Hex()
+	}
+	for i := range rubric.Criteria {
+		criterion := &rubric.Criteria[i]
+		item := model.RubricCriterion{
+			ID:          criterion.ID,
+			Title:       criterion.Title,
+			Description: criterion.Description,
+			Levels:      make([]model.RubricLevel, 0, len(criterion.Levels)),
+			MaxPoints:   criterion.MaxPoints(),
+		}
+		for _, level := range criterion.Levels {
+			item.Levels = append(item.Levels, model.RubricLevel{
+				ID:          level.ID,
+				Title:       level.Title,
+				Description: level.Description,
+				Points:      level.Points,
+			})
+		}
+		response.Criteria = append(response.Criteria, item)
+	}
+	return response
+}
+
+func NewRubricGradeResponse(grade *entity.RubricGrade, email string) *model.RubricGradeResponse {
+	response := &model.RubricGradeResponse{
+		AssignmentID: grade.AssignmentID.Hex(),
+		RubricID:     grade.RubricID.Hex(),
+		Email:        email,
+		Criteria:     make([]model.RubricGradeCriterion, 0, len(grade.Criteria)),
+		Comment:      grade.Comment,
+		Earned:       grade.Earned,
+		Possible:     grade.Possible,
+		Score:        grade.Score,
+		GraderEmail:  grade.GraderEmail,
+		GradedAt:     grade.GradedAt,
+	}
+	for _, criterion := range grade.Criteria {
+		response.Criteria = append(response.Criteria, model.RubricGradeCriterion{
+			CriterionID: criterion.CriterionID,
+			Title:       criterion.Title,
+			LevelID:     criterion.LevelID,
+			Level:       criterion.Level,
+			Points:      criterion.Points,
+			MaxPoints:   criterion.MaxPoints,
+			Comment:     criterion.Comment,
+		})
+	}
+	return response
+}
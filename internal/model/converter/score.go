@@ -0,0 +1,26 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+func NewScoreLedgerEntryResponse(entry *entity.ScoreLedgerEntry, email string) *model.ScoreLedgerEntryResponse {
+	response := &model.ScoreLedgerEntryResponse{
+		ID:           entry.ID.Hex(),
+		Email:        email,
+		AssignmentID: entry.AssignmentID.Hex(),
+		Kind:         entry.Kind,
+		Delta:        entry.Delta,
+		OldScore:     entry.OldScore,
+		NewScore:     entry.NewScore,
+		ActorEmail:   entry.ActorEmail,
+		Reason:       entry.Reason,
+		SourceIP:     entry.SourceIP,
+		CreatedAt:    entry.CreatedAt,
+	}
+	if entry.SubmissionID != nil {
+		response.SubmissionID = entry.SubmissionID.Hex()
+	}
+	return response
+}
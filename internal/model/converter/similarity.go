@@ -0,0 +1,50 @@
+package converter
+
+import (
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+// NewSimilarityReportResponse converts the report without its pairs, which the
+// caller adds with the students' emails.
+func NewSimilarityReportResponse(report *entity.SimilarityReport) *model.SimilarityReportResponse {
+	response := &model.SimilarityReportResponse{
+		AssignmentID: report.AssignmentID.Hex(),
+		Status:       report.Status,
+		Threshold:    report.Threshold,
+		StarterCode:  make([]model.StarterCode, 0, len(report.AllowList)),
+		Submissions:  report.Submissions,
+		Compared:     report.Compared,
+		Pairs:        []model.SimilarityPairResponse{},
+		Error:        report.Error,
+		RequestedAt:  report.RequestedAt,
+		StartedAt:    report.StartedAt,
+		FinishedAt:   report.FinishedAt,
+	}
+	for _, starter := range report.AllowList {
+		response.StarterCode = append(response.StarterCode, model.StarterCode{Name: starter.Name, Source: starter.Source})
+	}
+	return response
+}
+
+func NewSimilarityPairResponse(pair *entity.SimilarityPair, emailA, emailB string) *model.SimilarityPairResponse {
+	response := &model.SimilarityPairResponse{
+		EmailA:      emailA,
+		EmailB:      emailB,
+		SubmissionA: pair.SubmissionA.Hex(),
+		SubmissionB: pair.SubmissionB.Hex(),
+		Similarity:  pair.Similarity,
+		CoverageA:   pair.CoverageA,
+		CoverageB:   pair.CoverageB,
+		Regions:     make([]model.SimilarityRegion, 0, len(pair.Regions)),
+	}
+	for _, region := range pair.Regions {
+		response.Regions = append(response.Regions, model.SimilarityRegion{
+			StartA: region.StartA,
+			EndA:   region.EndA,
+			StartB: region.StartB,
+			EndB:   region.EndB,
+		})
+	}
+	return response
+}
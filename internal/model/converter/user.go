@@ -23,7 +23,9 @@ func NewResponseVerifyEmailUsingOtp(user *entity.User) *model.ResponseVerifyEmai
 }
 func NewVerifyAuthResponse(user *entity.User) *model.VerifyAuthResponse {
 	return &model.VerifyAuthResponse{
+		ID:    user.ID.Hex(),
 		Email: user.Email,
+		Role:  user.GetRole(),
 	}
 }
 
@@ -41,13 +43,40 @@ func NewUpdateUserResponse(user *entity.User) *model.ResponseUpdateProfile {
 		UpdatedAt:   user.UpdatedAt,
 	}
 }
-func NewGetUserResponse(user *entity.User) *model.ResponseGetProfiles {
-	return &model.ResponseGetProfiles{
+func NewGetUserResponse(user *entity.User, totalScore int, grades []entity.CourseGrade) *model.ResponseGetProfiles {
+	response := &model.ResponseGetProfiles{
 		Name:      user.Name,
 		Email:     user.Email,
-		Score: 	user.Score,
+		Score:     totalScore,
+		Grades:    make([]model.CourseGradeSummary, 0, len(grades)),
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
+	for _, grade := range grades {
+		response.Grades = append(response.Grades, model.CourseGradeSummary{
+			CourseID: grade.CourseID.Hex(),
+			Percent:  grade.Curved,
+			Letter:   grade.Letter,
+		})
+	}
+	return response
 }
 
+func NewAdminUserResponse(user *entity.User) *model.AdminUserResponse {
+	emailStatus := "deliverable"
+	if user.EmailUndeliverable {
+		emailStatus = "undeliverable"
+	}
+	return &model.AdminUserResponse{
+		ID:                       user.ID.Hex(),
+		Name:                     user.Name,
+		Email:                    user.Email,
+		Role:                     user.GetRole(),
+		IsEmailVerified:          user.IsEmailVerified,
+		EmailStatus:              emailStatus,
+		EmailUndeliverableReason: user.EmailUndeliverableReason,
+		EmailUndeliverableAt:     user.EmailUndeliverableAt,
+		CreatedAt:                user.CreatedAt,
+		UpdatedAt:                user.UpdatedAt,
+	}
+}
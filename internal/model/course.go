@@ -0,0 +1,126 @@
+package model
+
+import "time"
+
+type CreateCourseRequest struct {
+	ActorEmail  string `json:"-" validate:"required,email"`
+	Code        string `json:"code" validate:"required,max=20"`
+	Title       string `json:"title" validate:"required"`
+	Description string `json:"description"`
+	JoinEnabled *bool  `json:"join_enabled"`
+	Timezone    string `json:"timezone"`
+}
+
+type UpdateCourseRequest struct {
+	ActorEmail  string `json:"-" validate:"required,email"`
+	ID          string `json:"-" validate:"required"`
+	Code        string `json:"code" validate:"required,max=20"`
+	Title       string `json:"title" validate:"required"`
+	Description string `json:"description"`
+	JoinEnabled bool   `json:"join_enabled"`
+	Timezone    string `json:"timezone"`
+}
+
+type ListCourseRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ActorRole  string `json:"-"`
+	Page       int    `json:"page" validate:"min=1"`
+	Limit      int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type GetCourseRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ActorRole  string `json:"-"`
+	ID         string `json:"-" validate:"required"`
+}
+
+type CourseResponse struct {
+	ID               string     `json:"id"`
+	Code             string     `json:"code"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	JoinCode         string     `json:"join_code,omitempty"`
+	JoinEnabled      bool       `json:"join_enabled"`
+	Timezone         string     `json:"timezone"`
+	EnrollmentStatus string     `json:"enrollment_status,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at"`
+}
+
+type ListCourseResponse struct {
+	Courses []CourseResponse    `json:"courses"`
+	Paging  *PaginationMetadata `json:"paging"`
+}
+
+type ModuleResponse struct {
+	ID          string               `json:"id"`
+	Title       string               `json:"title"`
+	Description string               `json:"description"`
+	Position    int                  `json:"position"`
+	Assignments []AssignmentResponse `json:"assignments"`
+}
+
+type CourseDetailResponse struct {
+	CourseResponse
+	Modules []ModuleResponse `json:"modules"`
+	Score   int              `json:"score"`
+}
+
+type CreateModuleRequest struct {
+	ActorEmail  string `json:"-" validate:"required,email"`
+	CourseID    string `json:"-" validate:"required"`
+	Title       string `json:"title" validate:"required"`
+	Description string `json:"description"`
+	Position    *int   `json:"position" validate:"omitempty,min=0"`
+}
+
+type UpdateModuleRequest struct {
+	ActorEmail  string `json:"-" validate:"required,email"`
+	CourseID    string `json:"-" validate:"required"`
+	ID          string `json:"-" validate:"required"`
+	Title       string `json:"title" validate:"required"`
+	Description string `json:"description"`
+	Position    int    `json:"position" validate:"min=0"`
+}
+
+type JoinCourseRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	JoinCode   string `json:"join_code" validate:"required"`
+}
+
+type EnrollRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	CourseID   string `json:"-" validate:"required"`
+	Email      string `json:"email" validate:"required,email"`
+}
+
+type ListEnrollmentRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	CourseID   string `json:"-" validate:"required"`
+	Status     string `json:"status" validate:"omitempty,oneof=active dropped completed"`
+	Page       int    `json:"page" validate:"min=1"`
+	Limit      int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type UpdateEnrollmentRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	CourseID   string `json:"-" validate:"required"`
+	UserID     string `json:"-" validate:"required"`
+	Status     string `json:"status" validate:"required,oneof=active dropped completed"`
+}
+
+type EnrollmentResponse struct {
+	CourseID   string     `json:"course_id"`
+	UserID     string     `json:"user_id"`
+	Email      string     `json:"email"`
+	Name       string     `json:"name"`
+	Status     string     `json:"status"`
+	Method     string     `json:"method"`
+	EnrolledAt time.Time  `json:"enrolled_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+type ListEnrollmentResponse struct {
+	Enrollments []EnrollmentResponse `json:"enrollments"`
+	Paging      *PaginationMetadata  `json:"paging"`
+}
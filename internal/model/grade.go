@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+type GradeCategory struct {
+	Key        string  `json:"key" validate:"required,max=50"`
+	Name       string  `json:"name" validate:"required,max=100"`
+	Weight     float64 `json:"weight" validate:"gt=0,lte=100"`
+	DropLowest int     `json:"drop_lowest" validate:"min=0,max=20"`
+}
+
+type LetterGrade struct {
+	Letter string  `json:"letter" validate:"required,max=5"`
+	Min    float64 `json:"min" validate:"min=0,max=100"`
+}
+
+type GradeCurve struct {
+	Type       string  `json:"type" validate:"omitempty,oneof=none linear cap"`
+	TargetMean float64 `json:"target_mean" validate:"min=0,max=100"`
+	Cap        float64 `json:"cap" validate:"min=0,max=100"`
+}
+
+type SaveGradingSchemeRequest struct {
+	ActorEmail string          `json:"-" validate:"required,email"`
+	CourseID   string          `json:"-" validate:"required"`
+	Categories []GradeCategory `json:"categories" validate:"required,min=1,max=20,dive"`
+	Letters    []LetterGrade   `json:"letters" validate:"max=20,dive"`
+	Curve      GradeCurve      `json:"curve"`
+}
+
+type GetGradingSchemeRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ActorRole  string `json:"-"`
+	CourseID   string `json:"-" validate:"required"`
+}
+
+type GradingSchemeResponse struct {
+	CourseID   string          `json:"course_id"`
+	Categories []GradeCategory `json:"categories"`
+	Letters    []LetterGrade   `json:"letters"`
+	Curve      GradeCurve      `json:"curve"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	ComputedAt *time.Time      `json:"computed_at"`
+}
+
+// CourseGradeRequest asks for the caller's course grade, or a student's when
+// staff name them.
+type CourseGradeRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	ActorRole    string `json:"-"`
+	CourseID     string `json:"-" validate:"required"`
+	StudentEmail string `json:"email" validate:"omitempty,email"`
+}
+
+type CategoryGradeResponse struct {
+	Key      string   `json:"key"`
+	Name     string   `json:"name"`
+	Weight   float64  `json:"weight"`
+	Earned   int      `json:"earned"`
+	Possible int      `json:"possible"`
+	Percent  *float64 `json:"percent"`
+	Dropped  []string `json:"dropped"`
+}
+
+type CourseGradeResponse struct {
+	CourseID   string                  `json:"course_id"`
+	Email      string                  `json:"email"`
+	Categories []CategoryGradeResponse `json:"categories"`
+	Percent    *float64                `json:"percent"`
+	Curved     *float64                `json:"curved"`
+	Letter     string                  `json:"letter"`
+	ComputedAt time.Time               `json:"computed_at"`
+}
+
+// CourseGradeSummary is a course grade as listed on the student's profile.
+type CourseGradeSummary struct {
+	CourseID string   `json:"course_id"`
+	Percent  *float64 `json:"percent"`
+	Letter   string   `json:"letter"`
+}
@@ -0,0 +1,59 @@
+package model
+
+type GradebookExportRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	CourseID   string `json:"-" validate:"required"`
+	Format     string `json:"format" validate:"required,oneof=csv xlsx"`
+	Status     string `json:"status" validate:"omitempty,oneof=active dropped completed"`
+}
+
+// Grade import row statuses.
+const (
+	GradeImportValid     = "valid"
+	GradeImportUnchanged = "unchanged"
+	GradeImportInvalid   = "invalid"
+	GradeImportApplied   = "applied"
+)
+
+// Problems a grade import row can have.
+const (
+	GradeImportUnknownEmail        = "unknown_email"
+	GradeImportNotEnrolled         = "not_enrolled"
+	GradeImportNotInCohort         = "not_in_cohort"
+	GradeImportUnknownAssignment   = "unknown_assignment"
+	GradeImportAmbiguousAssignment = "ambiguous_assignment"
+	GradeImportInvalidScore        = "invalid_score"
+	GradeImportOutOfRange          = "out_of_range"
+	GradeImportDuplicate           = "duplicate"
+	GradeImportCommentTooLong      = "comment_too_long"
+)
+
+type GradeImportRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	SourceIP   string `json:"-"`
+	CourseID   string `json:"-" validate:"required"`
+	Confirm    bool   `json:"confirm"`
+	CSV        []byte `json:"-" validate:"required"`
+}
+
+type GradeImportRowResponse struct {
+	Row          int      `json:"row"`
+	Email        string   `json:"email"`
+	Assignment   string   `json:"assignment"`
+	AssignmentID string   `json:"assignment_id,omitempty"`
+	Score        *int     `json:"score,omitempty"`
+	OldScore     *int     `json:"old_score,omitempty"`
+	Comment      string   `json:"comment,omitempty"`
+	Status       string   `json:"status"`
+	Problems     []string `json:"problems,omitempty"`
+	DuplicateOf  int      `json:"duplicate_of,omitempty"`
+}
+
+type GradeImportResponse struct {
+	Applied   bool                     `json:"applied"`
+	Total     int                      `json:"total"`
+	Valid     int                      `json:"valid"`
+	Unchanged int                      `json:"unchanged"`
+	Invalid   int                      `json:"invalid"`
+	Rows      []GradeImportRowResponse `json:"rows"`
+}
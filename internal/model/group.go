@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// Outcome of adding one email to a group.
+const (
+	GroupMemberAdded         = "added"
+	GroupMemberAlreadyMember = "already_member"
+	GroupMemberInOtherGroup  = "in_other_group"
+	GroupMemberNotInCohort   = "not_in_cohort"
+	GroupMemberNotFound      = "not_found"
+)
+
+type CreateGroupRequest struct {
+	ActorEmail string   `json:"-" validate:"required,email"`
+	CohortID   string   `json:"-" validate:"required"`
+	Name       string   `json:"name" validate:"required,max=100"`
+	MaxSize    int      `json:"max_size" validate:"min=0,max=100"`
+	SelfSignup bool     `json:"self_signup"`
+	Emails     []string `json:"emails" validate:"max=100,dive,email"`
+}
+
+type UpdateGroupRequest struct {
+	CohortID   string `json:"-" validate:"required"`
+	ID         string `json:"-" validate:"required"`
+	Name       string `json:"name" validate:"required,max=100"`
+	MaxSize    int    `json:"max_size" validate:"min=0,max=100"`
+	SelfSignup bool   `json:"self_signup"`
+}
+
+// GroupRequest names a group of a cohort, for the actor to act on.
+type GroupRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	CohortID   string `json:"-" validate:"required"`
+	ID         string `json:"-" validate:"required"`
+}
+
+type ListGroupRequest struct {
+	CohortID string `json:"-" validate:"required"`
+}
+
+type AddGroupMembersRequest struct {
+	ActorEmail string   `json:"-" validate:"required,email"`
+	CohortID   string   `json:"-" validate:"required"`
+	ID         string   `json:"-" validate:"required"`
+	Emails     []string `json:"emails" validate:"required,min=1,max=100,dive,email"`
+}
+
+type RemoveGroupMemberRequest struct {
+	CohortID string `json:"-" validate:"required"`
+	ID       string `json:"-" validate:"required"`
+	UserID   string `json:"-" validate:"required"`
+}
+
+type GroupMemberResult struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+}
+
+type GroupMemberResponse struct {
+	UserID  string    `json:"user_id"`
+	Email   string    `json:"email"`
+	Name    string    `json:"name"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+type GroupResponse struct {
+	ID         string                `json:"id"`
+	CohortID   string                `json:"cohort_id"`
+	Name       string                `json:"name"`
+	MaxSize    int                   `json:"max_size"`
+	SelfSignup bool                  `json:"self_signup"`
+	Size       int                   `json:"size"`
+	Members    []GroupMemberResponse `json:"members"`
+	Results    []GroupMemberResult   `json:"results,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	UpdatedAt  *time.Time            `json:"updated_at"`
+}
+
+type ListGroupResponse struct {
+	Groups []GroupResponse `json:"groups"`
+}
+
+// GroupSummary is the group a student did a group assignment with, as shown
+// next to their scores.
+type GroupSummary struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
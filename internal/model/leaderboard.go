@@ -0,0 +1,49 @@
+package model
+
+type LeaderboardRequest struct {
+	UserEmail    string `json:"-" validate:"required,email"`
+	ActorRole    string `json:"-"`
+	CourseID     string `json:"course_id"`
+	Page         int    `json:"page" validate:"min=1"`
+	Limit        int    `json:"limit" validate:"min=1,max=100"`
+	AssignmentID string `json:"assignment_id"`
+	CohortID     string `json:"cohort_id"`
+}
+
+type LeaderboardMeRequest struct {
+	UserEmail    string `json:"-" validate:"required,email"`
+	ActorRole    string `json:"-"`
+	CourseID     string `json:"course_id"`
+	AssignmentID string `json:"assignment_id"`
+	CohortID     string `json:"cohort_id"`
+	Neighbours   int    `json:"neighbours" validate:"min=0,max=10"`
+}
+
+type LeaderboardEntryResponse struct {
+	Rank  int    `json:"rank"`
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	IsMe  bool   `json:"is_me"`
+}
+
+type LeaderboardResponse struct {
+	Entries []LeaderboardEntryResponse `json:"entries"`
+	Paging  *PaginationMetadata        `json:"paging"`
+}
+
+type LeaderboardMeResponse struct {
+	Ranked     bool                       `json:"ranked"`
+	Rank       int                        `json:"rank"`
+	Score      int                        `json:"score"`
+	OptedOut   bool                       `json:"opted_out"`
+	Neighbours []LeaderboardEntryResponse `json:"neighbours"`
+}
+
+type UpdateLeaderboardVisibilityRequest struct {
+	UserEmail string `json:"-" validate:"required,email"`
+	OptOut    bool   `json:"opt_out"`
+}
+
+type UpdateLeaderboardVisibilityResponse struct {
+	OptOut bool `json:"opt_out"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+type MailWebhookEvent struct {
+	Type       string     `json:"type" validate:"required,oneof=bounce complaint"`
+	BounceType string     `json:"bounce_type" validate:"omitempty,oneof=hard soft"`
+	Email      string     `json:"email" validate:"required,email"`
+	Status     string     `json:"status"`
+	Diagnostic string     `json:"diagnostic"`
+	Timestamp  *time.Time `json:"timestamp"`
+}
+
+type MailWebhookRequest struct {
+	Events []MailWebhookEvent `json:"events" validate:"required,min=1,dive"`
+}
+
+type MailWebhookResponse struct {
+	Recorded   int `json:"recorded"`
+	Suppressed int `json:"suppressed"`
+}
+
+type MailEventResponse struct {
+	Type       string    `json:"type"`
+	Source     string    `json:"source"`
+	Status     string    `json:"status"`
+	Diagnostic string    `json:"diagnostic"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
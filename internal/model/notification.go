@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+type ListNotificationRequest struct {
+	UserEmail string `json:"email" validate:"required,email"`
+	Page      int    `json:"page" validate:"min=1"`
+	Limit     int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type NotificationResponse struct {
+	ID        string     `json:"id"`
+	Category  string     `json:"category"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	IsRead    bool       `json:"is_read"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ListNotificationResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int64                  `json:"unread_count"`
+	Paging        *PaginationMetadata    `json:"paging"`
+}
+
+type MarkNotificationReadRequest struct {
+	UserEmail      string `json:"email" validate:"required,email"`
+	NotificationID string `json:"id" validate:"required"`
+}
+
+type MarkAllNotificationReadResponse struct {
+	Updated     int64 `json:"updated"`
+	UnreadCount int64 `json:"unread_count"`
+}
+
+type NotificationPreferencesResponse struct {
+	Timezone    string            `json:"timezone"`
+	Preferences map[string]string `json:"preferences"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	UserEmail   string            `json:"-" validate:"required,email"`
+	Timezone    string            `json:"timezone"`
+	Preferences map[string]string `json:"preferences"`
+}
@@ -0,0 +1,134 @@
+package model
+
+import "time"
+
+type SavePeerReviewRequest struct {
+	ActorEmail    string    `json:"-" validate:"required,email"`
+	AssignmentID  string    `json:"-" validate:"required"`
+	RubricID      string    `json:"rubric_id" validate:"required"`
+	Reviewers     int       `json:"reviewers" validate:"required,min=1,max=10"`
+	Aggregation   string    `json:"aggregation" validate:"omitempty,oneof=median trimmed_mean"`
+	CreditPercent int       `json:"credit_percent" validate:"min=0,max=100"`
+	ReviewDueAt   time.Time `json:"review_due_at" validate:"required"`
+}
+
+type PeerReviewResponse struct {
+	AssignmentID  string          `json:"assignment_id"`
+	Rubric        *RubricResponse `json:"rubric,omitempty"`
+	Reviewers     int             `json:"reviewers"`
+	Aggregation   string          `json:"aggregation"`
+	CreditPercent int             `json:"credit_percent"`
+	ReviewDueAt   time.Time       `json:"review_due_at"`
+	AllocatedAt   *time.Time      `json:"allocated_at"`
+	FinalizedAt   *time.Time      `json:"finalized_at"`
+}
+
+type SavePeerSubmissionRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Content      string `json:"content" validate:"required,max=20000"`
+}
+
+type PeerSubmissionResponse struct {
+	ID           string    `json:"id"`
+	AssignmentID string    `json:"assignment_id"`
+	Content      string    `json:"content"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+}
+
+// PeerReviewTaskResponse is a review as its reviewer sees it: the work to
+// review, but not whose it is. Staff also get both emails.
+type PeerReviewTaskResponse struct {
+	ID            string                 `json:"id"`
+	AssignmentID  string                 `json:"assignment_id"`
+	Content       string                 `json:"content,omitempty"`
+	AuthorEmail   string                 `json:"author_email,omitempty"`
+	ReviewerEmail string                 `json:"reviewer_email,omitempty"`
+	Criteria      []RubricGradeCriterion `json:"criteria"`
+	Comment       string                 `json:"comment,omitempty"`
+	Earned        int                    `json:"earned"`
+	Possible      int                    `json:"possible"`
+	DueAt         time.Time              `json:"due_at"`
+	SubmittedAt   *time.Time             `json:"submitted_at"`
+}
+
+type ListPeerReviewTaskRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	Page       int    `json:"page" validate:"min=1"`
+	Limit      int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type ListAssignmentPeerReviewRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Page         int    `json:"page" validate:"min=1"`
+	Limit        int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type ListPeerReviewTaskResponse struct {
+	Reviews []PeerReviewTaskResponse `json:"reviews"`
+	Paging  *PaginationMetadata      `json:"paging"`
+}
+
+type GetPeerReviewTaskRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ID         string `json:"-" validate:"required"`
+}
+
+type SubmitPeerReviewRequest struct {
+	ActorEmail string            `json:"-" validate:"required,email"`
+	ID         string            `json:"-" validate:"required"`
+	Criteria   []RubricSelection `json:"criteria" validate:"required,min=1,dive"`
+	Comment    string            `json:"comment" validate:"max=5000"`
+}
+
+// ReceivedPeerReviewRequest asks for the reviews of the caller's own work.
+type ReceivedPeerReviewRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+}
+
+type ReceivedPeerReviewResponse struct {
+	Reviews []PeerReviewTaskResponse `json:"reviews"`
+	Result  *PeerResultResponse      `json:"result"`
+}
+
+type PeerReviewActionRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+}
+
+type AllocatePeerReviewResponse struct {
+	Submissions int `json:"submissions"`
+	Reviews     int `json:"reviews"`
+}
+
+type FinalizePeerReviewResponse struct {
+	Scored   int `json:"scored"`
+	Unscored int `json:"unscored"`
+}
+
+type OverridePeerScoreRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	SourceIP     string `json:"-"`
+	AssignmentID string `json:"-" validate:"required"`
+	Email        string `json:"email" validate:"required,email"`
+	Score        *int   `json:"score" validate:"required,min=0"`
+	Reason       string `json:"reason" validate:"required,max=500"`
+}
+
+type PeerResultResponse struct {
+	Email          string   `json:"email,omitempty"`
+	Reviews        int      `json:"reviews"`
+	PeerScore      *float64 `json:"peer_score"`
+	Assigned       int      `json:"assigned"`
+	OnTime         int      `json:"on_time"`
+	Score          *int     `json:"score"`
+	Override       *int     `json:"override,omitempty"`
+	OverrideReason string   `json:"override_reason,omitempty"`
+}
+
+type ListPeerResultResponse struct {
+	Results []PeerResultResponse `json:"results"`
+	Paging  *PaginationMetadata  `json:"paging"`
+}
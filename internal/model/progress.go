@@ -0,0 +1,74 @@
+package model
+
+import "time"
+
+type StartAssignmentRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+}
+
+type CourseProgressRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	ActorRole    string `json:"-"`
+	CourseID     string `json:"-" validate:"required"`
+	StudentEmail string `json:"email" validate:"omitempty,email"`
+}
+
+type ProgressItemResponse struct {
+	AssignmentID string     `json:"assignment_id"`
+	Title        string     `json:"title"`
+	Status       string     `json:"status"`
+	Score        int        `json:"score"`
+	StartedAt    *time.Time `json:"started_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	GradedAt     *time.Time `json:"graded_at"`
+}
+
+type ModuleProgressResponse struct {
+	ModuleID       string                 `json:"module_id"`
+	Title          string                 `json:"title"`
+	Completed      int                    `json:"completed"`
+	Total          int                    `json:"total"`
+	Percent        float64                `json:"percent"`
+	LastActivityAt *time.Time             `json:"last_activity_at"`
+	Items          []ProgressItemResponse `json:"items,omitempty"`
+}
+
+type CourseProgressResponse struct {
+	CourseID       string                   `json:"course_id"`
+	Email          string                   `json:"email"`
+	Completed      int                      `json:"completed"`
+	Total          int                      `json:"total"`
+	Percent        float64                  `json:"percent"`
+	LastActivityAt *time.Time               `json:"last_activity_at"`
+	Modules        []ModuleProgressResponse `json:"modules"`
+}
+
+type ProgressMatrixRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	CourseID   string `json:"-" validate:"required"`
+	Status     string `json:"status" validate:"omitempty,oneof=active dropped completed"`
+	Page       int    `json:"page" validate:"min=1"`
+	Limit      int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type ProgressMatrixColumn struct {
+	ModuleID string `json:"module_id"`
+	Title    string `json:"title"`
+	Total    int    `json:"total"`
+}
+
+type ProgressMatrixRow struct {
+	UserID         string                   `json:"user_id"`
+	Email          string                   `json:"email"`
+	Name           string                   `json:"name"`
+	Percent        float64                  `json:"percent"`
+	LastActivityAt *time.Time               `json:"last_activity_at"`
+	Modules        []ModuleProgressResponse `json:"modules"`
+}
+
+type ProgressMatrixResponse struct {
+	Columns []ProgressMatrixColumn `json:"columns"`
+	Rows    []ProgressMatrixRow    `json:"rows"`
+	Paging  *PaginationMetadata    `json:"paging"`
+}
@@ -0,0 +1,317 @@
+package model
+
+import "time"
+
+type CreateQuestionBankRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	Name       string `json:"name" validate:"required,max=200"`
+	CourseID   string `json:"course_id"`
+}
+
+type UpdateQuestionBankRequest struct {
+	ID       string `json:"-" validate:"required"`
+	Name     string `json:"name" validate:"required,max=200"`
+	CourseID string `json:"course_id"`
+}
+
+type ListQuestionBankRequest struct {
+	CourseID string `json:"course_id"`
+	Page     int    `json:"page" validate:"min=1"`
+	Limit    int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type QuestionBankResponse struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	CourseID      string     `json:"course_id,omitempty"`
+	QuestionCount int64      `json:"question_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+type ListQuestionBankResponse struct {
+	Banks  []QuestionBankResponse `json:"banks"`
+	Paging *PaginationMetadata    `json:"paging"`
+}
+
+type QuestionChoice struct {
+	Text    string `json:"text" validate:"required"`
+	Correct bool   `json:"correct"`
+}
+
+type QuestionParameter struct {
+	Name string  `json:"name" validate:"required,max=32"`
+	Min  float64 `json:"min"`
+	Max  float64 `json:"max"`
+	Step float64 `json:"step" validate:"min=0"`
+}
+
+type SaveQuestionRequest struct {
+	ActorEmail    string              `json:"-" validate:"required,email"`
+	BankID        string              `json:"-" validate:"required"`
+	ID            string              `json:"-"`
+	Type          string              `json:"type" validate:"required,oneof=multiple_choice multiple_answer numeric short_text"`
+	Prompt        string              `json:"prompt" validate:"required"`
+	Points        int                 `json:"points" validate:"min=0"`
+	Choices       []QuestionChoice    `json:"choices" validate:"omitempty,max=26,dive"`
+	Answer        *float64            `json:"answer"`
+	Tolerance     float64             `json:"tolerance" validate:"min=0"`
+	Parameters    []QuestionParameter `json:"parameters" validate:"omitempty,max=10,dive"`
+	Formula       string              `json:"formula" validate:"max=500"`
+	Patterns      []string            `json:"patterns" validate:"omitempty,dive,required"`
+	CaseSensitive bool                `json:"case_sensitive"`
+}
+
+type PreviewQuestionRequest struct {
+	BankID string `json:"-" validate:"required"`
+	ID     string `json:"-" validate:"required"`
+	Seed   int64  `json:"seed"`
+}
+
+type ListQuestionRequest struct {
+	BankID string `json:"-" validate:"required"`
+	Page   int    `json:"page" validate:"min=1"`
+	Limit  int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type QuestionChoiceResponse struct {
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	Correct bool   `json:"correct"`
+}
+
+// QuestionResponse includes the answer key and is only ever sent to staff. For
+// a parameterized question shown as a variant, Values holds the parameter
+// values drawn and Answer the answer computed from them.
+type QuestionResponse struct {
+	ID            string                   `json:"id"`
+	BankID        string                   `json:"bank_id"`
+	Type          string                   `json:"type"`
+	Prompt        string                   `json:"prompt"`
+	Points        int                      `json:"points"`
+	Choices       []QuestionChoiceResponse `json:"choices,omitempty"`
+	Answer        *float64                 `json:"answer,omitempty"`
+	Tolerance     float64                  `json:"tolerance,omitempty"`
+	Parameters    []QuestionParameter      `json:"parameters,omitempty"`
+	Formula       string                   `json:"formula,omitempty"`
+	Values        map[string]float64       `json:"values,omitempty"`
+	Patterns      []string                 `json:"patterns,omitempty"`
+	CaseSensitive bool                     `json:"case_sensitive,omitempty"`
+	CreatedAt     time.Time                `json:"created_at"`
+	UpdatedAt     *time.Time               `json:"updated_at"`
+}
+
+type ListQuestionResponse struct {
+	Questions []QuestionResponse  `json:"questions"`
+	Paging    *PaginationMetadata `json:"paging"`
+}
+
+// Question import statuses.
+const (
+	QuestionImportImported    = "imported"
+	QuestionImportUnsupported = "unsupported"
+	QuestionImportInvalid     = "invalid"
+)
+
+type ImportQuestionsRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	BankID     string `json:"-" validate:"required"`
+	Format     string `json:"format" validate:"omitempty,oneof=gift qti"`
+	Data       []byte `json:"-" validate:"required"`
+}
+
+// ImportedQuestionResponse reports one item of the file. Kind is the question
+// type it became, or the item type as the format names it when unsupported.
+type ImportedQuestionResponse struct {
+	Index      int    `json:"index"`
+	Title      string `json:"title,omitempty"`
+	Kind       string `json:"kind"`
+	Status     string `json:"status"`
+	Problem    string `json:"problem,omitempty"`
+	QuestionID string `json:"question_id,omitempty"`
+}
+
+type ImportQuestionsResponse struct {
+	Format      string                     `json:"format"`
+	Total       int                        `json:"total"`
+	Imported    int                        `json:"imported"`
+	Unsupported int                        `json:"unsupported"`
+	Invalid     int                        `json:"invalid"`
+	Questions   []ImportedQuestionResponse `json:"questions"`
+}
+
+type ExportQuestionsRequest struct {
+	BankID string `json:"-" validate:"required"`
+	Format string `json:"format" validate:"required,oneof=gift qti"`
+}
+
+type QuizSection struct {
+	BankID string `json:"bank_id" validate:"required"`
+	Count  int    `json:"count" validate:"required,min=1"`
+}
+
+type QuizExam struct {
+	AccessCode      string   `json:"access_code" validate:"max=64"`
+	AllowedNetworks []string `json:"allowed_networks" validate:"max=50,dive,required"`
+}
+
+type SaveQuizRequest struct {
+	ActorEmail     string        `json:"-" validate:"required,email"`
+	AssignmentID   string        `json:"-" validate:"required"`
+	Sections       []QuizSection `json:"sections" validate:"required,min=1,dive"`
+	TimeLimit      int           `json:"time_limit" validate:"min=0"`
+	ShuffleChoices bool          `json:"shuffle_choices"`
+	Exam           *QuizExam     `json:"exam"`
+}
+
+// QuizExamResponse only shows staff the access code and allowed networks;
+// students just learn whether they need a code.
+type QuizExamResponse struct {
+	AccessCodeRequired bool     `json:"access_code_required"`
+	AccessCode         string   `json:"access_code,omitempty"`
+	AllowedNetworks    []string `json:"allowed_networks,omitempty"`
+}
+
+type QuizResponse struct {
+	AssignmentID   string            `json:"assignment_id"`
+	Sections       []QuizSection     `json:"sections"`
+	QuestionCount  int               `json:"question_count"`
+	TimeLimit      int               `json:"time_limit"`
+	ShuffleChoices bool              `json:"shuffle_choices"`
+	Exam           *QuizExamResponse `json:"exam,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      *time.Time        `json:"updated_at"`
+}
+
+type StartQuizAttemptRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	AccessCode   string `json:"access_code"`
+	SourceIP     string `json:"-"`
+}
+
+type GetQuizAttemptRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ID         string `json:"-" validate:"required"`
+}
+
+type QuizAnswer struct {
+	QuestionID string   `json:"question_id" validate:"required"`
+	ChoiceIDs  []string `json:"choice_ids,omitempty"`
+	Number     *float64 `json:"number,omitempty"`
+	Text       string   `json:"text,omitempty" validate:"max=1000"`
+}
+
+type SaveQuizAnswersRequest struct {
+	ActorEmail string       `json:"-" validate:"required,email"`
+	ID         string       `json:"-" validate:"required"`
+	Answers    []QuizAnswer `json:"answers" validate:"dive"`
+	SourceIP   string       `json:"-"`
+}
+
+type ListQuizAttemptRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Email        string `json:"email" validate:"omitempty,email"`
+	Page         int    `json:"page" validate:"min=1"`
+	Limit        int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type QuizChoiceResponse struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// QuizQuestionResponse is a question as the student taking it sees it, without
+// anything that gives the answer away.
+type QuizQuestionResponse struct {
+	ID      string               `json:"id"`
+	Type    string               `json:"type"`
+	Prompt  string               `json:"prompt"`
+	Points  int                  `json:"points"`
+	Choices []QuizChoiceResponse `json:"choices,omitempty"`
+}
+
+type QuizQuestionResultResponse struct {
+	QuestionID string  `json:"question_id"`
+	Earned     float64 `json:"earned"`
+	Correct    bool    `json:"correct"`
+}
+
+type QuizAttemptResponse struct {
+	ID           string                       `json:"id"`
+	AssignmentID string                       `json:"assignment_id"`
+	Email        string                       `json:"email,omitempty"`
+	Status       string                       `json:"status"`
+	Exam         bool                         `json:"exam,omitempty"`
+	Questions    []QuizQuestionResponse       `json:"questions,omitempty"`
+	Answers      []QuizAnswer                 `json:"answers,omitempty"`
+	Results      []QuizQuestionResultResponse `json:"results,omitempty"`
+	AnswerKey    []QuestionResponse           `json:"answer_key,omitempty"`
+	Seed         *int64                       `json:"seed,omitempty"`
+	Earned       *float64                     `json:"earned,omitempty"`
+	Possible     int                          `json:"possible"`
+	Score        *int                         `json:"score,omitempty"`
+	Attempt      int                          `json:"attempt,omitempty"`
+	StartedAt    time.Time                    `json:"started_at"`
+	ExpiresAt    *time.Time                   `json:"expires_at"`
+	SubmittedAt  *time.Time                   `json:"submitted_at"`
+}
+
+type ListQuizAttemptResponse struct {
+	Attempts []QuizAttemptResponse `json:"attempts"`
+	Paging   *PaginationMetadata   `json:"paging"`
+}
+
+// ExamEvent is an integrity event as the exam client reports it; At is when it
+// happened on the client.
+type ExamEvent struct {
+	Type   string     `json:"type" validate:"required,oneof=focus_lost focus_gained paste copy fullscreen_exit fullscreen_enter"`
+	Detail string     `json:"detail" validate:"max=500"`
+	At     *time.Time `json:"at"`
+}
+
+type RecordExamEventsRequest struct {
+	ActorEmail string      `json:"-" validate:"required,email"`
+	ID         string      `json:"-" validate:"required"`
+	Events     []ExamEvent `json:"events" validate:"required,min=1,max=100,dive"`
+	SourceIP   string      `json:"-"`
+}
+
+type RecordExamEventsResponse struct {
+	Recorded int `json:"recorded"`
+}
+
+type ExamEventResponse struct {
+	Type       string     `json:"type"`
+	Detail     string     `json:"detail,omitempty"`
+	IP         string     `json:"ip"`
+	ClientAt   *time.Time `json:"client_at"`
+	ReceivedAt time.Time  `json:"received_at"`
+}
+
+type ExamTimelineResponse struct {
+	AttemptID string              `json:"attempt_id"`
+	Email     string              `json:"email"`
+	IP        string              `json:"ip"`
+	Events    []ExamEventResponse `json:"events"`
+}
+
+// ExamAttemptSummary is one exam attempt with how many integrity events of
+// each type it had.
+type ExamAttemptSummary struct {
+	AttemptID   string         `json:"attempt_id"`
+	Email       string         `json:"email"`
+	Status      string         `json:"status"`
+	IP          string         `json:"ip"`
+	Events      map[string]int `json:"events"`
+	TotalEvents int            `json:"total_events"`
+	StartedAt   time.Time      `json:"started_at"`
+	ExpiresAt   *time.Time     `json:"expires_at"`
+	SubmittedAt *time.Time     `json:"submitted_at"`
+}
+
+type ExamSummaryResponse struct {
+	Attempts []ExamAttemptSummary `json:"attempts"`
+	Paging   *PaginationMetadata  `json:"paging"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+type CreateRegradeRequest struct {
+	ActorEmail    string `json:"-" validate:"required,email"`
+	AssignmentID  string `json:"-" validate:"required"`
+	Justification string `json:"justification" validate:"required,min=10,max=5000"`
+}
+
+// ListRegradeRequest lists the caller's own requests, or for staff the queue
+// of the students they teach. Status "pending" means open or escalated.
+type ListRegradeRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	Status       string `json:"status" validate:"omitempty,oneof=pending open escalated accepted rejected"`
+	AssignmentID string `json:"assignment_id"`
+	Page         int    `json:"page" validate:"min=1"`
+	Limit        int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type GetRegradeRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ID         string `json:"-" validate:"required"`
+}
+
+// ResolveRegradeRequest accepts a request with the score it should end up at,
+// or rejects it with a response to the student.
+type ResolveRegradeRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	SourceIP   string `json:"-"`
+	ID         string `json:"-" validate:"required"`
+	NewScore   *int   `json:"new_score" validate:"omitempty,min=0"`
+	Response   string `json:"response" validate:"max=5000"`
+}
+
+type EscalateRegradeRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ID         string `json:"-" validate:"required"`
+	Note       string `json:"note" validate:"max=2000"`
+}
+
+type RegradeRequestResponse struct {
+	ID            string     `json:"id"`
+	AssignmentID  string     `json:"assignment_id"`
+	Email         string     `json:"email"`
+	Justification string     `json:"justification"`
+	Status        string     `json:"status"`
+	Score         int        `json:"score"`
+	NewScore      *int       `json:"new_score,omitempty"`
+	Response      string     `json:"response,omitempty"`
+	EscalatedAt   *time.Time `json:"escalated_at,omitempty"`
+	EscalateNote  string     `json:"escalate_note,omitempty"`
+	ResolvedBy    string     `json:"resolved_by,omitempty"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+type ListRegradeRequestResponse struct {
+	Requests []RegradeRequestResponse `json:"requests"`
+	Paging   *PaginationMetadata      `json:"paging"`
+}
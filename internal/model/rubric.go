@@ -0,0 +1,117 @@
+package model
+
+import "time"
+
+// RubricLevel and RubricCriterion keep their id when a rubric is edited, so
+// grades given before still point at them; new ones get an id from the server.
+type RubricLevel struct {
+	ID          string `json:"id"`
+	Title       string `json:"title" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=1000"`
+	Points      int    `json:"points" validate:"min=0,max=1000"`
+}
+
+type RubricCriterion struct {
+	ID          string        `json:"id"`
+	Title       string        `json:"title" validate:"required,max=200"`
+	Description string        `json:"description" validate:"max=2000"`
+	Levels      []RubricLevel `json:"levels" validate:"required,min=1,max=10,dive"`
+	MaxPoints   int           `json:"max_points"`
+}
+
+type SaveRubricRequest struct {
+	ActorEmail  string            `json:"-" validate:"required,email"`
+	ID          string            `json:"-"`
+	CourseID    string            `json:"course_id"`
+	Title       string            `json:"title" validate:"required,max=200"`
+	Description string            `json:"description" validate:"max=2000"`
+	Criteria    []RubricCriterion `json:"criteria" validate:"required,min=1,max=50,dive"`
+}
+
+type ListRubricRequest struct {
+	CourseID string `json:"course_id"`
+	Page     int    `json:"page" validate:"min=1"`
+	Limit    int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type RubricResponse struct {
+	ID          string            `json:"id"`
+	CourseID    string            `json:"course_id,omitempty"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Criteria    []RubricCriterion `json:"criteria"`
+	MaxPoints   int               `json:"max_points"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   *time.Time        `json:"updated_at"`
+}
+
+type ListRubricResponse struct {
+	Rubrics []RubricResponse    `json:"rubrics"`
+	Paging  *PaginationMetadata `json:"paging"`
+}
+
+type AttachRubricRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	RubricID     string `json:"rubric_id" validate:"required"`
+}
+
+type RubricSelection struct {
+	CriterionID string `json:"criterion_id" validate:"required"`
+	LevelID     string `json:"level_id" validate:"required"`
+	Comment     string `json:"comment" validate:"max=2000"`
+}
+
+// GradeRubricRequest picks a level for every criterion of the assignment's
+// rubric for one student.
+type GradeRubricRequest struct {
+	ActorEmail   string            `json:"-" validate:"required,email"`
+	SourceIP     string            `json:"-"`
+	AssignmentID string            `json:"-" validate:"required"`
+	Email        string            `json:"email" validate:"required,email"`
+	Criteria     []RubricSelection `json:"criteria" validate:"required,min=1,dive"`
+	Comment      string            `json:"comment" validate:"max=5000"`
+}
+
+// GetRubricGradeRequest asks for the caller's own grade, or for a student's
+// when staff name them.
+type GetRubricGradeRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Email        string `json:"email" validate:"omitempty,email"`
+}
+
+type ListRubricGradeRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Page         int    `json:"page" validate:"min=1"`
+	Limit        int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type RubricGradeCriterion struct {
+	CriterionID string `json:"criterion_id"`
+	Title       string `json:"title"`
+	LevelID     string `json:"level_id"`
+	Level       string `json:"level"`
+	Points      int    `json:"points"`
+	MaxPoints   int    `json:"max_points"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+type RubricGradeResponse struct {
+	AssignmentID string                 `json:"assignment_id"`
+	RubricID     string                 `json:"rubric_id"`
+	Email        string                 `json:"email"`
+	Criteria     []RubricGradeCriterion `json:"criteria"`
+	Comment      string                 `json:"comment,omitempty"`
+	Earned       int                    `json:"earned"`
+	Possible     int                    `json:"possible"`
+	Score        int                    `json:"score"`
+	GraderEmail  string                 `json:"grader_email"`
+	GradedAt     time.Time              `json:"graded_at"`
+}
+
+type ListRubricGradeResponse struct {
+	Grades []RubricGradeResponse `json:"grades"`
+	Paging *PaginationMetadata   `json:"paging"`
+}
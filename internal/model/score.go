@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+type ScoreCorrectionRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	SourceIP     string `json:"-"`
+	UserEmail    string `json:"email" validate:"required,email"`
+	AssignmentID string `json:"assignment_id" validate:"required"`
+	Delta        *int   `json:"delta"`
+	NewScore     *int   `json:"new_score"`
+	Reason       string `json:"reason" validate:"required,max=500"`
+}
+
+type ScoreHistoryRequest struct {
+	ActorEmail   string `json:"-"`
+	UserEmail    string `json:"email" validate:"omitempty,email"`
+	AssignmentID string `json:"assignment_id"`
+	Page         int    `json:"page" validate:"min=1"`
+	Limit        int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type ScoreLedgerEntryResponse struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	AssignmentID string    `json:"assignment_id"`
+	Kind         string    `json:"kind"`
+	Delta        int       `json:"delta"`
+	OldScore     int       `json:"old_score"`
+	NewScore     int       `json:"new_score"`
+	ActorEmail   string    `json:"actor_email"`
+	Reason       string    `json:"reason,omitempty"`
+	SourceIP     string    `json:"source_ip,omitempty"`
+	SubmissionID string    `json:"submission_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ScoreHistoryResponse struct {
+	Entries []ScoreLedgerEntryResponse `json:"entries"`
+	Paging  *PaginationMetadata        `json:"paging"`
+}
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+type StarterCode struct {
+	Name   string `json:"name" validate:"required,max=200"`
+	Source string `json:"source" validate:"required,max=262144"`
+}
+
+// SaveAllowListRequest replaces the starter code an assignment's similarity
+// checks ignore.
+type SaveAllowListRequest struct {
+	ActorEmail   string        `json:"-" validate:"required,email"`
+	AssignmentID string        `json:"-" validate:"required"`
+	StarterCode  []StarterCode `json:"starter_code" validate:"max=20,dive"`
+}
+
+// CheckSimilarityRequest queues a similarity check. Threshold is the
+// similarity in percent from which pairs are reported, 50 when left out.
+type CheckSimilarityRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+	Threshold    int    `json:"threshold" validate:"omitempty,min=1,max=100"`
+}
+
+type GetSimilarityReportRequest struct {
+	ActorEmail   string `json:"-" validate:"required,email"`
+	AssignmentID string `json:"-" validate:"required"`
+}
+
+type SimilarityRegion struct {
+	StartA int `json:"start_a"`
+	EndA   int `json:"end_a"`
+	StartB int `json:"start_b"`
+	EndB   int `json:"end_b"`
+}
+
+type SimilarityPairResponse struct {
+	EmailA      string             `json:"email_a"`
+	EmailB      string             `json:"email_b"`
+	SubmissionA string             `json:"submission_a"`
+	SubmissionB string             `json:"submission_b"`
+	Similarity  float64            `json:"similarity"`
+	CoverageA   float64            `json:"coverage_a"`
+	CoverageB   float64            `json:"coverage_b"`
+	Regions     []SimilarityRegion `json:"regions"`
+}
+
+type SimilarityReportResponse struct {
+	AssignmentID string                   `json:"assignment_id"`
+	Status       string                   `json:"status"`
+	Threshold    int                      `json:"threshold"`
+	StarterCode  []StarterCode            `json:"starter_code"`
+	Submissions  int                      `json:"submissions"`
+	Compared     int                      `json:"compared"`
+	Pairs        []SimilarityPairResponse `json:"pairs"`
+	Error        string                   `json:"error,omitempty"`
+	RequestedAt  *time.Time               `json:"requested_at"`
+	StartedAt    *time.Time               `json:"started_at"`
+	FinishedAt   *time.Time               `json:"finished_at"`
+}
@@ -48,7 +48,9 @@ type VerifyAuthRequest struct {
 }
 
 type VerifyAuthResponse struct {
+	ID    string `json:"id"`
 	Email string `json:"email"`
+	Role  string `json:"role"`
 }
 
 type RequestOTPResetPassword struct {
@@ -76,18 +78,46 @@ type RequestGetProfiles struct {
 }
 
 type ResponseGetProfiles struct {
-	Name      string     `json:"name"`
-	Email     string     `json:"email"`
-	Score     int        `json:"score"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	// Score is the sum of the user's raw assignment scores across courses;
+	// Grades are the weighted final grades of the courses with a grading scheme.
+	Score     int                  `json:"score"`
+	Grades    []CourseGradeSummary `json:"grades"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt *time.Time           `json:"updated_at"`
 }
 
-type UpdateScoreRequest struct {
-	Score int `json:"score"`
+type AdminListUserRequest struct {
+	ActorEmail    string `json:"-"`
+	Page          int    `json:"page" validate:"min=1"`
+	Limit         int    `json:"limit" validate:"min=1,max=100"`
+	Search        string `json:"search"`
+	Undeliverable bool   `json:"undeliverable"`
 }
 
-type UpdateScoreResponse struct {
-	Email string `json:"email"`
-	Score int    `json:"score"`
+type AdminSetRoleRequest struct {
+	ActorEmail string `json:"-" validate:"required,email"`
+	ID         string `json:"-" validate:"required"`
+	Role       string `json:"role" validate:"required,oneof=student instructor ta admin"`
+}
+
+type AdminUserResponse struct {
+	ID                       string               `json:"id"`
+	Name                     string               `json:"name"`
+	Email                    string               `json:"email"`
+	Role                     string               `json:"role"`
+	Cohorts                  []UserCohortResponse `json:"cohorts"`
+	IsEmailVerified          bool                 `json:"is_email_verified"`
+	EmailStatus              string               `json:"email_status"`
+	EmailUndeliverableReason string               `json:"email_undeliverable_reason,omitempty"`
+	EmailUndeliverableAt     *time.Time           `json:"email_undeliverable_at,omitempty"`
+	RecentMailEvents         []MailEventResponse  `json:"recent_mail_events,omitempty"`
+	CreatedAt                time.Time            `json:"created_at"`
+	UpdatedAt                *time.Time           `json:"updated_at"`
+}
+
+type AdminListUserResponse struct {
+	Users  []AdminUserResponse `json:"users"`
+	Paging *PaginationMetadata `json:"paging"`
 }
@@ -0,0 +1,374 @@
+package questionfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+var giftWeight = regexp.MustCompile(`^%(-?[0-9]+(?:\.[0-9]+)?)%`)
+
+// giftSpecial are the characters GIFT escapes with a backslash.
+const giftSpecial = `~=#{}:\`
+
+// ParseGIFT reads the questions of a Moodle GIFT file. Questions are separated
+// by blank lines; comments and $CATEGORY lines are skipped. Multiple choice,
+// multiple answer, true/false, short answer and numerical questions are read;
+// essays, matching, missing word and description items are reported as
+// unsupported.
+func ParseGIFT(data []byte) []ImportedQuestion {
+	text := strings.ReplaceAll(string(bytes.TrimPrefix(data, []byte("\ufeff"))), "\r\n", "\n")
+
+	items := []ImportedQuestion{}
+	var block []string
+	flush := func() {
+		if len(block) > 0 {
+			items = append(items, parseGIFTItem(len(items)+1, strings.Join(block, "\n")))
+			block = nil
+		}
+	}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "//"), strings.HasPrefix(trimmed, "$CATEGORY:"):
+		default:
+			block = append(block, line)
+		}
+	}
+	flush()
+	return items
+}
+
+func parseGIFTItem(index int, text string) ImportedQuestion {
+	item := ImportedQuestion{Index: index}
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "::") {
+		if end := giftIndex(text[2:], "::"); end >= 0 {
+			item.Title = giftUnescape(strings.TrimSpace(text[2 : 2+end]))
+			text = strings.TrimSpace(text[4+end:])
+		}
+	}
+	if strings.HasPrefix(text, "[") {
+		if end := strings.Index(text, "]"); end > 0 && !strings.ContainsAny(text[1:end], " {") {
+			text = strings.TrimSpace(text[end+1:])
+		}
+	}
+
+	open := giftIndex(text, "{")
+	if open < 0 {
+		item.Kind = "description"
+		item.Problem = "description items have no answer"
+		return item
+	}
+	close := giftIndex(text[open:], "}")
+	if close < 0 {
+		item.Kind = "invalid"
+		item.Problem = "answer block is not closed"
+		return item
+	}
+	close += open
+	prompt := giftUnescape(strings.TrimSpace(text[:open]))
+	answer := strings.TrimSpace(text[open+1 : close])
+	if after := strings.TrimSpace(text[close+1:]); after != "" {
+		item.Kind = "missing_word"
+		item.Problem = "missing word questions are not supported"
+		return item
+	}
+
+	switch {
+	case answer == "":
+		item.Kind = "essay"
+		item.Problem = "essay questions cannot be auto-graded"
+	case strings.HasPrefix(answer, "#"):
+		item.Kind = "numerical"
+		item.Question, item.Problem = giftNumeric(prompt, answer[1:])
+	case giftTrueFalse(answer) != "":
+		item.Kind = "true_false"
+		correct := giftTrueFalse(answer) == "T"
+		item.Question = choiceRequest(prompt, []model.QuestionChoice{
+			{Text: "True", Correct: correct},
+			{Text: "False", Correct: !correct},
+		}, false)
+	default:
+		answers := giftAnswers(answer)
+		matching, wrong := false, false
+		for _, a := range answers {
+			matching = matching || giftIndex(a.text, "->") >= 0
+			wrong = wrong || a.marker == '~'
+		}
+		switch {
+		case len(answers) == 0:
+			item.Kind = "invalid"
+			item.Problem = "answer block has no answers"
+		case matching:
+			item.Kind = "matching"
+			item.Problem = "matching questions are not supported"
+		case wrong:
+			item.Kind = "multiple_choice"
+			item.Question = giftChoice(prompt, answers)
+		default:
+			item.Kind = "short_answer"
+			item.Question, item.Problem = giftShortAnswer(prompt, answers)
+		}
+	}
+	if item.Question != nil {
+		item.Kind = item.Question.Type
+	}
+	return item
+}
+
+type giftAnswer struct {
+	marker rune
+	weight *float64
+	text   string
+}
+
+// giftAnswers splits an answer block into its =right and ~wrong answers, with
+// any %weight% read off and any #feedback dropped.
+func giftAnswers(block string) []giftAnswer {
+	answers := []giftAnswer{}
+	var current *giftAnswer
+	var text strings.Builder
+	end := func() {
+		if current != nil {
+			body := strings.TrimSpace(text.String())
+			if feedback := giftIndex(body, "#"); feedback >= 0 {
+				body = strings.TrimSpace(body[:feedback])
+			}
+			if match := giftWeight.FindStringSubmatch(body); match != nil {
+				weight, _ := strconv.ParseFloat(match[1], 64)
+				current.weight = &weight
+				body = strings.TrimSpace(body[len(match[0]):])
+			}
+			current.text = body
+			answers = append(answers, *current)
+		}
+		text.Reset()
+	}
+	runes := []rune(block)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\' && i+1 < len(runes):
+			text.WriteRune(r)
+			text.WriteRune(runes[i+1])
+			i++
+		case r == '=' || r == '~':
+			end()
+			current = &giftAnswer{marker: r}
+		default:
+			text.WriteRune(r)
+		}
+	}
+	end()
+	return answers
+}
+
+// giftChoice reads a choice question. A choice is correct when it is marked =
+// or has a positive weight; weights on ~ answers are how GIFT writes multiple
+// answer questions.
+func giftChoice(prompt string, answers []giftAnswer) *model.SaveQuestionRequest {
+	choices := make([]model.QuestionChoice, 0, len(answers))
+	multiple := false
+	for _, answer := range answers {
+		correct := answer.marker == '='
+		if answer.weight != nil {
+			correct = *answer.weight > 0
+			multiple = multiple || (answer.marker == '~' && correct)
+		}
+		choices = append(choices, model.QuestionChoice{Text: giftUnescape(answer.text), Correct: correct})
+	}
+	return choiceRequest(prompt, choices, multiple)
+}
+
+// giftShortAnswer accepts every answer worth full credit, matched literally and
+// ignoring case as Moodle does.
+func giftShortAnswer(prompt string, answers []giftAnswer) (*model.SaveQuestionRequest, string) {
+	request := &model.SaveQuestionRequest{Type: entity.QuestionShortText, Prompt: prompt}
+	for _, answer := range answers {
+		if answer.weight != nil && *answer.weight < 100 {
+			continue
+		}
+		if text := giftUnescape(answer.text); text != "" {
+			request.Patterns = append(request.Patterns, regexp.QuoteMeta(text))
+		}
+	}
+	if len(request.Patterns) == 0 {
+		return nil, "short answer question has no answer worth full credit"
+	}
+	return request, ""
+}
+
+// giftNumeric reads "answer:tolerance", "min..max" or a list of =answers, of
+// which the first worth full credit is used.
+func giftNumeric(prompt, block string) (*model.SaveQuestionRequest, string) {
+	value := strings.TrimSpace(block)
+	if giftIndex(value, "=") >= 0 {
+		value = ""
+		for _, answer := range giftAnswers(block) {
+			if answer.marker == '=' && (answer.weight == nil || *answer.weight >= 100) {
+				value = answer.text
+				break
+			}
+		}
+	} else if feedback := giftIndex(value, "#"); feedback >= 0 {
+		value = strings.TrimSpace(value[:feedback])
+	}
+
+	var answer, tolerance float64
+	var err error
+	if low, high, ok := strings.Cut(value, ".."); ok {
+		var lowest, highest float64
+		if lowest, err = strconv.ParseFloat(strings.TrimSpace(low), 64); err == nil {
+			highest, err = strconv.ParseFloat(strings.TrimSpace(high), 64)
+		}
+		answer, tolerance = (lowest+highest)/2, (highest-lowest)/2
+	} else {
+		number, margin, _ := strings.Cut(value, ":")
+		if answer, err = strconv.ParseFloat(strings.TrimSpace(number), 64); err == nil && margin != "" {
+			tolerance, err = strconv.ParseFloat(strings.TrimSpace(margin), 64)
+		}
+	}
+	if err != nil || tolerance < 0 {
+		return nil, "numerical answer is not a number"
+	}
+	return &model.SaveQuestionRequest{
+		Type:      entity.QuestionNumeric,
+		Prompt:    prompt,
+		Answer:    &answer,
+		Tolerance: tolerance,
+	}, ""
+}
+
+// giftTrueFalse returns "T" or "F" for a true/false answer block, or "".
+func giftTrueFalse(block string) string {
+	if feedback := giftIndex(block, "#"); feedback >= 0 {
+		block = block[:feedback]
+	}
+	switch strings.ToUpper(strings.TrimSpace(block)) {
+	case "T", "TRUE":
+		return "T"
+	case "F", "FALSE":
+		return "F"
+	}
+	return ""
+}
+
+// giftIndex finds the first occurrence of sep that is not escaped.
+func giftIndex(text, sep string) int {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+func giftUnescape(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			switch next := text[i+1]; {
+			case next == 'n':
+				out.WriteByte('\n')
+				i++
+				continue
+			case strings.IndexByte(giftSpecial, next) >= 0:
+				out.WriteByte(next)
+				i++
+				continue
+			}
+		}
+		out.WriteByte(text[i])
+	}
+	return out.String()
+}
+
+func giftEscape(text string) string {
+	var out strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\n':
+			out.WriteString(`\n`)
+		case strings.ContainsRune(giftSpecial, r):
+			out.WriteRune('\\')
+			out.WriteRune(r)
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+// WriteGIFT writes the questions in GIFT, titled with their ids. Questions the
+// format cannot hold are written as a comment saying why. GIFT has no points,
+// so they are not kept.
+func WriteGIFT(w io.Writer, questions []entity.Question) error {
+	out := bufio.NewWriter(w)
+	for i := range questions {
+		question := &questions[i]
+		if problem := QuestionExportProblem(question); problem != "" {
+			fmt.Fprintf(out, "// skipped %s: %s\n\n", question.ID.Hex(), problem)
+			continue
+		}
+		fmt.Fprintf(out, "::%s:: %s {", question.ID.Hex(), giftEscape(question.Prompt))
+		switch question.Type {
+		case entity.QuestionMultipleChoice:
+			for _, choice := range question.Choices {
+				marker := "~"
+				if choice.Correct {
+					marker = "="
+				}
+				fmt.Fprintf(out, "\n\t%s%s", marker, giftEscape(choice.Text))
+			}
+			out.WriteString("\n")
+		case entity.QuestionMultipleAnswer:
+			correct, wrong := 0, 0
+			for _, choice := range question.Choices {
+				if choice.Correct {
+					correct++
+				} else {
+					wrong++
+				}
+			}
+			for _, choice := range question.Choices {
+				weight := -100 / float64(max(wrong, 1))
+				if choice.Correct {
+					weight = 100 / float64(correct)
+				}
+				// Moodle only takes weights from its own list, which has them
+				// to five decimals.
+				weight = math.Round(weight*1e5) / 1e5
+				fmt.Fprintf(out, "\n\t~%%%s%%%s", strconv.FormatFloat(weight, 'f', -1, 64), giftEscape(choice.Text))
+			}
+			out.WriteString("\n")
+		case entity.QuestionNumeric:
+			fmt.Fprintf(out, "#%s:%s", strconv.FormatFloat(question.Answer, 'f', -1, 64),
+				strconv.FormatFloat(question.Tolerance, 'f', -1, 64))
+		case entity.QuestionShortText:
+			for _, pattern := range question.Patterns {
+				text, _ := literalPattern(pattern)
+				fmt.Fprintf(out, "\n\t=%s", giftEscape(text))
+			}
+			out.WriteString("\n")
+		}
+		out.WriteString("}\n\n")
+	}
+	return out.Flush()
+}
@@ -0,0 +1,94 @@
+package questionfile
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+)
+
+func TestParseGIFT(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		kind    string
+		title   string
+		prompt  string
+		correct []bool
+		answer  float64
+		margin  float64
+		pattern []string
+	}{
+		{name: "true", text: "::Sky:: The sky is blue. {T}", kind: entity.QuestionMultipleChoice,
+			title: "Sky", prompt: "The sky is blue.", correct: []bool{true, false}},
+		{name: "false with feedback", text: "Water is dry. {FALSE#It is wet.}", kind: entity.QuestionMultipleChoice,
+			prompt: "Water is dry.", correct: []bool{false, true}},
+		{name: "choice with feedback and format", text: "[html]Pick {=right#yes ~wrong#no}", kind: entity.QuestionMultipleChoice,
+			prompt: "Pick", correct: []bool{true, false}},
+		{name: "weights", text: "Pick two {~%50%a ~%50%b ~%-100%c}", kind: entity.QuestionMultipleAnswer,
+			prompt: "Pick two", correct: []bool{true, true, false}},
+		{name: "two marked right", text: "Pick {=a =b ~c}", kind: entity.QuestionMultipleAnswer,
+			prompt: "Pick", correct: []bool{true, true, false}},
+		{name: "escaped braces", text: `Is \{x\} a set? {=yes ~no}`, kind: entity.QuestionMultipleChoice,
+			prompt: "Is {x} a set?", correct: []bool{true, false}},
+		{name: "numeric tolerance", text: "Pi? {#3.14:0.01}", kind: entity.QuestionNumeric,
+			prompt: "Pi?", answer: 3.14, margin: 0.01},
+		{name: "numeric range", text: "Between? {#1..3}", kind: entity.QuestionNumeric,
+			prompt: "Between?", answer: 2, margin: 1},
+		{name: "numeric answers", text: "Value? {#=%50%4:1 =5:0.5#close}", kind: entity.QuestionNumeric,
+			prompt: "Value?", answer: 5, margin: 0.5},
+		{name: "short answer", text: "Capital? {=Paris =%50%Lyon =Paris, France}", kind: entity.QuestionShortText,
+			prompt: "Capital?", pattern: []string{"Paris", `Paris, France`}},
+		{name: "essay", text: "Discuss. {}", kind: "essay"},
+		{name: "matching", text: "Match {=a -> 1 =b -> 2}", kind: "matching"},
+		{name: "missing word", text: "The {=cat ~dog} sat.", kind: "missing_word"},
+		{name: "description", text: "Just some text.", kind: "description"},
+		{name: "unclosed", text: "Broken {=a", kind: "invalid"},
+		{name: "no full credit short answer", text: "Guess {=%50%maybe}", kind: "short_answer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := ParseGIFT([]byte("// a comment\n$CATEGORY: tests\n\n" + tt.text + "\n"))
+			if len(items) != 1 {
+				t.Fatalf("read %d items, want 1", len(items))
+			}
+			item := items[0]
+			if item.Kind != tt.kind {
+				t.Errorf("Kind = %q, want %q (problem %q)", item.Kind, tt.kind, item.Problem)
+			}
+			if item.Title != tt.title {
+				t.Errorf("Title = %q, want %q", item.Title, tt.title)
+			}
+			question := item.Question
+			if tt.prompt == "" {
+				if question != nil || item.Problem == "" {
+					t.Errorf("got question %+v and problem %q, want only a problem", question, item.Problem)
+				}
+				return
+			}
+			if question == nil {
+				t.Fatalf("no question: %s", item.Problem)
+			}
+			if question.Prompt != tt.prompt {
+				t.Errorf("Prompt = %q, want %q", question.Prompt, tt.prompt)
+			}
+			correct := []bool{}
+			for _, choice := range question.Choices {
+				correct = append(correct, choice.Correct)
+			}
+			if tt.correct != nil && !slices.Equal(correct, tt.correct) {
+				t.Errorf("correct = %v, want %v", correct, tt.correct)
+			}
+			if tt.kind == entity.QuestionNumeric && (question.Answer == nil || *question.Answer != tt.answer || question.Tolerance != tt.margin) {
+				t.Errorf("answer = %v±%v, want %v±%v", question.Answer, question.Tolerance, tt.answer, tt.margin)
+			}
+			for i, pattern := range tt.pattern {
+				tt.pattern[i] = regexp.QuoteMeta(pattern)
+			}
+			if tt.pattern != nil && !slices.Equal(question.Patterns, tt.pattern) {
+				t.Errorf("Patterns = %q, want %q", question.Patterns, tt.pattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,513 @@
+package questionfile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+var ErrNotQTI = errors.New("file is not a QTI 2.1 item or content package")
+
+const (
+	qtiMaxFiles    = 2000
+	qtiMaxFileSize = 4 << 20
+	qtiNamespace   = "http://www.imsglobal.org/xsd/imsqti_v2p1"
+	qtiMatch       = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/match_correct"
+	qtiMapResponse = "http://www.imsglobal.org/question/qti_v2p1/rptemplates/map_response"
+)
+
+var qtiTolerance = regexp.MustCompile(`tolerance="([^"]*)"`)
+
+type qtiItem struct {
+	XMLName    xml.Name
+	Identifier string                   `xml:"identifier,attr"`
+	Title      string                   `xml:"title,attr"`
+	Responses  []qtiResponseDeclaration `xml:"responseDeclaration"`
+	Outcomes   []qtiOutcomeDeclaration  `xml:"outcomeDeclaration"`
+	ItemBody   struct {
+		Inner []byte `xml:",innerxml"`
+	} `xml:"itemBody"`
+	ResponseProcessing struct {
+		Template string `xml:"template,attr"`
+		Inner    []byte `xml:",innerxml"`
+	} `xml:"responseProcessing"`
+}
+
+type qtiResponseDeclaration struct {
+	Identifier  string   `xml:"identifier,attr"`
+	Cardinality string   `xml:"cardinality,attr"`
+	BaseType    string   `xml:"baseType,attr"`
+	Correct     []string `xml:"correctResponse>value"`
+	Mapping     []struct {
+		Key           string  `xml:"mapKey,attr"`
+		Value         float64 `xml:"mappedValue,attr"`
+		CaseSensitive bool    `xml:"caseSensitive,attr"`
+	} `xml:"mapping>mapEntry"`
+}
+
+type qtiOutcomeDeclaration struct {
+	Identifier    string   `xml:"identifier,attr"`
+	NormalMaximum string   `xml:"normalMaximum,attr"`
+	Default       []string `xml:"defaultValue>value"`
+}
+
+type qtiChoice struct {
+	id   string
+	text strings.Builder
+}
+
+type qtiInteraction struct {
+	kind     string
+	response string
+	prompt   strings.Builder
+	choices  []*qtiChoice
+}
+
+// ParseQTI reads the assessment items of a QTI 2.1 content package, or of a
+// single item's XML. Choice interactions and text entry interactions with a
+// numeric or text answer are read; items with any other interaction, or more
+// than one, are reported as unsupported.
+func ParseQTI(data []byte) ([]ImportedQuestion, error) {
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		item, ok := parseQTIItem(1, data)
+		if !ok {
+			return nil, ErrNotQTI
+		}
+		return []ImportedQuestion{item}, nil
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil || len(archive.File) > qtiMaxFiles {
+		return nil, ErrNotQTI
+	}
+	items := []ImportedQuestion{}
+	for _, file := range archive.File {
+		if !strings.EqualFold(path.Ext(file.Name), ".xml") || strings.EqualFold(path.Base(file.Name), "imsmanifest.xml") {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, ErrNotQTI
+		}
+		content, err := io.ReadAll(io.LimitReader(reader, qtiMaxFileSize))
+		_ = reader.Close()
+		if err != nil {
+			return nil, ErrNotQTI
+		}
+		// Packages also hold tests, sections and metadata, which are skipped.
+		if item, ok := parseQTIItem(len(items)+1, content); ok {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil, ErrNotQTI
+	}
+	return items, nil
+}
+
+// parseQTIItem reports false when the XML is not an assessment item.
+func parseQTIItem(index int, data []byte) (ImportedQuestion, bool) {
+	var item qtiItem
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(&item); err != nil || item.XMLName.Local != "assessmentItem" {
+		return ImportedQuestion{}, false
+	}
+	imported := ImportedQuestion{Index: index, Title: item.Title}
+	if imported.Title == "" {
+		imported.Title = item.Identifier
+	}
+
+	body, interactions := qtiBody(item.ItemBody.Inner)
+	switch len(interactions) {
+	case 0:
+		imported.Kind = "none"
+		imported.Problem = "item has no interaction to answer"
+		return imported, true
+	case 1:
+	default:
+		imported.Kind = "composite"
+		imported.Problem = "items with more than one interaction are not supported"
+		return imported, true
+	}
+	interaction := interactions[0]
+	imported.Kind = interaction.kind
+	prompt := qtiText(body + "\n" + interaction.prompt.String())
+
+	var declaration *qtiResponseDeclaration
+	for i := range item.Responses {
+		if item.Responses[i].Identifier == interaction.response {
+			declaration = &item.Responses[i]
+		}
+	}
+	if declaration == nil {
+		imported.Problem = "interaction has no response declaration"
+		return imported, true
+	}
+
+	switch {
+	case interaction.kind == "choiceInteraction":
+		imported.Question, imported.Problem = qtiChoiceQuestion(prompt, declaration, interaction)
+	case interaction.kind == "textEntryInteraction" && (declaration.BaseType == "float" || declaration.BaseType == "integer"):
+		imported.Question, imported.Problem = qtiNumericQuestion(prompt, declaration, item.ResponseProcessing.Inner)
+	case interaction.kind == "textEntryInteraction" && declaration.BaseType == "string":
+		imported.Question, imported.Problem = qtiTextQuestion(prompt, declaration)
+	case interaction.kind == "extendedTextInteraction":
+		imported.Problem = "essay questions cannot be auto-graded"
+	default:
+		imported.Problem = interaction.kind + " is not supported"
+	}
+	if imported.Question != nil {
+		imported.Kind = imported.Question.Type
+		imported.Question.Points = qtiPoints(&item)
+	}
+	return imported, true
+}
+
+func qtiChoiceQuestion(prompt string, declaration *qtiResponseDeclaration, interaction *qtiInteraction) (*model.SaveQuestionRequest, string) {
+	correct := map[string]bool{}
+	for _, value := range declaration.Correct {
+		correct[strings.TrimSpace(value)] = true
+	}
+	for _, entry := range declaration.Mapping {
+		if entry.Value > 0 {
+			correct[entry.Key] = true
+		}
+	}
+	choices := make([]model.QuestionChoice, 0, len(interaction.choices))
+	for _, choice := range interaction.choices {
+		choices = append(choices, model.QuestionChoice{Text: qtiText(choice.text.String()), Correct: correct[choice.id]})
+	}
+	if len(correct) == 0 {
+		return nil, "choice interaction has no correct response"
+	}
+	return choiceRequest(prompt, choices, declaration.Cardinality == "multiple"), ""
+}
+
+// qtiNumericQuestion takes the tolerance from an equal with an absolute
+// tolerance in the response processing, when there is one.
+func qtiNumericQuestion(prompt string, declaration *qtiResponseDeclaration, processing []byte) (*model.SaveQuestionRequest, string) {
+	if len(declaration.Correct) == 0 {
+		return nil, "text entry interaction has no correct response"
+	}
+	answer, err := strconv.ParseFloat(strings.TrimSpace(declaration.Correct[0]), 64)
+	if err != nil {
+		return nil, "numeric correct response is not a number"
+	}
+	request := &model.SaveQuestionRequest{Type: entity.QuestionNumeric, Prompt: prompt, Answer: &answer}
+	if match := qtiTolerance.FindSubmatch(processing); match != nil {
+		if fields := strings.Fields(string(match[1])); len(fields) > 0 {
+			request.Tolerance, _ = strconv.ParseFloat(fields[0], 64)
+		}
+	}
+	return request, ""
+}
+
+// qtiTextQuestion accepts the correct response and every mapped answer with a
+// positive value. Without a mapping QTI compares exactly, so case matters.
+func qtiTextQuestion(prompt string, declaration *qtiResponseDeclaration) (*model.SaveQuestionRequest, string) {
+	request := &model.SaveQuestionRequest{Type: entity.QuestionShortText, Prompt: prompt, CaseSensitive: true}
+	answers := []string{}
+	for _, value := range declaration.Correct {
+		answers = append(answers, strings.TrimSpace(value))
+	}
+	for _, entry := range declaration.Mapping {
+		if entry.Value > 0 {
+			answers = append(answers, strings.TrimSpace(entry.Key))
+			request.CaseSensitive = request.CaseSensitive && entry.CaseSensitive
+		}
+	}
+	for _, answer := range answers {
+		pattern := regexp.QuoteMeta(answer)
+		if answer != "" && !slices.Contains(request.Patterns, pattern) {
+			request.Patterns = append(request.Patterns, pattern)
+		}
+	}
+	if len(request.Patterns) == 0 {
+		return nil, "text entry interaction has no correct response"
+	}
+	return request, ""
+}
+
+// qtiPoints reads the item's MAXSCORE, or the normal maximum of its SCORE, and
+// falls back to one point.
+func qtiPoints(item *qtiItem) int {
+	for _, outcome := range item.Outcomes {
+		value := ""
+		switch {
+		case outcome.Identifier == "MAXSCORE" && len(outcome.Default) > 0:
+			value = outcome.Default[0]
+		case outcome.Identifier == "SCORE":
+			value = outcome.NormalMaximum
+		}
+		if points, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && points >= 1 {
+			return int(math.Round(points))
+		}
+	}
+	return 1
+}
+
+// qtiBody walks an item body and returns its text outside interactions, with
+// the interactions found in it.
+func qtiBody(inner []byte) (string, []*qtiInteraction) {
+	var body strings.Builder
+	var interactions []*qtiInteraction
+	var current *qtiInteraction
+	var choice *qtiChoice
+	inPrompt := false
+
+	decoder := xml.NewDecoder(bytes.NewReader(inner))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	out := func() *strings.Builder {
+		switch {
+		case choice != nil:
+			return &choice.text
+		case current != nil && inPrompt:
+			return &current.prompt
+		case current == nil:
+			return &body
+		}
+		return nil
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			switch {
+			case current == nil && strings.HasSuffix(name, "Interaction"):
+				current = &qtiInteraction{kind: name, response: qtiAttr(t, "responseIdentifier")}
+				interactions = append(interactions, current)
+			case current != nil && name == "simpleChoice":
+				choice = &qtiChoice{id: qtiAttr(t, "identifier")}
+				current.choices = append(current.choices, choice)
+			case current != nil && name == "prompt":
+				inPrompt = true
+			case name == "img":
+				if b := out(); b != nil {
+					fmt.Fprintf(b, " [image: %s] ", qtiAttr(t, "alt"))
+				}
+			case qtiBlock(name):
+				if b := out(); b != nil {
+					b.WriteString("\n")
+				}
+			}
+		case xml.EndElement:
+			name := t.Name.Local
+			switch {
+			case current != nil && name == current.kind:
+				current = nil
+			case name == "simpleChoice":
+				choice = nil
+			case name == "prompt":
+				inPrompt = false
+			case qtiBlock(name):
+				if b := out(); b != nil {
+					b.WriteString("\n")
+				}
+			}
+		case xml.CharData:
+			if b := out(); b != nil {
+				b.Write(t)
+			}
+		}
+	}
+	return body.String(), interactions
+}
+
+func qtiBlock(name string) bool {
+	switch name {
+	case "p", "div", "br", "li", "tr", "h1", "h2", "h3", "h4", "h5", "h6", "pre", "blockquote":
+		return true
+	}
+	return false
+}
+
+func qtiAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// qtiText collapses the whitespace on each line and drops empty lines.
+func qtiText(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WriteQTI writes the questions as a QTI 2.1 content package with one item per
+// question. Questions the format cannot hold are listed in the manifest as
+// comments saying why.
+func WriteQTI(w io.Writer, questions []entity.Question) error {
+	archive := zip.NewWriter(w)
+	var resources strings.Builder
+	for i := range questions {
+		question := &questions[i]
+		identifier := "q" + question.ID.Hex()
+		if problem := QuestionExportProblem(question); problem != "" {
+			fmt.Fprintf(&resources, "<!-- skipped %s: %s -->\n", question.ID.Hex(), xmlEscape(problem))
+			continue
+		}
+		href := "items/" + identifier + ".xml"
+		file, err := archive.Create(href)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(file, qtiItemXML(identifier, question)); err != nil {
+			return err
+		}
+		fmt.Fprintf(&resources, `<resource identifier="%s" type="imsqti_item_xmlv2p1" href="%s"><file href="%s"/></resource>`+"\n",
+			identifier, href, href)
+	}
+
+	file, err := archive.Create("imsmanifest.xml")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(file, `<?xml version="1.0" encoding="UTF-8"?>
+<manifest xmlns="http://www.imsglobal.org/xsd/imscp_v1p1" identifier="MANIFEST">
+<metadata><schema>QTIv2.1 Package</schema><schemaversion>1.0.0</schemaversion></metadata>
+<organizations/>
+<resources>
+%s</resources>
+</manifest>
+`, resources.String())
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func qtiItemXML(identifier string, question *entity.Question) string {
+	var declaration, body, processing strings.Builder
+	prompt := qtiParagraphs(question.Prompt)
+	switch question.Type {
+	case entity.QuestionMultipleChoice, entity.QuestionMultipleAnswer:
+		cardinality, maxChoices := "single", 1
+		if question.Type == entity.QuestionMultipleAnswer {
+			cardinality, maxChoices = "multiple", 0
+		}
+		correct := 0
+		fmt.Fprintf(&declaration, `<responseDeclaration identifier="RESPONSE" cardinality="%s" baseType="identifier"><correctResponse>`, cardinality)
+		for _, choice := range question.Choices {
+			if choice.Correct {
+				correct++
+				fmt.Fprintf(&declaration, "<value>%s</value>", choice.ID)
+			}
+		}
+		declaration.WriteString("</correctResponse>")
+		if question.Type == entity.QuestionMultipleAnswer {
+			// Each correct choice earns its share and each wrong one takes a
+			// share away, as the quiz engine grades them.
+			share := strconv.FormatFloat(1/float64(max(correct, 1)), 'f', -1, 64)
+			declaration.WriteString(`<mapping lowerBound="0" upperBound="1" defaultValue="0">`)
+			for _, choice := range question.Choices {
+				value := "-" + share
+				if choice.Correct {
+					value = share
+				}
+				fmt.Fprintf(&declaration, `<mapEntry mapKey="%s" mappedValue="%s"/>`, choice.ID, value)
+			}
+			declaration.WriteString("</mapping>")
+		}
+		declaration.WriteString("</responseDeclaration>")
+
+		body.WriteString(prompt)
+		fmt.Fprintf(&body, `<choiceInteraction responseIdentifier="RESPONSE" shuffle="false" maxChoices="%d">`, maxChoices)
+		for _, choice := range question.Choices {
+			fmt.Fprintf(&body, `<simpleChoice identifier="%s">%s</simpleChoice>`, choice.ID, xmlEscape(choice.Text))
+		}
+		body.WriteString("</choiceInteraction>")
+
+		template := qtiMatch
+		if question.Type == entity.QuestionMultipleAnswer {
+			template = qtiMapResponse
+		}
+		fmt.Fprintf(&processing, `<responseProcessing template="%s"/>`, template)
+	case entity.QuestionNumeric:
+		answer := strconv.FormatFloat(question.Answer, 'f', -1, 64)
+		tolerance := strconv.FormatFloat(question.Tolerance, 'f', -1, 64)
+		fmt.Fprintf(&declaration, `<responseDeclaration identifier="RESPONSE" cardinality="single" baseType="float"><correctResponse><value>%s</value></correctResponse></responseDeclaration>`, answer)
+		body.WriteString(prompt)
+		body.WriteString(`<p><textEntryInteraction responseIdentifier="RESPONSE"/></p>`)
+		fmt.Fprintf(&processing, `<responseProcessing><responseCondition><responseIf>`+
+			`<equal toleranceMode="absolute" tolerance="%s %s"><variable identifier="RESPONSE"/><correct identifier="RESPONSE"/></equal>`+
+			`<setOutcomeValue identifier="SCORE"><baseValue baseType="float">1</baseValue></setOutcomeValue>`+
+			`</responseIf></responseCondition></responseProcessing>`, tolerance, tolerance)
+	case entity.QuestionShortText:
+		declaration.WriteString(`<responseDeclaration identifier="RESPONSE" cardinality="single" baseType="string">`)
+		var mapping strings.Builder
+		for i, pattern := range question.Patterns {
+			text, _ := literalPattern(pattern)
+			if i == 0 {
+				fmt.Fprintf(&declaration, "<correctResponse><value>%s</value></correctResponse>", xmlEscape(text))
+			}
+			fmt.Fprintf(&mapping, `<mapEntry mapKey="%s" mappedValue="1" caseSensitive="%t"/>`, xmlEscape(text), question.CaseSensitive)
+		}
+		fmt.Fprintf(&declaration, `<mapping lowerBound="0" upperBound="1" defaultValue="0">%s</mapping></responseDeclaration>`, mapping.String())
+		body.WriteString(prompt)
+		body.WriteString(`<p><textEntryInteraction responseIdentifier="RESPONSE"/></p>`)
+		fmt.Fprintf(&processing, `<responseProcessing template="%s"/>`, qtiMapResponse)
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<assessmentItem xmlns="%s" identifier="%s" title="%s" adaptive="false" timeDependent="false">
+%s
+<outcomeDeclaration identifier="SCORE" cardinality="single" baseType="float"><defaultValue><value>0</value></defaultValue></outcomeDeclaration>
+<outcomeDeclaration identifier="MAXSCORE" cardinality="single" baseType="float"><defaultValue><value>%d</value></defaultValue></outcomeDeclaration>
+<itemBody>%s</itemBody>
+%s
+</assessmentItem>
+`, qtiNamespace, identifier, xmlEscape(qtiTitle(question.Prompt)), declaration.String(), question.Points, body.String(), processing.String())
+}
+
+func qtiParagraphs(text string) string {
+	var out strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(&out, "<p>%s</p>", xmlEscape(line))
+		}
+	}
+	return out.String()
+}
+
+// qtiTitle shortens the prompt's first line to a title.
+func qtiTitle(prompt string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	if runes := []rune(title); len(runes) > 80 {
+		title = string(runes[:77]) + "..."
+	}
+	return title
+}
+
+func xmlEscape(text string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
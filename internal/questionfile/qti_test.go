@@ -0,0 +1,17 @@
+package questionfile
+
+import "testing"
+
+func TestParseQTIRejectsOtherFiles(t *testing.T) {
+	tests := []string{
+		"",
+		"not xml",
+		`<?xml version="1.0"?><assessmentTest identifier="t"/>`,
+		"PK\x03\x04 broken zip",
+	}
+	for _, data := range tests {
+		if _, err := ParseQTI([]byte(data)); err != ErrNotQTI {
+			t.Errorf("ParseQTI(%q) error = %v, want ErrNotQTI", data, err)
+		}
+	}
+}
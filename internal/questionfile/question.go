@@ -0,0 +1,66 @@
+package questionfile
+
+import (
+	"regexp/syntax"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+)
+
+// ImportedQuestion is one item read from a GIFT or QTI file. Question is nil
+// when the item is of a kind the quiz engine has no type for; Kind names that
+// kind as the format does and Problem says why it was left out.
+type ImportedQuestion struct {
+	Index    int
+	Title    string
+	Kind     string
+	Question *model.SaveQuestionRequest
+	Problem  string
+}
+
+// QuestionExportProblem says why a question cannot be written to GIFT or QTI
+// without changing how it is graded, or returns "" when it can. Neither format
+// has parameters or regular expression answers.
+func QuestionExportProblem(question *entity.Question) string {
+	if len(question.Parameters) > 0 {
+		return "parameterized questions cannot be exported"
+	}
+	if question.Type == entity.QuestionShortText {
+		for _, pattern := range question.Patterns {
+			if _, ok := literalPattern(pattern); !ok {
+				return "short text answers matched by regular expressions cannot be exported"
+			}
+		}
+	}
+	return ""
+}
+
+// literalPattern returns the text a short text pattern matches when it matches
+// exactly one string, as the patterns made from imported answers do.
+func literalPattern(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil || re.Op != syntax.OpLiteral || re.Flags&syntax.FoldCase != 0 {
+		return "", false
+	}
+	return string(re.Rune), true
+}
+
+// choiceRequest builds a choice question, picking multiple answer when more
+// than one choice is correct.
+func choiceRequest(prompt string, choices []model.QuestionChoice, multiple bool) *model.SaveQuestionRequest {
+	correct := 0
+	for _, choice := range choices {
+		if choice.Correct {
+			correct++
+		}
+	}
+	request := &model.SaveQuestionRequest{
+		Type:    entity.QuestionMultipleChoice,
+		Prompt:  prompt,
+		Choices: choices,
+	}
+	if multiple || correct > 1 {
+		request.Type = entity.QuestionMultipleAnswer
+	}
+	return request
+}
@@ -0,0 +1,194 @@
+package questionfile
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// exportable are questions both formats hold without changing their grading.
+func exportable() []entity.Question {
+	return []entity.Question{
+		{
+			Type:   entity.QuestionMultipleChoice,
+			Prompt: "Which of these is a {prime}?\nPick one.",
+			Points: 2,
+			Choices: []entity.QuestionChoice{
+				{ID: "a", Text: "4"},
+				{ID: "b", Text: "7 = 7", Correct: true},
+				{ID: "c", Text: "9 ~ 10 # or <so>"},
+			},
+		},
+		{
+			Type:   entity.QuestionMultipleAnswer,
+			Prompt: `Which are even? (a \ b)`,
+			Points: 3,
+			Choices: []entity.QuestionChoice{
+				{ID: "a", Text: "2", Correct: true},
+				{ID: "b", Text: "3"},
+				{ID: "c", Text: "4", Correct: true},
+				{ID: "d", Text: "5"},
+				{ID: "e", Text: "6", Correct: true},
+			},
+		},
+		{
+			Type:   entity.QuestionMultipleAnswer,
+			Prompt: "Only one right, but graded per choice",
+			Points: 1,
+			Choices: []entity.QuestionChoice{
+				{ID: "a", Text: "yes", Correct: true},
+				{ID: "b", Text: "no"},
+			},
+		},
+		{
+			Type:      entity.QuestionNumeric,
+			Prompt:    "What is g in m/s^2?",
+			Points:    1,
+			Answer:    9.81,
+			Tolerance: 0.05,
+		},
+		{
+			Type:   entity.QuestionNumeric,
+			Prompt: "What is -1 + 1?",
+			Points: 1,
+		},
+		{
+			Type:     entity.QuestionShortText,
+			Prompt:   "Name the capital of France.",
+			Points:   1,
+			Patterns: []string{regexp.QuoteMeta("Paris"), regexp.QuoteMeta("Paris, France.")},
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	questions := exportable()
+	for i := range questions {
+		questions[i].ID = primitive.NewObjectID()
+	}
+	formats := []struct {
+		name   string
+		write  func(*bytes.Buffer, []entity.Question) error
+		parse  func([]byte) ([]ImportedQuestion, error)
+		points bool
+	}{
+		{
+			name:  "gift",
+			write: func(b *bytes.Buffer, q []entity.Question) error { return WriteGIFT(b, q) },
+			parse: func(data []byte) ([]ImportedQuestion, error) { return ParseGIFT(data), nil },
+		},
+		{
+			name:   "qti",
+			write:  func(b *bytes.Buffer, q []entity.Question) error { return WriteQTI(b, q) },
+			parse:  ParseQTI,
+			points: true,
+		},
+	}
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := format.write(&buffer, questions); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			items, err := format.parse(buffer.Bytes())
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if len(items) != len(questions) {
+				t.Fatalf("read %d items, want %d", len(items), len(questions))
+			}
+			for i, item := range items {
+				if item.Question == nil {
+					t.Errorf("item %d: %s", i+1, item.Problem)
+					continue
+				}
+				want := request(&questions[i])
+				if !format.points {
+					want.Points = 0
+				}
+				if problem := compareRequest(item.Question, want); problem != "" {
+					t.Errorf("item %d: %s", i+1, problem)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteSkipsQuestionsFormatsCannotHold(t *testing.T) {
+	questions := []entity.Question{
+		{ID: primitive.NewObjectID(), Type: entity.QuestionNumeric, Prompt: "{a} + 1", Formula: "a + 1",
+			Parameters: []entity.QuestionParameter{{Name: "a", Min: 1, Max: 9, Step: 1}}},
+		{ID: primitive.NewObjectID(), Type: entity.QuestionShortText, Prompt: "Any colour", Patterns: []string{"red|blue"}},
+		{ID: primitive.NewObjectID(), Type: entity.QuestionShortText, Prompt: "Exact case", Patterns: []string{"(?i)Go"}},
+	}
+	for i := range questions {
+		if QuestionExportProblem(&questions[i]) == "" {
+			t.Errorf("question %d has no export problem", i+1)
+		}
+	}
+
+	var gift bytes.Buffer
+	if err := WriteGIFT(&gift, questions); err != nil {
+		t.Fatal(err)
+	}
+	if items := ParseGIFT(gift.Bytes()); len(items) != 0 {
+		t.Errorf("GIFT kept %d skipped questions", len(items))
+	}
+	if got := strings.Count(gift.String(), "// skipped "); got != len(questions) {
+		t.Errorf("GIFT has %d skip comments, want %d", got, len(questions))
+	}
+
+	var qti bytes.Buffer
+	if err := WriteQTI(&qti, questions); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseQTI(qti.Bytes()); err != ErrNotQTI {
+		t.Errorf("ParseQTI of a package with no items: error = %v, want ErrNotQTI", err)
+	}
+}
+
+// request is the import a question's export should read back as.
+func request(question *entity.Question) *model.SaveQuestionRequest {
+	want := &model.SaveQuestionRequest{
+		Type:          question.Type,
+		Prompt:        question.Prompt,
+		Points:        question.Points,
+		Patterns:      question.Patterns,
+		CaseSensitive: question.CaseSensitive,
+	}
+	for _, choice := range question.Choices {
+		want.Choices = append(want.Choices, model.QuestionChoice{Text: choice.Text, Correct: choice.Correct})
+	}
+	if question.Type == entity.QuestionNumeric {
+		want.Answer, want.Tolerance = &question.Answer, question.Tolerance
+	}
+	return want
+}
+
+func compareRequest(got, want *model.SaveQuestionRequest) string {
+	switch {
+	case got.Type != want.Type:
+		return "Type = " + got.Type + ", want " + want.Type
+	case got.Prompt != want.Prompt:
+		return "Prompt = " + got.Prompt + ", want " + want.Prompt
+	case got.Points != want.Points:
+		return "Points differ"
+	case !slices.Equal(got.Choices, want.Choices):
+		return "Choices differ"
+	case (got.Answer == nil) != (want.Answer == nil) || got.Answer != nil && *got.Answer != *want.Answer:
+		return "Answer differs"
+	case got.Tolerance != want.Tolerance:
+		return "Tolerance differs"
+	case !slices.Equal(got.Patterns, want.Patterns):
+		return "Patterns differ"
+	case got.CaseSensitive != want.CaseSensitive:
+		return "CaseSensitive differs"
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AssignmentRepository struct {
+	DB *mongo.Client
+}
+
+func NewAssignmentRepository(db *mongo.Client) *AssignmentRepository {
+	return &AssignmentRepository{
+		DB: db,
+	}
+}
+
+func (r *AssignmentRepository) Create(ctx context.Context, assignment *entity.Assignment) error {
+	collection := r.DB.Database("digital-voter").Collection("assignments")
+	assignment.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, assignment)
+	if err != nil {
+		return err
+	}
+	assignment.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *AssignmentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.Assignment, error) {
+	assignment := &entity.Assignment{}
+	collection := r.DB.Database("digital-voter").Collection("assignments")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(assignment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func (r *AssignmentRepository) FindLegacy(ctx context.Context) (*entity.Assignment, error) {
+	assignment := &entity.Assignment{}
+	collection := r.DB.Database("digital-voter").Collection("assignments")
+	err := collection.FindOne(ctx, bson.M{"legacy": true}).Decode(assignment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// FindAll lists assignments, newest first. A nil courseIDs lists every
+// assignment; otherwise only those of the given courses and those outside any
+// course are returned.
+func (r *AssignmentRepository) FindAll(ctx context.Context, courseIDs []primitive.ObjectID, page, limit int) ([]entity.Assignment, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("assignments")
+	filter := bson.M{}
+	if courseIDs != nil {
+		filter["$or"] = bson.A{bson.M{"course_id": bson.M{"$in": courseIDs}}, bson.M{"course_id": nil}}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	assignments := []entity.Assignment{}
+	if err = cursor.All(ctx, &assignments); err != nil {
+		return nil, 0, err
+	}
+	return assignments, total, nil
+}
+
+// FindByCourse returns the assignments of a course ordered by position within
+// their module.
+func (r *AssignmentRepository) FindByCourse(ctx context.Context, courseID primitive.ObjectID) ([]entity.Assignment, error) {
+	collection := r.DB.Database("digital-voter").Collection("assignments")
+	findOptions := options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"course_id": courseID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	assignments := []entity.Assignment{}
+	if err = cursor.All(ctx, &assignments); err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func (r *AssignmentRepository) CountByModule(ctx context.Context, moduleID primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("assignments")
+	return collection.CountDocuments(ctx, bson.M{"module_id": moduleID})
+}
+
+func (r *AssignmentRepository) Update(ctx context.Context, assignment *entity.Assignment) error {
+	collection := r.DB.Database("digital-voter").Collection("assignments")
+	now := util.NowInWIB()
+	assignment.UpdatedAt = &now
+	update := bson.M{
+		"$set": bson.M{
+			"title":        assignment.Title,
+			"description":  assignment.Description,
+			"course_id":    assignment.CourseID,
+			"module_id":    assignment.ModuleID,
+			"position":     assignment.Position,
+			"max_score":    assignment.MaxScore,
+			"opens_at":     assignment.OpensAt,
+			"due_at":       assignment.DueAt,
+			"closes_at":    assignment.ClosesAt,
+			"late_policy":  assignment.LatePolicy,
+			"max_attempts": assignment.MaxAttempts,
+			"score_policy": assignment.ScorePolicy,
+			"regrade_days": assignment.RegradeDays,
+			"group_mode":   assignment.GroupMode,
+			"category":     assignment.Category,
+			"updated_at":   assignment.UpdatedAt,
+		},
+	}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": assignment.ID}, update)
+	return err
+}
+
+// SetGrading records how the assignment is graded.
+func (r *AssignmentRepository) SetGrading(ctx context.Context, assignmentID primitive.ObjectID, grading string) error {
+	collection := r.DB.Database("digital-voter").Collection("assignments")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": assignmentID}, bson.M{"$set": bson.M{"grading": grading}})
+	return err
+}
+
+// SetRubric has the assignment graded with the rubric.
+func (r *AssignmentRepository) SetRubric(ctx context.Context, assignmentID, rubricID primitive.ObjectID) error {
+	collection := r.DB.Database("digital-voter").Collection("assignments")
+	update := bson.M{"$set": bson.M{"rubric_id": rubricID, "grading": entity.GradingRubric}}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": assignmentID}, update)
+	return err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ClientKeyRepository struct {
+	DB *mongo.Client
+}
+
+func NewClientKeyRepository(db *mongo.Client) *ClientKeyRepository {
+	return &ClientKeyRepository{
+		DB: db,
+	}
+}
+
+func (r *ClientKeyRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("client_keys")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "assignment_id", Value: 1}},
+	})
+	return err
+}
+
+func (r *ClientKeyRepository) Create(ctx context.Context, key *entity.ClientKey) error {
+	collection := r.DB.Database("digital-voter").Collection("client_keys")
+	key.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, key)
+	if err != nil {
+		return err
+	}
+	key.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ClientKeyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.ClientKey, error) {
+	key := &entity.ClientKey{}
+	collection := r.DB.Database("digital-voter").Collection("client_keys")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// FindByAssignment lists an assignment's keys, revoked ones included, oldest
+// first.
+func (r *ClientKeyRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID) ([]entity.ClientKey, error) {
+	collection := r.DB.Database("digital-voter").Collection("client_keys")
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"assignment_id": assignmentID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	keys := []entity.ClientKey{}
+	if err = cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke stops a key from being accepted and reports whether it was active.
+func (r *ClientKeyRepository) Revoke(ctx context.Context, assignmentID, id primitive.ObjectID) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("client_keys")
+	filter := bson.M{"_id": id, "assignment_id": assignmentID, "revoked_at": nil}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": util.NowInWIB()}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CodeGraderRepository struct {
+	DB *mongo.Client
+}
+
+func NewCodeGraderRepository(db *mongo.Client) *CodeGraderRepository {
+	return &CodeGraderRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes allows one code grader per assignment.
+func (r *CodeGraderRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("code_graders")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "assignment_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *CodeGraderRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID) (*entity.CodeGrader, error) {
+	grader := &entity.CodeGrader{}
+	collection := r.DB.Database("digital-voter").Collection("code_graders")
+	err := collection.FindOne(ctx, bson.M{"assignment_id": assignmentID}).Decode(grader)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return grader, nil
+}
+
+// Save creates the assignment's code grader or replaces its settings.
+func (r *CodeGraderRepository) Save(ctx context.Context, grader *entity.CodeGrader) error {
+	collection := r.DB.Database("digital-voter").Collection("code_graders")
+	now := util.NowInWIB()
+	grader.UpdatedAt = &now
+	update := bson.M{
+		"$set": bson.M{
+			"runner":       grader.Runner,
+			"language":     grader.Language,
+			"filename":     grader.Filename,
+			"build":        grader.Build,
+			"run":          grader.Run,
+			"time_limit":   grader.TimeLimit,
+			"memory_limit": grader.MemoryLimit,
+			"compare":      grader.Compare,
+			"tests":        grader.Tests,
+			"updated_at":   grader.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"created_by": grader.CreatedBy,
+			"created_at": now,
+		},
+	}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, bson.M{"assignment_id": grader.AssignmentID}, update, updateOptions).Decode(grader)
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CodeSubmissionRepository struct {
+	DB *mongo.Client
+}
+
+func NewCodeSubmissionRepository(db *mongo.Client) *CodeSubmissionRepository {
+	return &CodeSubmissionRepository{
+		DB: db,
+	}
+}
+
+func (r *CodeSubmissionRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "assignment_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "submitted_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "submitted_at", Value: 1}}},
+	})
+	return err
+}
+
+func (r *CodeSubmissionRepository) Create(ctx context.Context, submission *entity.CodeSubmission) error {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	result, err := collection.InsertOne(ctx, submission)
+	if err != nil {
+		return err
+	}
+	submission.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *CodeSubmissionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.CodeSubmission, error) {
+	submission := &entity.CodeSubmission{}
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(submission)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return submission, nil
+}
+
+// CountPending counts a student's submissions to an assignment that are still
+// waiting to be graded.
+func (r *CodeSubmissionRepository) CountPending(ctx context.Context, assignmentID, userID primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	filter := bson.M{
+		"assignment_id": assignmentID,
+		"user_id":       userID,
+		"status":        bson.M{"$in": []string{entity.CodeSubmissionQueued, entity.CodeSubmissionRunning}},
+	}
+	return collection.CountDocuments(ctx, filter)
+}
+
+// FindByAssignment lists submissions newest first without their source and
+// results. A non-nil userIDs limits it to those students.
+func (r *CodeSubmissionRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID, userIDs []primitive.ObjectID, page, limit int) ([]entity.CodeSubmission, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	filter := bson.M{"assignment_id": assignmentID}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetProjection(bson.M{"source": 0, "results": 0, "build_output": 0}).
+		SetSort(bson.D{{Key: "submitted_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	submissions := []entity.CodeSubmission{}
+	if err = cursor.All(ctx, &submissions); err != nil {
+		return nil, 0, err
+	}
+	return submissions, total, nil
+}
+
+// Claim takes the oldest queued submission, or a running one whose lock ran
+// out, and locks it until lockedUntil. It returns nil when there is nothing to
+// grade. Submissions already tried maxTries times are left for FailStale.
+func (r *CodeSubmissionRepository) Claim(ctx context.Context, now, lockedUntil time.Time, maxTries int) (*entity.CodeSubmission, error) {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	filter := bson.M{
+		"tries": bson.M{"$lt": maxTries},
+		"$or": []bson.M{
+			{"status": entity.CodeSubmissionQueued},
+			{"status": entity.CodeSubmissionRunning, "locked_until": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": entity.CodeSubmissionRunning, "started_at": now, "locked_until": lockedUntil},
+		"$inc": bson.M{"tries": 1},
+	}
+	updateOptions := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "submitted_at", Value: 1}}).
+		SetReturnDocument(options.After)
+	submission := &entity.CodeSubmission{}
+	err := collection.FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(submission)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return submission, nil
+}
+
+// Finish stores the outcome of a claimed submission and releases it. It
+// reports false when the lock had run out and another worker claimed it.
+func (r *CodeSubmissionRepository) Finish(ctx context.Context, submission *entity.CodeSubmission) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	filter := bson.M{
+		"_id":          submission.ID,
+		"status":       entity.CodeSubmissionRunning,
+		"tries":        submission.Tries,
+		"locked_until": submission.LockedUntil,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       submission.Status,
+			"build_output": submission.BuildOutput,
+			"results":      submission.Results,
+			"earned":       submission.Earned,
+			"possible":     submission.Possible,
+			"score":        submission.Score,
+			"error":        submission.Error,
+			"finished_at":  submission.FinishedAt,
+		},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Release puts a claimed submission back in the queue, for when grading it
+// failed for reasons that had nothing to do with the program.
+func (r *CodeSubmissionRepository) Release(ctx context.Context, submission *entity.CodeSubmission, reason string) error {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	filter := bson.M{"_id": submission.ID, "status": entity.CodeSubmissionRunning, "tries": submission.Tries}
+	update := bson.M{
+		"$set":   bson.M{"status": entity.CodeSubmissionQueued, "error": reason},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	_, err := collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// FailStale marks submissions that used up their tries without being graded as
+// failed, and returns how many there were.
+func (r *CodeSubmissionRepository) FailStale(ctx context.Context, now time.Time, maxTries int) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	filter := bson.M{
+		"tries": bson.M{"$gte": maxTries},
+		"$or": []bson.M{
+			{"status": entity.CodeSubmissionQueued},
+			{"status": entity.CodeSubmissionRunning, "locked_until": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set":   bson.M{"status": entity.CodeSubmissionFailed, "finished_at": now},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// SetAttempt links a graded submission to the submission attempt its score was
+// recorded as.
+func (r *CodeSubmissionRepository) SetAttempt(ctx context.Context, id primitive.ObjectID, attempt int) error {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"attempt": attempt}})
+	return err
+}
+
+// FindLatestSources returns each student's latest submission to the assignment
+// with its source but without its results.
+func (r *CodeSubmissionRepository) FindLatestSources(ctx context.Context, assignmentID primitive.ObjectID) ([]entity.CodeSubmission, error) {
+	collection := r.DB.Database("digital-voter").Collection("code_submissions")
+	findOptions := options.Find().
+		SetProjection(bson.M{"results": 0, "build_output": 0}).
+		SetSort(bson.D{{Key: "user_id", Value: 1}, {Key: "submitted_at", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.M{"assignment_id": assignmentID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	submissions := []entity.CodeSubmission{}
+	for cursor.Next(ctx) {
+		submission := entity.CodeSubmission{}
+		if err = cursor.Decode(&submission); err != nil {
+			return nil, err
+		}
+		if n := len(submissions); n > 0 && submissions[n-1].UserID == submission.UserID {
+			continue
+		}
+		submissions = append(submissions, submission)
+	}
+	return submissions, cursor.Err()
+}
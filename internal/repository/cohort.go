@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CohortRepository struct {
+	DB *mongo.Client
+}
+
+func NewCohortRepository(db *mongo.Client) *CohortRepository {
+	return &CohortRepository{
+		DB: db,
+	}
+}
+
+func (r *CohortRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("cohorts")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *CohortRepository) Create(ctx context.Context, cohort *entity.Cohort) error {
+	collection := r.DB.Database("digital-voter").Collection("cohorts")
+	cohort.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, cohort)
+	if err != nil {
+		return err
+	}
+	cohort.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *CohortRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.Cohort, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *CohortRepository) FindByName(ctx context.Context, name string) (*entity.Cohort, error) {
+	return r.findOne(ctx, bson.M{"name": name})
+}
+
+func (r *CohortRepository) findOne(ctx context.Context, filter bson.M) (*entity.Cohort, error) {
+	cohort := &entity.Cohort{}
+	collection := r.DB.Database("digital-voter").Collection("cohorts")
+	err := collection.FindOne(ctx, filter).Decode(cohort)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return cohort, nil
+}
+
+// AnyInCourse reports whether one of the given cohorts belongs to the course.
+func (r *CohortRepository) AnyInCourse(ctx context.Context, ids []primitive.ObjectID, courseID primitive.ObjectID) (bool, error) {
+	if len(ids) == 0 {
+		return false, nil
+	}
+	collection := r.DB.Database("digital-voter").Collection("cohorts")
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}, "course_id": courseID}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// FindCourseIDs returns the courses the given cohorts belong to.
+func (r *CohortRepository) FindCourseIDs(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	courseIDs := []primitive.ObjectID{}
+	if len(ids) == 0 {
+		return courseIDs, nil
+	}
+	collection := r.DB.Database("digital-voter").Collection("cohorts")
+	values, err := collection.Distinct(ctx, "course_id", bson.M{"_id": bson.M{"$in": ids}, "course_id": bson.M{"$ne": nil}})
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			courseIDs = append(courseIDs, id)
+		}
+	}
+	return courseIDs, nil
+}
+
+// FindAll lists cohorts by name. A nil ids lists every cohort; otherwise only
+// the given cohorts are returned.
+func (r *CohortRepository) FindAll(ctx context.Context, ids []primitive.ObjectID, page, limit int) ([]entity.Cohort, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("cohorts")
+	filter := bson.M{}
+	if ids != nil {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	cohorts := []entity.Cohort{}
+	if err = cursor.All(ctx, &cohorts); err != nil {
+		return nil, 0, err
+	}
+	return cohorts, total, nil
+}
+
+func (r *CohortRepository) Update(ctx context.Context, cohort *entity.Cohort) error {
+	collection := r.DB.Database("digital-voter").Collection("cohorts")
+	now := util.NowInWIB()
+	cohort.UpdatedAt = &now
+	set := bson.M{
+		"name":       cohort.Name,
+		"updated_at": cohort.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+	if cohort.CourseID != nil {
+		set["course_id"] = cohort.CourseID
+	} else {
+		update["$unset"] = bson.M{"course_id": ""}
+	}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": cohort.ID}, update)
+	return err
+}
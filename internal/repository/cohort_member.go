@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CohortMemberRepository struct {
+	DB *mongo.Client
+}
+
+func NewCohortMemberRepository(db *mongo.Client) *CohortMemberRepository {
+	return &CohortMemberRepository{
+		DB: db,
+	}
+}
+
+func (r *CohortMemberRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("cohort_members")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "cohort_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// a student belongs to at most one cohort
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"role": entity.CohortRoleStudent}),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "role", Value: 1}},
+		},
+	})
+	return err
+}
+
+// Add inserts the membership. It fails with a duplicate key error when the user
+// is already in the cohort or, for students, in any other cohort.
+func (r *CohortMemberRepository) Add(ctx context.Context, member *entity.CohortMember) error {
+	collection := r.DB.Database("digital-voter").Collection("cohort_members")
+	member.AddedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, member)
+	if err != nil {
+		return err
+	}
+	member.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *CohortMemberRepository) Find(ctx context.Context, cohortID, userID primitive.ObjectID) (*entity.CohortMember, error) {
+	member := &entity.CohortMember{}
+	collection := r.DB.Database("digital-voter").Collection("cohort_members")
+	err := collection.FindOne(ctx, bson.M{"cohort_id": cohortID, "user_id": userID}).Decode(member)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func (r *CohortMemberRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]entity.CohortMember, error) {
+	return r.find(ctx, bson.M{"user_id": userID})
+}
+
+// FindStudentCohortID returns the cohort the student belongs to, or nil.
+func (r *CohortMemberRepository) FindStudentCohortID(ctx context.Context, userID primitive.ObjectID) (*primitive.ObjectID, error) {
+	members, err := r.find(ctx, bson.M{"user_id": userID, "role": entity.CohortRoleStudent})
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	return &members[0].CohortID, nil
+}
+
+// FindByCohort lists the members of a cohort, optionally only those with the
+// given role, in the order they were added.
+func (r *CohortMemberRepository) FindByCohort(ctx context.Context, cohortID primitive.ObjectID, role string, page, limit int) ([]entity.CohortMember, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("cohort_members")
+	filter := bson.M{"cohort_id": cohortID}
+	if role != "" {
+		filter["role"] = role
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "added_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	members := []entity.CohortMember{}
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, 0, err
+	}
+	return members, total, nil
+}
+
+func (r *CohortMemberRepository) CountByCohort(ctx context.Context, cohortID primitive.ObjectID, role string) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("cohort_members")
+	return collection.CountDocuments(ctx, bson.M{"cohort_id": cohortID, "role": role})
+}
+
+// FindStudentIDs returns the students of the given cohorts. The result is never
+// nil, so it can be passed on as a filter that matches nobody.
+func (r *CohortMemberRepository) FindStudentIDs(ctx context.Context, cohortIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	members, err := r.find(ctx, bson.M{"cohort_id": bson.M{"$in": cohortIDs}, "role": entity.CohortRoleStudent})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+	return ids, nil
+}
+
+// FindTaughtCohortIDs returns the cohorts in which the user is an instructor or TA.
+func (r *CohortMemberRepository) FindTaughtCohortIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	members, err := r.find(ctx, bson.M{
+		"user_id": userID,
+		"role":    bson.M{"$in": bson.A{entity.CohortRoleInstructor, entity.CohortRoleTA}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.CohortID)
+	}
+	return ids, nil
+}
+
+// FindStaffIDs returns the members of the cohort with any of the given staff
+// roles, e.g. to notify whoever teaches a student.
+func (r *CohortMemberRepository) FindStaffIDs(ctx context.Context, cohortID primitive.ObjectID, roles ...string) ([]primitive.ObjectID, error) {
+	members, err := r.find(ctx, bson.M{"cohort_id": cohortID, "role": bson.M{"$in": roles}})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+	return ids, nil
+}
+
+// IsStudentIn reports whether the user is a student of any of the cohorts.
+func (r *CohortMemberRepository) IsStudentIn(ctx context.Context, userID primitive.ObjectID, cohortIDs []primitive.ObjectID) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("cohort_members")
+	count, err := collection.CountDocuments(ctx, bson.M{
+		"user_id":   userID,
+		"role":      entity.CohortRoleStudent,
+		"cohort_id": bson.M{"$in": cohortIDs},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *CohortMemberRepository) Remove(ctx context.Context, cohortID, userID primitive.ObjectID) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("cohort_members")
+	result, err := collection.DeleteOne(ctx, bson.M{"cohort_id": cohortID, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// MoveStudents moves students from one cohort to another in a single update.
+// A nil userIDs moves every student of the cohort.
+func (r *CohortMemberRepository) MoveStudents(ctx context.Context, fromID, toID primitive.ObjectID, userIDs []primitive.ObjectID, movedBy primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("cohort_members")
+	filter := bson.M{"cohort_id": fromID, "role": entity.CohortRoleStudent}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"cohort_id": toID,
+			"added_by":  movedBy,
+			"added_at":  util.NowInWIB(),
+		},
+	}
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *CohortMemberRepository) find(ctx context.Context, filter bson.M) ([]entity.CohortMember, error) {
+	collection := r.DB.Database("digital-voter").Collection("cohort_members")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	members := []entity.CohortMember{}
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CourseRepository struct {
+	DB *mongo.Client
+}
+
+func NewCourseRepository(db *mongo.Client) *CourseRepository {
+	return &CourseRepository{
+		DB: db,
+	}
+}
+
+func (r *CourseRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("courses")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "join_code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	return err
+}
+
+func (r *CourseRepository) Create(ctx context.Context, course *entity.Course) error {
+	collection := r.DB.Database("digital-voter").Collection("courses")
+	course.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, course)
+	if err != nil {
+		return err
+	}
+	course.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *CourseRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.Course, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *CourseRepository) FindByJoinCode(ctx context.Context, joinCode string) (*entity.Course, error) {
+	return r.findOne(ctx, bson.M{"join_code": joinCode})
+}
+
+func (r *CourseRepository) findOne(ctx context.Context, filter bson.M) (*entity.Course, error) {
+	course := &entity.Course{}
+	collection := r.DB.Database("digital-voter").Collection("courses")
+	err := collection.FindOne(ctx, filter).Decode(course)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return course, nil
+}
+
+// FindIDsByCreator returns the ids of the courses the user created.
+func (r *CourseRepository) FindIDsByCreator(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	collection := r.DB.Database("digital-voter").Collection("courses")
+	cursor, err := collection.Find(ctx, bson.M{"created_by": userID}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	courses := []entity.Course{}
+	if err = cursor.All(ctx, &courses); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(courses))
+	for _, course := range courses {
+		ids = append(ids, course.ID)
+	}
+	return ids, nil
+}
+
+// FindAll lists courses by code. A nil ids lists every course; otherwise only
+// the given courses are returned.
+func (r *CourseRepository) FindAll(ctx context.Context, ids []primitive.ObjectID, page, limit int) ([]entity.Course, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("courses")
+	filter := bson.M{}
+	if ids != nil {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "code", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	courses := []entity.Course{}
+	if err = cursor.All(ctx, &courses); err != nil {
+		return nil, 0, err
+	}
+	return courses, total, nil
+}
+
+func (r *CourseRepository) Update(ctx context.Context, course *entity.Course) error {
+	collection := r.DB.Database("digital-voter").Collection("courses")
+	now := util.NowInWIB()
+	course.UpdatedAt = &now
+	update := bson.M{
+		"$set": bson.M{
+			"code":         course.Code,
+			"title":        course.Title,
+			"description":  course.Description,
+			"join_code":    course.JoinCode,
+			"join_enabled": course.JoinEnabled,
+			"timezone":     course.Timezone,
+			"updated_at":   course.UpdatedAt,
+		},
+	}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": course.ID}, update)
+	return err
+}
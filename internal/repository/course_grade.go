@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CourseGradeRepository struct {
+	DB *mongo.Client
+}
+
+func NewCourseGradeRepository(db *mongo.Client) *CourseGradeRepository {
+	return &CourseGradeRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes allows one grade per student and course.
+func (r *CourseGradeRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("course_grades")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "course_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *CourseGradeRepository) FindByCourseAndUser(ctx context.Context, courseID, userID primitive.ObjectID) (*entity.CourseGrade, error) {
+	grade := &entity.CourseGrade{}
+	collection := r.DB.Database("digital-voter").Collection("course_grades")
+	err := collection.FindOne(ctx, bson.M{"course_id": courseID, "user_id": userID}).Decode(grade)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return grade, nil
+}
+
+func (r *CourseGradeRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]entity.CourseGrade, error) {
+	collection := r.DB.Database("digital-voter").Collection("course_grades")
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	grades := []entity.CourseGrade{}
+	if err = cursor.All(ctx, &grades); err != nil {
+		return nil, err
+	}
+	return grades, nil
+}
+
+// ReplaceCourse writes the course's grades and removes those of students who
+// no longer got one in this computation.
+func (r *CourseGradeRepository) ReplaceCourse(ctx context.Context, courseID primitive.ObjectID, grades []entity.CourseGrade, computedAt time.Time) error {
+	collection := r.DB.Database("digital-voter").Collection("course_grades")
+	if len(grades) > 0 {
+		models := make([]mongo.WriteModel, 0, len(grades))
+		for _, grade := range grades {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"course_id": courseID, "user_id": grade.UserID}).
+				SetUpdate(bson.M{"$set": bson.M{
+					"categories":  grade.Categories,
+					"percent":     grade.Percent,
+					"curved":      grade.Curved,
+					"letter":      grade.Letter,
+					"computed_at": computedAt,
+				}}).
+				SetUpsert(true))
+		}
+		if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+	_, err := collection.DeleteMany(ctx, bson.M{"course_id": courseID, "computed_at": bson.M{"$lt": computedAt}})
+	return err
+}
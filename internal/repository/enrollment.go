@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type EnrollmentRepository struct {
+	DB *mongo.Client
+}
+
+func NewEnrollmentRepository(db *mongo.Client) *EnrollmentRepository {
+	return &EnrollmentRepository{
+		DB: db,
+	}
+}
+
+func (r *EnrollmentRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "course_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+	})
+	return err
+}
+
+// Save enrolls the user or, when they were enrolled before, overwrites the
+// status and method of their existing enrollment.
+func (r *EnrollmentRepository) Save(ctx context.Context, enrollment *entity.Enrollment) error {
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	now := util.NowInWIB()
+	enrollment.EnrolledAt = now
+	enrollment.UpdatedAt = &now
+	filter := bson.M{"course_id": enrollment.CourseID, "user_id": enrollment.UserID}
+	set := bson.M{
+		"status":      enrollment.Status,
+		"method":      enrollment.Method,
+		"enrolled_at": enrollment.EnrolledAt,
+		"updated_at":  enrollment.UpdatedAt,
+	}
+	if !enrollment.EnrolledBy.IsZero() {
+		set["enrolled_by"] = enrollment.EnrolledBy
+	}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, filter, bson.M{"$set": set}, updateOptions).Decode(enrollment)
+}
+
+func (r *EnrollmentRepository) FindByCourseAndUser(ctx context.Context, courseID, userID primitive.ObjectID) (*entity.Enrollment, error) {
+	enrollment := &entity.Enrollment{}
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	err := collection.FindOne(ctx, bson.M{"course_id": courseID, "user_id": userID}).Decode(enrollment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+func (r *EnrollmentRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]entity.Enrollment, error) {
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	enrollments := []entity.Enrollment{}
+	if err = cursor.All(ctx, &enrollments); err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+// FindByCourse lists enrollments of a course, optionally only those with the
+// given status, oldest first. A non-nil userIDs limits it to those users.
+func (r *EnrollmentRepository) FindByCourse(ctx context.Context, courseID primitive.ObjectID, userIDs []primitive.ObjectID, status string, page, limit int) ([]entity.Enrollment, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	filter := bson.M{"course_id": courseID}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "enrolled_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	enrollments := []entity.Enrollment{}
+	if err = cursor.All(ctx, &enrollments); err != nil {
+		return nil, 0, err
+	}
+	return enrollments, total, nil
+}
+
+func (r *EnrollmentRepository) FindActiveUserIDs(ctx context.Context, courseID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	findOptions := options.Find().SetProjection(bson.M{"user_id": 1})
+	cursor, err := collection.Find(ctx, bson.M{"course_id": courseID, "status": entity.EnrollmentActive}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var enrollments []entity.Enrollment
+	if err = cursor.All(ctx, &enrollments); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		ids = append(ids, enrollment.UserID)
+	}
+	return ids, nil
+}
+
+// FindGradedUserIDs returns the students of the course who get a course grade:
+// those enrolled now and those who completed it.
+func (r *EnrollmentRepository) FindGradedUserIDs(ctx context.Context, courseID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	filter := bson.M{"course_id": courseID, "status": bson.M{"$in": bson.A{entity.EnrollmentActive, entity.EnrollmentCompleted}}}
+	findOptions := options.Find().SetProjection(bson.M{"user_id": 1})
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var enrollments []entity.Enrollment
+	if err = cursor.All(ctx, &enrollments); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		ids = append(ids, enrollment.UserID)
+	}
+	return ids, nil
+}
+
+// ChangedSince reports whether any enrollment of the course changed after since.
+func (r *EnrollmentRepository) ChangedSince(ctx context.Context, courseID primitive.ObjectID, since time.Time) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	count, err := collection.CountDocuments(ctx, bson.M{"course_id": courseID, "updated_at": bson.M{"$gt": since}}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+func (r *EnrollmentRepository) UpdateStatus(ctx context.Context, courseID, userID primitive.ObjectID, status string) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	update := bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": util.NowInWIB(),
+		},
+	}
+	result, err := collection.UpdateOne(ctx, bson.M{"course_id": courseID, "user_id": userID}, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// StreamGradebook calls fn with one gradebook row per enrollment of the course,
+// ordered by email, as the rows arrive from the cursor. A non-nil userIDs limits
+// it to those students; status, when set, to enrollments with that status.
+func (r *EnrollmentRepository) StreamGradebook(ctx context.Context, courseID primitive.ObjectID, userIDs []primitive.ObjectID,
+	status string, assignmentIDs []primitive.ObjectID, fn func(row *entity.GradebookRow) error) error {
+	collection := r.DB.Database("digital-voter").Collection("enrollments")
+	match := bson.M{"course_id": courseID}
+	if userIDs != nil {
+		match["user_id"] = bson.M{"$in": userIDs}
+	}
+	if status != "" {
+		match["status"] = status
+	}
+	ofStudent := func(extra ...bson.M) bson.A {
+		conditions := bson.A{
+			bson.M{"$eq": bson.A{"$user_id", "$$user_id"}},
+		}
+		for _, condition := range extra {
+			conditions = append(conditions, condition)
+		}
+		return bson.A{bson.M{"$match": bson.M{"$expr": bson.M{"$and": conditions}}}}
+	}
+	inCourse := bson.M{"$in": bson.A{"$assignment_id", assignmentIDs}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.M{"from": "users", "localField": "user_id", "foreignField": "_id", "as": "user"}}},
+		{{Key: "$unwind", Value: "$user"}},
+		{{Key: "$sort", Value: bson.D{{Key: "user.email", Value: 1}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "cohort_members",
+			"let":  bson.M{"user_id": "$user_id"},
+			"pipeline": append(ofStudent(bson.M{"$eq": bson.A{"$role", entity.CohortRoleStudent}}),
+				bson.M{"$lookup": bson.M{"from": "cohorts", "localField": "cohort_id", "foreignField": "_id", "as": "cohort"}},
+				bson.M{"$unwind": "$cohort"},
+				bson.M{"$project": bson.M{"name": "$cohort.name", "cohort_id": 1}},
+			),
+			"as": "cohort",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "group_members",
+			"let": bson.M{
+				"user_id":   "$user_id",
+				"cohort_id": bson.M{"$arrayElemAt": bson.A{"$cohort.cohort_id", 0}},
+			},
+			"pipeline": append(ofStudent(bson.M{"$eq": bson.A{"$cohort_id", "$$cohort_id"}}),
+				bson.M{"$lookup": bson.M{"from": "groups", "localField": "group_id", "foreignField": "_id", "as": "group"}},
+				bson.M{"$unwind": "$group"},
+				bson.M{"$project": bson.M{"name": "$group.name"}},
+			),
+			"as": "group",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":     "course_grades",
+			"let":      bson.M{"user_id": "$user_id"},
+			"pipeline": ofStudent(bson.M{"$eq": bson.A{"$course_id", courseID}}),
+			"as":       "grade",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":     "scores",
+			"let":      bson.M{"user_id": "$user_id"},
+			"pipeline": ofStudent(inCourse),
+			"as":       "scores",
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":     "submissions",
+			"let":      bson.M{"user_id": "$user_id"},
+			"pipeline": append(ofStudent(inCourse), bson.M{"$project": bson.M{"payload_ref": 0, "grader": 0}}),
+			"as":       "submissions",
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":         "$user_id",
+			"email":       "$user.email",
+			"name":        "$user.name",
+			"cohort":      bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$cohort.name", 0}}, ""}},
+			"group":       bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$group.name", 0}}, ""}},
+			"status":      1,
+			"grade":       bson.M{"$arrayElemAt": bson.A{"$grade", 0}},
+			"scores":      1,
+			"submissions": 1,
+		}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		row := &entity.GradebookRow{}
+		if err = cursor.Decode(row); err != nil {
+			return err
+		}
+		if err = fn(row); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
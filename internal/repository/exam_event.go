@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ExamEventRepository struct {
+	DB *mongo.Client
+}
+
+func NewExamEventRepository(db *mongo.Client) *ExamEventRepository {
+	return &ExamEventRepository{
+		DB: db,
+	}
+}
+
+func (r *ExamEventRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("exam_events")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "attempt_id", Value: 1}, {Key: "received_at", Value: 1}}},
+		{Keys: bson.D{{Key: "assignment_id", Value: 1}, {Key: "user_id", Value: 1}}},
+	})
+	return err
+}
+
+func (r *ExamEventRepository) CreateMany(ctx context.Context, events []entity.ExamEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	collection := r.DB.Database("digital-voter").Collection("exam_events")
+	documents := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		documents = append(documents, event)
+	}
+	_, err := collection.InsertMany(ctx, documents)
+	return err
+}
+
+// FindByAttempt returns an attempt's timeline in the order the server got it.
+func (r *ExamEventRepository) FindByAttempt(ctx context.Context, attemptID primitive.ObjectID) ([]entity.ExamEvent, error) {
+	collection := r.DB.Database("digital-voter").Collection("exam_events")
+	findOptions := options.Find().SetSort(bson.D{{Key: "received_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"attempt_id": attemptID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	events := []entity.ExamEvent{}
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// CountByAttempts counts the events of each type of the given attempts.
+// Attempts without events are left out.
+func (r *ExamEventRepository) CountByAttempts(ctx context.Context, attemptIDs []primitive.ObjectID) (map[primitive.ObjectID]entity.ExamEventCounts, error) {
+	collection := r.DB.Database("digital-voter").Collection("exam_events")
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"attempt_id": bson.M{"$in": attemptIDs}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"attempt_id": "$attempt_id", "type": "$type"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$_id.attempt_id",
+			"counts": bson.M{"$push": bson.M{"k": "$_id.type", "v": "$count"}},
+			"total":  bson.M{"$sum": "$count"},
+		}}},
+		{{Key: "$project", Value: bson.M{"counts": bson.M{"$arrayToObject": "$counts"}, "total": 1}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	rows := []entity.ExamEventCounts{}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[primitive.ObjectID]entity.ExamEventCounts, len(rows))
+	for _, row := range rows {
+		counts[row.AttemptID] = row
+	}
+	return counts, nil
+}
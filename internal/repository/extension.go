@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ExtensionRepository struct {
+	DB *mongo.Client
+}
+
+func NewExtensionRepository(db *mongo.Client) *ExtensionRepository {
+	return &ExtensionRepository{
+		DB: db,
+	}
+}
+
+func (r *ExtensionRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("extensions")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "assignment_id", Value: 1}},
+	})
+	return err
+}
+
+func (r *ExtensionRepository) Create(ctx context.Context, extension *entity.Extension) error {
+	collection := r.DB.Database("digital-voter").Collection("extensions")
+	extension.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, extension)
+	if err != nil {
+		return err
+	}
+	extension.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ExtensionRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID) ([]entity.Extension, error) {
+	return r.find(ctx, bson.M{"assignment_id": assignmentID})
+}
+
+// FindByID returns the assignment's extension with the id, or nil.
+func (r *ExtensionRepository) FindByID(ctx context.Context, assignmentID, id primitive.ObjectID) (*entity.Extension, error) {
+	extensions, err := r.find(ctx, bson.M{"_id": id, "assignment_id": assignmentID})
+	if err != nil || len(extensions) == 0 {
+		return nil, err
+	}
+	return &extensions[0], nil
+}
+
+// FindForUser returns the extensions granted to the user directly or to their cohort.
+func (r *ExtensionRepository) FindForUser(ctx context.Context, assignmentID, userID primitive.ObjectID, cohortID *primitive.ObjectID) ([]entity.Extension, error) {
+	match := bson.A{bson.M{"user_id": userID}}
+	if cohortID != nil {
+		match = append(match, bson.M{"cohort_id": *cohortID})
+	}
+	return r.find(ctx, bson.M{"assignment_id": assignmentID, "$or": match})
+}
+
+func (r *ExtensionRepository) find(ctx context.Context, filter bson.M) ([]entity.Extension, error) {
+	collection := r.DB.Database("digital-voter").Collection("extensions")
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	extensions := []entity.Extension{}
+	if err = cursor.All(ctx, &extensions); err != nil {
+		return nil, err
+	}
+	return extensions, nil
+}
+
+func (r *ExtensionRepository) Delete(ctx context.Context, assignmentID, id primitive.ObjectID) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("extensions")
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id, "assignment_id": assignmentID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// MigrateCohort points extensions granted to a legacy cohort name at the cohort
+// that replaced it.
+func (r *ExtensionRepository) MigrateCohort(ctx context.Context, name string, cohortID primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("extensions")
+	update := bson.M{
+		"$set":   bson.M{"cohort_id": cohortID},
+		"$unset": bson.M{"cohort": ""},
+	}
+	result, err := collection.UpdateMany(ctx, bson.M{"cohort": name}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GradingSchemeRepository struct {
+	DB *mongo.Client
+}
+
+func NewGradingSchemeRepository(db *mongo.Client) *GradingSchemeRepository {
+	return &GradingSchemeRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes allows one grading scheme per course.
+func (r *GradingSchemeRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("grading_schemes")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "course_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *GradingSchemeRepository) FindByCourse(ctx context.Context, courseID primitive.ObjectID) (*entity.GradingScheme, error) {
+	scheme := &entity.GradingScheme{}
+	collection := r.DB.Database("digital-voter").Collection("grading_schemes")
+	err := collection.FindOne(ctx, bson.M{"course_id": courseID}).Decode(scheme)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return scheme, nil
+}
+
+func (r *GradingSchemeRepository) FindAll(ctx context.Context) ([]entity.GradingScheme, error) {
+	collection := r.DB.Database("digital-voter").Collection("grading_schemes")
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	schemes := []entity.GradingScheme{}
+	if err = cursor.All(ctx, &schemes); err != nil {
+		return nil, err
+	}
+	return schemes, nil
+}
+
+// Save replaces the course's scheme. Its grades count as not computed until
+// they are worked out again with it.
+func (r *GradingSchemeRepository) Save(ctx context.Context, scheme *entity.GradingScheme) error {
+	collection := r.DB.Database("digital-voter").Collection("grading_schemes")
+	scheme.UpdatedAt = util.NowInWIB()
+	scheme.ComputedAt = nil
+	update := bson.M{"$set": bson.M{
+		"categories":  scheme.Categories,
+		"letters":     scheme.Letters,
+		"curve":       scheme.Curve,
+		"updated_by":  scheme.UpdatedBy,
+		"updated_at":  scheme.UpdatedAt,
+		"computed_at": nil,
+	}}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, bson.M{"course_id": scheme.CourseID}, update, updateOptions).Decode(scheme)
+}
+
+// MarkComputed records when the course's grades were worked out, unless the
+// scheme was changed since it was read for that.
+func (r *GradingSchemeRepository) MarkComputed(ctx context.Context, scheme *entity.GradingScheme, computedAt time.Time) error {
+	collection := r.DB.Database("digital-voter").Collection("grading_schemes")
+	filter := bson.M{"_id": scheme.ID, "updated_at": scheme.UpdatedAt}
+	_, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"computed_at": computedAt}})
+	return err
+}
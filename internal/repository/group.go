@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GroupRepository struct {
+	DB *mongo.Client
+}
+
+func NewGroupRepository(db *mongo.Client) *GroupRepository {
+	return &GroupRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes keeps group names unique within a cohort.
+func (r *GroupRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("groups")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "cohort_id", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *GroupRepository) Create(ctx context.Context, group *entity.Group) error {
+	collection := r.DB.Database("digital-voter").Collection("groups")
+	group.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, group)
+	if err != nil {
+		return err
+	}
+	group.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *GroupRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.Group, error) {
+	group := &entity.Group{}
+	collection := r.DB.Database("digital-voter").Collection("groups")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(group)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return group, nil
+}
+
+// FindByCohort lists the cohort's groups by name.
+func (r *GroupRepository) FindByCohort(ctx context.Context, cohortID primitive.ObjectID) ([]entity.Group, error) {
+	collection := r.DB.Database("digital-voter").Collection("groups")
+	cursor, err := collection.Find(ctx, bson.M{"cohort_id": cohortID}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	groups := []entity.Group{}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *GroupRepository) Update(ctx context.Context, group *entity.Group) error {
+	collection := r.DB.Database("digital-voter").Collection("groups")
+	now := util.NowInWIB()
+	group.UpdatedAt = &now
+	update := bson.M{
+		"$set": bson.M{
+			"name":        group.Name,
+			"max_size":    group.MaxSize,
+			"self_signup": group.SelfSignup,
+			"updated_at":  group.UpdatedAt,
+		},
+	}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": group.ID}, update)
+	return err
+}
+
+func (r *GroupRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	collection := r.DB.Database("digital-voter").Collection("groups")
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// Reserve takes a place in the group for a new member. With limit set it
+// reports false when the group is full; instructors placing students are not
+// held to the limit.
+func (r *GroupRepository) Reserve(ctx context.Context, id primitive.ObjectID, limit bool) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("groups")
+	filter := bson.M{"_id": id}
+	if limit {
+		filter["$or"] = bson.A{
+			bson.M{"max_size": 0},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$size", "$max_size"}}},
+		}
+	}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"size": 1}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Release gives back a place taken with Reserve.
+func (r *GroupRepository) Release(ctx context.Context, id primitive.ObjectID) error {
+	collection := r.DB.Database("digital-voter").Collection("groups")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id, "size": bson.M{"$gt": 0}}, bson.M{"$inc": bson.M{"size": -1}})
+	return err
+}
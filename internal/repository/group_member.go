@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GroupMemberRepository struct {
+	DB *mongo.Client
+}
+
+func NewGroupMemberRepository(db *mongo.Client) *GroupMemberRepository {
+	return &GroupMemberRepository{
+		DB: db,
+	}
+}
+
+func (r *GroupMemberRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("group_members")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// a student belongs to at most one group of their cohort
+			Keys:    bson.D{{Key: "cohort_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "group_id", Value: 1}}},
+	})
+	return err
+}
+
+// Add inserts the membership. It fails with a duplicate key error when the
+// student is already in a group of the cohort.
+func (r *GroupMemberRepository) Add(ctx context.Context, member *entity.GroupMember) error {
+	collection := r.DB.Database("digital-voter").Collection("group_members")
+	member.AddedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, member)
+	if err != nil {
+		return err
+	}
+	member.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *GroupMemberRepository) Remove(ctx context.Context, groupID, userID primitive.ObjectID) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("group_members")
+	result, err := collection.DeleteOne(ctx, bson.M{"group_id": groupID, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *GroupMemberRepository) RemoveAll(ctx context.Context, groupID primitive.ObjectID) error {
+	collection := r.DB.Database("digital-voter").Collection("group_members")
+	_, err := collection.DeleteMany(ctx, bson.M{"group_id": groupID})
+	return err
+}
+
+// FindByCohortAndUser returns the student's group in the cohort, or nil.
+func (r *GroupMemberRepository) FindByCohortAndUser(ctx context.Context, cohortID, userID primitive.ObjectID) (*entity.GroupMember, error) {
+	member := &entity.GroupMember{}
+	collection := r.DB.Database("digital-voter").Collection("group_members")
+	err := collection.FindOne(ctx, bson.M{"cohort_id": cohortID, "user_id": userID}).Decode(member)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+// FindByGroups lists the members of the groups in the order they joined.
+func (r *GroupMemberRepository) FindByGroups(ctx context.Context, groupIDs []primitive.ObjectID) ([]entity.GroupMember, error) {
+	collection := r.DB.Database("digital-voter").Collection("group_members")
+	findOptions := options.Find().SetSort(bson.D{{Key: "added_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"group_id": bson.M{"$in": groupIDs}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	members := []entity.GroupMember{}
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// FindUserIDs returns the members of a group.
+func (r *GroupMemberRepository) FindUserIDs(ctx context.Context, groupID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	members, err := r.FindByGroups(ctx, []primitive.ObjectID{groupID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+	return ids, nil
+}
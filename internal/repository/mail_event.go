@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MailEventRepository struct {
+	DB *mongo.Client
+}
+
+func NewMailEventRepository(db *mongo.Client) *MailEventRepository {
+	return &MailEventRepository{
+		DB: db,
+	}
+}
+
+func (r *MailEventRepository) Create(ctx context.Context, event *entity.MailEvent) error {
+	collection := r.DB.Database("digital-voter").Collection("mail_events")
+	event.CreatedAt = util.NowInWIB()
+	_, err := collection.InsertOne(ctx, event)
+	return err
+}
+
+func (r *MailEventRepository) FindRecentByEmail(ctx context.Context, email string, limit int) ([]entity.MailEvent, error) {
+	collection := r.DB.Database("digital-voter").Collection("mail_events")
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "occurred_at", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, bson.M{"email": email}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	events := []entity.MailEvent{}
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
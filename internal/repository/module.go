@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ModuleRepository struct {
+	DB *mongo.Client
+}
+
+func NewModuleRepository(db *mongo.Client) *ModuleRepository {
+	return &ModuleRepository{
+		DB: db,
+	}
+}
+
+func (r *ModuleRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("modules")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "position", Value: 1}},
+	})
+	return err
+}
+
+func (r *ModuleRepository) Create(ctx context.Context, module *entity.Module) error {
+	collection := r.DB.Database("digital-voter").Collection("modules")
+	module.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, module)
+	if err != nil {
+		return err
+	}
+	module.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ModuleRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.Module, error) {
+	module := &entity.Module{}
+	collection := r.DB.Database("digital-voter").Collection("modules")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(module)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return module, nil
+}
+
+func (r *ModuleRepository) FindByCourse(ctx context.Context, courseID primitive.ObjectID) ([]entity.Module, error) {
+	collection := r.DB.Database("digital-voter").Collection("modules")
+	findOptions := options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "created_at", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"course_id": courseID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	modules := []entity.Module{}
+	if err = cursor.All(ctx, &modules); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+func (r *ModuleRepository) CountByCourse(ctx context.Context, courseID primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("modules")
+	return collection.CountDocuments(ctx, bson.M{"course_id": courseID})
+}
+
+func (r *ModuleRepository) Update(ctx context.Context, module *entity.Module) error {
+	collection := r.DB.Database("digital-voter").Collection("modules")
+	now := util.NowInWIB()
+	module.UpdatedAt = &now
+	update := bson.M{
+		"$set": bson.M{
+			"title":       module.Title,
+			"description": module.Description,
+			"position":    module.Position,
+			"updated_at":  module.UpdatedAt,
+		},
+	}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": module.ID}, update)
+	return err
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type NotificationRepository struct {
+	DB *mongo.Client
+}
+
+func NewNotificationRepository(db *mongo.Client) *NotificationRepository {
+	return &NotificationRepository{
+		DB: db,
+	}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, notification *entity.Notification) error {
+	collection := r.DB.Database("digital-voter").Collection("notifications")
+	notification.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, notification)
+	if err != nil {
+		return err
+	}
+	notification.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *NotificationRepository) FindByUser(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]entity.Notification, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("notifications")
+	filter := bson.M{"user_id": userID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	notifications := []entity.Notification{}
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("notifications")
+	return collection.CountDocuments(ctx, bson.M{"user_id": userID, "read_at": nil})
+}
+
+// MarkRead reports whether a notification with the given id belongs to the user.
+// Marking an already read notification is not an error.
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id primitive.ObjectID) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("notifications")
+	filter := bson.M{"_id": id, "user_id": userID}
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil || count == 0 {
+		return false, err
+	}
+
+	filter["read_at"] = nil
+	update := bson.M{
+		"$set": bson.M{
+			"read_at": util.NowInWIB(),
+		},
+	}
+	_, err = collection.UpdateOne(ctx, filter, update)
+	return err == nil, err
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("notifications")
+	filter := bson.M{"user_id": userID, "read_at": nil}
+	update := bson.M{
+		"$set": bson.M{
+			"read_at": util.NowInWIB(),
+		},
+	}
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *NotificationRepository) FindPendingDigestUserIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	collection := r.DB.Database("digital-voter").Collection("notifications")
+	filter := bson.M{"delivery": entity.NotificationDeliveryDailyDigest, "emailed_at": nil}
+	values, err := collection.Distinct(ctx, "user_id", filter)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if userID, ok := value.(primitive.ObjectID); ok {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+func (r *NotificationRepository) FindPendingDigest(ctx context.Context, userID primitive.ObjectID) ([]entity.Notification, error) {
+	collection := r.DB.Database("digital-voter").Collection("notifications")
+	filter := bson.M{"user_id": userID, "delivery": entity.NotificationDeliveryDailyDigest, "emailed_at": nil}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	notifications := []entity.Notification{}
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (r *NotificationRepository) MarkEmailed(ctx context.Context, ids []primitive.ObjectID) error {
+	collection := r.DB.Database("digital-voter").Collection("notifications")
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+	update := bson.M{
+		"$set": bson.M{
+			"emailed_at": util.NowInWIB(),
+		},
+	}
+	_, err := collection.UpdateMany(ctx, filter, update)
+	return err
+}
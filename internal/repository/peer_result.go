@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PeerResultRepository struct {
+	DB *mongo.Client
+}
+
+func NewPeerResultRepository(db *mongo.Client) *PeerResultRepository {
+	return &PeerResultRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes allows one result per student and assignment.
+func (r *PeerResultRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_results")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "assignment_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *PeerResultRepository) FindByAssignmentAndUser(ctx context.Context, assignmentID, userID primitive.ObjectID) (*entity.PeerResult, error) {
+	result := &entity.PeerResult{}
+	collection := r.DB.Database("digital-voter").Collection("peer_results")
+	err := collection.FindOne(ctx, bson.M{"assignment_id": assignmentID, "user_id": userID}).Decode(result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindByAssignment lists results. A non-nil userIDs limits it to those
+// students.
+func (r *PeerResultRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID, userIDs []primitive.ObjectID, page, limit int) ([]entity.PeerResult, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("peer_results")
+	filter := bson.M{"assignment_id": assignmentID}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "user_id", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	results := []entity.PeerResult{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// Save stores the computed part of a result. An override staff gave before
+// is kept.
+func (r *PeerResultRepository) Save(ctx context.Context, result *entity.PeerResult) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_results")
+	now := util.NowInWIB()
+	result.UpdatedAt = &now
+	filter := bson.M{"assignment_id": result.AssignmentID, "user_id": result.UserID}
+	update := bson.M{"$set": bson.M{
+		"reviews":    result.Reviews,
+		"peer_score": result.PeerScore,
+		"assigned":   result.Assigned,
+		"on_time":    result.OnTime,
+		"score":      result.Score,
+		"updated_at": result.UpdatedAt,
+	}}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(result)
+}
+
+// SetOverride stores the score staff gave the student instead of the computed
+// one.
+func (r *PeerResultRepository) SetOverride(ctx context.Context, result *entity.PeerResult) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_results")
+	now := util.NowInWIB()
+	result.UpdatedAt = &now
+	filter := bson.M{"assignment_id": result.AssignmentID, "user_id": result.UserID}
+	update := bson.M{"$set": bson.M{
+		"override":        result.Override,
+		"override_by":     result.OverrideBy,
+		"override_reason": result.OverrideReason,
+		"score":           result.Score,
+		"updated_at":      result.UpdatedAt,
+	}}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(result)
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PeerReviewRepository struct {
+	DB *mongo.Client
+}
+
+func NewPeerReviewRepository(db *mongo.Client) *PeerReviewRepository {
+	return &PeerReviewRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes allows one peer review set-up per assignment.
+func (r *PeerReviewRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_reviews")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "assignment_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *PeerReviewRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID) (*entity.PeerReview, error) {
+	review := &entity.PeerReview{}
+	collection := r.DB.Database("digital-voter").Collection("peer_reviews")
+	err := collection.FindOne(ctx, bson.M{"assignment_id": assignmentID}).Decode(review)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return review, nil
+}
+
+// FindUnfinalized returns the peer reviews whose scores were not recorded yet.
+func (r *PeerReviewRepository) FindUnfinalized(ctx context.Context) ([]entity.PeerReview, error) {
+	collection := r.DB.Database("digital-voter").Collection("peer_reviews")
+	cursor, err := collection.Find(ctx, bson.M{"finalized_at": nil})
+	if err != nil {
+		return nil, err
+	}
+	reviews := []entity.PeerReview{}
+	if err = cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// Save creates the assignment's peer review or replaces its settings.
+func (r *PeerReviewRepository) Save(ctx context.Context, review *entity.PeerReview) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_reviews")
+	now := util.NowInWIB()
+	review.UpdatedAt = &now
+	update := bson.M{
+		"$set": bson.M{
+			"rubric_id":      review.RubricID,
+			"reviewers":      review.Reviewers,
+			"aggregation":    review.Aggregation,
+			"credit_percent": review.CreditPercent,
+			"review_due_at":  review.ReviewDueAt,
+			"updated_at":     review.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"allocated_at": nil,
+			"finalized_at": nil,
+			"created_by":   review.CreatedBy,
+			"created_at":   now,
+		},
+	}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, bson.M{"assignment_id": review.AssignmentID}, update, updateOptions).Decode(review)
+}
+
+// MarkAllocated claims the handing out of reviews. It reports false when they
+// were already handed out.
+func (r *PeerReviewRepository) MarkAllocated(ctx context.Context, id primitive.ObjectID, at time.Time) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("peer_reviews")
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": id, "allocated_at": nil}, bson.M{"$set": bson.M{"allocated_at": at}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ReleaseAllocation undoes MarkAllocated when the reviews could not be stored.
+func (r *PeerReviewRepository) ReleaseAllocation(ctx context.Context, id primitive.ObjectID) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_reviews")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"allocated_at": nil}})
+	return err
+}
+
+func (r *PeerReviewRepository) MarkFinalized(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_reviews")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"finalized_at": at}})
+	return err
+}
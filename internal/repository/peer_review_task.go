@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PeerReviewTaskRepository struct {
+	DB *mongo.Client
+}
+
+func NewPeerReviewTaskRepository(db *mongo.Client) *PeerReviewTaskRepository {
+	return &PeerReviewTaskRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes lets a student review a submission only once and finds their
+// reviews quickly.
+func (r *PeerReviewTaskRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_review_tasks")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "assignment_id", Value: 1}, {Key: "reviewer_id", Value: 1}, {Key: "author_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "reviewer_id", Value: 1}, {Key: "due_at", Value: 1}}},
+	})
+	return err
+}
+
+func (r *PeerReviewTaskRepository) CreateMany(ctx context.Context, tasks []entity.PeerReviewTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	collection := r.DB.Database("digital-voter").Collection("peer_review_tasks")
+	now := util.NowInWIB()
+	documents := make([]interface{}, 0, len(tasks))
+	for i := range tasks {
+		tasks[i].CreatedAt = now
+		documents = append(documents, tasks[i])
+	}
+	_, err := collection.InsertMany(ctx, documents)
+	return err
+}
+
+func (r *PeerReviewTaskRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.PeerReviewTask, error) {
+	task := &entity.PeerReviewTask{}
+	collection := r.DB.Database("digital-voter").Collection("peer_review_tasks")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+// FindByReviewer lists a student's reviews, the ones due first first.
+func (r *PeerReviewTaskRepository) FindByReviewer(ctx context.Context, reviewerID primitive.ObjectID, page, limit int) ([]entity.PeerReviewTask, int64, error) {
+	return r.findPage(ctx, bson.M{"reviewer_id": reviewerID}, bson.D{{Key: "due_at", Value: 1}, {Key: "_id", Value: 1}}, page, limit)
+}
+
+// FindByAssignment lists an assignment's reviews by author. A non-nil
+// authorIDs limits it to the reviews of those students' work.
+func (r *PeerReviewTaskRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID, authorIDs []primitive.ObjectID, page, limit int) ([]entity.PeerReviewTask, int64, error) {
+	filter := bson.M{"assignment_id": assignmentID}
+	if authorIDs != nil {
+		filter["author_id"] = bson.M{"$in": authorIDs}
+	}
+	return r.findPage(ctx, filter, bson.D{{Key: "author_id", Value: 1}, {Key: "_id", Value: 1}}, page, limit)
+}
+
+// FindAllByAssignment returns every review of the assignment, for scoring.
+func (r *PeerReviewTaskRepository) FindAllByAssignment(ctx context.Context, assignmentID primitive.ObjectID) ([]entity.PeerReviewTask, error) {
+	return r.find(ctx, bson.M{"assignment_id": assignmentID})
+}
+
+// FindCompletedByAuthor returns the handed in reviews of a student's work.
+func (r *PeerReviewTaskRepository) FindCompletedByAuthor(ctx context.Context, assignmentID, authorID primitive.ObjectID) ([]entity.PeerReviewTask, error) {
+	return r.find(ctx, bson.M{"assignment_id": assignmentID, "author_id": authorID, "submitted_at": bson.M{"$ne": nil}})
+}
+
+// Submit stores the reviewer's grading. Reviews can be changed until the
+// assignment's scores are finalized.
+func (r *PeerReviewTaskRepository) Submit(ctx context.Context, task *entity.PeerReviewTask) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_review_tasks")
+	update := bson.M{"$set": bson.M{
+		"criteria":     task.Criteria,
+		"comment":      task.Comment,
+		"earned":       task.Earned,
+		"possible":     task.Possible,
+		"submitted_at": task.SubmittedAt,
+	}}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": task.ID}, update)
+	return err
+}
+
+func (r *PeerReviewTaskRepository) find(ctx context.Context, filter bson.M) ([]entity.PeerReviewTask, error) {
+	collection := r.DB.Database("digital-voter").Collection("peer_review_tasks")
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []entity.PeerReviewTask{}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *PeerReviewTaskRepository) findPage(ctx context.Context, filter bson.M, sort bson.D, page, limit int) ([]entity.PeerReviewTask, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("peer_review_tasks")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(sort).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	tasks := []entity.PeerReviewTask{}
+	if err = cursor.All(ctx, &tasks); err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+// SetDueAt moves the due date of every review of the assignment.
+func (r *PeerReviewTaskRepository) SetDueAt(ctx context.Context, assignmentID primitive.ObjectID, dueAt time.Time) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_review_tasks")
+	_, err := collection.UpdateMany(ctx, bson.M{"assignment_id": assignmentID}, bson.M{"$set": bson.M{"due_at": dueAt}})
+	return err
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PeerSubmissionRepository struct {
+	DB *mongo.Client
+}
+
+func NewPeerSubmissionRepository(db *mongo.Client) *PeerSubmissionRepository {
+	return &PeerSubmissionRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes allows one submission per student and assignment.
+func (r *PeerSubmissionRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_submissions")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "assignment_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *PeerSubmissionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.PeerSubmission, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *PeerSubmissionRepository) FindByAssignmentAndUser(ctx context.Context, assignmentID, userID primitive.ObjectID) (*entity.PeerSubmission, error) {
+	return r.findOne(ctx, bson.M{"assignment_id": assignmentID, "user_id": userID})
+}
+
+func (r *PeerSubmissionRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID) ([]entity.PeerSubmission, error) {
+	collection := r.DB.Database("digital-voter").Collection("peer_submissions")
+	cursor, err := collection.Find(ctx, bson.M{"assignment_id": assignmentID})
+	if err != nil {
+		return nil, err
+	}
+	submissions := []entity.PeerSubmission{}
+	if err = cursor.All(ctx, &submissions); err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+// Save stores the student's submission, replacing any earlier one.
+func (r *PeerSubmissionRepository) Save(ctx context.Context, submission *entity.PeerSubmission) error {
+	collection := r.DB.Database("digital-voter").Collection("peer_submissions")
+	filter := bson.M{"assignment_id": submission.AssignmentID, "user_id": submission.UserID}
+	update := bson.M{"$set": bson.M{
+		"content":      submission.Content,
+		"submitted_at": submission.SubmittedAt,
+	}}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(submission)
+}
+
+func (r *PeerSubmissionRepository) findOne(ctx context.Context, filter bson.M) (*entity.PeerSubmission, error) {
+	submission := &entity.PeerSubmission{}
+	collection := r.DB.Database("digital-voter").Collection("peer_submissions")
+	err := collection.FindOne(ctx, filter).Decode(submission)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return submission, nil
+}
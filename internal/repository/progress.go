@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProgressRepository struct {
+	DB *mongo.Client
+}
+
+func NewProgressRepository(db *mongo.Client) *ProgressRepository {
+	return &ProgressRepository{
+		DB: db,
+	}
+}
+
+func (r *ProgressRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("progress")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "module_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "user_id", Value: 1}},
+		},
+	})
+	return err
+}
+
+// RecordItem moves an assignment of the module to status unless it is already
+// further along. Each status keeps the time it was first reached, and score is
+// only written for completed or graded items.
+func (r *ProgressRepository) RecordItem(ctx context.Context, userID, courseID, moduleID, assignmentID primitive.ObjectID, status string, score int) error {
+	collection := r.DB.Database("digital-voter").Collection("progress")
+	now := util.NowInWIB()
+	item := "items." + assignmentID.Hex() + "."
+
+	set := bson.M{
+		"course_id":        courseID,
+		"last_activity_at": now,
+	}
+	if entity.ProgressLevel(status) >= entity.ProgressLevel(entity.ProgressCompleted) {
+		set[item+"score"] = score
+	}
+	update := bson.M{
+		"$set": set,
+		"$max": bson.M{item + "level": entity.ProgressLevel(status)},
+		"$min": bson.M{item + status + "_at": now},
+	}
+	filter := bson.M{"user_id": userID, "module_id": moduleID}
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *ProgressRepository) FindByCourse(ctx context.Context, courseID primitive.ObjectID, userIDs []primitive.ObjectID) ([]entity.ModuleProgress, error) {
+	collection := r.DB.Database("digital-voter").Collection("progress")
+	cursor, err := collection.Find(ctx, bson.M{"course_id": courseID, "user_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, err
+	}
+	progress := []entity.ModuleProgress{}
+	if err = cursor.All(ctx, &progress); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
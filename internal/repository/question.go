@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuestionRepository struct {
+	DB *mongo.Client
+}
+
+func NewQuestionRepository(db *mongo.Client) *QuestionRepository {
+	return &QuestionRepository{
+		DB: db,
+	}
+}
+
+func (r *QuestionRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("questions")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "bank_id", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	return err
+}
+
+func (r *QuestionRepository) Create(ctx context.Context, question *entity.Question) error {
+	collection := r.DB.Database("digital-voter").Collection("questions")
+	question.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, question)
+	if err != nil {
+		return err
+	}
+	question.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *QuestionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.Question, error) {
+	question := &entity.Question{}
+	collection := r.DB.Database("digital-voter").Collection("questions")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(question)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return question, nil
+}
+
+// FindByBank lists a bank's questions in the order they were added.
+func (r *QuestionRepository) FindByBank(ctx context.Context, bankID primitive.ObjectID, page, limit int) ([]entity.Question, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("questions")
+	filter := bson.M{"bank_id": bankID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	questions := []entity.Question{}
+	if err = cursor.All(ctx, &questions); err != nil {
+		return nil, 0, err
+	}
+	return questions, total, nil
+}
+
+// FindAllByBank returns every question of a bank in the order they were added.
+func (r *QuestionRepository) FindAllByBank(ctx context.Context, bankID primitive.ObjectID) ([]entity.Question, error) {
+	collection := r.DB.Database("digital-voter").Collection("questions")
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"bank_id": bankID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	questions := []entity.Question{}
+	if err = cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (r *QuestionRepository) CountByBank(ctx context.Context, bankID primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("questions")
+	return collection.CountDocuments(ctx, bson.M{"bank_id": bankID})
+}
+
+// Sample draws count distinct questions from the bank at random.
+func (r *QuestionRepository) Sample(ctx context.Context, bankID primitive.ObjectID, count int) ([]entity.Question, error) {
+	collection := r.DB.Database("digital-voter").Collection("questions")
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"bank_id": bankID}}},
+		{{Key: "$sample", Value: bson.M{"size": count}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	questions := []entity.Question{}
+	if err = cursor.All(ctx, &questions); err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+func (r *QuestionRepository) Update(ctx context.Context, question *entity.Question) error {
+	collection := r.DB.Database("digital-voter").Collection("questions")
+	now := util.NowInWIB()
+	question.UpdatedAt = &now
+	update := bson.M{"$set": bson.M{
+		"type":           question.Type,
+		"prompt":         question.Prompt,
+		"points":         question.Points,
+		"choices":        question.Choices,
+		"answer":         question.Answer,
+		"tolerance":      question.Tolerance,
+		"parameters":     question.Parameters,
+		"formula":        question.Formula,
+		"patterns":       question.Patterns,
+		"case_sensitive": question.CaseSensitive,
+		"updated_at":     question.UpdatedAt,
+	}}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": question.ID}, update)
+	return err
+}
+
+func (r *QuestionRepository) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("questions")
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuestionBankRepository struct {
+	DB *mongo.Client
+}
+
+func NewQuestionBankRepository(db *mongo.Client) *QuestionBankRepository {
+	return &QuestionBankRepository{
+		DB: db,
+	}
+}
+
+func (r *QuestionBankRepository) Create(ctx context.Context, bank *entity.QuestionBank) error {
+	collection := r.DB.Database("digital-voter").Collection("question_banks")
+	bank.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, bank)
+	if err != nil {
+		return err
+	}
+	bank.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *QuestionBankRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.QuestionBank, error) {
+	bank := &entity.QuestionBank{}
+	collection := r.DB.Database("digital-voter").Collection("question_banks")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(bank)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return bank, nil
+}
+
+// FindAll lists banks by name, optionally only those of a course.
+func (r *QuestionBankRepository) FindAll(ctx context.Context, courseID *primitive.ObjectID, page, limit int) ([]entity.QuestionBank, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("question_banks")
+	filter := bson.M{}
+	if courseID != nil {
+		filter["course_id"] = courseID
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	banks := []entity.QuestionBank{}
+	if err = cursor.All(ctx, &banks); err != nil {
+		return nil, 0, err
+	}
+	return banks, total, nil
+}
+
+func (r *QuestionBankRepository) Update(ctx context.Context, bank *entity.QuestionBank) error {
+	collection := r.DB.Database("digital-voter").Collection("question_banks")
+	now := util.NowInWIB()
+	bank.UpdatedAt = &now
+	update := bson.M{"$set": bson.M{
+		"name":       bank.Name,
+		"course_id":  bank.CourseID,
+		"updated_at": bank.UpdatedAt,
+	}}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": bank.ID}, update)
+	return err
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuizRepository struct {
+	DB *mongo.Client
+}
+
+func NewQuizRepository(db *mongo.Client) *QuizRepository {
+	return &QuizRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes allows one quiz per assignment.
+func (r *QuizRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("quizzes")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "assignment_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *QuizRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID) (*entity.Quiz, error) {
+	quiz := &entity.Quiz{}
+	collection := r.DB.Database("digital-voter").Collection("quizzes")
+	err := collection.FindOne(ctx, bson.M{"assignment_id": assignmentID}).Decode(quiz)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return quiz, nil
+}
+
+// Save creates the assignment's quiz or replaces its settings.
+func (r *QuizRepository) Save(ctx context.Context, quiz *entity.Quiz) error {
+	collection := r.DB.Database("digital-voter").Collection("quizzes")
+	now := util.NowInWIB()
+	quiz.UpdatedAt = &now
+	update := bson.M{
+		"$set": bson.M{
+			"sections":        quiz.Sections,
+			"time_limit":      quiz.TimeLimit,
+			"shuffle_choices": quiz.ShuffleChoices,
+			"exam":            quiz.Exam,
+			"updated_at":      quiz.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{
+			"created_by": quiz.CreatedBy,
+			"created_at": now,
+		},
+	}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, bson.M{"assignment_id": quiz.AssignmentID}, update, updateOptions).Decode(quiz)
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type QuizAttemptRepository struct {
+	DB *mongo.Client
+}
+
+func NewQuizAttemptRepository(db *mongo.Client) *QuizAttemptRepository {
+	return &QuizAttemptRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes lets a student have only one attempt of a quiz in progress, so
+// starting twice at once cannot draw two sets of questions.
+func (r *QuizAttemptRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "quiz_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": entity.QuizAttemptInProgress}),
+		},
+		{Keys: bson.D{{Key: "assignment_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "started_at", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+	})
+	return err
+}
+
+func (r *QuizAttemptRepository) Create(ctx context.Context, attempt *entity.QuizAttempt) error {
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	result, err := collection.InsertOne(ctx, attempt)
+	if err != nil {
+		return err
+	}
+	attempt.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *QuizAttemptRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.QuizAttempt, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *QuizAttemptRepository) FindInProgress(ctx context.Context, quizID, userID primitive.ObjectID) (*entity.QuizAttempt, error) {
+	return r.findOne(ctx, bson.M{"quiz_id": quizID, "user_id": userID, "status": entity.QuizAttemptInProgress})
+}
+
+// CountByQuizAndUser counts the attempts a student has started on a quiz.
+func (r *QuizAttemptRepository) CountByQuizAndUser(ctx context.Context, quizID, userID primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	return collection.CountDocuments(ctx, bson.M{"quiz_id": quizID, "user_id": userID})
+}
+
+func (r *QuizAttemptRepository) findOne(ctx context.Context, filter bson.M) (*entity.QuizAttempt, error) {
+	attempt := &entity.QuizAttempt{}
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	err := collection.FindOne(ctx, filter).Decode(attempt)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// FindByAssignment lists attempts newest first without their questions and
+// answers. A non-nil userIDs limits it to those students.
+func (r *QuizAttemptRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID, userIDs []primitive.ObjectID, page, limit int) ([]entity.QuizAttempt, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	filter := bson.M{"assignment_id": assignmentID}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetProjection(bson.M{"questions": 0, "answers": 0, "results": 0}).
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	attempts := []entity.QuizAttempt{}
+	if err = cursor.All(ctx, &attempts); err != nil {
+		return nil, 0, err
+	}
+	return attempts, total, nil
+}
+
+// FindExpired returns attempts still in progress whose time ran out before the
+// given time.
+func (r *QuizAttemptRepository) FindExpired(ctx context.Context, before time.Time, limit int) ([]entity.QuizAttempt, error) {
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	filter := bson.M{"status": entity.QuizAttemptInProgress, "expires_at": bson.M{"$lt": before}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	attempts := []entity.QuizAttempt{}
+	if err = cursor.All(ctx, &attempts); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// SaveAnswers replaces the answers of an attempt that is still in progress and
+// reports whether it was.
+func (r *QuizAttemptRepository) SaveAnswers(ctx context.Context, attempt *entity.QuizAttempt) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	filter := bson.M{"_id": attempt.ID, "status": entity.QuizAttemptInProgress}
+	result, err := collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"answers": attempt.Answers}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SetLastIP records the address an exam attempt was last used from.
+func (r *QuizAttemptRepository) SetLastIP(ctx context.Context, id primitive.ObjectID, ip string) error {
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"exam.last_ip": ip}})
+	return err
+}
+
+// Finish stores the graded attempt as submitted. It reports false when the
+// attempt had already been finished, so each attempt is only scored once.
+func (r *QuizAttemptRepository) Finish(ctx context.Context, attempt *entity.QuizAttempt) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	filter := bson.M{"_id": attempt.ID, "status": entity.QuizAttemptInProgress}
+	update := bson.M{"$set": bson.M{
+		"status":       entity.QuizAttemptSubmitted,
+		"answers":      attempt.Answers,
+		"results":      attempt.Results,
+		"earned":       attempt.Earned,
+		"possible":     attempt.Possible,
+		"score":        attempt.Score,
+		"submitted_at": attempt.SubmittedAt,
+	}}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SetAttempt links a finished attempt to the submission its score was recorded as.
+func (r *QuizAttemptRepository) SetAttempt(ctx context.Context, attemptID primitive.ObjectID, attempt int) error {
+	collection := r.DB.Database("digital-voter").Collection("quiz_attempts")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": attemptID}, bson.M{"$set": bson.M{"attempt": attempt}})
+	return err
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RegradeRequestRepository struct {
+	DB *mongo.Client
+}
+
+func NewRegradeRequestRepository(db *mongo.Client) *RegradeRequestRepository {
+	return &RegradeRequestRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes allows one pending request per student and assignment, and
+// keeps the staff queue, which lists by status oldest first, fast.
+func (r *RegradeRequestRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("regrade_requests")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "assignment_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"pending": true}),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	return err
+}
+
+// Create stores a new request. It fails with a duplicate key error when the
+// student already has a pending request for the assignment.
+func (r *RegradeRequestRepository) Create(ctx context.Context, request *entity.RegradeRequest) error {
+	collection := r.DB.Database("digital-voter").Collection("regrade_requests")
+	request.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, request)
+	if err != nil {
+		return err
+	}
+	request.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *RegradeRequestRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.RegradeRequest, error) {
+	request := &entity.RegradeRequest{}
+	collection := r.DB.Database("digital-voter").Collection("regrade_requests")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(request)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+// FindAll lists requests oldest first, so the queue is worked in order. Empty
+// statuses and nil ids are not filtered on.
+func (r *RegradeRequestRepository) FindAll(ctx context.Context, statuses []string, assignmentID *primitive.ObjectID,
+	userIDs []primitive.ObjectID, page, limit int) ([]entity.RegradeRequest, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("regrade_requests")
+	filter := bson.M{}
+	if len(statuses) > 0 {
+		filter["status"] = bson.M{"$in": statuses}
+	}
+	if assignmentID != nil {
+		filter["assignment_id"] = *assignmentID
+	}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	requests := []entity.RegradeRequest{}
+	if err = cursor.All(ctx, &requests); err != nil {
+		return nil, 0, err
+	}
+	return requests, total, nil
+}
+
+// Transition saves the request's new status and what came with it, but only
+// while it is still in one of the from statuses. It reports whether it did, so
+// two staff members cannot both resolve the same request.
+func (r *RegradeRequestRepository) Transition(ctx context.Context, request *entity.RegradeRequest, from ...string) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("regrade_requests")
+	now := util.NowInWIB()
+	request.UpdatedAt = &now
+	set := bson.M{
+		"status":         request.Status,
+		"new_score":      request.NewScore,
+		"response":       request.Response,
+		"escalated_by":   request.EscalatedBy,
+		"escalated_at":   request.EscalatedAt,
+		"escalate_note":  request.EscalateNote,
+		"resolved_by":    request.ResolvedBy,
+		"resolved_email": request.ResolvedEmail,
+		"resolved_at":    request.ResolvedAt,
+		"updated_at":     request.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+	if request.Pending {
+		set["pending"] = true
+	} else {
+		update["$unset"] = bson.M{"pending": ""}
+	}
+	filter := bson.M{"_id": request.ID, "status": bson.M{"$in": from}}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RubricRepository struct {
+	DB *mongo.Client
+}
+
+func NewRubricRepository(db *mongo.Client) *RubricRepository {
+	return &RubricRepository{
+		DB: db,
+	}
+}
+
+func (r *RubricRepository) Create(ctx context.Context, rubric *entity.Rubric) error {
+	collection := r.DB.Database("digital-voter").Collection("rubrics")
+	rubric.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, rubric)
+	if err != nil {
+		return err
+	}
+	rubric.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *RubricRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entity.Rubric, error) {
+	rubric := &entity.Rubric{}
+	collection := r.DB.Database("digital-voter").Collection("rubrics")
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(rubric)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rubric, nil
+}
+
+// FindAll lists rubrics by title, optionally only those of a course.
+func (r *RubricRepository) FindAll(ctx context.Context, courseID *primitive.ObjectID, page, limit int) ([]entity.Rubric, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("rubrics")
+	filter := bson.M{}
+	if courseID != nil {
+		filter["course_id"] = courseID
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "title", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	rubrics := []entity.Rubric{}
+	if err = cursor.All(ctx, &rubrics); err != nil {
+		return nil, 0, err
+	}
+	return rubrics, total, nil
+}
+
+func (r *RubricRepository) Update(ctx context.Context, rubric *entity.Rubric) error {
+	collection := r.DB.Database("digital-voter").Collection("rubrics")
+	now := util.NowInWIB()
+	rubric.UpdatedAt = &now
+	update := bson.M{"$set": bson.M{
+		"course_id":   rubric.CourseID,
+		"title":       rubric.Title,
+		"description": rubric.Description,
+		"criteria":    rubric.Criteria,
+		"updated_at":  rubric.UpdatedAt,
+	}}
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": rubric.ID}, update)
+	return err
+}
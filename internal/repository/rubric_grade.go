@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RubricGradeRepository struct {
+	DB *mongo.Client
+}
+
+func NewRubricGradeRepository(db *mongo.Client) *RubricGradeRepository {
+	return &RubricGradeRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes allows one rubric grade per student and assignment.
+func (r *RubricGradeRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("rubric_grades")
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "assignment_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (r *RubricGradeRepository) FindByAssignmentAndUser(ctx context.Context, assignmentID, userID primitive.ObjectID) (*entity.RubricGrade, error) {
+	grade := &entity.RubricGrade{}
+	collection := r.DB.Database("digital-voter").Collection("rubric_grades")
+	err := collection.FindOne(ctx, bson.M{"assignment_id": assignmentID, "user_id": userID}).Decode(grade)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return grade, nil
+}
+
+// FindByAssignment lists grades most recently graded first. A non-nil userIDs
+// limits it to those students.
+func (r *RubricGradeRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID, userIDs []primitive.ObjectID, page, limit int) ([]entity.RubricGrade, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("rubric_grades")
+	filter := bson.M{"assignment_id": assignmentID}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "graded_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	grades := []entity.RubricGrade{}
+	if err = cursor.All(ctx, &grades); err != nil {
+		return nil, 0, err
+	}
+	return grades, total, nil
+}
+
+// Save stores the student's grade for the assignment, replacing any earlier one.
+func (r *RubricGradeRepository) Save(ctx context.Context, grade *entity.RubricGrade) error {
+	collection := r.DB.Database("digital-voter").Collection("rubric_grades")
+	filter := bson.M{"assignment_id": grade.AssignmentID, "user_id": grade.UserID}
+	update := bson.M{"$set": bson.M{
+		"rubric_id":    grade.RubricID,
+		"criteria":     grade.Criteria,
+		"comment":      grade.Comment,
+		"earned":       grade.Earned,
+		"possible":     grade.Possible,
+		"score":        grade.Score,
+		"grader_id":    grade.GraderID,
+		"grader_email": grade.GraderEmail,
+		"graded_at":    grade.GradedAt,
+	}}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(grade)
+}
@@ -0,0 +1,486 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScoreRepository struct {
+	DB *mongo.Client
+}
+
+func NewScoreRepository(db *mongo.Client) *ScoreRepository {
+	return &ScoreRepository{
+		DB: db,
+	}
+}
+
+func (r *ScoreRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "assignment_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			// serves per-assignment leaderboards without scanning every score
+			Keys: bson.D{{Key: "assignment_id", Value: 1}, {Key: "score", Value: -1}},
+		},
+		{
+			// covers course leaderboards, which total a course's scores per user
+			Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "user_id", Value: 1}, {Key: "score", Value: 1}},
+		},
+		{
+			// serves the course grade job looking for courses with new scores
+			Keys: bson.D{{Key: "course_id", Value: 1}, {Key: "updated_at", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	totals := r.DB.Database("digital-voter").Collection("score_totals")
+	_, err = totals.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "total", Value: -1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	return err
+}
+
+// ChangedSince reports whether any score of the course changed after since.
+func (r *ScoreRepository) ChangedSince(ctx context.Context, courseID primitive.ObjectID, since time.Time) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	count, err := collection.CountDocuments(ctx, bson.M{"course_id": courseID, "updated_at": bson.M{"$gt": since}}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+func (r *ScoreRepository) FindByUserAndAssignment(ctx context.Context, userID, assignmentID primitive.ObjectID) (*entity.Score, error) {
+	score := &entity.Score{}
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	err := collection.FindOne(ctx, bson.M{"user_id": userID, "assignment_id": assignmentID}).Decode(score)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return score, nil
+}
+
+func (r *ScoreRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]entity.Score, error) {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	cursor, err := collection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	scores := []entity.Score{}
+	if err = cursor.All(ctx, &scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// FindByUsersAndAssignments returns the scores of any of the users for any of
+// the assignments.
+func (r *ScoreRepository) FindByUsersAndAssignments(ctx context.Context, userIDs, assignmentIDs []primitive.ObjectID) ([]entity.Score, error) {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	filter := bson.M{"user_id": bson.M{"$in": userIDs}, "assignment_id": bson.M{"$in": assignmentIDs}}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	scores := []entity.Score{}
+	if err = cursor.All(ctx, &scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// SaveProjection writes the projected score fields. Callers keep it in step with
+// the ledger by calling it in the same transaction as ScoreLedgerRepository.Append.
+func (r *ScoreRepository) SaveProjection(ctx context.Context, score *entity.Score) error {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	now := util.NowInWIB()
+	score.UpdatedAt = &now
+	filter := bson.M{"user_id": score.UserID, "assignment_id": score.AssignmentID}
+	set := bson.M{
+		"score":            score.Score,
+		"submission_score": score.SubmissionScore,
+		"adjustment":       score.Adjustment,
+		"attempts":         score.Attempts,
+		"updated_at":       score.UpdatedAt,
+	}
+	if score.CourseID != nil {
+		set["course_id"] = score.CourseID
+	}
+	update := bson.M{
+		"$set": set,
+		"$setOnInsert": bson.M{
+			"created_at": now,
+		},
+	}
+	if _, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return err
+	}
+	return r.refreshTotal(ctx, score.UserID)
+}
+
+// refreshTotal recomputes the user's entry in score_totals, which keeps each
+// user's total over all their scores together with their name and leaderboard
+// visibility, so global leaderboards read one indexed document per user rather
+// than totalling every score.
+func (r *ScoreRepository) refreshTotal(ctx context.Context, userID primitive.ObjectID) error {
+	total, err := r.sum(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return err
+	}
+	user := &entity.User{}
+	users := r.DB.Database("digital-voter").Collection("users")
+	err = users.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"name": 1, "leaderboard_opt_out": 1})).Decode(user)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	totals := r.DB.Database("digital-voter").Collection("score_totals")
+	update := bson.M{"$set": bson.M{
+		"total":               total,
+		"name":                user.Name,
+		"leaderboard_opt_out": user.LeaderboardOptOut,
+		"updated_at":          util.NowInWIB(),
+	}}
+	_, err = totals.UpdateOne(ctx, bson.M{"_id": userID}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// RebuildTotals recomputes score_totals from every score, e.g. after the score
+// projections were rebuilt.
+func (r *ScoreRepository) RebuildTotals(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$user_id", "total": bson.M{"$sum": "$score"}}}},
+		{{Key: "$lookup", Value: bson.M{"from": "users", "localField": "_id", "foreignField": "_id", "as": "user"}}},
+		{{Key: "$unwind", Value: "$user"}},
+		{{Key: "$project", Value: bson.M{
+			"total":               1,
+			"name":                "$user.name",
+			"leaderboard_opt_out": bson.M{"$ifNull": bson.A{"$user.leaderboard_opt_out", false}},
+			"updated_at":          "$$NOW",
+		}}},
+		{{Key: "$merge", Value: bson.M{"into": "score_totals", "whenMatched": "replace", "whenNotMatched": "insert"}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+// SetCourse moves every score of an assignment to the course the assignment now
+// belongs to, so course totals and leaderboards follow the assignment.
+func (r *ScoreRepository) SetCourse(ctx context.Context, assignmentID primitive.ObjectID, courseID *primitive.ObjectID) error {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	update := bson.M{"$set": bson.M{"course_id": courseID}}
+	if courseID == nil {
+		update = bson.M{"$unset": bson.M{"course_id": ""}}
+	}
+	_, err := collection.UpdateMany(ctx, bson.M{"assignment_id": assignmentID}, update)
+	return err
+}
+
+func (r *ScoreRepository) FindAll(ctx context.Context) ([]entity.Score, error) {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	scores := []entity.Score{}
+	if err = cursor.All(ctx, &scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// WithTransaction runs fn in a MongoDB transaction. Repository calls made with
+// the context passed to fn take part in it.
+func (r *ScoreRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := r.DB.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
+func (r *ScoreRepository) SumByUser(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	return r.sum(ctx, bson.M{"user_id": userID})
+}
+
+func (r *ScoreRepository) SumByUserInCourse(ctx context.Context, userID, courseID primitive.ObjectID) (int, error) {
+	return r.sum(ctx, bson.M{"user_id": userID, "course_id": courseID})
+}
+
+// SumByUsers totals the scores of each user, optionally only within a course.
+// Users without scores are left out of the map.
+func (r *ScoreRepository) SumByUsers(ctx context.Context, userIDs []primitive.ObjectID, courseID *primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	match := bson.M{"user_id": bson.M{"$in": userIDs}}
+	if courseID != nil {
+		match["course_id"] = *courseID
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id", "total": bson.M{"$sum": "$score"}}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var result []struct {
+		UserID primitive.ObjectID `bson:"_id"`
+		Total  int                `bson:"total"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	totals := make(map[primitive.ObjectID]int, len(result))
+	for _, row := range result {
+		totals[row.UserID] = row.Total
+	}
+	return totals, nil
+}
+
+func (r *ScoreRepository) sum(ctx context.Context, filter bson.M) (int, error) {
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$score"}}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	var result []struct {
+		Total int `bson:"total"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return result[0].Total, nil
+}
+
+// leaderboardPipeline totals scores per user and ranks them competition style:
+// equal totals share a rank and the next rank skips ahead (1, 2, 2, 4).
+func leaderboardPipeline(filter entity.LeaderboardFilter) mongo.Pipeline {
+	match := bson.M{}
+	if filter.CourseID != nil {
+		match["course_id"] = *filter.CourseID
+	}
+	if filter.AssignmentID != nil {
+		match["assignment_id"] = *filter.AssignmentID
+	}
+	if filter.UserIDs != nil {
+		match["user_id"] = bson.M{"$in": filter.UserIDs}
+	}
+	pipeline := mongo.Pipeline{}
+	if len(match) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: match}})
+	}
+
+	visible := bson.M{"user.leaderboard_opt_out": bson.M{"$ne": true}}
+	if filter.IncludeUserID != nil {
+		visible = bson.M{"$or": bson.A{visible, bson.M{"_id": *filter.IncludeUserID}}}
+	}
+
+	return append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$user_id", "total": bson.M{"$sum": "$score"}}}},
+		bson.D{{Key: "$lookup", Value: bson.M{"from": "users", "localField": "_id", "foreignField": "_id", "as": "user"}}},
+		bson.D{{Key: "$unwind", Value: "$user"}},
+		bson.D{{Key: "$match", Value: visible}},
+		bson.D{{Key: "$setWindowFields", Value: bson.M{
+			"sortBy": bson.D{{Key: "total", Value: -1}, {Key: "user.name", Value: 1}, {Key: "_id", Value: 1}},
+			"output": bson.M{
+				"rank":     bson.M{"$rank": bson.M{}},
+				"position": bson.M{"$documentNumber": bson.M{}},
+			},
+		}}},
+		bson.D{{Key: "$project", Value: bson.M{"name": "$user.name", "total": 1, "rank": 1, "position": 1}}},
+	)
+}
+
+// Leaderboard returns a page of the ranking. Rankings over all assignments
+// outside any course are read from score_totals; the others total the
+// matching scores.
+func (r *ScoreRepository) Leaderboard(ctx context.Context, filter entity.LeaderboardFilter, page, limit int) ([]entity.LeaderboardEntry, int64, error) {
+	if filter.CourseID == nil && filter.AssignmentID == nil {
+		match := totalsFilter(filter)
+		totals := r.DB.Database("digital-voter").Collection("score_totals")
+		count, err := totals.CountDocuments(ctx, match)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries, err := r.rankedTotals(ctx, match, (page-1)*limit, limit)
+		if err != nil {
+			return nil, 0, err
+		}
+		return entries, count, nil
+	}
+
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	pipeline := append(leaderboardPipeline(filter), bson.D{{Key: "$facet", Value: bson.M{
+		"entries": bson.A{
+			bson.M{"$sort": bson.M{"position": 1}},
+			bson.M{"$skip": (page - 1) * limit},
+			bson.M{"$limit": limit},
+		},
+		"count": bson.A{bson.M{"$count": "total"}},
+	}}})
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	var result []struct {
+		Entries []entity.LeaderboardEntry `bson:"entries"`
+		Count   []struct {
+			Total int64 `bson:"total"`
+		} `bson:"count"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, 0, err
+	}
+	if len(result) == 0 || len(result[0].Count) == 0 {
+		return []entity.LeaderboardEntry{}, 0, nil
+	}
+	return result[0].Entries, result[0].Count[0].Total, nil
+}
+
+// LeaderboardAround returns the user's entry and every entry within distance
+// positions of it, or nil when the user has no scores under the filter.
+func (r *ScoreRepository) LeaderboardAround(ctx context.Context, filter entity.LeaderboardFilter, userID primitive.ObjectID, distance int) (*entity.LeaderboardEntry, []entity.LeaderboardEntry, error) {
+	if filter.CourseID == nil && filter.AssignmentID == nil {
+		return r.totalsAround(ctx, filter, userID, distance)
+	}
+
+	collection := r.DB.Database("digital-voter").Collection("scores")
+	pipeline := append(leaderboardPipeline(filter), bson.D{{Key: "$match", Value: bson.M{"_id": userID}}})
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	var mine []entity.LeaderboardEntry
+	if err = cursor.All(ctx, &mine); err != nil {
+		return nil, nil, err
+	}
+	if len(mine) == 0 {
+		return nil, []entity.LeaderboardEntry{}, nil
+	}
+
+	position := mine[0].Position
+	pipeline = append(leaderboardPipeline(filter),
+		bson.D{{Key: "$match", Value: bson.M{"position": bson.M{"$gte": position - distance, "$lte": position + distance}}}},
+		bson.D{{Key: "$sort", Value: bson.M{"position": 1}}},
+	)
+	cursor, err = collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	neighbours := []entity.LeaderboardEntry{}
+	if err = cursor.All(ctx, &neighbours); err != nil {
+		return nil, nil, err
+	}
+	return &mine[0], neighbours, nil
+}
+
+// totalsFilter selects the score_totals entries the leaderboard shows.
+func totalsFilter(filter entity.LeaderboardFilter) bson.M {
+	visible := bson.M{"leaderboard_opt_out": bson.M{"$ne": true}}
+	if filter.IncludeUserID != nil {
+		visible = bson.M{"$or": bson.A{visible, bson.M{"_id": *filter.IncludeUserID}}}
+	}
+	if filter.UserIDs != nil {
+		visible = bson.M{"$and": bson.A{visible, bson.M{"_id": bson.M{"$in": filter.UserIDs}}}}
+	}
+	return visible
+}
+
+// rankedTotals reads limit score_totals entries from position skip+1 on, in
+// leaderboard order, and ranks them competition style. Only the first entry's
+// rank needs counting the totals above it; the others follow from the page.
+func (r *ScoreRepository) rankedTotals(ctx context.Context, match bson.M, skip, limit int) ([]entity.LeaderboardEntry, error) {
+	totals := r.DB.Database("digital-voter").Collection("score_totals")
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "total", Value: -1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit))
+	cursor, err := totals.Find(ctx, match, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	entries := []entity.LeaderboardEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	above, err := totals.CountDocuments(ctx, bson.M{"$and": bson.A{match, bson.M{"total": bson.M{"$gt": entries[0].Total}}}})
+	if err != nil {
+		return nil, err
+	}
+	rank := int(above) + 1
+	for i := range entries {
+		entries[i].Position = skip + i + 1
+		if i > 0 && entries[i].Total != entries[i-1].Total {
+			rank = entries[i].Position
+		}
+		entries[i].Rank = rank
+	}
+	return entries, nil
+}
+
+func (r *ScoreRepository) totalsAround(ctx context.Context, filter entity.LeaderboardFilter, userID primitive.ObjectID, distance int) (*entity.LeaderboardEntry, []entity.LeaderboardEntry, error) {
+	match := totalsFilter(filter)
+	totals := r.DB.Database("digital-voter").Collection("score_totals")
+	mine := &entity.LeaderboardEntry{}
+	err := totals.FindOne(ctx, bson.M{"$and": bson.A{match, bson.M{"_id": userID}}}).Decode(mine)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, []entity.LeaderboardEntry{}, nil
+		}
+		return nil, nil, err
+	}
+
+	ahead := bson.M{"$or": bson.A{
+		bson.M{"total": bson.M{"$gt": mine.Total}},
+		bson.M{"total": mine.Total, "name": bson.M{"$lt": mine.Name}},
+		bson.M{"total": mine.Total, "name": mine.Name, "_id": bson.M{"$lt": mine.UserID}},
+	}}
+	before, err := totals.CountDocuments(ctx, bson.M{"$and": bson.A{match, ahead}})
+	if err != nil {
+		return nil, nil, err
+	}
+	position := int(before) + 1
+	start := max(position-distance, 1)
+	neighbours, err := r.rankedTotals(ctx, match, start-1, position+distance-start+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range neighbours {
+		if neighbours[i].UserID == userID {
+			return &neighbours[i], neighbours, nil
+		}
+	}
+	return nil, []entity.LeaderboardEntry{}, nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ScoreLedgerRepository deliberately has no update or delete methods: the ledger
+// is append-only and mistakes are fixed with compensating entries.
+type ScoreLedgerRepository struct {
+	DB *mongo.Client
+}
+
+func NewScoreLedgerRepository(db *mongo.Client) *ScoreLedgerRepository {
+	return &ScoreLedgerRepository{
+		DB: db,
+	}
+}
+
+func (r *ScoreLedgerRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("score_ledger")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "assignment_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "assignment_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func (r *ScoreLedgerRepository) Append(ctx context.Context, entry *entity.ScoreLedgerEntry) error {
+	collection := r.DB.Database("digital-voter").Collection("score_ledger")
+	entry.CreatedAt = util.NowInWIB()
+	result, err := collection.InsertOne(ctx, entry)
+	if err != nil {
+		return err
+	}
+	entry.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *ScoreLedgerRepository) CountByUserAndAssignment(ctx context.Context, userID, assignmentID primitive.ObjectID) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("score_ledger")
+	return collection.CountDocuments(ctx, bson.M{"user_id": userID, "assignment_id": assignmentID})
+}
+
+// FindLatest returns the user's newest entry for the assignment of one of the
+// given kinds, or nil when there is none.
+func (r *ScoreLedgerRepository) FindLatest(ctx context.Context, userID, assignmentID primitive.ObjectID, kinds ...string) (*entity.ScoreLedgerEntry, error) {
+	entry := &entity.ScoreLedgerEntry{}
+	collection := r.DB.Database("digital-voter").Collection("score_ledger")
+	filter := bson.M{"user_id": userID, "assignment_id": assignmentID, "kind": bson.M{"$in": kinds}}
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	err := collection.FindOne(ctx, filter, findOptions).Decode(entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// FindHistory returns entries newest first. Nil ids are not filtered on.
+func (r *ScoreLedgerRepository) FindHistory(ctx context.Context, userIDs []primitive.ObjectID, assignmentID *primitive.ObjectID, page, limit int) ([]entity.ScoreLedgerEntry, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("score_ledger")
+	filter := bson.M{}
+	if userIDs != nil {
+		filter["user_id"] = bson.M{"$in": userIDs}
+	}
+	if assignmentID != nil {
+		filter["assignment_id"] = *assignmentID
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := []entity.ScoreLedgerEntry{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// RebuildProjections recomputes every Score document from the ledger sums,
+// leaving attempt counts untouched.
+func (r *ScoreLedgerRepository) RebuildProjections(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("score_ledger")
+	correction := bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$kind", entity.ScoreChangeCorrection}}, "$delta", 0}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":        bson.M{"user_id": "$user_id", "assignment_id": "$assignment_id"},
+			"score":      bson.M{"$sum": "$delta"},
+			"adjustment": bson.M{"$sum": correction},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":              0,
+			"user_id":          "$_id.user_id",
+			"assignment_id":    "$_id.assignment_id",
+			"score":            1,
+			"adjustment":       1,
+			"submission_score": bson.M{"$subtract": bson.A{"$score", "$adjustment"}},
+			"updated_at":       "$$NOW",
+		}}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           "scores",
+			"on":             bson.A{"user_id", "assignment_id"},
+			"whenMatched":    "merge",
+			"whenNotMatched": "insert",
+		}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
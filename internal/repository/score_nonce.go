@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScoreNonceRepository struct {
+	DB *mongo.Client
+}
+
+func NewScoreNonceRepository(db *mongo.Client) *ScoreNonceRepository {
+	return &ScoreNonceRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes lets each nonce be used once per key and has MongoDB drop
+// nonces once they expire.
+func (r *ScoreNonceRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("score_nonces")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key_id", Value: 1}, {Key: "nonce", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+// Use stores the nonce and reports false when it had been used before.
+func (r *ScoreNonceRepository) Use(ctx context.Context, nonce *entity.ScoreNonce) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("score_nonces")
+	if _, err := collection.InsertOne(ctx, nonce); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SimilarityReportRepository struct {
+	DB *mongo.Client
+}
+
+func NewSimilarityReportRepository(db *mongo.Client) *SimilarityReportRepository {
+	return &SimilarityReportRepository{
+		DB: db,
+	}
+}
+
+func (r *SimilarityReportRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("similarity_reports")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "assignment_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "requested_at", Value: 1}}},
+	})
+	return err
+}
+
+func (r *SimilarityReportRepository) FindByAssignment(ctx context.Context, assignmentID primitive.ObjectID) (*entity.SimilarityReport, error) {
+	report := &entity.SimilarityReport{}
+	collection := r.DB.Database("digital-voter").Collection("similarity_reports")
+	err := collection.FindOne(ctx, bson.M{"assignment_id": assignmentID}).Decode(report)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// SaveAllowList replaces the starter code the assignment's checks ignore. It
+// applies from the next check on.
+func (r *SimilarityReportRepository) SaveAllowList(ctx context.Context, report *entity.SimilarityReport) error {
+	collection := r.DB.Database("digital-voter").Collection("similarity_reports")
+	now := util.NowInWIB()
+	update := bson.M{
+		"$set": bson.M{"allow_list": report.AllowList, "updated_at": now},
+		"$setOnInsert": bson.M{
+			"threshold":   entity.DefaultSimilarityThreshold,
+			"pairs":       []entity.SimilarityPair{},
+			"started_at":  nil,
+			"finished_at": nil,
+		},
+	}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, bson.M{"assignment_id": report.AssignmentID}, update, updateOptions).Decode(report)
+}
+
+// Queue asks for the assignment to be checked again. A check already running
+// is superseded: its worker can no longer finish it.
+func (r *SimilarityReportRepository) Queue(ctx context.Context, report *entity.SimilarityReport) error {
+	collection := r.DB.Database("digital-voter").Collection("similarity_reports")
+	now := util.NowInWIB()
+	update := bson.M{
+		"$set": bson.M{
+			"status":       entity.SimilarityQueued,
+			"threshold":    report.Threshold,
+			"tries":        0,
+			"error":        "",
+			"requested_by": report.RequestedBy,
+			"requested_at": now,
+			"updated_at":   now,
+		},
+		"$setOnInsert": bson.M{
+			"allow_list":  []entity.StarterCode{},
+			"pairs":       []entity.SimilarityPair{},
+			"started_at":  nil,
+			"finished_at": nil,
+		},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	updateOptions := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, bson.M{"assignment_id": report.AssignmentID}, update, updateOptions).Decode(report)
+}
+
+// Claim takes the oldest queued check, or a running one whose lock ran out,
+// and locks it until lockedUntil. It returns nil when there is nothing to
+// check. Checks already tried maxTries times are left for FailStale.
+func (r *SimilarityReportRepository) Claim(ctx context.Context, now, lockedUntil time.Time, maxTries int) (*entity.SimilarityReport, error) {
+	collection := r.DB.Database("digital-voter").Collection("similarity_reports")
+	filter := bson.M{
+		"tries": bson.M{"$lt": maxTries},
+		"$or": []bson.M{
+			{"status": entity.SimilarityQueued},
+			{"status": entity.SimilarityRunning, "locked_until": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": entity.SimilarityRunning, "started_at": now, "locked_until": lockedUntil},
+		"$inc": bson.M{"tries": 1},
+	}
+	updateOptions := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "requested_at", Value: 1}}).
+		SetReturnDocument(options.After)
+	report := &entity.SimilarityReport{}
+	err := collection.FindOneAndUpdate(ctx, filter, update, updateOptions).Decode(report)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+// Finish stores the outcome of a claimed check and releases it. It reports
+// false when the check was queued again or claimed by another worker meanwhile.
+func (r *SimilarityReportRepository) Finish(ctx context.Context, report *entity.SimilarityReport) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("similarity_reports")
+	filter := bson.M{
+		"_id":          report.ID,
+		"status":       entity.SimilarityRunning,
+		"tries":        report.Tries,
+		"locked_until": report.LockedUntil,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      report.Status,
+			"submissions": report.Submissions,
+			"compared":    report.Compared,
+			"pairs":       report.Pairs,
+			"error":       report.Error,
+			"finished_at": report.FinishedAt,
+		},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// FailStale marks checks that used up their tries as failed, and returns how
+// many there were.
+func (r *SimilarityReportRepository) FailStale(ctx context.Context, now time.Time, maxTries int) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("similarity_reports")
+	filter := bson.M{
+		"tries": bson.M{"$gte": maxTries},
+		"$or": []bson.M{
+			{"status": entity.SimilarityQueued},
+			{"status": entity.SimilarityRunning, "locked_until": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{
+		"$set":   bson.M{"status": entity.SimilarityFailed, "finished_at": now},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	result, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
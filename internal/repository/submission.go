@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SubmissionRepository struct {
+	DB *mongo.Client
+}
+
+func NewSubmissionRepository(db *mongo.Client) *SubmissionRepository {
+	return &SubmissionRepository{
+		DB: db,
+	}
+}
+
+// EnsureIndexes makes attempt numbers unique per user and assignment, so two
+// concurrent submissions cannot both claim the same attempt.
+func (r *SubmissionRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("submissions")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "assignment_id", Value: 1}, {Key: "attempt", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	return err
+}
+
+func (r *SubmissionRepository) Create(ctx context.Context, submission *entity.Submission) error {
+	collection := r.DB.Database("digital-voter").Collection("submissions")
+	if submission.SubmittedAt.IsZero() {
+		submission.SubmittedAt = util.NowInWIB()
+	}
+	result, err := collection.InsertOne(ctx, submission)
+	if err != nil {
+		return err
+	}
+	submission.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *SubmissionRepository) FindByUserAndAssignment(ctx context.Context, userID, assignmentID primitive.ObjectID) ([]entity.Submission, error) {
+	collection := r.DB.Database("digital-voter").Collection("submissions")
+	filter := bson.M{"user_id": userID, "assignment_id": assignmentID}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "attempt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	submissions := []entity.Submission{}
+	if err = cursor.All(ctx, &submissions); err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+// BackfillRawScores gives submissions recorded before late penalties existed a
+// raw score equal to their score.
+func (r *SubmissionRepository) BackfillRawScores(ctx context.Context) (int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("submissions")
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{"raw_score": "$score"}}}}
+	result, err := collection.UpdateMany(ctx, bson.M{"raw_score": bson.M{"$exists": false}}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"regexp"
 	"time"
 
 	"github.com/Erwanph/be-wan-central-lab/internal/entity"
@@ -9,6 +10,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepository struct {
@@ -21,6 +23,14 @@ func NewUserRepository(db *mongo.Client) *UserRepository {
 	}
 }
 
+func (r *UserRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.DB.Database("digital-voter").Collection("users")
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}}},
+	})
+	return err
+}
+
 func (r *UserRepository) CreateCustomUser(ctx context.Context, user *entity.User) error {
 	collection := r.DB.Database("digital-voter").Collection("users")
 	user.CreatedAt = time.Now()
@@ -147,21 +157,208 @@ func (r *UserRepository) UpdateProfiles(ctx context.Context, user *entity.User,
 			"updatedAt": user.UpdatedAt,
 		},
 	}
+	if user.Email != oldEmail {
+		update["$unset"] = bson.M{
+			"email_undeliverable":        "",
+			"email_undeliverable_reason": "",
+			"email_undeliverable_at":     "",
+		}
+	}
+	if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+		return err
+	}
+	// leaderboards sort ties by the name kept with the user's score total
+	totals := r.DB.Database("digital-voter").Collection("score_totals")
+	_, err := totals.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"name": user.Name}})
+	return err
+}
+
+func (r *UserRepository) UpdateNotificationPreferences(ctx context.Context, user *entity.User) error {
+	collection := r.DB.Database("digital-voter").Collection("users")
+
+	filter := bson.M{"_id": user.ID}
+	update := bson.M{
+		"$set": bson.M{
+			"timezone":                 user.Timezone,
+			"notification_preferences": user.NotificationPreferences,
+			"updated_at":               util.NowInWIB(),
+		},
+	}
 	_, err := collection.UpdateOne(ctx, filter, update)
 	return err
 }
 
-func (r *UserRepository) UpdateScore(ctx context.Context, user *entity.User) error {
-    collection := r.DB.Database("digital-voter").Collection("users")
-    
-    filter := bson.M{"email": user.Email}
-    update := bson.M{
-        "$set": bson.M{
-            "score": user.Score,
-            "updated_at": util.NowInWIB(),
-        },
-    }
-    
-    _, err := collection.UpdateOne(ctx, filter, update)
-    return err
+func (r *UserRepository) UpdateLastDigestAt(ctx context.Context, userID primitive.ObjectID, sentAt time.Time) error {
+	collection := r.DB.Database("digital-voter").Collection("users")
+
+	filter := bson.M{"_id": userID}
+	update := bson.M{
+		"$set": bson.M{
+			"last_digest_at": sentAt,
+		},
+	}
+	_, err := collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// MarkEmailUndeliverable reports whether a user with the address exists.
+func (r *UserRepository) MarkEmailUndeliverable(ctx context.Context, email, reason string, at time.Time) (bool, error) {
+	collection := r.DB.Database("digital-voter").Collection("users")
+
+	filter := bson.M{"email": email}
+	update := bson.M{
+		"$set": bson.M{
+			"email_undeliverable":        true,
+			"email_undeliverable_reason": reason,
+			"email_undeliverable_at":     at,
+		},
+	}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *UserRepository) ClearEmailUndeliverable(ctx context.Context, userID primitive.ObjectID) error {
+	collection := r.DB.Database("digital-voter").Collection("users")
+
+	filter := bson.M{"_id": userID}
+	update := bson.M{
+		"$unset": bson.M{
+			"email_undeliverable":        "",
+			"email_undeliverable_reason": "",
+			"email_undeliverable_at":     "",
+		},
+	}
+	_, err := collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// FindAll lists users newest first. A non-nil ids limits the list to those users.
+func (r *UserRepository) FindAll(ctx context.Context, ids []primitive.ObjectID, search string, undeliverableOnly bool, page, limit int) ([]entity.User, int64, error) {
+	collection := r.DB.Database("digital-voter").Collection("users")
+
+	filter := bson.M{}
+	if ids != nil {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	if search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(search), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"email": pattern}, bson.M{"name": pattern}}
+	}
+	if undeliverableOnly {
+		filter["email_undeliverable"] = true
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	users := []entity.User{}
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *UserRepository) FindWithLegacyScore(ctx context.Context) ([]entity.User, error) {
+	collection := r.DB.Database("digital-voter").Collection("users")
+	cursor, err := collection.Find(ctx, bson.M{"score": bson.M{"$gt": 0}})
+	if err != nil {
+		return nil, err
+	}
+	users := []entity.User{}
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FindIDsByRole treats users without a stored role as students.
+func (r *UserRepository) FindIDsByRole(ctx context.Context, role string) ([]primitive.ObjectID, error) {
+	collection := r.DB.Database("digital-voter").Collection("users")
+	filter := bson.M{"role": role}
+	if role == entity.RoleStudent {
+		filter = bson.M{"role": bson.M{"$in": bson.A{nil, "", entity.RoleStudent}}}
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	ids := []primitive.ObjectID{}
+	for cursor.Next(ctx) {
+		var user entity.User
+		if err = cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		ids = append(ids, user.ID)
+	}
+	return ids, cursor.Err()
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, userID primitive.ObjectID, role string) error {
+	collection := r.DB.Database("digital-voter").Collection("users")
+
+	filter := bson.M{"_id": userID}
+	update := bson.M{
+		"$set": bson.M{
+			"role":       role,
+			"updated_at": util.NowInWIB(),
+		},
+	}
+	_, err := collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (r *UserRepository) UpdateLeaderboardOptOut(ctx context.Context, userID primitive.ObjectID, optOut bool) error {
+	collection := r.DB.Database("digital-voter").Collection("users")
+
+	filter := bson.M{"_id": userID}
+	update := bson.M{
+		"$set": bson.M{
+			"leaderboard_opt_out": optOut,
+			"updated_at":          util.NowInWIB(),
+		},
+	}
+	if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+		return err
+	}
+	totals := r.DB.Database("digital-voter").Collection("score_totals")
+	_, err := totals.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"leaderboard_opt_out": optOut}})
+	return err
+}
+
+func (r *UserRepository) FindWithLegacyCohort(ctx context.Context) ([]entity.User, error) {
+	collection := r.DB.Database("digital-voter").Collection("users")
+	cursor, err := collection.Find(ctx, bson.M{"cohort": bson.M{"$nin": bson.A{nil, ""}}})
+	if err != nil {
+		return nil, err
+	}
+	users := []entity.User{}
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *UserRepository) FindByEmails(ctx context.Context, emails []string) ([]entity.User, error) {
+	collection := r.DB.Database("digital-voter").Collection("users")
+	cursor, err := collection.Find(ctx, bson.M{"email": bson.M{"$in": emails}})
+	if err != nil {
+		return nil, err
+	}
+	users := []entity.User{}
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
 }
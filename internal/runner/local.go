@@ -0,0 +1,194 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for limits a job leaves at zero.
+const (
+	DefaultTime        = 2 * time.Second
+	DefaultMemoryBytes = 256 << 20
+	DefaultOutputBytes = 64 << 10
+	DefaultProcesses   = 64
+)
+
+// LocalRunner runs jobs as processes on this host, each in a fresh directory
+// under WorkDir that is removed afterwards. Programs get a minimal environment
+// and rlimits on CPU time, address space, file size, open files and processes.
+// They only run on Linux, with the service running as root: each is confined
+// to a root of its own holding the system's binaries and libraries read-only,
+// its job directory as /work and a private /tmp, runs as UID in a pid
+// namespace of its own with no network, and, when CgroupParent names a
+// writable cgroup v2 directory, in a cgroup of its own that also caps memory.
+// Elsewhere Run fails with ErrUnavailable.
+type LocalRunner struct {
+	WorkDir      string
+	CgroupParent string
+	// UID is the unprivileged user programs run as. It should own nothing else
+	// on the host; without it no program is run.
+	UID int
+	// AllowNetwork leaves programs on the host's network. Only use it where the
+	// host is firewalled.
+	AllowNetwork bool
+}
+
+func NewLocalRunner(workDir string, cgroupParent string, uid int, allowNetwork bool) *LocalRunner {
+	return &LocalRunner{WorkDir: workDir, CgroupParent: cgroupParent, UID: uid, AllowNetwork: allowNetwork}
+}
+
+func (r *LocalRunner) Run(ctx context.Context, job *Job) (*Report, error) {
+	if len(job.Run) == 0 {
+		return nil, errors.New("job has no run command")
+	}
+	dir, err := os.MkdirTemp(r.WorkDir, "job-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	for name, data := range job.Files {
+		path := filepath.Join(dir, filepath.Clean("/"+name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return nil, err
+		}
+	}
+	// Programs run as the grader uid, which needs to be able to write build
+	// output.
+	if err := os.Chmod(dir, 0o777); err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	if len(job.Build) > 0 {
+		outcome, err := r.start(ctx, dir, job.Build, nil, withDefaults(job.BuildLimits))
+		if err != nil {
+			return nil, err
+		}
+		report.Build = outcome
+		if report.BuildFailed() {
+			return report, nil
+		}
+	}
+	for _, input := range job.Inputs {
+		outcome, err := r.start(ctx, dir, job.Run, input, withDefaults(job.RunLimits))
+		if err != nil {
+			return nil, err
+		}
+		report.Runs = append(report.Runs, *outcome)
+	}
+	return report, nil
+}
+
+func withDefaults(limits Limits) Limits {
+	if limits.Time <= 0 {
+		limits.Time = DefaultTime
+	}
+	if limits.MemoryBytes <= 0 {
+		limits.MemoryBytes = DefaultMemoryBytes
+	}
+	if limits.OutputBytes <= 0 {
+		limits.OutputBytes = DefaultOutputBytes
+	}
+	if limits.Processes <= 0 {
+		limits.Processes = DefaultProcesses
+	}
+	return limits
+}
+
+// rlimitScript sets the rlimits in a shell before it execs the command, since
+// Go cannot set them on a child process directly. CPU time gets a second of
+// slack over the wall clock limit, which is enforced separately.
+func rlimitScript(limits Limits) string {
+	cpu := int64(limits.Time/time.Second) + 1
+	return strings.Join([]string{
+		"ulimit -t " + strconv.FormatInt(cpu, 10),
+		"ulimit -v " + strconv.FormatInt(limits.MemoryBytes>>10, 10),
+		"ulimit -f " + strconv.FormatInt(max(limits.OutputBytes>>10, 1)*16, 10),
+		"ulimit -n 64",
+		`exec "$0" "$@"`,
+	}, "; ")
+}
+
+func (r *LocalRunner) start(parent context.Context, dir string, command []string, input []byte, limits Limits) (*Outcome, error) {
+	ctx, cancel := context.WithTimeout(parent, limits.Time)
+	defer cancel()
+
+	cmd := exec.Command("/bin/sh", append([]string{"-c", rlimitScript(limits)}, command...)...)
+	cmd.Dir = dir
+	cmd.Env = []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=/work", "TMPDIR=/tmp", "LANG=C.UTF-8"}
+	cmd.Stdin = bytes.NewReader(input)
+	stdout := &limitedBuffer{limit: limits.OutputBytes}
+	stderr := &limitedBuffer{limit: limits.OutputBytes}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	// Do not wait on a background child that keeps the output pipes open; it
+	// is killed with the rest of the process group below.
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	sandbox, err := r.isolate(cmd, limits)
+	if err != nil {
+		return nil, err
+	}
+	defer sandbox.release()
+
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", command[0], err)
+	}
+	sandbox.started()
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var waitErr error
+	timedOut := false
+	select {
+	case waitErr = <-done:
+	case <-ctx.Done():
+		timedOut = true
+		sandbox.kill(cmd)
+		waitErr = <-done
+	}
+	// Children the program left behind share its process group.
+	sandbox.kill(cmd)
+
+	outcome := &Outcome{
+		Status:   StatusOK,
+		Stdout:   stdout.data,
+		Stderr:   stderr.data,
+		Duration: time.Since(started),
+	}
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+	if err := sandbox.failed(); err != nil {
+		return nil, err
+	}
+	var exitErr *exec.ExitError
+	if waitErr != nil && !errors.As(waitErr, &exitErr) && !errors.Is(waitErr, exec.ErrWaitDelay) {
+		return nil, waitErr
+	}
+	if cmd.ProcessState != nil {
+		outcome.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	switch {
+	case timedOut || signalled(cmd, "cpu"):
+		outcome.Status = StatusTimeout
+	case sandbox.outOfMemory():
+		outcome.Status = StatusMemoryLimit
+	case stdout.overflow || stderr.overflow || signalled(cmd, "fsize"):
+		outcome.Status = StatusOutputLimit
+	case outcome.ExitCode != 0:
+		outcome.Status = StatusRuntimeError
+	}
+	return outcome, nil
+}
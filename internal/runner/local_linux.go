@@ -0,0 +1,175 @@
+//go:build linux
+
+package runner
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+type sandbox struct {
+	root   string
+	errors *os.File // read end of the sandbox helper's setup error pipe
+	report *os.File // write end, handed to the helper
+	cgroup string
+	file   *os.File
+}
+
+// isolate has the command started through the sandbox helper, which confines
+// it to the job directory in its own mount, pid, IPC, UTS and network
+// namespaces and runs it as the grader uid, in its own process group and, when
+// a cgroup parent is configured, in a new cgroup below it. The helper is the
+// pid namespace's init, so killing it kills everything the program started. Setting that up needs root;
+// an unprivileged service cannot run programs at all.
+func (r *LocalRunner) isolate(cmd *exec.Cmd, limits Limits) (*sandbox, error) {
+	if os.Geteuid() != 0 || r.UID <= 0 {
+		return nil, ErrUnavailable
+	}
+	root, err := os.MkdirTemp(r.WorkDir, "root-")
+	if err != nil {
+		return nil, err
+	}
+	box := &sandbox{root: root}
+	if box.errors, box.report, err = os.Pipe(); err != nil {
+		box.release()
+		return nil, err
+	}
+	cmd.ExtraFiles = []*os.File{box.report}
+	cmd.Args = append([]string{sandboxInit, root, cmd.Dir, strconv.Itoa(r.UID), strconv.Itoa(limits.Processes), cmd.Path}, cmd.Args...)
+	cmd.Path = "/proc/self/exe"
+
+	attr := &syscall.SysProcAttr{
+		Setpgid:    true,
+		Pdeathsig:  syscall.SIGKILL,
+		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWPID | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+	}
+	if !r.AllowNetwork {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	cmd.SysProcAttr = attr
+
+	if r.CgroupParent == "" {
+		return box, nil
+	}
+	name := make([]byte, 8)
+	_, _ = rand.Read(name)
+	box.cgroup = filepath.Join(r.CgroupParent, "job-"+hex.EncodeToString(name))
+	if err := os.Mkdir(box.cgroup, 0o755); err != nil {
+		box.cgroup = ""
+		box.release()
+		return nil, err
+	}
+	settings := map[string]string{
+		"memory.max":      strconv.FormatInt(limits.MemoryBytes, 10),
+		"memory.swap.max": "0",
+		"pids.max":        strconv.Itoa(limits.Processes + sandboxThreads),
+	}
+	for file, value := range settings {
+		if err := os.WriteFile(filepath.Join(box.cgroup, file), []byte(value), 0o644); err != nil {
+			box.release()
+			return nil, err
+		}
+	}
+	file, err := os.Open(box.cgroup)
+	if err != nil {
+		box.release()
+		return nil, err
+	}
+	box.file = file
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(file.Fd())
+	return box, nil
+}
+
+// started lets go of the helper's end of the error pipe once it has it.
+func (b *sandbox) started() {
+	b.report.Close()
+	b.report = nil
+}
+
+// failed returns the error the sandbox helper reported, wrapped in
+// ErrUnavailable, when it could not set the sandbox up. Call it after the
+// command finished.
+func (b *sandbox) failed() error {
+	message, err := io.ReadAll(b.errors)
+	if err != nil {
+		return err
+	}
+	if len(message) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnavailable, message)
+	}
+	return nil
+}
+
+// kill kills everything the program started: the whole cgroup when there is
+// one, otherwise its process group.
+func (b *sandbox) kill(cmd *exec.Cmd) {
+	if b.cgroup != "" {
+		_ = os.WriteFile(filepath.Join(b.cgroup, "cgroup.kill"), []byte("1"), 0o644)
+	}
+	if cmd.Process != nil {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// outOfMemory reports whether the cgroup killed a process for using more than
+// its memory limit.
+func (b *sandbox) outOfMemory() bool {
+	if b.cgroup == "" {
+		return false
+	}
+	events, err := os.ReadFile(filepath.Join(b.cgroup, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range bytes.Split(events, []byte("\n")) {
+		if fields := bytes.Fields(line); len(fields) == 2 && string(fields[0]) == "oom_kill" {
+			return string(fields[1]) != "0"
+		}
+	}
+	return false
+}
+
+func (b *sandbox) release() {
+	if b.file != nil {
+		b.file.Close()
+	}
+	if b.cgroup != "" {
+		_ = os.Remove(b.cgroup)
+	}
+	if b.errors != nil {
+		b.errors.Close()
+	}
+	if b.report != nil {
+		b.report.Close()
+	}
+	_ = os.Remove(b.root)
+}
+
+// signalled reports whether the program was killed for going over its CPU time
+// ("cpu") or file size ("fsize") rlimit. The sandbox helper passes that on as
+// an exit code of 128 plus the signal.
+func signalled(cmd *exec.Cmd, limit string) bool {
+	if cmd.ProcessState == nil {
+		return false
+	}
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Exited() {
+		return false
+	}
+	switch limit {
+	case "cpu":
+		return status.ExitStatus() == 128+int(syscall.SIGXCPU)
+	case "fsize":
+		return status.ExitStatus() == 128+int(syscall.SIGXFSZ)
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package runner
+
+import "os/exec"
+
+type sandbox struct{}
+
+// isolate refuses to run programs: only Linux can confine them to their job
+// directory.
+func (r *LocalRunner) isolate(cmd *exec.Cmd, limits Limits) (*sandbox, error) {
+	return nil, ErrUnavailable
+}
+
+func (b *sandbox) started() {}
+
+func (b *sandbox) failed() error { return nil }
+
+func (b *sandbox) kill(cmd *exec.Cmd) {}
+
+func (b *sandbox) outOfMemory() bool { return false }
+
+func (b *sandbox) release() {}
+
+func signalled(cmd *exec.Cmd, limit string) bool { return false }
@@ -0,0 +1,90 @@
+// Package runner builds and runs submitted programs in isolation. The grading
+// logic only sees the Runner interface, so the local process runner can be
+// swapped for one that runs jobs in containers or on remote workers.
+package runner
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Outcome statuses of one run of a program.
+const (
+	StatusOK           = "ok"
+	StatusRuntimeError = "runtime_error"
+	StatusTimeout      = "timeout"
+	StatusMemoryLimit  = "memory_limit"
+	StatusOutputLimit  = "output_limit"
+)
+
+// ErrUnavailable means the runner cannot run programs safely on this host, e.g.
+// because it cannot cut them off from the network.
+var ErrUnavailable = errors.New("runner is not available on this host")
+
+// Limits bound one run. Zero values are replaced by the runner's defaults.
+type Limits struct {
+	Time        time.Duration
+	MemoryBytes int64
+	OutputBytes int64
+	Processes   int
+}
+
+// Job is a program together with the inputs to run it on. Build is run once
+// before the inputs and is skipped when empty; Run is started once per input
+// with the input on stdin.
+type Job struct {
+	Files       map[string][]byte
+	Build       []string
+	BuildLimits Limits
+	Run         []string
+	RunLimits   Limits
+	Inputs      [][]byte
+}
+
+// Outcome is what one run of the program did. Stdout and Stderr are cut at the
+// output limit.
+type Outcome struct {
+	Status   string
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+	Duration time.Duration
+}
+
+// Report has the build outcome, nil when there was no build step, and one run
+// outcome per input. Inputs are not run when the build fails.
+type Report struct {
+	Build *Outcome
+	Runs  []Outcome
+}
+
+// BuildFailed reports whether the build step ran and did not succeed.
+func (r *Report) BuildFailed() bool {
+	return r.Build != nil && (r.Build.Status != StatusOK || r.Build.ExitCode != 0)
+}
+
+type Runner interface {
+	// Run builds and runs the job. An error means the runner itself failed and
+	// the job may be retried; anything the program does wrong is reported in
+	// the outcomes instead.
+	Run(ctx context.Context, job *Job) (*Report, error)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and notes whether
+// more were written.
+type limitedBuffer struct {
+	data     []byte
+	limit    int64
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - int64(len(b.data)); room < int64(len(p)) {
+		b.data = append(b.data, p[:max(room, 0)]...)
+		b.overflow = true
+		return len(p), nil
+	}
+	b.data = append(b.data, p...)
+	return len(p), nil
+}
@@ -0,0 +1,224 @@
+//go:build linux
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxInit is the argv[0] the service starts itself with to set up a job's
+// sandbox and then run the program as the init of the job's pid namespace,
+// since Go cannot run code between fork and exec.
+const sandboxInit = "runner-sandbox-init"
+
+// sandboxErrFD is where the sandbox helper reports a failed setup. The program
+// does not inherit it, so the runner reading nothing from it once the helper
+// exited means the program ran.
+const sandboxErrFD = 3
+
+// sandboxThreads is how many of a job's processes the helper's own threads may
+// take up, on top of the job's process limit.
+const sandboxThreads = 8
+
+// sandboxBinds are the host paths programs see, read-only: the system's
+// binaries and libraries and the files the dynamic linker and compiler
+// alternatives need. Nothing else of the host, its /proc included, is visible.
+var sandboxBinds = []string{
+	"/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32", "/usr",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d",
+}
+
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/full", "/dev/random", "/dev/urandom"}
+
+func init() {
+	if len(os.Args) > 0 && os.Args[0] == sandboxInit {
+		runtime.GOMAXPROCS(1)
+		runSandbox(os.Args[1:])
+	}
+}
+
+// runSandbox is the sandbox helper. Its arguments are the directory to build
+// the new root in, the job directory, the uid to run as, the process limit,
+// the program's path and its argv. It never returns.
+func runSandbox(args []string) {
+	err := fmt.Errorf("sandbox helper got %d arguments", len(args))
+	if len(args) >= 6 {
+		err = enterSandbox(args[0], args[1], args[2], args[3])
+		if err == nil {
+			var process *os.Process
+			process, err = os.StartProcess(args[4], args[5:], &os.ProcAttr{
+				Env:   os.Environ(),
+				Files: []*os.File{os.Stdin, os.Stdout, os.Stderr},
+			})
+			if err == nil {
+				os.Exit(reap(process.Pid))
+			}
+		}
+	}
+	fmt.Fprint(os.NewFile(sandboxErrFD, "sandbox-errors"), err)
+	os.Exit(127)
+}
+
+// reap waits for the program as the init of its pid namespace, reaping
+// whatever else the job leaves behind, and returns the code to exit with: the
+// program's own, or 128 plus the signal that killed it, as shells report it,
+// since init cannot die of a signal. Its exit kills the rest of the job.
+func reap(pid int) int {
+	for {
+		var status unix.WaitStatus
+		child, err := unix.Wait4(-1, &status, 0, nil)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return 127
+		}
+		if child != pid {
+			continue
+		}
+		if status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return status.ExitStatus()
+	}
+}
+
+// enterSandbox runs in fresh mount, pid, IPC, UTS and usually network
+// namespaces. It pivots into a tmpfs root holding the sandbox binds, the job
+// directory as /work and a private /tmp, then drops to the grader uid for good
+// with RLIMIT_NPROC at the job's process limit. That limit counts every process
+// of the grader uid, so it holds even without a cgroup.
+func enterSandbox(root, jobDir, uidArg, processesArg string) error {
+	unix.CloseOnExec(sandboxErrFD)
+	uid, err := strconv.Atoi(uidArg)
+	if err != nil || uid <= 0 {
+		return fmt.Errorf("invalid grader uid %q", uidArg)
+	}
+	processes, err := strconv.ParseUint(processesArg, 10, 64)
+	if err != nil || processes == 0 {
+		return fmt.Errorf("invalid process limit %q", processesArg)
+	}
+	if err = unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if err = unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=1m,mode=0755"); err != nil {
+		return fmt.Errorf("mount root: %w", err)
+	}
+	for _, path := range sandboxBinds {
+		if err = bindInto(root, path, unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV); err != nil {
+			return err
+		}
+	}
+	for _, path := range sandboxDevices {
+		if err = bindInto(root, path, unix.MS_NOSUID|unix.MS_NOEXEC); err != nil {
+			return err
+		}
+	}
+
+	work := filepath.Join(root, "work")
+	if err = os.Mkdir(work, 0o755); err != nil {
+		return err
+	}
+	if err = bind(jobDir, work, unix.MS_NOSUID|unix.MS_NODEV); err != nil {
+		return err
+	}
+	tmp := filepath.Join(root, "tmp")
+	if err = os.Mkdir(tmp, 0o755); err != nil {
+		return err
+	}
+	if err = unix.Mount("tmpfs", tmp, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=64m,mode=1777"); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+
+	old := filepath.Join(root, ".old")
+	if err = os.Mkdir(old, 0o700); err != nil {
+		return err
+	}
+	if err = unix.PivotRoot(root, old); err != nil {
+		return fmt.Errorf("pivot root: %w", err)
+	}
+	if err = unix.Chdir("/"); err != nil {
+		return err
+	}
+	if err = unix.Unmount("/.old", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("detach host root: %w", err)
+	}
+	if err = os.Remove("/.old"); err != nil {
+		return err
+	}
+	if err = unix.Mount("", "/", "", unix.MS_REMOUNT|unix.MS_RDONLY|unix.MS_NOSUID|unix.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("make root read-only: %w", err)
+	}
+	if err = unix.Chdir("/work"); err != nil {
+		return err
+	}
+
+	if err = unix.Setgroups(nil); err != nil {
+		return fmt.Errorf("drop groups: %w", err)
+	}
+	if err = unix.Setresgid(uid, uid, uid); err != nil {
+		return fmt.Errorf("set gid: %w", err)
+	}
+	if err = unix.Setresuid(uid, uid, uid); err != nil {
+		return fmt.Errorf("set uid: %w", err)
+	}
+	limit := &unix.Rlimit{Cur: processes + sandboxThreads, Max: processes + sandboxThreads}
+	if err = unix.Setrlimit(unix.RLIMIT_NPROC, limit); err != nil {
+		return fmt.Errorf("set process limit: %w", err)
+	}
+	if err = unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("set no_new_privs: %w", err)
+	}
+	return nil
+}
+
+// bindInto makes the host path visible at the same place under root. Symlinks,
+// such as /bin on merged-/usr systems, are copied rather than bound, and paths
+// the host does not have are skipped.
+func bindInto(root, path string, flags uintptr) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(root, path)
+	if err = os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	case info.IsDir():
+		err = os.Mkdir(target, 0o755)
+	default:
+		err = os.WriteFile(target, nil, 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	return bind(path, target, flags)
+}
+
+// bind mounts source on target, then applies flags, which a bind mount only
+// takes on a remount.
+func bind(source, target string, flags uintptr) error {
+	if err := unix.Mount(source, target, "", unix.MS_BIND, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", source, err)
+	}
+	if err := unix.Mount("", target, "", unix.MS_BIND|unix.MS_REMOUNT|flags, ""); err != nil {
+		return fmt.Errorf("remount %s: %w", source, err)
+	}
+	return nil
+}
@@ -0,0 +1,560 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AssignmentUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	AssignmentRepository   *repository.AssignmentRepository
+	CourseRepository       *repository.CourseRepository
+	ModuleRepository       *repository.ModuleRepository
+	ExtensionRepository    *repository.ExtensionRepository
+	EnrollmentRepository   *repository.EnrollmentRepository
+	CohortRepository       *repository.CohortRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	ScoreRepository        *repository.ScoreRepository
+	UserRepository         *repository.UserRepository
+	NotificationUseCase    *NotificationUseCase
+}
+
+func NewAssignmentUseCase(logger *logrus.Logger, validate *validator.Validate,
+	assignmentRepository *repository.AssignmentRepository, courseRepository *repository.CourseRepository,
+	moduleRepository *repository.ModuleRepository, extensionRepository *repository.ExtensionRepository,
+	enrollmentRepository *repository.EnrollmentRepository, cohortRepository *repository.CohortRepository,
+	cohortMemberRepository *repository.CohortMemberRepository, scoreRepository *repository.ScoreRepository,
+	userRepository *repository.UserRepository, notificationUseCase *NotificationUseCase) *AssignmentUseCase {
+	return &AssignmentUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		AssignmentRepository:   assignmentRepository,
+		CourseRepository:       courseRepository,
+		ModuleRepository:       moduleRepository,
+		ExtensionRepository:    extensionRepository,
+		EnrollmentRepository:   enrollmentRepository,
+		CohortRepository:       cohortRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		ScoreRepository:        scoreRepository,
+		UserRepository:         userRepository,
+		NotificationUseCase:    notificationUseCase,
+	}
+}
+
+func (c *AssignmentUseCase) Create(ctx context.Context, request *model.CreateAssignmentRequest) (*model.AssignmentResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	latePolicy, err := newLatePolicy(request.OpensAt, request.DueAt, request.ClosesAt, request.LatePolicy)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.UserRepository.FindByEmail(ctx, request.ActorEmail)
+	if err != nil || actor == nil {
+		return nil, util.ErrInvalidCredential
+	}
+
+	assignment := &entity.Assignment{
+		Title:       request.Title,
+		Description: request.Description,
+		MaxScore:    request.MaxScore,
+		OpensAt:     request.OpensAt,
+		DueAt:       request.DueAt,
+		ClosesAt:    request.ClosesAt,
+		LatePolicy:  latePolicy,
+		MaxAttempts: request.MaxAttempts,
+		ScorePolicy: request.ScorePolicy,
+		RegradeDays: request.RegradeDays,
+		GroupMode:   request.GroupMode,
+		Category:    request.Category,
+		CreatedBy:   actor.ID,
+	}
+	if assignment.ScorePolicy == "" {
+		assignment.ScorePolicy = entity.ScorePolicyBest
+	}
+	if err = c.placeInModule(ctx, actor, assignment, request.ModuleID, request.Position); err != nil {
+		return nil, err
+	}
+	if err = c.AssignmentRepository.Create(ctx, assignment); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create assignment in database")
+		return nil, util.ErrInternalDefault
+	}
+
+	loc, err := c.location(ctx, assignment)
+	if err != nil {
+		return nil, err
+	}
+	go c.announce(assignment, loc)
+	return converter.NewAssignmentResponse(assignment, loc), nil
+}
+
+// newLatePolicy checks that the assignment opens before it is due and is due
+// before it closes, and that the late policy has the settings its type needs.
+func newLatePolicy(opensAt, dueAt, closesAt *time.Time, request *model.LatePolicy) (entity.LatePolicy, error) {
+	policy := entity.LatePolicy{Type: entity.LatePolicyNone}
+	if opensAt != nil && closesAt != nil && !opensAt.Before(*closesAt) {
+		return policy, util.ErrInvalidAssignmentWindow
+	}
+	if dueAt != nil && ((opensAt != nil && !opensAt.Before(*dueAt)) || (closesAt != nil && closesAt.Before(*dueAt))) {
+		return policy, util.ErrInvalidAssignmentWindow
+	}
+	if request == nil {
+		return policy, nil
+	}
+	switch {
+	case request.Type == entity.LatePolicyPercentPerDay && request.Percent <= 0:
+		return policy, util.ErrInvalidLatePolicy
+	case request.Type == entity.LatePolicyLinearDecay && request.Days <= 0:
+		return policy, util.ErrInvalidLatePolicy
+	}
+	policy.Type = request.Type
+	policy.Percent = request.Percent
+	policy.Days = request.Days
+	policy.Floor = request.Floor
+	return policy, nil
+}
+
+// placeInModule puts the assignment into the module, and so its course, at the
+// given position or after the module's last assignment. An empty moduleID takes
+// the assignment out of any course. The actor must be staff of the module's
+// course.
+func (c *AssignmentUseCase) placeInModule(ctx context.Context, actor *entity.User, assignment *entity.Assignment, moduleID string, position *int) error {
+	if moduleID == "" {
+		assignment.CourseID = nil
+		assignment.ModuleID = nil
+		assignment.Position = 0
+		return nil
+	}
+	id, err := primitive.ObjectIDFromHex(moduleID)
+	if err != nil {
+		return util.ErrInvalidID
+	}
+	module, err := c.ModuleRepository.FindByID(ctx, id)
+	if err != nil {
+		return util.ErrInternalDefault
+	}
+	if module == nil {
+		return util.ErrModuleNotFound
+	}
+	if err = requireCourseStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, module.CourseID); err != nil {
+		return err
+	}
+
+	if position != nil {
+		assignment.Position = *position
+	} else if assignment.ModuleID == nil || *assignment.ModuleID != module.ID {
+		count, err := c.AssignmentRepository.CountByModule(ctx, module.ID)
+		if err != nil {
+			return util.ErrInternalDefault
+		}
+		assignment.Position = int(count)
+	}
+	assignment.CourseID = &module.CourseID
+	assignment.ModuleID = &module.ID
+	return nil
+}
+
+// announce tells the students who can work on a newly published assignment about
+// it: the active students of its course, or every student when it has no course.
+// It runs after the request has returned, so it uses its own context.
+func (c *AssignmentUseCase) announce(assignment *entity.Assignment, loc *time.Location) {
+	ctx := context.Background()
+	var userIDs []primitive.ObjectID
+	var err error
+	if assignment.CourseID != nil {
+		userIDs, err = c.EnrollmentRepository.FindActiveUserIDs(ctx, *assignment.CourseID)
+	} else {
+		userIDs, err = c.UserRepository.FindIDsByRole(ctx, entity.RoleStudent)
+	}
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"assignment_id": assignment.ID.Hex(),
+			util.LogError:   err,
+		}).Error("Failed to find students to announce assignment")
+		return
+	}
+	body := fmt.Sprintf("%q has been published with a maximum score of %d.", assignment.Title, assignment.MaxScore)
+	if assignment.DueAt != nil {
+		body += fmt.Sprintf(" It is due at %s.", assignment.DueAt.In(loc).Format("2 Jan 2006 15:04 MST"))
+	}
+	if assignment.ClosesAt != nil {
+		body += fmt.Sprintf(" It closes at %s.", assignment.ClosesAt.In(loc).Format("2 Jan 2006 15:04 MST"))
+	}
+	for _, userID := range userIDs {
+		_ = c.NotificationUseCase.Notify(ctx, userID, entity.NotificationCategoryAssignment, "New assignment published", body)
+	}
+}
+
+func (c *AssignmentUseCase) Update(ctx context.Context, request *model.UpdateAssignmentRequest) (*model.AssignmentResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	latePolicy, err := newLatePolicy(request.OpensAt, request.DueAt, request.ClosesAt, request.LatePolicy)
+	if err != nil {
+		return nil, err
+	}
+	assignment, err := c.find(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.UserRepository.FindByEmail(ctx, request.ActorEmail)
+	if err != nil || actor == nil {
+		return nil, util.ErrInvalidCredential
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, err
+	}
+
+	assignment.Title = request.Title
+	assignment.Description = request.Description
+	assignment.MaxScore = request.MaxScore
+	assignment.OpensAt = request.OpensAt
+	assignment.DueAt = request.DueAt
+	assignment.ClosesAt = request.ClosesAt
+	assignment.LatePolicy = latePolicy
+	assignment.MaxAttempts = request.MaxAttempts
+	assignment.RegradeDays = request.RegradeDays
+	assignment.GroupMode = request.GroupMode
+	assignment.Category = request.Category
+	if request.ScorePolicy != "" {
+		assignment.ScorePolicy = request.ScorePolicy
+	}
+	previousCourseID := assignment.CourseID
+	if err = c.placeInModule(ctx, actor, assignment, request.ModuleID, request.Position); err != nil {
+		return nil, err
+	}
+	if err = c.AssignmentRepository.Update(ctx, assignment); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update assignment in database")
+		return nil, util.ErrInternalDefault
+	}
+	if !sameObjectID(previousCourseID, assignment.CourseID) {
+		if err = c.ScoreRepository.SetCourse(ctx, assignment.ID, assignment.CourseID); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				util.LogError:   err,
+			}).Error("Failed to move assignment scores to the new course")
+			return nil, util.ErrInternalDefault
+		}
+	}
+	loc, err := c.location(ctx, assignment)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewAssignmentResponse(assignment, loc), nil
+}
+
+// Get returns an assignment of a course the caller is enrolled in or teaches,
+// or one outside any course.
+func (c *AssignmentUseCase) Get(ctx context.Context, actorEmail, id string) (*model.AssignmentResponse, error) {
+	assignment, err := c.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.UserRepository.FindByEmail(ctx, actorEmail)
+	if err != nil || actor == nil {
+		return nil, util.ErrInvalidCredential
+	}
+	if assignment.CourseID != nil && actor.GetRole() != entity.RoleAdmin {
+		if entity.IsStaffRole(actor.GetRole()) {
+			err = requireCourseStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, *assignment.CourseID)
+		} else {
+			_, err = requireCourseAccess(ctx, c.EnrollmentRepository, *assignment.CourseID, actor.ID, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	loc, err := c.location(ctx, assignment)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewAssignmentResponse(assignment, loc), nil
+}
+
+// List lists the assignments of the courses the caller is enrolled in or
+// teaches, together with those outside any course. Admins see all of them.
+func (c *AssignmentUseCase) List(ctx context.Context, request *model.ListAssignmentRequest) (*model.ListAssignmentResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	actor, err := c.UserRepository.FindByEmail(ctx, request.ActorEmail)
+	if err != nil || actor == nil {
+		return nil, util.ErrInvalidCredential
+	}
+	courseIDs, err := courseScope(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, c.EnrollmentRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	assignments, total, err := c.AssignmentRepository.FindAll(ctx, courseIDs, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to list assignments")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListAssignmentResponse{
+		Assignments: make([]model.AssignmentResponse, 0, len(assignments)),
+		Paging:      converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range assignments {
+		loc, err := c.location(ctx, &assignments[i])
+		if err != nil {
+			return nil, err
+		}
+		response.Assignments = append(response.Assignments, *converter.NewAssignmentResponse(&assignments[i], loc))
+	}
+	return response, nil
+}
+
+// CreateExtension gives one student, or every student in a cohort, a later due
+// date for the assignment. Instructors may only extend for students and cohorts
+// they teach.
+func (c *AssignmentUseCase) CreateExtension(ctx context.Context, request *model.CreateExtensionRequest) (*model.ExtensionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.find(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.UserRepository.FindByEmail(ctx, request.ActorEmail)
+	if err != nil || actor == nil {
+		return nil, util.ErrInvalidCredential
+	}
+
+	extension := &entity.Extension{
+		AssignmentID: assignment.ID,
+		DueAt:        request.DueAt,
+		Reason:       request.Reason,
+		CreatedBy:    actor.ID,
+	}
+	var student *entity.User
+	if request.Email != "" {
+		student, err = c.UserRepository.FindByEmail(ctx, request.Email)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if student == nil {
+			return nil, util.ErrUserNotFound
+		}
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, student.ID); err != nil {
+			return nil, err
+		}
+		extension.UserID = &student.ID
+	} else {
+		cohortID, err := primitive.ObjectIDFromHex(request.CohortID)
+		if err != nil {
+			return nil, util.ErrInvalidID
+		}
+		cohort, err := c.CohortRepository.FindByID(ctx, cohortID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if cohort == nil {
+			return nil, util.ErrCohortNotFound
+		}
+		if actor.GetRole() != entity.RoleAdmin {
+			member, err := c.CohortMemberRepository.Find(ctx, cohort.ID, actor.ID)
+			if err != nil {
+				return nil, util.ErrInternalDefault
+			}
+			if member == nil || !entity.IsCohortStaffRole(member.Role) {
+				return nil, util.ErrPermissionDenied
+			}
+		}
+		extension.CohortID = &cohort.ID
+	}
+	if err = c.ExtensionRepository.Create(ctx, extension); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create extension in database")
+		return nil, util.ErrInternalDefault
+	}
+
+	loc, err := c.location(ctx, assignment)
+	if err != nil {
+		return nil, err
+	}
+	if student != nil {
+		_ = c.NotificationUseCase.Notify(ctx, student.ID, entity.NotificationCategoryAssignment, "Deadline extended",
+			fmt.Sprintf("%q is now due for you at %s.", assignment.Title, extension.DueAt.In(loc).Format("2 Jan 2006 15:04 MST")))
+		return converter.NewExtensionResponse(extension, student.Email, loc), nil
+	}
+	return converter.NewExtensionResponse(extension, "", loc), nil
+}
+
+// ListExtensions lists the assignment's extensions for the students and cohorts
+// the caller teaches, or all of them for admins.
+func (c *AssignmentUseCase) ListExtensions(ctx context.Context, actorEmail, id string) (*model.ListExtensionResponse, error) {
+	assignment, err := c.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.UserRepository.FindByEmail(ctx, actorEmail)
+	if err != nil || actor == nil {
+		return nil, util.ErrInvalidCredential
+	}
+	scope, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	cohortIDs, err := c.CohortMemberRepository.FindTaughtCohortIDs(ctx, actor.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	loc, err := c.location(ctx, assignment)
+	if err != nil {
+		return nil, err
+	}
+	extensions, err := c.ExtensionRepository.FindByAssignment(ctx, assignment.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"assignment_id": id,
+			util.LogError:   err,
+		}).Error("Failed to list extensions")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListExtensionResponse{
+		Extensions: make([]model.ExtensionResponse, 0, len(extensions)),
+	}
+	for i := range extensions {
+		if scope != nil && !inScope(&extensions[i], scope, cohortIDs) {
+			continue
+		}
+		email := ""
+		if extensions[i].UserID != nil {
+			if user, err := c.UserRepository.FindByID(ctx, *extensions[i].UserID); err == nil && user != nil {
+				email = user.Email
+			}
+		}
+		response.Extensions = append(response.Extensions, *converter.NewExtensionResponse(&extensions[i], email, loc))
+	}
+	return response, nil
+}
+
+// DeleteExtension removes an extension for a student or cohort the caller
+// teaches.
+func (c *AssignmentUseCase) DeleteExtension(ctx context.Context, actorEmail, assignmentID, id string) error {
+	assignment, err := c.find(ctx, assignmentID)
+	if err != nil {
+		return err
+	}
+	extensionID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return util.ErrInvalidID
+	}
+	actor, err := c.UserRepository.FindByEmail(ctx, actorEmail)
+	if err != nil || actor == nil {
+		return util.ErrInvalidCredential
+	}
+	extension, err := c.ExtensionRepository.FindByID(ctx, assignment.ID, extensionID)
+	if err != nil {
+		return util.ErrInternalDefault
+	}
+	if extension == nil {
+		return util.ErrExtensionNotFound
+	}
+	if extension.UserID != nil {
+		err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, *extension.UserID)
+	} else if extension.CohortID != nil && actor.GetRole() != entity.RoleAdmin {
+		member, err := c.CohortMemberRepository.Find(ctx, *extension.CohortID, actor.ID)
+		if err != nil {
+			return util.ErrInternalDefault
+		}
+		if member == nil || !entity.IsCohortStaffRole(member.Role) {
+			return util.ErrPermissionDenied
+		}
+	}
+	if err != nil {
+		return err
+	}
+	found, err := c.ExtensionRepository.Delete(ctx, assignment.ID, extensionID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"extension_id": id,
+			util.LogError:  err,
+		}).Error("Failed to delete extension")
+		return util.ErrInternalDefault
+	}
+	if !found {
+		return util.ErrExtensionNotFound
+	}
+	return nil
+}
+
+// inScope reports whether the extension is for one of the students or cohorts
+// in scope.
+func inScope(extension *entity.Extension, studentIDs, cohortIDs []primitive.ObjectID) bool {
+	switch {
+	case extension.UserID != nil:
+		return slices.Contains(studentIDs, *extension.UserID)
+	case extension.CohortID != nil:
+		return slices.Contains(cohortIDs, *extension.CohortID)
+	}
+	return false
+}
+
+func (c *AssignmentUseCase) location(ctx context.Context, assignment *entity.Assignment) (*time.Location, error) {
+	return courseLocation(ctx, c.CourseRepository, assignment.CourseID)
+}
+
+func (c *AssignmentUseCase) find(ctx context.Context, id string) (*entity.Assignment, error) {
+	assignmentID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"assignment_id": id,
+			util.LogError:   err,
+		}).Error("Failed to find assignment in database")
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	return assignment, nil
+}
+
+func sameObjectID(a, b *primitive.ObjectID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// courseLocation returns the timezone of the course, or the default timezone for
+// assignments that belong to no course.
+func courseLocation(ctx context.Context, courses *repository.CourseRepository, courseID *primitive.ObjectID) (*time.Location, error) {
+	if courseID == nil {
+		return converter.CourseLocation(nil), nil
+	}
+	course, err := courses.FindByID(ctx, *courseID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	return converter.CourseLocation(course), nil
+}
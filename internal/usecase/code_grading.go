@@ -0,0 +1,563 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/runner"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxSourceBytes = 256 << 10
+	// codeReportBytes is how much of each test's output is kept in the report.
+	codeReportBytes = 4 << 10
+	// codeGradingTime bounds the run time of all of a grader's tests together,
+	// so a submission is graded well within codeGradingLease.
+	codeGradingTime        = 10 * time.Minute
+	codeGradingLease       = 15 * time.Minute
+	codeBuildTime          = 30 * time.Second
+	codeGradingTries       = 3
+	defaultCodeTimeLimit   = 2000
+	defaultCodeMemoryLimit = 256
+)
+
+type CodeGradingUseCase struct {
+	Log                      *logrus.Logger
+	Validate                 *validator.Validate
+	CodeGraderRepository     *repository.CodeGraderRepository
+	CodeSubmissionRepository *repository.CodeSubmissionRepository
+	AssignmentRepository     *repository.AssignmentRepository
+	CourseRepository         *repository.CourseRepository
+	EnrollmentRepository     *repository.EnrollmentRepository
+	SubmissionRepository     *repository.SubmissionRepository
+	CohortRepository         *repository.CohortRepository
+	CohortMemberRepository   *repository.CohortMemberRepository
+	UserRepository           *repository.UserRepository
+	ScoreUseCase             *ScoreUseCase
+	Runners                  map[string]runner.Runner
+}
+
+func NewCodeGradingUseCase(logger *logrus.Logger, validate *validator.Validate,
+	codeGraderRepository *repository.CodeGraderRepository, codeSubmissionRepository *repository.CodeSubmissionRepository,
+	assignmentRepository *repository.AssignmentRepository, courseRepository *repository.CourseRepository,
+	enrollmentRepository *repository.EnrollmentRepository, submissionRepository *repository.SubmissionRepository,
+	cohortRepository *repository.CohortRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	userRepository *repository.UserRepository, scoreUseCase *ScoreUseCase, runners map[string]runner.Runner) *CodeGradingUseCase {
+	return &CodeGradingUseCase{
+		Log:                      logger,
+		Validate:                 validate,
+		CodeGraderRepository:     codeGraderRepository,
+		CodeSubmissionRepository: codeSubmissionRepository,
+		AssignmentRepository:     assignmentRepository,
+		CourseRepository:         courseRepository,
+		EnrollmentRepository:     enrollmentRepository,
+		SubmissionRepository:     submissionRepository,
+		CohortRepository:         cohortRepository,
+		CohortMemberRepository:   cohortMemberRepository,
+		UserRepository:           userRepository,
+		ScoreUseCase:             scoreUseCase,
+		Runners:                  runners,
+	}
+}
+
+// GetGrader returns the assignment's code grader, hidden tests included, to
+// the staff of its course.
+func (c *CodeGradingUseCase) GetGrader(ctx context.Context, actorEmail, assignmentID string) (*model.CodeGraderResponse, error) {
+	assignment, grader, err := c.findGrader(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, actorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, err
+	}
+	return converter.NewCodeGraderResponse(grader), nil
+}
+
+// SaveGrader attaches a code grader to the assignment, or changes the one it
+// has, and makes the assignment auto-graded. Submissions already graded keep
+// their scores.
+func (c *CodeGradingUseCase) SaveGrader(ctx context.Context, request *model.SaveCodeGraderRequest) (*model.CodeGraderResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, err
+	}
+
+	grader := &entity.CodeGrader{
+		AssignmentID: assignment.ID,
+		Runner:       request.Runner,
+		Language:     request.Language,
+		Filename:     request.Filename,
+		Build:        request.Build,
+		Run:          request.Run,
+		TimeLimit:    request.TimeLimit,
+		MemoryLimit:  request.MemoryLimit,
+		Compare:      request.Compare,
+		Tests:        make([]entity.CodeTest, 0, len(request.Tests)),
+		CreatedBy:    actor.ID,
+	}
+	if grader.Runner == "" {
+		grader.Runner = entity.CodeRunnerLocal
+	}
+	if grader.TimeLimit == 0 {
+		grader.TimeLimit = defaultCodeTimeLimit
+	}
+	if grader.MemoryLimit == 0 {
+		grader.MemoryLimit = defaultCodeMemoryLimit
+	}
+	if grader.Compare == "" {
+		grader.Compare = entity.CodeCompareTrimmed
+	}
+	if language, ok := entity.CodeLanguages[grader.Language]; ok {
+		grader.Filename, grader.Build, grader.Run = language.Filename, language.Build, language.Run
+	}
+	if grader.Filename != strings.TrimSpace(grader.Filename) || strings.ContainsAny(grader.Filename, `/\`) ||
+		strings.HasPrefix(grader.Filename, ".") || len(grader.Run) == 0 || grader.Run[0] == "" {
+		return nil, util.ErrInvalidCodeGrader
+	}
+	if _, ok := c.Runners[grader.Runner]; !ok {
+		return nil, util.ErrInvalidCodeGrader
+	}
+	for _, test := range request.Tests {
+		grader.Tests = append(grader.Tests, entity.CodeTest{
+			Name:     test.Name,
+			Input:    test.Input,
+			Expected: test.Expected,
+			Weight:   test.Weight,
+			Hidden:   test.Hidden,
+		})
+	}
+	if time.Duration(len(grader.Tests)*grader.TimeLimit)*time.Millisecond > codeGradingTime {
+		return nil, util.ErrCodeGraderTooSlow
+	}
+
+	if err = c.CodeGraderRepository.Save(ctx, grader); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to save code grader")
+		return nil, util.ErrInternalDefault
+	}
+	if assignment.Grading != entity.GradingCode {
+		if err = c.AssignmentRepository.SetGrading(ctx, assignment.ID, entity.GradingCode); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				util.LogError:   err,
+			}).Error("Failed to make assignment code graded")
+			return nil, util.ErrInternalDefault
+		}
+	}
+	return converter.NewCodeGraderResponse(grader), nil
+}
+
+// Submit queues an uploaded source file for grading when the assignment is
+// open and attempts are left. A student has at most one submission waiting at
+// a time, so queued submissions cannot add up to more attempts than allowed.
+func (c *CodeGradingUseCase) Submit(ctx context.Context, request *model.SubmitCodeRequest) (*model.CodeSubmissionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	if len(request.Source) > maxSourceBytes {
+		return nil, util.ErrSourceTooLarge
+	}
+	if !utf8.Valid(request.Source) {
+		return nil, util.ErrInvalidSource
+	}
+	assignment, grader, err := c.findGrader(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.CourseID != nil {
+		enrollment, err := c.EnrollmentRepository.FindByCourseAndUser(ctx, *assignment.CourseID, user.ID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if enrollment == nil || enrollment.Status != entity.EnrollmentActive {
+			return nil, util.ErrNotEnrolled
+		}
+	}
+
+	loc, err := courseLocation(ctx, c.CourseRepository, assignment.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	extension, err := c.ScoreUseCase.extension(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	_, closesAt := assignment.DeadlinesFor(extension)
+	if !assignment.IsOpenUntil(now, closesAt) {
+		return nil, util.ErrAssignmentClosed
+	}
+	pending, err := c.CodeSubmissionRepository.CountPending(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if pending > 0 {
+		return nil, util.ErrCodeSubmissionPending
+	}
+	submissions, err := c.SubmissionRepository.FindByUserAndAssignment(ctx, user.ID, assignment.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment.AttemptsLeft(len(submissions)) == 0 {
+		return nil, util.ErrAttemptLimitReached
+	}
+
+	submission := &entity.CodeSubmission{
+		AssignmentID: assignment.ID,
+		UserID:       user.ID,
+		Language:     grader.Language,
+		Filename:     grader.Filename,
+		Source:       string(request.Source),
+		Status:       entity.CodeSubmissionQueued,
+		SourceIP:     request.SourceIP,
+		SubmittedAt:  now,
+	}
+	if err = c.CodeSubmissionRepository.Create(ctx, submission); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"assignment_id": assignment.ID.Hex(),
+			"email":         user.Email,
+			util.LogError:   err,
+		}).Error("Failed to queue code submission")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewCodeSubmissionResponse(submission, "", false), nil
+}
+
+// GetSubmission shows a submission and its test report to the student who made
+// it, or to staff teaching the student, who also see the hidden tests' output.
+func (c *CodeGradingUseCase) GetSubmission(ctx context.Context, request *model.GetCodeSubmissionRequest) (*model.CodeSubmissionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	id, err := primitive.ObjectIDFromHex(request.ID)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	submission, err := c.CodeSubmissionRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if submission == nil {
+		return nil, util.ErrCodeSubmissionNotFound
+	}
+	if submission.UserID == actor.ID {
+		return converter.NewCodeSubmissionResponse(submission, "", false), nil
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, submission.UserID); err != nil {
+		return nil, err
+	}
+	email := ""
+	if user, err := c.UserRepository.FindByID(ctx, submission.UserID); err == nil && user != nil {
+		email = user.Email
+	}
+	return converter.NewCodeSubmissionResponse(submission, email, true), nil
+}
+
+// ListSubmissions shows staff the submissions of the students they teach,
+// optionally only those of one student.
+func (c *CodeGradingUseCase) ListSubmissions(ctx context.Context, request *model.ListCodeSubmissionRequest) (*model.ListCodeSubmissionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	var userIDs []primitive.ObjectID
+	if request.Email != "" {
+		user, err := c.findUser(ctx, request.Email)
+		if err != nil {
+			return nil, err
+		}
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+			return nil, err
+		}
+		userIDs = []primitive.ObjectID{user.ID}
+	} else if userIDs, err = studentScope(ctx, c.CohortMemberRepository, actor); err != nil {
+		return nil, err
+	}
+
+	submissions, total, err := c.CodeSubmissionRepository.FindByAssignment(ctx, assignment.ID, userIDs, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find code submissions")
+		return nil, util.ErrInternalDefault
+	}
+
+	emails := map[primitive.ObjectID]string{}
+	response := &model.ListCodeSubmissionResponse{
+		Submissions: make([]model.CodeSubmissionResponse, 0, len(submissions)),
+		Paging:      converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range submissions {
+		email, ok := emails[submissions[i].UserID]
+		if !ok {
+			if user, err := c.UserRepository.FindByID(ctx, submissions[i].UserID); err == nil && user != nil {
+				email = user.Email
+			}
+			emails[submissions[i].UserID] = email
+		}
+		response.Submissions = append(response.Submissions, *converter.NewCodeSubmissionResponse(&submissions[i], email, true))
+	}
+	return response, nil
+}
+
+// GradeQueued works through the grading queue until it is empty or limit
+// submissions were graded, and returns how many it graded. Several API
+// processes can run it at once; each submission is claimed by one of them.
+func (c *CodeGradingUseCase) GradeQueued(ctx context.Context, limit int) (int, error) {
+	if failed, err := c.CodeSubmissionRepository.FailStale(ctx, time.Now(), codeGradingTries); err != nil {
+		return 0, err
+	} else if failed > 0 {
+		c.Log.WithField("count", failed).Warn("Gave up grading code submissions")
+	}
+	graded := 0
+	for graded < limit {
+		now := time.Now()
+		submission, err := c.CodeSubmissionRepository.Claim(ctx, now, now.Add(codeGradingLease), codeGradingTries)
+		if err != nil {
+			return graded, err
+		}
+		if submission == nil {
+			return graded, nil
+		}
+		if err = c.grade(ctx, submission); err != nil {
+			return graded, err
+		}
+		graded++
+	}
+	return graded, nil
+}
+
+// grade runs a claimed submission against its assignment's tests and records
+// the score. When the runner itself fails the submission goes back in the
+// queue to be tried again.
+func (c *CodeGradingUseCase) grade(ctx context.Context, submission *entity.CodeSubmission) error {
+	assignment, err := c.AssignmentRepository.FindByID(ctx, submission.AssignmentID)
+	if err != nil {
+		return err
+	}
+	var grader *entity.CodeGrader
+	if assignment != nil {
+		if grader, err = c.CodeGraderRepository.FindByAssignment(ctx, assignment.ID); err != nil {
+			return err
+		}
+	}
+	if grader == nil {
+		submission.Status = entity.CodeSubmissionFailed
+		submission.Error = "assignment no longer has a code grader"
+		return c.finish(ctx, submission, nil)
+	}
+	run, ok := c.Runners[grader.Runner]
+	if !ok {
+		submission.Status = entity.CodeSubmissionFailed
+		submission.Error = "runner " + grader.Runner + " is not set up"
+		return c.finish(ctx, submission, nil)
+	}
+
+	memory := int64(grader.MemoryLimit) << 20
+	job := &runner.Job{
+		Files:       map[string][]byte{submission.Filename: []byte(submission.Source)},
+		Build:       grader.Build,
+		BuildLimits: runner.Limits{Time: codeBuildTime, MemoryBytes: max(memory, 1<<30)},
+		Run:         grader.Run,
+		RunLimits:   runner.Limits{Time: time.Duration(grader.TimeLimit) * time.Millisecond, MemoryBytes: memory},
+		Inputs:      make([][]byte, 0, len(grader.Tests)),
+	}
+	for _, test := range grader.Tests {
+		job.Inputs = append(job.Inputs, []byte(test.Input))
+	}
+	report, err := run.Run(ctx, job)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"submission_id": submission.ID.Hex(),
+			util.LogError:   err,
+		}).Error("Failed to run code submission")
+		return c.CodeSubmissionRepository.Release(ctx, submission, "runner failed, grading will be retried")
+	}
+
+	submission.Error = ""
+	submission.Results = make([]entity.CodeTestResult, 0, len(grader.Tests))
+	if report.Build != nil {
+		submission.BuildOutput = truncateOutput(string(report.Build.Stdout)+string(report.Build.Stderr), 2*codeReportBytes)
+	}
+	for i, test := range grader.Tests {
+		result := entity.CodeTestResult{
+			Name:   test.Name,
+			Status: entity.CodeTestCompileError,
+			Weight: test.Weight,
+			Hidden: test.Hidden,
+		}
+		if i < len(report.Runs) {
+			outcome := report.Runs[i]
+			result.Status = codeTestStatus(grader, &test, &outcome)
+			result.TimeMs = outcome.Duration.Milliseconds()
+			result.ExitCode = outcome.ExitCode
+			result.Stdout = truncateOutput(string(outcome.Stdout), codeReportBytes)
+			result.Stderr = truncateOutput(string(outcome.Stderr), codeReportBytes)
+		}
+		submission.Results = append(submission.Results, result)
+	}
+	submission.Grade(assignment.MaxScore)
+	submission.Status = entity.CodeSubmissionGraded
+	return c.finish(ctx, submission, assignment)
+}
+
+func codeTestStatus(grader *entity.CodeGrader, test *entity.CodeTest, outcome *runner.Outcome) string {
+	switch outcome.Status {
+	case runner.StatusTimeout:
+		return entity.CodeTestTimeout
+	case runner.StatusMemoryLimit:
+		return entity.CodeTestMemoryLimit
+	case runner.StatusOutputLimit:
+		return entity.CodeTestOutputLimit
+	case runner.StatusRuntimeError:
+		return entity.CodeTestRuntimeError
+	}
+	if !grader.Matches(test.Expected, string(outcome.Stdout)) {
+		return entity.CodeTestWrongAnswer
+	}
+	return entity.CodeTestPassed
+}
+
+// finish stores the report and, for a graded submission, records its score as
+// a submission of the assignment made when the code was uploaded. As with
+// quizzes the report stands even when the score cannot be recorded.
+func (c *CodeGradingUseCase) finish(ctx context.Context, submission *entity.CodeSubmission, assignment *entity.Assignment) error {
+	finishedAt := time.Now()
+	submission.FinishedAt = &finishedAt
+	finished, err := c.CodeSubmissionRepository.Finish(ctx, submission)
+	if err != nil || !finished || submission.Status != entity.CodeSubmissionGraded {
+		return err
+	}
+
+	user, err := c.UserRepository.FindByID(ctx, submission.UserID)
+	if err != nil || user == nil {
+		c.Log.WithFields(logrus.Fields{
+			"submission_id": submission.ID.Hex(),
+			util.LogError:   err,
+		}).Error("Failed to find the student of a code submission")
+		return nil
+	}
+	response, err := c.ScoreUseCase.RecordScore(ctx, &model.RecordScoreRequest{
+		UserEmail:    user.Email,
+		AssignmentID: assignment.ID.Hex(),
+		Score:        submission.Score,
+		PayloadRef:   "code_submission:" + submission.ID.Hex(),
+		Grader:       entity.GradingCode,
+		SourceIP:     submission.SourceIP,
+		AutoGraded:   true,
+		SubmittedAt:  &submission.SubmittedAt,
+	})
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"submission_id": submission.ID.Hex(),
+			util.LogError:   err,
+		}).Warn("Failed to record code submission score")
+		return nil
+	}
+	if err = c.CodeSubmissionRepository.SetAttempt(ctx, submission.ID, response.Attempt); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"submission_id": submission.ID.Hex(),
+			util.LogError:   err,
+		}).Error("Failed to link code submission to its submission")
+	}
+	return nil
+}
+
+// truncateOutput cuts text to at most limit bytes without splitting a character.
+func truncateOutput(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	text = text[:limit]
+	for !utf8.ValidString(text) && len(text) > 0 {
+		text = text[:len(text)-1]
+	}
+	return text + "\n[truncated]"
+}
+
+func (c *CodeGradingUseCase) findAssignment(ctx context.Context, id string) (*entity.Assignment, error) {
+	assignmentID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	return assignment, nil
+}
+
+func (c *CodeGradingUseCase) findGrader(ctx context.Context, assignmentID string) (*entity.Assignment, *entity.CodeGrader, error) {
+	assignment, err := c.findAssignment(ctx, assignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	grader, err := c.CodeGraderRepository.FindByAssignment(ctx, assignment.ID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	if grader == nil {
+		return nil, nil, util.ErrCodeGraderNotFound
+	}
+	return assignment, grader, nil
+}
+
+func (c *CodeGradingUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,623 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CohortUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	CohortRepository       *repository.CohortRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	CourseRepository       *repository.CourseRepository
+	ScoreRepository        *repository.ScoreRepository
+	ExtensionRepository    *repository.ExtensionRepository
+	UserRepository         *repository.UserRepository
+	NotificationUseCase    *NotificationUseCase
+}
+
+func NewCohortUseCase(logger *logrus.Logger, validate *validator.Validate,
+	cohortRepository *repository.CohortRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	courseRepository *repository.CourseRepository, scoreRepository *repository.ScoreRepository,
+	extensionRepository *repository.ExtensionRepository, userRepository *repository.UserRepository,
+	notificationUseCase *NotificationUseCase) *CohortUseCase {
+	return &CohortUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		CohortRepository:       cohortRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		CourseRepository:       courseRepository,
+		ScoreRepository:        scoreRepository,
+		ExtensionRepository:    extensionRepository,
+		UserRepository:         userRepository,
+		NotificationUseCase:    notificationUseCase,
+	}
+}
+
+// Create adds the cohort. Instructors who create one become its first
+// instructor; admins manage every cohort and are not added.
+func (c *CohortUseCase) Create(ctx context.Context, request *model.CreateCohortRequest) (*model.CohortResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	courseID, err := c.courseID(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	cohort := &entity.Cohort{
+		Name:      strings.TrimSpace(request.Name),
+		CourseID:  courseID,
+		CreatedBy: actor.ID,
+	}
+	if err = c.CohortRepository.Create(ctx, cohort); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, util.ErrCohortNameTaken
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create cohort in database")
+		return nil, util.ErrInternalDefault
+	}
+
+	var member *entity.CohortMember
+	if actor.GetRole() != entity.RoleAdmin {
+		member = &entity.CohortMember{
+			CohortID: cohort.ID,
+			UserID:   actor.ID,
+			Role:     entity.CohortRoleInstructor,
+			AddedBy:  actor.ID,
+		}
+		if err = c.CohortMemberRepository.Add(ctx, member); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				util.LogError:   err,
+			}).Error("Failed to add cohort creator as instructor")
+			return nil, util.ErrInternalDefault
+		}
+	}
+	return converter.NewCohortResponse(cohort, member, 0), nil
+}
+
+func (c *CohortUseCase) Update(ctx context.Context, request *model.UpdateCohortRequest) (*model.CohortResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	cohort, err := c.find(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	courseID, err := c.courseID(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	cohort.Name = strings.TrimSpace(request.Name)
+	cohort.CourseID = courseID
+	if err = c.CohortRepository.Update(ctx, cohort); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, util.ErrCohortNameTaken
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update cohort in database")
+		return nil, util.ErrInternalDefault
+	}
+	students, err := c.CohortMemberRepository.CountByCohort(ctx, cohort.ID, entity.CohortRoleStudent)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewCohortResponse(cohort, nil, students), nil
+}
+
+// List returns every cohort to admins and the cohorts the caller belongs to,
+// with their role in each, to everyone else.
+func (c *CohortUseCase) List(ctx context.Context, request *model.ListCohortRequest) (*model.ListCohortResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	members, err := c.CohortMemberRepository.FindByUser(ctx, user.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find cohort memberships")
+		return nil, util.ErrInternalDefault
+	}
+	byCohort := make(map[primitive.ObjectID]*entity.CohortMember, len(members))
+	var ids []primitive.ObjectID
+	if request.ActorRole != entity.RoleAdmin {
+		ids = make([]primitive.ObjectID, 0, len(members))
+	}
+	for i := range members {
+		byCohort[members[i].CohortID] = &members[i]
+		if request.ActorRole != entity.RoleAdmin {
+			ids = append(ids, members[i].CohortID)
+		}
+	}
+
+	cohorts, total, err := c.CohortRepository.FindAll(ctx, ids, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to list cohorts")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListCohortResponse{
+		Cohorts: make([]model.CohortResponse, 0, len(cohorts)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range cohorts {
+		students, err := c.CohortMemberRepository.CountByCohort(ctx, cohorts[i].ID, entity.CohortRoleStudent)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		response.Cohorts = append(response.Cohorts, *converter.NewCohortResponse(&cohorts[i], byCohort[cohorts[i].ID], students))
+	}
+	return response, nil
+}
+
+func (c *CohortUseCase) Get(ctx context.Context, request *model.GetCohortRequest) (*model.CohortResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	cohort, err := c.find(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	member, err := c.CohortMemberRepository.Find(ctx, cohort.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	students, err := c.CohortMemberRepository.CountByCohort(ctx, cohort.ID, entity.CohortRoleStudent)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewCohortResponse(cohort, member, students), nil
+}
+
+// AddMembers adds every listed user with the same role and reports the outcome
+// per email instead of failing the whole batch. Students already placed in
+// another cohort are left there; move them instead.
+func (c *CohortUseCase) AddMembers(ctx context.Context, request *model.AddCohortMembersRequest) (*model.AddCohortMembersResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	cohort, err := c.find(ctx, request.CohortID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.AddCohortMembersResponse{
+		Results: make([]model.CohortMemberResult, 0, len(request.Emails)),
+	}
+	for _, email := range request.Emails {
+		status, err := c.addMember(ctx, cohort, actor, email, request.Role)
+		if err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				"email":         email,
+				util.LogError:   err,
+			}).Error("Failed to add cohort member")
+			return nil, util.ErrInternalDefault
+		}
+		if status == model.CohortMemberAdded {
+			response.Added++
+		}
+		response.Results = append(response.Results, model.CohortMemberResult{Email: email, Status: status})
+	}
+	return response, nil
+}
+
+func (c *CohortUseCase) addMember(ctx context.Context, cohort *entity.Cohort, actor *entity.User, email, role string) (string, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return model.CohortMemberNotFound, nil
+	}
+	// Students join as students; only staff accounts may teach a cohort.
+	if (role == entity.CohortRoleStudent) != (user.GetRole() == entity.RoleStudent) {
+		return model.CohortMemberInvalidRole, nil
+	}
+	existing, err := c.CohortMemberRepository.Find(ctx, cohort.ID, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return model.CohortMemberAlreadyMember, nil
+	}
+
+	member := &entity.CohortMember{
+		CohortID: cohort.ID,
+		UserID:   user.ID,
+		Role:     role,
+		AddedBy:  actor.ID,
+	}
+	if err = c.CohortMemberRepository.Add(ctx, member); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return model.CohortMemberInOtherCohort, nil
+		}
+		return "", err
+	}
+	_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategoryCourse, "Added to cohort",
+		fmt.Sprintf("You have been added to %s as %s.", cohort.Name, role))
+	return model.CohortMemberAdded, nil
+}
+
+func (c *CohortUseCase) ListMembers(ctx context.Context, request *model.ListCohortMemberRequest) (*model.ListCohortMemberResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	cohort, err := c.find(ctx, request.CohortID)
+	if err != nil {
+		return nil, err
+	}
+	members, total, err := c.CohortMemberRepository.FindByCohort(ctx, cohort.ID, request.Role, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to list cohort members")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListCohortMemberResponse{
+		Members: make([]model.CohortMemberResponse, 0, len(members)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range members {
+		user, err := c.UserRepository.FindByID(ctx, members[i].UserID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		response.Members = append(response.Members, *converter.NewCohortMemberResponse(&members[i], user))
+	}
+	return response, nil
+}
+
+func (c *CohortUseCase) RemoveMember(ctx context.Context, request *model.RemoveCohortMemberRequest) error {
+	if err := c.Validate.Struct(request); err != nil {
+		return util.NewCustomError(err)
+	}
+	cohort, err := c.find(ctx, request.CohortID)
+	if err != nil {
+		return err
+	}
+	userID, err := primitive.ObjectIDFromHex(request.UserID)
+	if err != nil {
+		return util.ErrInvalidID
+	}
+	found, err := c.CohortMemberRepository.Remove(ctx, cohort.ID, userID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to remove cohort member")
+		return util.ErrInternalDefault
+	}
+	if !found {
+		return util.ErrCohortMemberNotFound
+	}
+	return nil
+}
+
+// MoveStudents moves students between two cohorts in one update. Instructors
+// must teach both cohorts; admins may move between any two.
+func (c *CohortUseCase) MoveStudents(ctx context.Context, request *model.MoveCohortStudentsRequest) (*model.MoveCohortStudentsResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	from, err := c.find(ctx, request.FromCohortID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := c.find(ctx, request.ToCohortID)
+	if err != nil {
+		return nil, err
+	}
+	if from.ID == to.ID {
+		return nil, util.ErrInvalidCohortMove
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if request.ActorRole != entity.RoleAdmin {
+		for _, cohort := range []*entity.Cohort{from, to} {
+			if err = c.requireMember(ctx, cohort.ID, actor.ID, entity.CohortRoleInstructor); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	response := &model.MoveCohortStudentsResponse{NotFound: []string{}}
+	var userIDs []primitive.ObjectID
+	if len(request.Emails) > 0 {
+		userIDs = make([]primitive.ObjectID, 0, len(request.Emails))
+		for _, email := range request.Emails {
+			user, err := c.UserRepository.FindByEmail(ctx, email)
+			if err != nil {
+				return nil, util.ErrInternalDefault
+			}
+			if user == nil {
+				response.NotFound = append(response.NotFound, email)
+				continue
+			}
+			userIDs = append(userIDs, user.ID)
+		}
+	}
+	response.Moved, err = c.CohortMemberRepository.MoveStudents(ctx, from.ID, to.ID, userIDs, actor.ID)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// one of the students also teaches the target cohort
+			return nil, util.ErrInvalidCohortMove
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to move cohort students")
+		return nil, util.ErrInternalDefault
+	}
+	return response, nil
+}
+
+// Scores lists the cohort's students with their total score, within the given
+// course or the cohort's own course when none is given.
+func (c *CohortUseCase) Scores(ctx context.Context, request *model.CohortScoresRequest) (*model.CohortScoresResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	cohort, err := c.find(ctx, request.CohortID)
+	if err != nil {
+		return nil, err
+	}
+	courseID := cohort.CourseID
+	if request.CourseID != "" {
+		if courseID, err = c.courseID(ctx, request.CourseID); err != nil {
+			return nil, err
+		}
+	}
+
+	members, total, err := c.CohortMemberRepository.FindByCohort(ctx, cohort.ID, entity.CohortRoleStudent, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to list cohort students")
+		return nil, util.ErrInternalDefault
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(members))
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	totals, err := c.ScoreRepository.SumByUsers(ctx, userIDs, courseID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to total cohort scores")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.CohortScoresResponse{
+		CohortID: cohort.ID.Hex(),
+		Students: make([]model.CohortScoreResponse, 0, len(members)),
+		Paging:   converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	if courseID != nil {
+		response.CourseID = courseID.Hex()
+	}
+	for _, userID := range userIDs {
+		user, err := c.UserRepository.FindByID(ctx, userID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		row := model.CohortScoreResponse{UserID: userID.Hex(), Score: totals[userID]}
+		if user != nil {
+			row.Email = user.Email
+			row.Name = user.Name
+		}
+		response.Students = append(response.Students, row)
+	}
+	return response, nil
+}
+
+// CheckMember backs AuthMiddleware.RequireCohortRole: the user must belong to
+// the cohort with one of the roles.
+func (c *CohortUseCase) CheckMember(ctx context.Context, cohortID, userID string, roles ...string) error {
+	id, err := primitive.ObjectIDFromHex(cohortID)
+	if err != nil {
+		return util.ErrInvalidID
+	}
+	memberID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return util.ErrPermissionDenied
+	}
+	return c.requireMember(ctx, id, memberID, roles...)
+}
+
+func (c *CohortUseCase) requireMember(ctx context.Context, cohortID, userID primitive.ObjectID, roles ...string) error {
+	member, err := c.CohortMemberRepository.Find(ctx, cohortID, userID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"cohort_id":   cohortID.Hex(),
+			"user_id":     userID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to find cohort member")
+		return util.ErrInternalDefault
+	}
+	if member == nil || !util.Contain(roles, member.Role) {
+		return util.ErrPermissionDenied
+	}
+	return nil
+}
+
+// MigrateLegacyCohorts turns the free-text cohort names users carried before
+// cohorts existed into cohorts with those users as members, and points cohort
+// extensions at them. Running it again only adds what is missing.
+func (c *CohortUseCase) MigrateLegacyCohorts(ctx context.Context) (int, error) {
+	users, err := c.UserRepository.FindWithLegacyCohort(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cohorts := map[string]*entity.Cohort{}
+	added := 0
+	for _, user := range users {
+		name := strings.TrimSpace(user.Cohort)
+		cohort, ok := cohorts[name]
+		if !ok {
+			if cohort, err = c.CohortRepository.FindByName(ctx, name); err != nil {
+				return added, err
+			}
+			if cohort == nil {
+				cohort = &entity.Cohort{Name: name}
+				if err = c.CohortRepository.Create(ctx, cohort); err != nil {
+					return added, err
+				}
+			}
+			if _, err = c.ExtensionRepository.MigrateCohort(ctx, user.Cohort, cohort.ID); err != nil {
+				return added, err
+			}
+			cohorts[name] = cohort
+		}
+
+		role := entity.CohortRoleStudent
+		if entity.IsStaffRole(user.GetRole()) {
+			role = entity.CohortRoleInstructor
+		}
+		member := &entity.CohortMember{CohortID: cohort.ID, UserID: user.ID, Role: role}
+		if err = c.CohortMemberRepository.Add(ctx, member); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+func (c *CohortUseCase) find(ctx context.Context, id string) (*entity.Cohort, error) {
+	cohortID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	cohort, err := c.CohortRepository.FindByID(ctx, cohortID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"cohort_id":   id,
+			util.LogError: err,
+		}).Error("Failed to find cohort by ID in database")
+		return nil, util.ErrInternalDefault
+	}
+	if cohort == nil {
+		return nil, util.ErrCohortNotFound
+	}
+	return cohort, nil
+}
+
+// courseID checks that the optional course exists.
+func (c *CohortUseCase) courseID(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	courseID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	course, err := c.CourseRepository.FindByID(ctx, courseID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if course == nil {
+		return nil, util.ErrCourseNotFound
+	}
+	return &course.ID, nil
+}
+
+func (c *CohortUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// requireStudentAccess lets staff act on a student only when they teach one of
+// the student's cohorts. Admins may act on everybody, and everybody on
+// themselves.
+func requireStudentAccess(ctx context.Context, members *repository.CohortMemberRepository, actor *entity.User, studentID primitive.ObjectID) error {
+	if actor.ID == studentID || actor.GetRole() == entity.RoleAdmin {
+		return nil
+	}
+	if !entity.IsStaffRole(actor.GetRole()) {
+		return util.ErrPermissionDenied
+	}
+	cohortIDs, err := members.FindTaughtCohortIDs(ctx, actor.ID)
+	if err != nil {
+		return util.ErrInternalDefault
+	}
+	ok, err := members.IsStudentIn(ctx, studentID, cohortIDs)
+	if err != nil {
+		return util.ErrInternalDefault
+	}
+	if !ok {
+		return util.ErrStudentNotInCohort
+	}
+	return nil
+}
+
+// studentScope returns the students the actor may see: nil for admins, who see
+// everybody, and otherwise the students of the cohorts the actor teaches.
+func studentScope(ctx context.Context, members *repository.CohortMemberRepository, actor *entity.User) ([]primitive.ObjectID, error) {
+	if actor.GetRole() == entity.RoleAdmin {
+		return nil, nil
+	}
+	cohortIDs, err := members.FindTaughtCohortIDs(ctx, actor.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	ids, err := members.FindStudentIDs(ctx, cohortIDs)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	return ids, nil
+}
@@ -0,0 +1,637 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CourseUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	CourseRepository       *repository.CourseRepository
+	ModuleRepository       *repository.ModuleRepository
+	EnrollmentRepository   *repository.EnrollmentRepository
+	CohortRepository       *repository.CohortRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	AssignmentRepository   *repository.AssignmentRepository
+	ScoreRepository        *repository.ScoreRepository
+	UserRepository         *repository.UserRepository
+	NotificationUseCase    *NotificationUseCase
+}
+
+func NewCourseUseCase(logger *logrus.Logger, validate *validator.Validate,
+	courseRepository *repository.CourseRepository, moduleRepository *repository.ModuleRepository,
+	enrollmentRepository *repository.EnrollmentRepository, cohortRepository *repository.CohortRepository,
+	cohortMemberRepository *repository.CohortMemberRepository, assignmentRepository *repository.AssignmentRepository,
+	scoreRepository *repository.ScoreRepository, userRepository *repository.UserRepository,
+	notificationUseCase *NotificationUseCase) *CourseUseCase {
+	return &CourseUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		CourseRepository:       courseRepository,
+		ModuleRepository:       moduleRepository,
+		EnrollmentRepository:   enrollmentRepository,
+		CohortRepository:       cohortRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		AssignmentRepository:   assignmentRepository,
+		ScoreRepository:        scoreRepository,
+		UserRepository:         userRepository,
+		NotificationUseCase:    notificationUseCase,
+	}
+}
+
+func (c *CourseUseCase) Create(ctx context.Context, request *model.CreateCourseRequest) (*model.CourseResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	if request.Timezone == "" {
+		request.Timezone = util.DefaultTimezone
+	}
+	if !util.IsValidTimezone(request.Timezone) {
+		return nil, util.ErrInvalidTimezone
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	joinCode, err := util.GenerateJoinCode()
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+
+	course := &entity.Course{
+		Code:        request.Code,
+		Title:       request.Title,
+		Description: request.Description,
+		JoinCode:    joinCode,
+		JoinEnabled: request.JoinEnabled == nil || *request.JoinEnabled,
+		Timezone:    request.Timezone,
+		CreatedBy:   actor.ID,
+	}
+	if err = c.CourseRepository.Create(ctx, course); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, util.ErrCourseCodeTaken
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create course in database")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewCourseResponse(course, nil, true), nil
+}
+
+func (c *CourseUseCase) Update(ctx context.Context, request *model.UpdateCourseRequest) (*model.CourseResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	if request.Timezone != "" && !util.IsValidTimezone(request.Timezone) {
+		return nil, util.ErrInvalidTimezone
+	}
+	course, err := c.findManaged(ctx, request.ActorEmail, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	course.Code = request.Code
+	course.Title = request.Title
+	course.Description = request.Description
+	course.JoinEnabled = request.JoinEnabled
+	if request.Timezone != "" {
+		course.Timezone = request.Timezone
+	}
+	if err = c.CourseRepository.Update(ctx, course); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, util.ErrCourseCodeTaken
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update course in database")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewCourseResponse(course, nil, true), nil
+}
+
+// RotateJoinCode replaces the join code, e.g. after it was shared outside the class.
+func (c *CourseUseCase) RotateJoinCode(ctx context.Context, actorEmail, id string) (*model.CourseResponse, error) {
+	course, err := c.findManaged(ctx, actorEmail, id)
+	if err != nil {
+		return nil, err
+	}
+	if course.JoinCode, err = util.GenerateJoinCode(); err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if err = c.CourseRepository.Update(ctx, course); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"course_id":   id,
+			util.LogError: err,
+		}).Error("Failed to rotate course join code")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewCourseResponse(course, nil, true), nil
+}
+
+// List returns every course to staff and only the courses a student is enrolled
+// in, with their enrollment status, to everyone else.
+func (c *CourseUseCase) List(ctx context.Context, request *model.ListCourseRequest) (*model.ListCourseResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	staff := entity.IsStaffRole(request.ActorRole)
+
+	enrollments, err := c.EnrollmentRepository.FindByUser(ctx, user.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find enrollments")
+		return nil, util.ErrInternalDefault
+	}
+	byCourse := make(map[primitive.ObjectID]*entity.Enrollment, len(enrollments))
+	var ids []primitive.ObjectID
+	if !staff {
+		ids = make([]primitive.ObjectID, 0, len(enrollments))
+	}
+	for i := range enrollments {
+		byCourse[enrollments[i].CourseID] = &enrollments[i]
+		if !staff {
+			ids = append(ids, enrollments[i].CourseID)
+		}
+	}
+
+	courses, total, err := c.CourseRepository.FindAll(ctx, ids, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to list courses")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListCourseResponse{
+		Courses: make([]model.CourseResponse, 0, len(courses)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range courses {
+		response.Courses = append(response.Courses, *converter.NewCourseResponse(&courses[i], byCourse[courses[i].ID], staff))
+	}
+	return response, nil
+}
+
+// Get returns the course with its modules and their assignments in order, plus
+// the caller's total score in the course.
+func (c *CourseUseCase) Get(ctx context.Context, request *model.GetCourseRequest) (*model.CourseDetailResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.find(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	staff := entity.IsStaffRole(request.ActorRole)
+	enrollment, err := requireCourseAccess(ctx, c.EnrollmentRepository, course.ID, user.ID, staff)
+	if err != nil {
+		return nil, err
+	}
+
+	modules, err := c.ModuleRepository.FindByCourse(ctx, course.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find course modules")
+		return nil, util.ErrInternalDefault
+	}
+	assignments, err := c.AssignmentRepository.FindByCourse(ctx, course.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find course assignments")
+		return nil, util.ErrInternalDefault
+	}
+	score, err := c.ScoreRepository.SumByUserInCourse(ctx, user.ID, course.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+
+	byModule := make(map[primitive.ObjectID][]entity.Assignment, len(modules))
+	for _, assignment := range assignments {
+		if assignment.ModuleID != nil {
+			byModule[*assignment.ModuleID] = append(byModule[*assignment.ModuleID], assignment)
+		}
+	}
+	response := &model.CourseDetailResponse{
+		CourseResponse: *converter.NewCourseResponse(course, enrollment, staff),
+		Modules:        make([]model.ModuleResponse, 0, len(modules)),
+		Score:          score,
+	}
+	for i := range modules {
+		response.Modules = append(response.Modules, *converter.NewModuleResponse(&modules[i], byModule[modules[i].ID], converter.CourseLocation(course)))
+	}
+	return response, nil
+}
+
+func (c *CourseUseCase) CreateModule(ctx context.Context, request *model.CreateModuleRequest) (*model.ModuleResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.findManaged(ctx, request.ActorEmail, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	module := &entity.Module{
+		CourseID:    course.ID,
+		Title:       request.Title,
+		Description: request.Description,
+	}
+	if request.Position != nil {
+		module.Position = *request.Position
+	} else {
+		count, err := c.ModuleRepository.CountByCourse(ctx, course.ID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		module.Position = int(count)
+	}
+	if err = c.ModuleRepository.Create(ctx, module); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create module in database")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewModuleResponse(module, nil, converter.CourseLocation(course)), nil
+}
+
+func (c *CourseUseCase) UpdateModule(ctx context.Context, request *model.UpdateModuleRequest) (*model.ModuleResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.findManaged(ctx, request.ActorEmail, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	module, err := findModule(ctx, c.ModuleRepository, course.ID, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	module.Title = request.Title
+	module.Description = request.Description
+	module.Position = request.Position
+	if err = c.ModuleRepository.Update(ctx, module); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update module in database")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewModuleResponse(module, nil, converter.CourseLocation(course)), nil
+}
+
+// Join self-enrolls the caller with a join code. Joining again is harmless and
+// brings a dropped student back into the course.
+func (c *CourseUseCase) Join(ctx context.Context, request *model.JoinCourseRequest) (*model.EnrollmentResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	course, err := c.CourseRepository.FindByJoinCode(ctx, request.JoinCode)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if course == nil || !course.JoinEnabled {
+		return nil, util.ErrInvalidJoinCode
+	}
+
+	enrollment, err := c.EnrollmentRepository.FindByCourseAndUser(ctx, course.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if enrollment != nil && enrollment.CanAccess() {
+		return converter.NewEnrollmentResponse(enrollment, user), nil
+	}
+	enrollment = &entity.Enrollment{
+		CourseID: course.ID,
+		UserID:   user.ID,
+		Status:   entity.EnrollmentActive,
+		Method:   entity.EnrollmentMethodJoinCode,
+	}
+	if err = c.EnrollmentRepository.Save(ctx, enrollment); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to save enrollment")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewEnrollmentResponse(enrollment, user), nil
+}
+
+// Enroll enrolls one of the students the caller teaches, or anybody for admins.
+func (c *CourseUseCase) Enroll(ctx context.Context, request *model.EnrollRequest) (*model.EnrollmentResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.find(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.Email)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+		return nil, err
+	}
+
+	enrollment := &entity.Enrollment{
+		CourseID:   course.ID,
+		UserID:     user.ID,
+		Status:     entity.EnrollmentActive,
+		Method:     entity.EnrollmentMethodInstructor,
+		EnrolledBy: actor.ID,
+	}
+	if err = c.EnrollmentRepository.Save(ctx, enrollment); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to save enrollment")
+		return nil, util.ErrInternalDefault
+	}
+	_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategoryCourse, "Enrolled in course",
+		fmt.Sprintf("You have been enrolled in %s %s.", course.Code, course.Title))
+	return converter.NewEnrollmentResponse(enrollment, user), nil
+}
+
+// ListEnrollments lists the course's enrollments of the students the caller
+// teaches, or of everybody for admins.
+func (c *CourseUseCase) ListEnrollments(ctx context.Context, request *model.ListEnrollmentRequest) (*model.ListEnrollmentResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.find(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	enrollments, total, err := c.EnrollmentRepository.FindByCourse(ctx, course.ID, scope, request.Status, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to list enrollments")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListEnrollmentResponse{
+		Enrollments: make([]model.EnrollmentResponse, 0, len(enrollments)),
+		Paging:      converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range enrollments {
+		user, err := c.UserRepository.FindByID(ctx, enrollments[i].UserID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		response.Enrollments = append(response.Enrollments, *converter.NewEnrollmentResponse(&enrollments[i], user))
+	}
+	return response, nil
+}
+
+// UpdateEnrollment drops, completes or reactivates the enrollment of one of the
+// students the caller teaches, or of anybody for admins.
+func (c *CourseUseCase) UpdateEnrollment(ctx context.Context, request *model.UpdateEnrollmentRequest) (*model.EnrollmentResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.find(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := primitive.ObjectIDFromHex(request.UserID)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, userID); err != nil {
+		return nil, err
+	}
+
+	found, err := c.EnrollmentRepository.UpdateStatus(ctx, course.ID, userID, request.Status)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update enrollment status")
+		return nil, util.ErrInternalDefault
+	}
+	if !found {
+		return nil, util.ErrEnrollmentNotFound
+	}
+	enrollment, err := c.EnrollmentRepository.FindByCourseAndUser(ctx, course.ID, userID)
+	if err != nil || enrollment == nil {
+		return nil, util.ErrInternalDefault
+	}
+	user, err := c.UserRepository.FindByID(ctx, userID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewEnrollmentResponse(enrollment, user), nil
+}
+
+func (c *CourseUseCase) find(ctx context.Context, id string) (*entity.Course, error) {
+	courseID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	course, err := c.CourseRepository.FindByID(ctx, courseID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"course_id":   id,
+			util.LogError: err,
+		}).Error("Failed to find course in database")
+		return nil, util.ErrInternalDefault
+	}
+	if course == nil {
+		return nil, util.ErrCourseNotFound
+	}
+	return course, nil
+}
+
+// findManaged finds the course for a change only its staff may make.
+func (c *CourseUseCase) findManaged(ctx context.Context, actorEmail, id string) (*entity.Course, error) {
+	course, err := c.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, actorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireCourseStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, course.ID); err != nil {
+		return nil, err
+	}
+	return course, nil
+}
+
+func (c *CourseUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// requireCourseAccess lets staff through and otherwise requires an enrollment
+// that still grants access. The enrollment is returned when there is one.
+func requireCourseAccess(ctx context.Context, enrollments *repository.EnrollmentRepository,
+	courseID, userID primitive.ObjectID, staff bool) (*entity.Enrollment, error) {
+	enrollment, err := enrollments.FindByCourseAndUser(ctx, courseID, userID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if staff {
+		return enrollment, nil
+	}
+	if enrollment == nil || !enrollment.CanAccess() {
+		return nil, util.ErrNotEnrolled
+	}
+	return enrollment, nil
+}
+
+// requireCourseStaff lets admins, the instructor who created the course and the
+// staff of its cohorts manage the course.
+func requireCourseStaff(ctx context.Context, courses *repository.CourseRepository, cohorts *repository.CohortRepository,
+	members *repository.CohortMemberRepository, actor *entity.User, courseID primitive.ObjectID) error {
+	if actor.GetRole() == entity.RoleAdmin {
+		return nil
+	}
+	if !entity.IsStaffRole(actor.GetRole()) {
+		return util.ErrPermissionDenied
+	}
+	course, err := courses.FindByID(ctx, courseID)
+	if err != nil {
+		return util.ErrInternalDefault
+	}
+	if course == nil {
+		return util.ErrCourseNotFound
+	}
+	if course.CreatedBy == actor.ID {
+		return nil
+	}
+	cohortIDs, err := members.FindTaughtCohortIDs(ctx, actor.ID)
+	if err != nil {
+		return util.ErrInternalDefault
+	}
+	teaches, err := cohorts.AnyInCourse(ctx, cohortIDs, courseID)
+	if err != nil {
+		return util.ErrInternalDefault
+	}
+	if !teaches {
+		return util.ErrPermissionDenied
+	}
+	return nil
+}
+
+// requireAssignmentStaff applies requireCourseStaff to the assignment's course.
+// An assignment outside any course is managed by its creator and admins only.
+func requireAssignmentStaff(ctx context.Context, courses *repository.CourseRepository, cohorts *repository.CohortRepository,
+	members *repository.CohortMemberRepository, actor *entity.User, assignment *entity.Assignment) error {
+	if assignment.CourseID != nil {
+		return requireCourseStaff(ctx, courses, cohorts, members, actor, *assignment.CourseID)
+	}
+	if assignment.CreatedBy != actor.ID && actor.GetRole() != entity.RoleAdmin {
+		return util.ErrPermissionDenied
+	}
+	return nil
+}
+
+// courseScope returns the courses the actor may see: nil for admins, who see
+// every course, and otherwise the courses the actor is enrolled in or teaches.
+func courseScope(ctx context.Context, courses *repository.CourseRepository, cohorts *repository.CohortRepository,
+	members *repository.CohortMemberRepository, enrollments *repository.EnrollmentRepository, actor *entity.User) ([]primitive.ObjectID, error) {
+	if actor.GetRole() == entity.RoleAdmin {
+		return nil, nil
+	}
+	enrolled, err := enrollments.FindByUser(ctx, actor.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	ids := make([]primitive.ObjectID, 0, len(enrolled))
+	for _, enrollment := range enrolled {
+		if enrollment.CanAccess() {
+			ids = append(ids, enrollment.CourseID)
+		}
+	}
+	if !entity.IsStaffRole(actor.GetRole()) {
+		return ids, nil
+	}
+	created, err := courses.FindIDsByCreator(ctx, actor.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	cohortIDs, err := members.FindTaughtCohortIDs(ctx, actor.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	taught, err := cohorts.FindCourseIDs(ctx, cohortIDs)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	return append(append(ids, created...), taught...), nil
+}
+
+func findModule(ctx context.Context, modules *repository.ModuleRepository, courseID primitive.ObjectID, id string) (*entity.Module, error) {
+	moduleID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	module, err := modules.FindByID(ctx, moduleID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if module == nil || module.CourseID != courseID {
+		return nil, util.ErrModuleNotFound
+	}
+	return module, nil
+}
@@ -0,0 +1,420 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GradeUseCase struct {
+	Log                     *logrus.Logger
+	Validate                *validator.Validate
+	GradingSchemeRepository *repository.GradingSchemeRepository
+	CourseGradeRepository   *repository.CourseGradeRepository
+	CourseRepository        *repository.CourseRepository
+	AssignmentRepository    *repository.AssignmentRepository
+	EnrollmentRepository    *repository.EnrollmentRepository
+	ScoreRepository         *repository.ScoreRepository
+	ExtensionRepository     *repository.ExtensionRepository
+	CohortRepository        *repository.CohortRepository
+	CohortMemberRepository  *repository.CohortMemberRepository
+	UserRepository          *repository.UserRepository
+}
+
+func NewGradeUseCase(logger *logrus.Logger, validate *validator.Validate,
+	gradingSchemeRepository *repository.GradingSchemeRepository, courseGradeRepository *repository.CourseGradeRepository,
+	courseRepository *repository.CourseRepository, assignmentRepository *repository.AssignmentRepository,
+	enrollmentRepository *repository.EnrollmentRepository, scoreRepository *repository.ScoreRepository,
+	extensionRepository *repository.ExtensionRepository, cohortRepository *repository.CohortRepository,
+	cohortMemberRepository *repository.CohortMemberRepository, userRepository *repository.UserRepository) *GradeUseCase {
+	return &GradeUseCase{
+		Log:                     logger,
+		Validate:                validate,
+		GradingSchemeRepository: gradingSchemeRepository,
+		CourseGradeRepository:   courseGradeRepository,
+		CourseRepository:        courseRepository,
+		AssignmentRepository:    assignmentRepository,
+		EnrollmentRepository:    enrollmentRepository,
+		ScoreRepository:         scoreRepository,
+		ExtensionRepository:     extensionRepository,
+		CohortRepository:        cohortRepository,
+		CohortMemberRepository:  cohortMemberRepository,
+		UserRepository:          userRepository,
+	}
+}
+
+// SaveScheme sets how the course's final grades are worked out. Category
+// weights add up to 100; letters may come in any order and are kept highest
+// first. The course grades are worked out again with it by the next job run.
+func (c *GradeUseCase) SaveScheme(ctx context.Context, request *model.SaveGradingSchemeRequest) (*model.GradingSchemeResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.findCourse(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireCourseStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, course.ID); err != nil {
+		return nil, err
+	}
+
+	scheme := &entity.GradingScheme{
+		CourseID:   course.ID,
+		Categories: make([]entity.GradeCategory, 0, len(request.Categories)),
+		Letters:    make([]entity.LetterGrade, 0, len(request.Letters)),
+		Curve: entity.GradeCurve{
+			Type:       request.Curve.Type,
+			TargetMean: request.Curve.TargetMean,
+			Cap:        request.Curve.Cap,
+		},
+		UpdatedBy: actor.ID,
+	}
+	keys := map[string]bool{}
+	weights := 0.0
+	for _, category := range request.Categories {
+		if keys[category.Key] {
+			return nil, util.ErrDuplicateGradeKey
+		}
+		keys[category.Key] = true
+		weights += category.Weight
+		scheme.Categories = append(scheme.Categories, entity.GradeCategory{
+			Key:        category.Key,
+			Name:       category.Name,
+			Weight:     category.Weight,
+			DropLowest: category.DropLowest,
+		})
+	}
+	if entity.RoundPercent(weights) != 100 {
+		return nil, util.ErrInvalidGradeWeights
+	}
+	letters := map[string]bool{}
+	for _, letter := range request.Letters {
+		if letters[letter.Letter] {
+			return nil, util.ErrDuplicateGradeKey
+		}
+		letters[letter.Letter] = true
+		scheme.Letters = append(scheme.Letters, entity.LetterGrade{Letter: letter.Letter, Min: letter.Min})
+	}
+	sort.SliceStable(scheme.Letters, func(i, j int) bool { return scheme.Letters[i].Min > scheme.Letters[j].Min })
+	switch scheme.Curve.Type {
+	case "":
+		scheme.Curve.Type = entity.GradeCurveNone
+	case entity.GradeCurveLinear:
+		if scheme.Curve.TargetMean == 0 {
+			return nil, util.ErrInvalidGradeCurve
+		}
+	}
+
+	if err = c.GradingSchemeRepository.Save(ctx, scheme); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to save grading scheme")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewGradingSchemeResponse(scheme), nil
+}
+
+func (c *GradeUseCase) GetScheme(ctx context.Context, request *model.GetGradingSchemeRequest) (*model.GradingSchemeResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.findCourse(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = requireCourseAccess(ctx, c.EnrollmentRepository, course.ID, actor.ID, entity.IsStaffRole(request.ActorRole)); err != nil {
+		return nil, err
+	}
+	scheme, err := c.findScheme(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewGradingSchemeResponse(scheme), nil
+}
+
+// Get returns the caller's course grade, or a student's when the caller
+// teaches them. A student without a grade yet gets the course's grades worked
+// out first, when anything changed since they last were.
+func (c *GradeUseCase) Get(ctx context.Context, request *model.CourseGradeRequest) (*model.CourseGradeResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.findCourse(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	staff := entity.IsStaffRole(request.ActorRole)
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if request.StudentEmail != "" && request.StudentEmail != request.ActorEmail {
+		if !staff {
+			return nil, util.ErrPermissionDenied
+		}
+		actor := user
+		if user, err = c.findUser(ctx, request.StudentEmail); err != nil {
+			return nil, err
+		}
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+			return nil, err
+		}
+		staff = false
+	}
+	if _, err = requireCourseAccess(ctx, c.EnrollmentRepository, course.ID, user.ID, staff); err != nil {
+		return nil, err
+	}
+	scheme, err := c.findScheme(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	grade, err := c.CourseGradeRepository.FindByCourseAndUser(ctx, course.ID, user.ID)
+	if err == nil && grade == nil {
+		// e.g. a student enrolled since the grades were last worked out
+		var changed bool
+		if changed, err = c.changed(ctx, scheme); err == nil && changed {
+			if err = c.Recompute(ctx, scheme); err == nil {
+				grade, err = c.CourseGradeRepository.FindByCourseAndUser(ctx, course.ID, user.ID)
+			}
+		}
+	}
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find course grade")
+		return nil, util.ErrInternalDefault
+	}
+	if grade == nil {
+		return nil, util.ErrNotEnrolled
+	}
+	return converter.NewCourseGradeResponse(grade, user.Email), nil
+}
+
+// RecomputeChanged works out again the grades of every course whose scheme,
+// scores, enrollments, assignments or extensions changed, or whose assignments
+// fell due, since its grades were last worked out. It returns how many courses it did.
+func (c *GradeUseCase) RecomputeChanged(ctx context.Context) (int, error) {
+	schemes, err := c.GradingSchemeRepository.FindAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for i := range schemes {
+		scheme := &schemes[i]
+		changed, err := c.changed(ctx, scheme)
+		if err == nil && changed {
+			if err = c.Recompute(ctx, scheme); err == nil {
+				done++
+			}
+		}
+		if err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"course_id":   scheme.CourseID.Hex(),
+				util.LogError: err,
+			}).Error("Failed to recompute course grades")
+		}
+	}
+	return done, nil
+}
+
+func (c *GradeUseCase) changed(ctx context.Context, scheme *entity.GradingScheme) (bool, error) {
+	if scheme.ComputedAt == nil {
+		return true, nil
+	}
+	since := *scheme.ComputedAt
+	if changed, err := c.ScoreRepository.ChangedSince(ctx, scheme.CourseID, since); err != nil || changed {
+		return changed, err
+	}
+	if changed, err := c.EnrollmentRepository.ChangedSince(ctx, scheme.CourseID, since); err != nil || changed {
+		return changed, err
+	}
+	assignments, err := c.AssignmentRepository.FindByCourse(ctx, scheme.CourseID)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	for _, assignment := range assignments {
+		if assignment.CreatedAt.After(since) || assignment.UpdatedAt != nil && assignment.UpdatedAt.After(since) {
+			return true, nil
+		}
+		if dueAt, _ := assignment.DeadlinesFor(nil); dueAt != nil && dueAt.After(since) && !dueAt.After(now) {
+			return true, nil
+		}
+		if assignment.Category == "" {
+			continue
+		}
+		extensions, err := c.ExtensionRepository.FindByAssignment(ctx, assignment.ID)
+		if err != nil {
+			return false, err
+		}
+		for _, extension := range extensions {
+			if extension.CreatedAt.After(since) || extension.DueAt.After(since) && !extension.DueAt.After(now) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Recompute works out the grades of every student of the course who is
+// enrolled or completed it. Missing scores count as zero once the assignment is
+// due for the student, extension included, and the curve is fitted to the
+// whole course.
+func (c *GradeUseCase) Recompute(ctx context.Context, scheme *entity.GradingScheme) error {
+	computedAt := util.NowInWIB()
+	userIDs, err := c.EnrollmentRepository.FindGradedUserIDs(ctx, scheme.CourseID)
+	if err != nil {
+		return err
+	}
+	assignments, err := c.AssignmentRepository.FindByCourse(ctx, scheme.CourseID)
+	if err != nil {
+		return err
+	}
+	assignmentIDs := make([]primitive.ObjectID, 0, len(assignments))
+	extensions := map[primitive.ObjectID][]entity.Extension{}
+	for _, assignment := range assignments {
+		if assignment.Category == "" {
+			continue
+		}
+		assignmentIDs = append(assignmentIDs, assignment.ID)
+		if extensions[assignment.ID], err = c.ExtensionRepository.FindByAssignment(ctx, assignment.ID); err != nil {
+			return err
+		}
+	}
+	scores := map[primitive.ObjectID]map[primitive.ObjectID]int{}
+	if len(userIDs) > 0 && len(assignmentIDs) > 0 {
+		found, err := c.ScoreRepository.FindByUsersAndAssignments(ctx, userIDs, assignmentIDs)
+		if err != nil {
+			return err
+		}
+		for _, score := range found {
+			if scores[score.UserID] == nil {
+				scores[score.UserID] = map[primitive.ObjectID]int{}
+			}
+			scores[score.UserID][score.AssignmentID] = score.Score
+		}
+	}
+
+	grades := make([]entity.CourseGrade, 0, len(userIDs))
+	percents := []float64{}
+	for _, userID := range userIDs {
+		cohortID, err := c.CohortMemberRepository.FindStudentCohortID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		items := make([]entity.GradeItem, 0, len(assignmentIDs))
+		for _, assignment := range assignments {
+			if assignment.Category == "" {
+				continue
+			}
+			dueAt, _ := assignment.DeadlinesFor(extensionFor(extensions[assignment.ID], userID, cohortID))
+			item := entity.GradeItem{
+				AssignmentID: assignment.ID,
+				Category:     assignment.Category,
+				MaxScore:     assignment.MaxScore,
+				Due:          dueAt != nil && !dueAt.After(computedAt),
+			}
+			if score, ok := scores[userID][assignment.ID]; ok {
+				item.Score = &score
+			}
+			items = append(items, item)
+		}
+		categories, percent := scheme.Grade(items)
+		grades = append(grades, entity.CourseGrade{
+			CourseID:   scheme.CourseID,
+			UserID:     userID,
+			Categories: categories,
+			Percent:    percent,
+		})
+		if percent != nil {
+			percents = append(percents, *percent)
+		}
+	}
+	factor := scheme.Curve.CurveFactor(percents)
+	for i := range grades {
+		if grades[i].Percent != nil {
+			curved := entity.ApplyCurve(*grades[i].Percent, factor)
+			grades[i].Curved = &curved
+			grades[i].Letter = scheme.Letter(curved)
+		}
+	}
+
+	if err = c.CourseGradeRepository.ReplaceCourse(ctx, scheme.CourseID, grades, computedAt); err != nil {
+		return err
+	}
+	return c.GradingSchemeRepository.MarkComputed(ctx, scheme, computedAt)
+}
+
+// extensionFor picks the extension that applies to the student out of all the
+// assignment's extensions.
+func extensionFor(extensions []entity.Extension, userID primitive.ObjectID, cohortID *primitive.ObjectID) *entity.Extension {
+	applies := make([]entity.Extension, 0, len(extensions))
+	for _, extension := range extensions {
+		if extension.UserID != nil && *extension.UserID == userID ||
+			extension.CohortID != nil && cohortID != nil && *extension.CohortID == *cohortID {
+			applies = append(applies, extension)
+		}
+	}
+	return entity.PickExtension(applies, userID)
+}
+
+func (c *GradeUseCase) findScheme(ctx context.Context, courseID primitive.ObjectID) (*entity.GradingScheme, error) {
+	scheme, err := c.GradingSchemeRepository.FindByCourse(ctx, courseID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if scheme == nil {
+		return nil, util.ErrGradingSchemeNotFound
+	}
+	return scheme, nil
+}
+
+func (c *GradeUseCase) findCourse(ctx context.Context, id string) (*entity.Course, error) {
+	courseID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	course, err := c.CourseRepository.FindByID(ctx, courseID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if course == nil {
+		return nil, util.ErrCourseNotFound
+	}
+	return course, nil
+}
+
+func (c *GradeUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
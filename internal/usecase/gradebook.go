@@ -0,0 +1,273 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// gradebookFlushEvery is how many rows are buffered before they are pushed to
+// the client.
+const gradebookFlushEvery = 100
+
+type GradebookUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	CourseRepository       *repository.CourseRepository
+	ModuleRepository       *repository.ModuleRepository
+	AssignmentRepository   *repository.AssignmentRepository
+	EnrollmentRepository   *repository.EnrollmentRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	UserRepository         *repository.UserRepository
+}
+
+func NewGradebookUseCase(logger *logrus.Logger, validate *validator.Validate,
+	courseRepository *repository.CourseRepository, moduleRepository *repository.ModuleRepository,
+	assignmentRepository *repository.AssignmentRepository, enrollmentRepository *repository.EnrollmentRepository,
+	cohortMemberRepository *repository.CohortMemberRepository, userRepository *repository.UserRepository) *GradebookUseCase {
+	return &GradebookUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		CourseRepository:       courseRepository,
+		ModuleRepository:       moduleRepository,
+		AssignmentRepository:   assignmentRepository,
+		EnrollmentRepository:   enrollmentRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		UserRepository:         userRepository,
+	}
+}
+
+// GradebookExport is an export that passed every check. Its rows are only read
+// from the database while Write runs, so the response can be streamed.
+type GradebookExport struct {
+	Filename    string
+	ContentType string
+	write       func(ctx context.Context, w io.Writer) error
+}
+
+func (e *GradebookExport) Write(ctx context.Context, w io.Writer) error {
+	return e.write(ctx, w)
+}
+
+// Export prepares the course gradebook: one row per enrolled student the caller
+// teaches, one score and days-late column per assignment, then the total, the
+// final grade and its letter. The final grade is the one worked out with the
+// course's grading scheme, or the percentage of the points available when the
+// course has none.
+func (c *GradebookUseCase) Export(ctx context.Context, request *model.GradebookExportRequest) (*GradebookExport, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	courseID, err := primitive.ObjectIDFromHex(request.CourseID)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	course, err := c.CourseRepository.FindByID(ctx, courseID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if course == nil {
+		return nil, util.ErrCourseNotFound
+	}
+	actor, err := c.UserRepository.FindByEmail(ctx, request.ActorEmail)
+	if err != nil || actor == nil {
+		return nil, util.ErrInvalidCredential
+	}
+	scope, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := c.assignments(ctx, course.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find course assignments")
+		return nil, util.ErrInternalDefault
+	}
+
+	export := &GradebookExport{
+		Filename:    fmt.Sprintf("%s-gradebook.%s", course.Code, request.Format),
+		ContentType: "text/csv; charset=utf-8",
+	}
+	if request.Format == "xlsx" {
+		export.ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	export.write = func(ctx context.Context, w io.Writer) error {
+		out, err := newGradebookWriter(w, request.Format)
+		if err != nil {
+			return err
+		}
+		if err = c.writeRows(ctx, out, w, course.ID, scope, request.Status, assignments); err != nil {
+			return err
+		}
+		return out.Close()
+	}
+	return export, nil
+}
+
+func (c *GradebookUseCase) writeRows(ctx context.Context, out gradebookWriter, w io.Writer, courseID primitive.ObjectID,
+	scope []primitive.ObjectID, status string, assignments []entity.Assignment) error {
+	header := []any{"Email", "Name", "Cohort", "Group", "Status"}
+	assignmentIDs := make([]primitive.ObjectID, 0, len(assignments))
+	maxTotal := 0
+	for _, assignment := range assignments {
+		title := fmt.Sprintf("%s (%d)", assignment.Title, assignment.MaxScore)
+		if assignment.GroupMode {
+			title = fmt.Sprintf("%s (%d, group)", assignment.Title, assignment.MaxScore)
+		}
+		header = append(header, title, assignment.Title+" days late")
+		assignmentIDs = append(assignmentIDs, assignment.ID)
+		maxTotal += assignment.MaxScore
+	}
+	header = append(header, "Total", "Max", "Final grade (%)", "Letter")
+	if err := out.WriteRow(header); err != nil {
+		return err
+	}
+
+	rows := 0
+	return c.EnrollmentRepository.StreamGradebook(ctx, courseID, scope, status, assignmentIDs, func(row *entity.GradebookRow) error {
+		scores := make(map[primitive.ObjectID]int, len(row.Scores))
+		for _, score := range row.Scores {
+			scores[score.AssignmentID] = score.Score
+		}
+		submissions := make(map[primitive.ObjectID][]entity.Submission, len(assignments))
+		for _, submission := range row.Submissions {
+			submissions[submission.AssignmentID] = append(submissions[submission.AssignmentID], submission)
+		}
+
+		cells := []any{row.Email, row.Name, row.Cohort, row.Group, row.Status}
+		total := 0
+		for i := range assignments {
+			assignment := &assignments[i]
+			if score, ok := scores[assignment.ID]; ok {
+				cells = append(cells, score)
+				total += score
+			} else {
+				cells = append(cells, nil)
+			}
+			if daysLate := assignment.CountedDaysLate(submissions[assignment.ID]); daysLate > 0 {
+				cells = append(cells, daysLate)
+			} else {
+				cells = append(cells, nil)
+			}
+		}
+		cells = append(cells, total, maxTotal)
+		switch {
+		case row.Grade != nil && row.Grade.Curved != nil:
+			cells = append(cells, *row.Grade.Curved, row.Grade.Letter)
+		case row.Grade == nil && maxTotal > 0:
+			cells = append(cells, math.Round(float64(total)*1000/float64(maxTotal))/10, nil)
+		default:
+			cells = append(cells, nil, nil)
+		}
+		if err := out.WriteRow(cells); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%gradebookFlushEvery == 0 {
+			return flushGradebook(out, w)
+		}
+		return nil
+	})
+}
+
+// assignments returns the course's assignments in the order students see them:
+// by module, then by position within the module, with loose assignments last.
+func (c *GradebookUseCase) assignments(ctx context.Context, courseID primitive.ObjectID) ([]entity.Assignment, error) {
+	modules, err := c.ModuleRepository.FindByCourse(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := c.AssignmentRepository.FindByCourse(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	byModule := make(map[primitive.ObjectID][]entity.Assignment, len(modules))
+	var loose []entity.Assignment
+	for _, assignment := range assignments {
+		if assignment.ModuleID == nil {
+			loose = append(loose, assignment)
+			continue
+		}
+		byModule[*assignment.ModuleID] = append(byModule[*assignment.ModuleID], assignment)
+	}
+	ordered := make([]entity.Assignment, 0, len(assignments))
+	for _, module := range modules {
+		ordered = append(ordered, byModule[module.ID]...)
+	}
+	return append(ordered, loose...), nil
+}
+
+type gradebookWriter interface {
+	WriteRow(cells []any) error
+	Flush() error
+	Close() error
+}
+
+func newGradebookWriter(w io.Writer, format string) (gradebookWriter, error) {
+	if format == "xlsx" {
+		return util.NewXLSXWriter(w, "Gradebook")
+	}
+	return &csvGradebookWriter{csv: csv.NewWriter(w)}, nil
+}
+
+// flushGradebook pushes buffered rows through the export writer and the
+// response writer beneath it.
+func flushGradebook(out gradebookWriter, w io.Writer) error {
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	if flusher, ok := w.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+type csvGradebookWriter struct {
+	csv *csv.Writer
+}
+
+func (w *csvGradebookWriter) WriteRow(cells []any) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch value := cell.(type) {
+		case nil:
+		case string:
+			// Spreadsheets run cells starting with these as formulas.
+			if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+				value = "'" + value
+			}
+			record[i] = value
+		case int:
+			record[i] = strconv.Itoa(value)
+		case float64:
+			record[i] = strconv.FormatFloat(value, 'f', -1, 64)
+		default:
+			record[i] = fmt.Sprint(value)
+		}
+	}
+	return w.csv.Write(record)
+}
+
+func (w *csvGradebookWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+func (w *csvGradebookWriter) Close() error {
+	return w.Flush()
+}
@@ -0,0 +1,462 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type GroupUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	GroupRepository        *repository.GroupRepository
+	GroupMemberRepository  *repository.GroupMemberRepository
+	CohortRepository       *repository.CohortRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	UserRepository         *repository.UserRepository
+	NotificationUseCase    *NotificationUseCase
+}
+
+func NewGroupUseCase(logger *logrus.Logger, validate *validator.Validate,
+	groupRepository *repository.GroupRepository, groupMemberRepository *repository.GroupMemberRepository,
+	cohortRepository *repository.CohortRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	userRepository *repository.UserRepository, notificationUseCase *NotificationUseCase) *GroupUseCase {
+	return &GroupUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		GroupRepository:        groupRepository,
+		GroupMemberRepository:  groupMemberRepository,
+		CohortRepository:       cohortRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		UserRepository:         userRepository,
+		NotificationUseCase:    notificationUseCase,
+	}
+}
+
+// Create adds a group to the cohort and places the listed students in it,
+// reporting the outcome per email.
+func (c *GroupUseCase) Create(ctx context.Context, request *model.CreateGroupRequest) (*model.GroupResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	cohort, err := c.findCohort(ctx, request.CohortID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &entity.Group{
+		CohortID:   cohort.ID,
+		Name:       request.Name,
+		MaxSize:    request.MaxSize,
+		SelfSignup: request.SelfSignup,
+		CreatedBy:  actor.ID,
+	}
+	if err = c.GroupRepository.Create(ctx, group); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, util.ErrGroupNameTaken
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create group")
+		return nil, util.ErrInternalDefault
+	}
+	results, err := c.addMembers(ctx, cohort, group, actor, request.Emails)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.response(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	response.Results = results
+	return response, nil
+}
+
+func (c *GroupUseCase) Update(ctx context.Context, request *model.UpdateGroupRequest) (*model.GroupResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	_, group, err := c.find(ctx, request.CohortID, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	group.Name = request.Name
+	group.MaxSize = request.MaxSize
+	group.SelfSignup = request.SelfSignup
+	if err = c.GroupRepository.Update(ctx, group); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, util.ErrGroupNameTaken
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update group")
+		return nil, util.ErrInternalDefault
+	}
+	return c.response(ctx, group.ID)
+}
+
+// Delete removes the group and its memberships. Scores already given to its
+// members for group assignments stay.
+func (c *GroupUseCase) Delete(ctx context.Context, request *model.GroupRequest) error {
+	if err := c.Validate.Struct(request); err != nil {
+		return util.NewCustomError(err)
+	}
+	_, group, err := c.find(ctx, request.CohortID, request.ID)
+	if err != nil {
+		return err
+	}
+	if err = c.GroupMemberRepository.RemoveAll(ctx, group.ID); err == nil {
+		err = c.GroupRepository.Delete(ctx, group.ID)
+	}
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to delete group")
+		return util.ErrInternalDefault
+	}
+	return nil
+}
+
+// List shows the cohort's groups with their members.
+func (c *GroupUseCase) List(ctx context.Context, request *model.ListGroupRequest) (*model.ListGroupResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	cohort, err := c.findCohort(ctx, request.CohortID)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := c.GroupRepository.FindByCohort(ctx, cohort.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to list groups")
+		return nil, util.ErrInternalDefault
+	}
+	groupIDs := make([]primitive.ObjectID, 0, len(groups))
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.ID)
+	}
+	members, err := c.GroupMemberRepository.FindByGroups(ctx, groupIDs)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListGroupResponse{Groups: make([]model.GroupResponse, 0, len(groups))}
+	index := make(map[primitive.ObjectID]int, len(groups))
+	for i := range groups {
+		index[groups[i].ID] = i
+		response.Groups = append(response.Groups, *converter.NewGroupResponse(&groups[i]))
+	}
+	for i := range members {
+		user, err := c.UserRepository.FindByID(ctx, members[i].UserID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		group := &response.Groups[index[members[i].GroupID]]
+		group.Members = append(group.Members, *converter.NewGroupMemberResponse(&members[i], user))
+	}
+	return response, nil
+}
+
+// AddMembers places students of the cohort in the group, reporting the outcome
+// per email. Students already in another group are left there. Instructors are
+// not held to the group's size limit.
+func (c *GroupUseCase) AddMembers(ctx context.Context, request *model.AddGroupMembersRequest) (*model.GroupResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	cohort, group, err := c.find(ctx, request.CohortID, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	results, err := c.addMembers(ctx, cohort, group, actor, request.Emails)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.response(ctx, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	response.Results = results
+	return response, nil
+}
+
+func (c *GroupUseCase) addMembers(ctx context.Context, cohort *entity.Cohort, group *entity.Group, actor *entity.User, emails []string) ([]model.GroupMemberResult, error) {
+	results := make([]model.GroupMemberResult, 0, len(emails))
+	for _, email := range emails {
+		status, err := c.addMember(ctx, cohort, group, actor, email)
+		if err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"group_id":    group.ID.Hex(),
+				"email":       email,
+				util.LogError: err,
+			}).Error("Failed to add group member")
+			return nil, util.ErrInternalDefault
+		}
+		results = append(results, model.GroupMemberResult{Email: email, Status: status})
+	}
+	return results, nil
+}
+
+func (c *GroupUseCase) addMember(ctx context.Context, cohort *entity.Cohort, group *entity.Group, actor *entity.User, email string) (string, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return model.GroupMemberNotFound, nil
+	}
+	member, err := c.CohortMemberRepository.Find(ctx, cohort.ID, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if member == nil || member.Role != entity.CohortRoleStudent {
+		return model.GroupMemberNotInCohort, nil
+	}
+	existing, err := c.GroupMemberRepository.FindByCohortAndUser(ctx, cohort.ID, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		if existing.GroupID == group.ID {
+			return model.GroupMemberAlreadyMember, nil
+		}
+		return model.GroupMemberInOtherGroup, nil
+	}
+	if err = c.join(ctx, group, user, actor, false); err != nil {
+		if err == util.ErrAlreadyInGroup {
+			return model.GroupMemberInOtherGroup, nil
+		}
+		return "", err
+	}
+	_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategoryCourse, "Added to group",
+		fmt.Sprintf("You have been added to group %s of %s.", group.Name, cohort.Name))
+	return model.GroupMemberAdded, nil
+}
+
+// join takes a place in the group and adds the membership, giving the place
+// back when the student turns out to be in a group already.
+func (c *GroupUseCase) join(ctx context.Context, group *entity.Group, user, actor *entity.User, limit bool) error {
+	reserved, err := c.GroupRepository.Reserve(ctx, group.ID, limit)
+	if err != nil {
+		return err
+	}
+	if !reserved {
+		return util.ErrGroupFull
+	}
+	member := &entity.GroupMember{
+		GroupID:  group.ID,
+		CohortID: group.CohortID,
+		UserID:   user.ID,
+		AddedBy:  actor.ID,
+	}
+	if err = c.GroupMemberRepository.Add(ctx, member); err != nil {
+		if releaseErr := c.GroupRepository.Release(ctx, group.ID); releaseErr != nil {
+			c.Log.WithFields(logrus.Fields{
+				"group_id":    group.ID.Hex(),
+				util.LogError: releaseErr,
+			}).Error("Failed to release group place")
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return util.ErrAlreadyInGroup
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *GroupUseCase) RemoveMember(ctx context.Context, request *model.RemoveGroupMemberRequest) error {
+	if err := c.Validate.Struct(request); err != nil {
+		return util.NewCustomError(err)
+	}
+	_, group, err := c.find(ctx, request.CohortID, request.ID)
+	if err != nil {
+		return err
+	}
+	userID, err := primitive.ObjectIDFromHex(request.UserID)
+	if err != nil {
+		return util.ErrInvalidID
+	}
+	return c.leave(ctx, group, userID)
+}
+
+// Join lets a student of the cohort sign up for a group open for it, while it
+// has room.
+func (c *GroupUseCase) Join(ctx context.Context, request *model.GroupRequest) (*model.GroupResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	cohort, group, err := c.find(ctx, request.CohortID, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !group.SelfSignup {
+		return nil, util.ErrGroupClosed
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	member, err := c.CohortMemberRepository.Find(ctx, cohort.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if member == nil || member.Role != entity.CohortRoleStudent {
+		return nil, util.ErrCohortMemberNotFound
+	}
+	if err = c.join(ctx, group, user, user, true); err != nil {
+		if err == util.ErrGroupFull || err == util.ErrAlreadyInGroup {
+			return nil, err
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to join group")
+		return nil, util.ErrInternalDefault
+	}
+	return c.response(ctx, group.ID)
+}
+
+// Leave lets a student leave a self-signup group; groups formed by instructors
+// are only changed by them.
+func (c *GroupUseCase) Leave(ctx context.Context, request *model.GroupRequest) error {
+	if err := c.Validate.Struct(request); err != nil {
+		return util.NewCustomError(err)
+	}
+	_, group, err := c.find(ctx, request.CohortID, request.ID)
+	if err != nil {
+		return err
+	}
+	if !group.SelfSignup {
+		return util.ErrGroupClosed
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return err
+	}
+	return c.leave(ctx, group, user.ID)
+}
+
+func (c *GroupUseCase) leave(ctx context.Context, group *entity.Group, userID primitive.ObjectID) error {
+	found, err := c.GroupMemberRepository.Remove(ctx, group.ID, userID)
+	if err == nil && found {
+		err = c.GroupRepository.Release(ctx, group.ID)
+	}
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"group_id":    group.ID.Hex(),
+			"user_id":     userID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to remove group member")
+		return util.ErrInternalDefault
+	}
+	if !found {
+		return util.ErrGroupMemberNotFound
+	}
+	return nil
+}
+
+func (c *GroupUseCase) response(ctx context.Context, groupID primitive.ObjectID) (*model.GroupResponse, error) {
+	group, err := c.GroupRepository.FindByID(ctx, groupID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if group == nil {
+		return nil, util.ErrGroupNotFound
+	}
+	members, err := c.GroupMemberRepository.FindByGroups(ctx, []primitive.ObjectID{group.ID})
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	response := converter.NewGroupResponse(group)
+	for i := range members {
+		user, err := c.UserRepository.FindByID(ctx, members[i].UserID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		response.Members = append(response.Members, *converter.NewGroupMemberResponse(&members[i], user))
+	}
+	return response, nil
+}
+
+func (c *GroupUseCase) find(ctx context.Context, cohortID, id string) (*entity.Cohort, *entity.Group, error) {
+	cohort, err := c.findCohort(ctx, cohortID)
+	if err != nil {
+		return nil, nil, err
+	}
+	groupID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, util.ErrInvalidID
+	}
+	group, err := c.GroupRepository.FindByID(ctx, groupID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	if group == nil || group.CohortID != cohort.ID {
+		return nil, nil, util.ErrGroupNotFound
+	}
+	return cohort, group, nil
+}
+
+func (c *GroupUseCase) findCohort(ctx context.Context, id string) (*entity.Cohort, error) {
+	cohortID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	cohort, err := c.CohortRepository.FindByID(ctx, cohortID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if cohort == nil {
+		return nil, util.ErrCohortNotFound
+	}
+	return cohort, nil
+}
+
+func (c *GroupUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// studentGroup returns the group the student does group assignments with: their
+// group in the cohort they are in now, or nil.
+func studentGroup(ctx context.Context, members *repository.CohortMemberRepository, groupMembers *repository.GroupMemberRepository,
+	userID primitive.ObjectID) (*entity.GroupMember, error) {
+	cohortID, err := members.FindStudentCohortID(ctx, userID)
+	if err != nil || cohortID == nil {
+		return nil, err
+	}
+	return groupMembers.FindByCohortAndUser(ctx, *cohortID, userID)
+}
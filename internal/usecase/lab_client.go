@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// scoreClockSkew is how far a signed score's timestamp may be from the server
+// time. Nonces are kept for twice as long, so a submission cannot be replayed
+// after its nonce is forgotten.
+const scoreClockSkew = 5 * time.Minute
+
+type LabClientUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	ClientKeyRepository    *repository.ClientKeyRepository
+	ScoreNonceRepository   *repository.ScoreNonceRepository
+	AssignmentRepository   *repository.AssignmentRepository
+	CourseRepository       *repository.CourseRepository
+	CohortRepository       *repository.CohortRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	UserRepository         *repository.UserRepository
+	ScoreUseCase           *ScoreUseCase
+}
+
+func NewLabClientUseCase(logger *logrus.Logger, validate *validator.Validate,
+	clientKeyRepository *repository.ClientKeyRepository, scoreNonceRepository *repository.ScoreNonceRepository,
+	assignmentRepository *repository.AssignmentRepository, courseRepository *repository.CourseRepository,
+	cohortRepository *repository.CohortRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	userRepository *repository.UserRepository, scoreUseCase *ScoreUseCase) *LabClientUseCase {
+	return &LabClientUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		ClientKeyRepository:    clientKeyRepository,
+		ScoreNonceRepository:   scoreNonceRepository,
+		AssignmentRepository:   assignmentRepository,
+		CourseRepository:       courseRepository,
+		CohortRepository:       cohortRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		UserRepository:         userRepository,
+		ScoreUseCase:           scoreUseCase,
+	}
+}
+
+// CreateKey issues a signing key for the assignment's lab client builds. The
+// HMAC secret or generated Ed25519 private key is only in this response.
+func (c *LabClientUseCase) CreateKey(ctx context.Context, request *model.CreateClientKeyRequest) (*model.ClientKeyResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, actor, err := c.findKeyAssignment(ctx, request.ActorEmail, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &entity.ClientKey{
+		AssignmentID: assignment.ID,
+		Algorithm:    request.Algorithm,
+		Label:        request.Label,
+		CreatedBy:    actor.ID,
+	}
+	response := &model.ClientKeyResponse{}
+	switch {
+	case request.Algorithm == entity.ClientKeyHMAC:
+		key.Key = make([]byte, 32)
+		if _, err = rand.Read(key.Key); err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		response.Secret = base64.StdEncoding.EncodeToString(key.Key)
+	case request.PublicKey != "":
+		key.Key, err = base64.StdEncoding.DecodeString(request.PublicKey)
+		if err != nil || len(key.Key) != ed25519.PublicKeySize {
+			return nil, util.ErrInvalidClientKey
+		}
+	default:
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		key.Key = public
+		response.PrivateKey = base64.StdEncoding.EncodeToString(private.Seed())
+	}
+
+	if err = c.ClientKeyRepository.Create(ctx, key); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"assignment_id": assignment.ID.Hex(),
+			"algorithm":     key.Algorithm,
+			util.LogError:   err,
+		}).Error("Failed to create client key")
+		return nil, util.ErrInternalDefault
+	}
+	secret, private := response.Secret, response.PrivateKey
+	response = converter.NewClientKeyResponse(key)
+	response.Secret, response.PrivateKey = secret, private
+	return response, nil
+}
+
+func (c *LabClientUseCase) ListKeys(ctx context.Context, actorEmail, assignmentID string) (*model.ListClientKeyResponse, error) {
+	assignment, _, err := c.findKeyAssignment(ctx, actorEmail, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := c.ClientKeyRepository.FindByAssignment(ctx, assignment.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	response := &model.ListClientKeyResponse{Keys: make([]model.ClientKeyResponse, 0, len(keys))}
+	for i := range keys {
+		response.Keys = append(response.Keys, *converter.NewClientKeyResponse(&keys[i]))
+	}
+	return response, nil
+}
+
+// RevokeKey stops accepting scores signed with the key, e.g. when a lab client
+// build leaked. Scores already recorded with it stand.
+func (c *LabClientUseCase) RevokeKey(ctx context.Context, actorEmail, assignmentID, id string) error {
+	assignment, _, err := c.findKeyAssignment(ctx, actorEmail, assignmentID)
+	if err != nil {
+		return err
+	}
+	keyID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return util.ErrInvalidID
+	}
+	revoked, err := c.ClientKeyRepository.Revoke(ctx, assignment.ID, keyID)
+	if err != nil {
+		return util.ErrInternalDefault
+	}
+	if !revoked {
+		return util.ErrClientKeyNotFound
+	}
+	return nil
+}
+
+// SubmitScore records a score reported by a lab client after checking that it
+// was signed with an active key of the assignment for this user, that its
+// timestamp is recent and that its nonce was not used before. The score is
+// then recorded like any other, so it cannot exceed the assignment's max score.
+func (c *LabClientUseCase) SubmitScore(ctx context.Context, request *model.UpdateScoreRequest) (*model.UpdateScoreResponse, error) {
+	if request.KeyID == "" || request.Signature == "" {
+		return nil, util.ErrUnsignedScore
+	}
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	if strings.ContainsAny(request.PayloadRef, "\r\n") {
+		return nil, util.ErrInvalidScorePayload
+	}
+	keyID, err := primitive.ObjectIDFromHex(request.KeyID)
+	if err != nil {
+		return nil, util.ErrInvalidSignature
+	}
+	key, err := c.ClientKeyRepository.FindByID(ctx, keyID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if key == nil || key.RevokedAt != nil || key.AssignmentID.Hex() != request.AssignmentID {
+		return nil, util.ErrInvalidSignature
+	}
+	signature, err := base64.StdEncoding.DecodeString(request.Signature)
+	if err != nil {
+		return nil, util.ErrInvalidSignature
+	}
+	message := util.ScoreSigningMessage(request.UserEmail, request.AssignmentID, request.Score,
+		request.PayloadRef, request.Nonce, request.Timestamp)
+	if !key.Verify(message, signature) {
+		c.Log.WithFields(logrus.Fields{
+			"email":         request.UserEmail,
+			"assignment_id": request.AssignmentID,
+			"key_id":        request.KeyID,
+			"source_ip":     request.SourceIP,
+		}).Warn("Rejected score with an invalid signature")
+		return nil, util.ErrInvalidSignature
+	}
+	signedAt := time.Unix(request.Timestamp, 0)
+	if skew := time.Since(signedAt); skew > scoreClockSkew || skew < -scoreClockSkew {
+		return nil, util.ErrSignatureExpired
+	}
+
+	user, err := c.UserRepository.FindByEmail(ctx, request.UserEmail)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	fresh, err := c.ScoreNonceRepository.Use(ctx, &entity.ScoreNonce{
+		KeyID:     key.ID,
+		Nonce:     request.Nonce,
+		UserID:    user.ID,
+		ExpiresAt: signedAt.Add(2 * scoreClockSkew),
+	})
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"key_id":      request.KeyID,
+			util.LogError: err,
+		}).Error("Failed to store score nonce")
+		return nil, util.ErrInternalDefault
+	}
+	if !fresh {
+		c.Log.WithFields(logrus.Fields{
+			"email":         request.UserEmail,
+			"assignment_id": request.AssignmentID,
+			"key_id":        request.KeyID,
+			"source_ip":     request.SourceIP,
+		}).Warn("Rejected replayed score")
+		return nil, util.ErrReplayedSubmission
+	}
+
+	return c.ScoreUseCase.RecordScore(ctx, &model.RecordScoreRequest{
+		UserEmail:    request.UserEmail,
+		AssignmentID: request.AssignmentID,
+		Score:        request.Score,
+		PayloadRef:   request.PayloadRef,
+		Grader:       "lab_client:" + key.ID.Hex(),
+		SourceIP:     request.SourceIP,
+	})
+}
+
+// findKeyAssignment finds the assignment whose keys the actor manages. Only
+// admins and the staff of the assignment's course may, or for an assignment
+// outside any course, its creator.
+func (c *LabClientUseCase) findKeyAssignment(ctx context.Context, actorEmail, assignmentID string) (*entity.Assignment, *entity.User, error) {
+	assignment, err := c.findAssignment(ctx, assignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	actor, err := c.UserRepository.FindByEmail(ctx, actorEmail)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	if actor == nil {
+		return nil, nil, util.ErrUserNotFound
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, nil, err
+	}
+	return assignment, actor, nil
+}
+
+func (c *LabClientUseCase) findAssignment(ctx context.Context, id string) (*entity.Assignment, error) {
+	assignmentID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	return assignment, nil
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LeaderboardUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	ScoreRepository        *repository.ScoreRepository
+	EnrollmentRepository   *repository.EnrollmentRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	UserRepository         *repository.UserRepository
+}
+
+func NewLeaderboardUseCase(logger *logrus.Logger, validate *validator.Validate,
+	scoreRepository *repository.ScoreRepository, enrollmentRepository *repository.EnrollmentRepository,
+	cohortMemberRepository *repository.CohortMemberRepository, userRepository *repository.UserRepository) *LeaderboardUseCase {
+	return &LeaderboardUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		ScoreRepository:        scoreRepository,
+		EnrollmentRepository:   enrollmentRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		UserRepository:         userRepository,
+	}
+}
+
+func (c *LeaderboardUseCase) List(ctx context.Context, request *model.LeaderboardRequest) (*model.LeaderboardResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	user, err := c.findUser(ctx, request.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := c.newFilter(ctx, user, request.ActorRole, request.CourseID, request.AssignmentID, request.CohortID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, total, err := c.ScoreRepository.Leaderboard(ctx, filter, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to build leaderboard")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.LeaderboardResponse{
+		Entries: make([]model.LeaderboardEntryResponse, 0, len(entries)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range entries {
+		response.Entries = append(response.Entries, *converter.NewLeaderboardEntryResponse(&entries[i], user.ID))
+	}
+	return response, nil
+}
+
+// Me returns the caller's rank and the entries directly above and below them.
+// Callers who opted out still see their own standing; nobody else sees them.
+func (c *LeaderboardUseCase) Me(ctx context.Context, request *model.LeaderboardMeRequest) (*model.LeaderboardMeResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	user, err := c.findUser(ctx, request.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := c.newFilter(ctx, user, request.ActorRole, request.CourseID, request.AssignmentID, request.CohortID)
+	if err != nil {
+		return nil, err
+	}
+	filter.IncludeUserID = &user.ID
+
+	mine, neighbours, err := c.ScoreRepository.LeaderboardAround(ctx, filter, user.ID, request.Neighbours)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find leaderboard position")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.LeaderboardMeResponse{
+		OptedOut:   user.LeaderboardOptOut,
+		Neighbours: make([]model.LeaderboardEntryResponse, 0, len(neighbours)),
+	}
+	if mine != nil {
+		response.Ranked = true
+		response.Rank = mine.Rank
+		response.Score = mine.Total
+	}
+	for i := range neighbours {
+		response.Neighbours = append(response.Neighbours, *converter.NewLeaderboardEntryResponse(&neighbours[i], user.ID))
+	}
+	return response, nil
+}
+
+func (c *LeaderboardUseCase) UpdateVisibility(ctx context.Context, request *model.UpdateLeaderboardVisibilityRequest) (*model.UpdateLeaderboardVisibilityResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	user, err := c.findUser(ctx, request.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.UserRepository.UpdateLeaderboardOptOut(ctx, user.ID, request.OptOut); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update leaderboard visibility")
+		return nil, util.ErrInternalDefault
+	}
+	return &model.UpdateLeaderboardVisibilityResponse{OptOut: request.OptOut}, nil
+}
+
+func (c *LeaderboardUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// newFilter scopes the leaderboard to a course when one is given, which students
+// may only see for courses they are enrolled in, and to the students of a cohort,
+// which only its members and admins may see.
+func (c *LeaderboardUseCase) newFilter(ctx context.Context, user *entity.User, role, courseID, assignmentID, cohortID string) (entity.LeaderboardFilter, error) {
+	filter := entity.LeaderboardFilter{}
+	if courseID != "" {
+		id, err := primitive.ObjectIDFromHex(courseID)
+		if err != nil {
+			return filter, util.ErrInvalidID
+		}
+		if _, err = requireCourseAccess(ctx, c.EnrollmentRepository, id, user.ID, entity.IsStaffRole(role)); err != nil {
+			return filter, err
+		}
+		filter.CourseID = &id
+	}
+	if assignmentID != "" {
+		id, err := primitive.ObjectIDFromHex(assignmentID)
+		if err != nil {
+			return filter, util.ErrInvalidID
+		}
+		filter.AssignmentID = &id
+	}
+	if cohortID != "" {
+		id, err := primitive.ObjectIDFromHex(cohortID)
+		if err != nil {
+			return filter, util.ErrInvalidID
+		}
+		if role != entity.RoleAdmin {
+			member, err := c.CohortMemberRepository.Find(ctx, id, user.ID)
+			if err != nil {
+				return filter, util.ErrInternalDefault
+			}
+			if member == nil {
+				return filter, util.ErrPermissionDenied
+			}
+		}
+		if filter.UserIDs, err = c.CohortMemberRepository.FindStudentIDs(ctx, []primitive.ObjectID{id}); err != nil {
+			return filter, util.ErrInternalDefault
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type MailUseCase struct {
+	Log                 *logrus.Logger
+	Validate            *validator.Validate
+	MailEventRepository *repository.MailEventRepository
+	UserRepository      *repository.UserRepository
+	Config              *viper.Viper
+}
+
+func NewMailUseCase(logger *logrus.Logger, validate *validator.Validate,
+	mailEventRepository *repository.MailEventRepository, userRepository *repository.UserRepository,
+	config *viper.Viper) *MailUseCase {
+	return &MailUseCase{
+		Log:                 logger,
+		Validate:            validate,
+		MailEventRepository: mailEventRepository,
+		UserRepository:      userRepository,
+		Config:              config,
+	}
+}
+
+// Send delivers mail unless the address has hard bounced or complained. Every
+// outgoing email should go through here rather than util.SendMail directly.
+func (c *MailUseCase) Send(ctx context.Context, email, subject, body string) error {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user != nil && user.EmailUndeliverable {
+		c.Log.WithFields(logrus.Fields{
+			"email":   email,
+			"subject": subject,
+		}).Warn("Skipped sending to undeliverable address")
+		return util.ErrEmailUndeliverable
+	}
+	return util.SendMail(email, subject, body, c.Config)
+}
+
+func (c *MailUseCase) VerifyWebhookSecret(secret string) bool {
+	expected := c.Config.GetString("MAIL_WEBHOOK_SECRET")
+	if expected == "" || secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(secret)) == 1
+}
+
+func (c *MailUseCase) HandleWebhook(ctx context.Context, request *model.MailWebhookRequest) (*model.MailWebhookResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+
+	events := make([]entity.MailEvent, 0, len(request.Events))
+	for _, item := range request.Events {
+		event := entity.MailEvent{
+			Email:      strings.ToLower(item.Email),
+			Source:     entity.MailEventSourceWebhook,
+			Status:     item.Status,
+			Diagnostic: item.Diagnostic,
+			OccurredAt: util.NowInWIB(),
+		}
+		if item.Timestamp != nil {
+			event.OccurredAt = *item.Timestamp
+		}
+		switch {
+		case item.Type == "complaint":
+			event.Type = entity.MailEventComplaint
+		case item.BounceType == "soft":
+			event.Type = entity.MailEventSoftBounce
+		default:
+			event.Type = entity.MailEventHardBounce
+		}
+		events = append(events, event)
+	}
+	return c.RecordEvents(ctx, events)
+}
+
+func (c *MailUseCase) HandleRawReport(ctx context.Context, r io.Reader, source string) (*model.MailWebhookResponse, error) {
+	events, err := util.ParseMailReport(r)
+	if err != nil {
+		if errors.Is(err, util.ErrNotMailReport) {
+			return nil, util.ErrInvalidMailReport
+		}
+		return nil, util.NewCustomError(err)
+	}
+	for i := range events {
+		events[i].Source = source
+	}
+	return c.RecordEvents(ctx, events)
+}
+
+// RecordEvents stores each event and flags the matching user as undeliverable
+// when the event is a hard bounce or a complaint.
+func (c *MailUseCase) RecordEvents(ctx context.Context, events []entity.MailEvent) (*model.MailWebhookResponse, error) {
+	response := &model.MailWebhookResponse{}
+	for i := range events {
+		event := &events[i]
+		if err := c.MailEventRepository.Create(ctx, event); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"email":       event.Email,
+				"type":        event.Type,
+				util.LogError: err,
+			}).Error("Failed to record mail event")
+			return nil, util.ErrInternalDefault
+		}
+		response.Recorded++
+
+		if !event.Suppresses() {
+			continue
+		}
+		reason := event.Type
+		if event.Status != "" {
+			reason = fmt.Sprintf("%s (%s)", event.Type, event.Status)
+		}
+		flagged, err := c.UserRepository.MarkEmailUndeliverable(ctx, event.Email, reason, event.OccurredAt)
+		if err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"email":       event.Email,
+				util.LogError: err,
+			}).Error("Failed to flag user email as undeliverable")
+			return nil, util.ErrInternalDefault
+		}
+		if flagged {
+			response.Suppressed++
+		}
+	}
+	return response, nil
+}
+
+// IngestMailbox parses every bounce message dropped into dir. Processed files are
+// moved into dir/processed and unreadable ones into dir/failed, so the directory
+// only ever holds messages that still need work.
+func (c *MailUseCase) IngestMailbox(ctx context.Context, dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	processedDir := filepath.Join(dir, "processed")
+	failedDir := filepath.Join(dir, "failed")
+	if err = os.MkdirAll(processedDir, 0o755); err != nil {
+		return 0, err
+	}
+	if err = os.MkdirAll(failedDir, 0o755); err != nil {
+		return 0, err
+	}
+
+	recorded := 0
+	for _, item := range entries {
+		if item.IsDir() || strings.HasPrefix(item.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, item.Name())
+		target := processedDir
+
+		file, err := os.Open(path)
+		if err != nil {
+			return recorded, err
+		}
+		response, err := c.HandleRawReport(ctx, file, entity.MailEventSourceMailbox)
+		file.Close()
+		if err != nil {
+			if errors.Is(err, util.ErrInternalDefault) {
+				// Database trouble: leave the file for the next run.
+				return recorded, err
+			}
+			target = failedDir
+			c.Log.WithFields(logrus.Fields{
+				"file":        path,
+				util.LogError: err,
+			}).Warn("Failed to parse bounce message")
+		} else {
+			recorded += response.Recorded
+		}
+
+		if err = os.Rename(path, filepath.Join(target, item.Name())); err != nil {
+			return recorded, err
+		}
+	}
+	return recorded, nil
+}
@@ -0,0 +1,308 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type NotificationUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	NotificationRepository *repository.NotificationRepository
+	UserRepository         *repository.UserRepository
+	MailUseCase            *MailUseCase
+	Config                 *viper.Viper
+}
+
+func NewNotificationUseCase(logger *logrus.Logger, validate *validator.Validate,
+	notificationRepository *repository.NotificationRepository, userRepository *repository.UserRepository,
+	mailUseCase *MailUseCase, config *viper.Viper) *NotificationUseCase {
+	return &NotificationUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		NotificationRepository: notificationRepository,
+		UserRepository:         userRepository,
+		MailUseCase:            mailUseCase,
+		Config:                 config,
+	}
+}
+
+// Notify stores an in-app notification for the user and delivers it according to
+// the user's preference for the category. Other use cases call it after their own
+// work has succeeded, so failures are logged here and returned for the caller to
+// decide whether they matter.
+func (c *NotificationUseCase) Notify(ctx context.Context, userID primitive.ObjectID, category, title, body string) error {
+	user, err := c.UserRepository.FindByID(ctx, userID)
+	if err != nil || user == nil {
+		c.Log.WithFields(logrus.Fields{
+			"user_id":     userID.Hex(),
+			"category":    category,
+			util.LogError: err,
+		}).Error("Failed to find notification recipient")
+		return util.ErrUserNotFound
+	}
+
+	delivery := user.NotificationDelivery(category)
+	if delivery == entity.NotificationDeliveryOff {
+		return nil
+	}
+
+	notification := &entity.Notification{
+		UserID:   userID,
+		Category: category,
+		Title:    title,
+		Body:     body,
+		Delivery: delivery,
+	}
+	if err := c.NotificationRepository.Create(ctx, notification); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"user_id":     userID.Hex(),
+			"category":    category,
+			util.LogError: err,
+		}).Error("Failed to create notification")
+		return err
+	}
+
+	if delivery == entity.NotificationDeliveryInstantEmail && !user.EmailUndeliverable {
+		// SMTP can take seconds; don't hold the caller's request open for it.
+		go c.sendInstantEmail(user, notification)
+	}
+	return nil
+}
+
+func (c *NotificationUseCase) sendInstantEmail(user *entity.User, notification *entity.Notification) {
+	if err := c.MailUseCase.Send(context.Background(), user.Email, notification.Title, notification.Body); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       user.Email,
+			"category":    notification.Category,
+			util.LogError: err,
+		}).Error("Failed to send notification email")
+		return
+	}
+	if err := c.NotificationRepository.MarkEmailed(context.Background(), []primitive.ObjectID{notification.ID}); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"notification_id": notification.ID.Hex(),
+			util.LogError:     err,
+		}).Error("Failed to mark notification as emailed")
+	}
+}
+
+// SendDailyDigests batches every pending digest notification into one email per
+// user. A user receives at most one digest per local calendar day, and only once
+// their local clock has passed DIGEST_HOUR. It is safe to run as often as needed.
+func (c *NotificationUseCase) SendDailyDigests(ctx context.Context) (int, error) {
+	digestHour := 18
+	if c.Config.IsSet("DIGEST_HOUR") {
+		digestHour = c.Config.GetInt("DIGEST_HOUR")
+	}
+
+	userIDs, err := c.NotificationRepository.FindPendingDigestUserIDs(ctx)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogError: err,
+		}).Error("Failed to find users with pending digests")
+		return 0, err
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		user, err := c.UserRepository.FindByID(ctx, userID)
+		if err != nil || user == nil || user.EmailUndeliverable {
+			continue
+		}
+		now := util.NowIn(user.Timezone)
+		if now.Hour() < digestHour {
+			continue
+		}
+		if user.LastDigestAt != nil {
+			last := user.LastDigestAt.In(now.Location())
+			if last.Year() == now.Year() && last.YearDay() == now.YearDay() {
+				continue
+			}
+		}
+
+		notifications, err := c.NotificationRepository.FindPendingDigest(ctx, userID)
+		if err != nil || len(notifications) == 0 {
+			continue
+		}
+
+		var body strings.Builder
+		ids := make([]primitive.ObjectID, 0, len(notifications))
+		fmt.Fprintf(&body, "Hi %s, here is what happened in Central Lab today:\n\n", user.Name)
+		for _, notification := range notifications {
+			fmt.Fprintf(&body, "- [%s] %s: %s\n", notification.CreatedAt.In(now.Location()).Format("15:04"),
+				notification.Title, notification.Body)
+			ids = append(ids, notification.ID)
+		}
+
+		subject := fmt.Sprintf("Your Central Lab digest for %s", now.Format("2 Jan 2006"))
+		if err = c.MailUseCase.Send(ctx, user.Email, subject, body.String()); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"email":       user.Email,
+				util.LogError: err,
+			}).Error("Failed to send digest email")
+			continue
+		}
+		if err = c.NotificationRepository.MarkEmailed(ctx, ids); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"email":       user.Email,
+				util.LogError: err,
+			}).Error("Failed to mark digest notifications as emailed")
+		}
+		if err = c.UserRepository.UpdateLastDigestAt(ctx, user.ID, now); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"email":       user.Email,
+				util.LogError: err,
+			}).Error("Failed to update last digest time")
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (c *NotificationUseCase) GetPreferences(ctx context.Context, email string) (*model.NotificationPreferencesResponse, error) {
+	user, err := c.findUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewNotificationPreferencesResponse(user), nil
+}
+
+func (c *NotificationUseCase) UpdatePreferences(ctx context.Context, request *model.UpdateNotificationPreferencesRequest) (*model.NotificationPreferencesResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	if request.Timezone != "" && !util.IsValidTimezone(request.Timezone) {
+		return nil, util.ErrInvalidTimezone
+	}
+	for category, delivery := range request.Preferences {
+		if !util.Contain(entity.NotificationCategories, category) || !util.Contain(entity.NotificationDeliveries, delivery) {
+			return nil, util.ErrInvalidNotificationPreference
+		}
+	}
+
+	user, err := c.findUser(ctx, request.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	if request.Timezone != "" {
+		user.Timezone = request.Timezone
+	}
+	if user.NotificationPreferences == nil {
+		user.NotificationPreferences = map[string]string{}
+	}
+	for category, delivery := range request.Preferences {
+		user.NotificationPreferences[category] = delivery
+	}
+
+	if err = c.UserRepository.UpdateNotificationPreferences(ctx, user); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update notification preferences")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewNotificationPreferencesResponse(user), nil
+}
+
+func (c *NotificationUseCase) List(ctx context.Context, request *model.ListNotificationRequest) (*model.ListNotificationResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	user, err := c.findUser(ctx, request.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, total, err := c.NotificationRepository.FindByUser(ctx, user.ID, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find notifications in database")
+		return nil, util.ErrInternalDefault
+	}
+	unread, err := c.NotificationRepository.CountUnread(ctx, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListNotificationResponse{
+		Notifications: make([]model.NotificationResponse, 0, len(notifications)),
+		UnreadCount:   unread,
+		Paging:        converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range notifications {
+		response.Notifications = append(response.Notifications, *converter.NewNotificationResponse(&notifications[i]))
+	}
+	return response, nil
+}
+
+func (c *NotificationUseCase) MarkRead(ctx context.Context, request *model.MarkNotificationReadRequest) error {
+	if err := c.Validate.Struct(request); err != nil {
+		return util.NewCustomError(err)
+	}
+	id, err := primitive.ObjectIDFromHex(request.NotificationID)
+	if err != nil {
+		return util.ErrInvalidID
+	}
+	user, err := c.findUser(ctx, request.UserEmail)
+	if err != nil {
+		return err
+	}
+
+	found, err := c.NotificationRepository.MarkRead(ctx, user.ID, id)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to mark notification as read")
+		return util.ErrInternalDefault
+	}
+	if !found {
+		return util.ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (c *NotificationUseCase) MarkAllRead(ctx context.Context, email string) (*model.MarkAllNotificationReadResponse, error) {
+	user, err := c.findUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := c.NotificationRepository.MarkAllRead(ctx, user.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to mark all notifications as read")
+		return nil, util.ErrInternalDefault
+	}
+	return &model.MarkAllNotificationReadResponse{Updated: updated, UnreadCount: 0}, nil
+}
+
+func (c *NotificationUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
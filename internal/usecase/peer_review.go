@@ -0,0 +1,867 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PeerReviewUseCase struct {
+	Log                      *logrus.Logger
+	Validate                 *validator.Validate
+	PeerReviewRepository     *repository.PeerReviewRepository
+	PeerSubmissionRepository *repository.PeerSubmissionRepository
+	PeerReviewTaskRepository *repository.PeerReviewTaskRepository
+	PeerResultRepository     *repository.PeerResultRepository
+	RubricRepository         *repository.RubricRepository
+	AssignmentRepository     *repository.AssignmentRepository
+	CourseRepository         *repository.CourseRepository
+	EnrollmentRepository     *repository.EnrollmentRepository
+	CohortRepository         *repository.CohortRepository
+	CohortMemberRepository   *repository.CohortMemberRepository
+	UserRepository           *repository.UserRepository
+	ScoreUseCase             *ScoreUseCase
+	NotificationUseCase      *NotificationUseCase
+}
+
+func NewPeerReviewUseCase(logger *logrus.Logger, validate *validator.Validate,
+	peerReviewRepository *repository.PeerReviewRepository, peerSubmissionRepository *repository.PeerSubmissionRepository,
+	peerReviewTaskRepository *repository.PeerReviewTaskRepository, peerResultRepository *repository.PeerResultRepository,
+	rubricRepository *repository.RubricRepository, assignmentRepository *repository.AssignmentRepository,
+	courseRepository *repository.CourseRepository, enrollmentRepository *repository.EnrollmentRepository,
+	cohortRepository *repository.CohortRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	userRepository *repository.UserRepository, scoreUseCase *ScoreUseCase, notificationUseCase *NotificationUseCase) *PeerReviewUseCase {
+	return &PeerReviewUseCase{
+		Log:                      logger,
+		Validate:                 validate,
+		PeerReviewRepository:     peerReviewRepository,
+		PeerSubmissionRepository: peerSubmissionRepository,
+		PeerReviewTaskRepository: peerReviewTaskRepository,
+		PeerResultRepository:     peerResultRepository,
+		RubricRepository:         rubricRepository,
+		AssignmentRepository:     assignmentRepository,
+		CourseRepository:         courseRepository,
+		EnrollmentRepository:     enrollmentRepository,
+		CohortRepository:         cohortRepository,
+		CohortMemberRepository:   cohortMemberRepository,
+		UserRepository:           userRepository,
+		ScoreUseCase:             scoreUseCase,
+		NotificationUseCase:      notificationUseCase,
+	}
+}
+
+// Save sets the assignment up for peer review, or changes its settings, and
+// makes the assignment peer graded. Once the reviews are handed out the rubric
+// and number of reviewers are fixed.
+func (c *PeerReviewUseCase) Save(ctx context.Context, request *model.SavePeerReviewRequest) (*model.PeerReviewResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.DueAt == nil || !request.ReviewDueAt.After(*assignment.DueAt) {
+		return nil, util.ErrInvalidPeerReview
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, err
+	}
+	rubricID, err := primitive.ObjectIDFromHex(request.RubricID)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	rubric, err := c.RubricRepository.FindByID(ctx, rubricID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if rubric == nil {
+		return nil, util.ErrRubricNotFound
+	}
+	if rubric.CourseID != nil && !sameObjectID(rubric.CourseID, assignment.CourseID) {
+		return nil, util.ErrRubricOtherCourse
+	}
+	existing, err := c.PeerReviewRepository.FindByAssignment(ctx, assignment.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if existing != nil && existing.FinalizedAt != nil {
+		return nil, util.ErrPeerReviewClosed
+	}
+	if existing != nil && existing.AllocatedAt != nil &&
+		(existing.RubricID != rubric.ID || existing.Reviewers != request.Reviewers) {
+		return nil, util.ErrPeerReviewStarted
+	}
+
+	review := &entity.PeerReview{
+		AssignmentID:  assignment.ID,
+		RubricID:      rubric.ID,
+		Reviewers:     request.Reviewers,
+		Aggregation:   request.Aggregation,
+		CreditPercent: request.CreditPercent,
+		ReviewDueAt:   request.ReviewDueAt,
+		CreatedBy:     actor.ID,
+	}
+	if review.Aggregation == "" {
+		review.Aggregation = entity.PeerAggregationMedian
+	}
+	if err = c.PeerReviewRepository.Save(ctx, review); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to save peer review")
+		return nil, util.ErrInternalDefault
+	}
+	if review.AllocatedAt != nil && !existing.ReviewDueAt.Equal(review.ReviewDueAt) {
+		if err = c.PeerReviewTaskRepository.SetDueAt(ctx, assignment.ID, review.ReviewDueAt); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				util.LogError:   err,
+			}).Error("Failed to move peer review due dates")
+			return nil, util.ErrInternalDefault
+		}
+	}
+	if assignment.Grading != entity.GradingPeerReview {
+		if err = c.AssignmentRepository.SetGrading(ctx, assignment.ID, entity.GradingPeerReview); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				util.LogError:   err,
+			}).Error("Failed to make assignment peer reviewed")
+			return nil, util.ErrInternalDefault
+		}
+	}
+	return converter.NewPeerReviewResponse(review, rubric), nil
+}
+
+// Get shows the peer review settings with the rubric reviewers grade with.
+func (c *PeerReviewUseCase) Get(ctx context.Context, assignmentID string) (*model.PeerReviewResponse, error) {
+	_, review, err := c.findPeerReview(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	rubric, err := c.RubricRepository.FindByID(ctx, review.RubricID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewPeerReviewResponse(review, rubric), nil
+}
+
+// Submit hands in the student's work, replacing what they handed in before,
+// while the assignment is open and the reviews have not been handed out.
+func (c *PeerReviewUseCase) Submit(ctx context.Context, request *model.SavePeerSubmissionRequest) (*model.PeerSubmissionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, review, err := c.findPeerReview(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if review.AllocatedAt != nil {
+		return nil, util.ErrPeerReviewStarted
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.CourseID != nil {
+		enrollment, err := c.EnrollmentRepository.FindByCourseAndUser(ctx, *assignment.CourseID, user.ID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if enrollment == nil || enrollment.Status != entity.EnrollmentActive {
+			return nil, util.ErrNotEnrolled
+		}
+	}
+	loc, err := courseLocation(ctx, c.CourseRepository, assignment.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	extension, err := c.ScoreUseCase.extension(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	if _, closesAt := assignment.DeadlinesFor(extension); !assignment.IsOpenUntil(now, closesAt) {
+		return nil, util.ErrAssignmentClosed
+	}
+
+	submission := &entity.PeerSubmission{
+		AssignmentID: assignment.ID,
+		UserID:       user.ID,
+		Content:      request.Content,
+		SubmittedAt:  now,
+	}
+	if err = c.PeerSubmissionRepository.Save(ctx, submission); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to save peer review submission")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewPeerSubmissionResponse(submission), nil
+}
+
+func (c *PeerReviewUseCase) GetSubmission(ctx context.Context, request *model.PeerReviewActionRequest) (*model.PeerSubmissionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	submission, err := c.PeerSubmissionRepository.FindByAssignmentAndUser(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if submission == nil {
+		return nil, util.ErrPeerSubmissionNotFound
+	}
+	return converter.NewPeerSubmissionResponse(submission), nil
+}
+
+// Allocate hands out the reviews now instead of waiting for the due date.
+func (c *PeerReviewUseCase) Allocate(ctx context.Context, request *model.PeerReviewActionRequest) (*model.AllocatePeerReviewResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, review, err := c.findManagedPeerReview(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if review.AllocatedAt != nil {
+		return nil, util.ErrPeerReviewStarted
+	}
+	response, err := c.allocate(ctx, assignment, review)
+	if err != nil {
+		var customErr util.CustomError
+		if errors.As(err, &customErr) {
+			return nil, customErr
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to hand out peer reviews")
+		return nil, util.ErrInternalDefault
+	}
+	return response, nil
+}
+
+// allocate hands every submission to review.Reviewers other students of the
+// author's cohort; students without a cohort review each other. Within a group
+// the submitters are shuffled into a ring and each reviews the next ones, so
+// everybody gives and gets the same number of reviews and nobody reviews their
+// own work. Smaller groups get as many reviewers as they have other members.
+func (c *PeerReviewUseCase) allocate(ctx context.Context, assignment *entity.Assignment, review *entity.PeerReview) (*model.AllocatePeerReviewResponse, error) {
+	claimed, err := c.PeerReviewRepository.MarkAllocated(ctx, review.ID, util.NowInWIB())
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, util.ErrPeerReviewStarted
+	}
+	submissions, err := c.PeerSubmissionRepository.FindByAssignment(ctx, assignment.ID)
+	if err != nil {
+		return nil, c.releaseAllocation(ctx, review, err)
+	}
+
+	groups := map[primitive.ObjectID][]entity.PeerSubmission{}
+	for _, submission := range submissions {
+		cohortID, err := c.CohortMemberRepository.FindStudentCohortID(ctx, submission.UserID)
+		if err != nil {
+			return nil, c.releaseAllocation(ctx, review, err)
+		}
+		key := primitive.NilObjectID
+		if cohortID != nil {
+			key = *cohortID
+		}
+		groups[key] = append(groups[key], submission)
+	}
+	tasks := []entity.PeerReviewTask{}
+	assigned := map[primitive.ObjectID]int{}
+	for _, group := range groups {
+		rand.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+		reviewers := min(review.Reviewers, len(group)-1)
+		for i, submission := range group {
+			for offset := 1; offset <= reviewers; offset++ {
+				reviewer := group[(i+offset)%len(group)].UserID
+				tasks = append(tasks, entity.PeerReviewTask{
+					AssignmentID: assignment.ID,
+					SubmissionID: submission.ID,
+					AuthorID:     submission.UserID,
+					ReviewerID:   reviewer,
+					DueAt:        review.ReviewDueAt,
+				})
+				assigned[reviewer]++
+			}
+		}
+	}
+	if err = c.PeerReviewTaskRepository.CreateMany(ctx, tasks); err != nil {
+		return nil, c.releaseAllocation(ctx, review, err)
+	}
+
+	for reviewerID, count := range assigned {
+		_ = c.NotificationUseCase.Notify(ctx, reviewerID, entity.NotificationCategoryAssignment, "Peer reviews assigned",
+			fmt.Sprintf("You have %d submission(s) of %q to review by %s.", count, assignment.Title,
+				review.ReviewDueAt.Format("2006-01-02 15:04 MST")))
+	}
+	c.Log.WithFields(logrus.Fields{
+		"assignment_id": assignment.ID.Hex(),
+		"submissions":   len(submissions),
+		"reviews":       len(tasks),
+	}).Info("Handed out peer reviews")
+	return &model.AllocatePeerReviewResponse{Submissions: len(submissions), Reviews: len(tasks)}, nil
+}
+
+func (c *PeerReviewUseCase) releaseAllocation(ctx context.Context, review *entity.PeerReview, cause error) error {
+	if err := c.PeerReviewRepository.ReleaseAllocation(ctx, review.ID); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"assignment_id": review.AssignmentID.Hex(),
+			util.LogError:   err,
+		}).Error("Failed to release peer review allocation")
+	}
+	return cause
+}
+
+// ListTasks lists the reviews the student has to give. They show the work to
+// review but not whose it is.
+func (c *PeerReviewUseCase) ListTasks(ctx context.Context, request *model.ListPeerReviewTaskRequest) (*model.ListPeerReviewTaskResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	tasks, total, err := c.PeerReviewTaskRepository.FindByReviewer(ctx, user.ID, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find peer reviews")
+		return nil, util.ErrInternalDefault
+	}
+	response := &model.ListPeerReviewTaskResponse{
+		Reviews: make([]model.PeerReviewTaskResponse, 0, len(tasks)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range tasks {
+		content, err := c.content(ctx, &tasks[i])
+		if err != nil {
+			return nil, err
+		}
+		response.Reviews = append(response.Reviews, *converter.NewPeerReviewTaskResponse(&tasks[i], content))
+	}
+	return response, nil
+}
+
+// GetTask shows a review to its reviewer, or with both emails to staff who
+// teach the author.
+func (c *PeerReviewUseCase) GetTask(ctx context.Context, request *model.GetPeerReviewTaskRequest) (*model.PeerReviewTaskResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	task, err := c.findTask(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := c.content(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	response := converter.NewPeerReviewTaskResponse(task, content)
+	if task.ReviewerID == actor.ID {
+		return response, nil
+	}
+	if !entity.IsStaffRole(actor.GetRole()) {
+		return nil, util.ErrPeerReviewTaskNotFound
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, task.AuthorID); err != nil {
+		return nil, err
+	}
+	emails := map[primitive.ObjectID]string{}
+	response.AuthorEmail = c.email(ctx, emails, task.AuthorID)
+	response.ReviewerEmail = c.email(ctx, emails, task.ReviewerID)
+	return response, nil
+}
+
+// SubmitReview grades the work with the peer review's rubric. A review can be
+// changed until the scores are finalized; whether it was on time is decided by
+// when it was first handed in.
+func (c *PeerReviewUseCase) SubmitReview(ctx context.Context, request *model.SubmitPeerReviewRequest) (*model.PeerReviewTaskResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	task, err := c.findTask(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if task.ReviewerID != user.ID {
+		return nil, util.ErrPeerReviewTaskNotFound
+	}
+	_, review, err := c.findPeerReview(ctx, task.AssignmentID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if review.FinalizedAt != nil {
+		return nil, util.ErrPeerReviewClosed
+	}
+	rubric, err := c.RubricRepository.FindByID(ctx, review.RubricID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if rubric == nil {
+		return nil, util.ErrRubricNotFound
+	}
+
+	if task.Criteria, task.Earned, err = gradeWithRubric(rubric, request.Criteria); err != nil {
+		return nil, err
+	}
+	task.Possible = rubric.MaxPoints()
+	task.Comment = request.Comment
+	if task.SubmittedAt == nil {
+		now := util.NowInWIB()
+		task.SubmittedAt = &now
+	}
+	if err = c.PeerReviewTaskRepository.Submit(ctx, task); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to submit peer review")
+		return nil, util.ErrInternalDefault
+	}
+	content, err := c.content(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewPeerReviewTaskResponse(task, content), nil
+}
+
+// Received shows a student the reviews of their work, without who gave them,
+// and their result once there is one.
+func (c *PeerReviewUseCase) Received(ctx context.Context, request *model.ReceivedPeerReviewRequest) (*model.ReceivedPeerReviewResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, _, err := c.findPeerReview(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := c.PeerReviewTaskRepository.FindCompletedByAuthor(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	result, err := c.PeerResultRepository.FindByAssignmentAndUser(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	response := &model.ReceivedPeerReviewResponse{Reviews: make([]model.PeerReviewTaskResponse, 0, len(tasks))}
+	for i := range tasks {
+		response.Reviews = append(response.Reviews, *converter.NewPeerReviewTaskResponse(&tasks[i], ""))
+	}
+	if result != nil {
+		response.Result = converter.NewPeerResultResponse(result, user.Email)
+		response.Result.OverrideReason = ""
+	}
+	return response, nil
+}
+
+// ListReviews shows staff every review of the work of the students they teach,
+// with who gave it.
+func (c *PeerReviewUseCase) ListReviews(ctx context.Context, request *model.ListAssignmentPeerReviewRequest) (*model.ListPeerReviewTaskResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	userIDs, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	tasks, total, err := c.PeerReviewTaskRepository.FindByAssignment(ctx, assignment.ID, userIDs, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find peer reviews")
+		return nil, util.ErrInternalDefault
+	}
+
+	emails := map[primitive.ObjectID]string{}
+	response := &model.ListPeerReviewTaskResponse{
+		Reviews: make([]model.PeerReviewTaskResponse, 0, len(tasks)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range tasks {
+		item := converter.NewPeerReviewTaskResponse(&tasks[i], "")
+		item.AuthorEmail = c.email(ctx, emails, tasks[i].AuthorID)
+		item.ReviewerEmail = c.email(ctx, emails, tasks[i].ReviewerID)
+		response.Reviews = append(response.Reviews, *item)
+	}
+	return response, nil
+}
+
+// Finalize records the scores now instead of waiting for the review due date.
+// Reviews are closed from then on, so running it again records nothing new.
+func (c *PeerReviewUseCase) Finalize(ctx context.Context, request *model.PeerReviewActionRequest) (*model.FinalizePeerReviewResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, review, err := c.findManagedPeerReview(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if review.AllocatedAt == nil {
+		return nil, util.ErrPeerReviewNotStarted
+	}
+	response, err := c.finalize(ctx, assignment, review)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to finalize peer review")
+		return nil, util.ErrInternalDefault
+	}
+	return response, nil
+}
+
+// finalize combines the reviews each student's work got and the reviews they
+// gave on time into their result, and records it as their score. Students
+// whose work nobody reviewed are left without a score until staff override it.
+// Once finalized, scores that did not change are not recorded again.
+func (c *PeerReviewUseCase) finalize(ctx context.Context, assignment *entity.Assignment, review *entity.PeerReview) (*model.FinalizePeerReviewResponse, error) {
+	tasks, err := c.PeerReviewTaskRepository.FindAllByAssignment(ctx, assignment.ID)
+	if err != nil {
+		return nil, err
+	}
+	results := map[primitive.ObjectID]*entity.PeerResult{}
+	scores := map[primitive.ObjectID][]float64{}
+	result := func(userID primitive.ObjectID) *entity.PeerResult {
+		if results[userID] == nil {
+			results[userID] = &entity.PeerResult{AssignmentID: assignment.ID, UserID: userID}
+		}
+		return results[userID]
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		reviewer := result(task.ReviewerID)
+		reviewer.Assigned++
+		if task.OnTime() {
+			reviewer.OnTime++
+		}
+		author := result(task.AuthorID)
+		if task.SubmittedAt != nil && task.Possible > 0 {
+			author.Reviews++
+			scores[task.AuthorID] = append(scores[task.AuthorID], float64(task.Earned)/float64(task.Possible))
+		}
+	}
+
+	response := &model.FinalizePeerReviewResponse{}
+	for userID, result := range results {
+		existing, err := c.PeerResultRepository.FindByAssignmentAndUser(ctx, assignment.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(scores[userID]) > 0 {
+			peer := review.Combine(scores[userID])
+			score := review.Score(peer, result.OnTime, result.Assigned, assignment.MaxScore)
+			result.PeerScore, result.Score = &peer, &score
+		}
+		if existing != nil && existing.Override != nil {
+			result.Score = existing.Override
+		}
+		if err = c.PeerResultRepository.Save(ctx, result); err != nil {
+			return nil, err
+		}
+		if result.Score == nil {
+			response.Unscored++
+			continue
+		}
+		if review.FinalizedAt != nil && existing != nil && existing.Score != nil && *existing.Score == *result.Score {
+			response.Scored++
+			continue
+		}
+		user, err := c.UserRepository.FindByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			continue
+		}
+		if _, err = c.ScoreUseCase.recordGrade(ctx, assignment, user, nil, *result.Score, "Peer review", ""); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"assignment_id": assignment.ID.Hex(),
+				"email":         user.Email,
+				util.LogError:   err,
+			}).Warn("Failed to record peer review score")
+			response.Unscored++
+			continue
+		}
+		response.Scored++
+	}
+	if err = c.PeerReviewRepository.MarkFinalized(ctx, review.ID, util.NowInWIB()); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Override gives a student a score of the staff member's choosing instead of
+// the one worked out from the reviews, e.g. for work nobody reviewed. Once the
+// scores are finalized it is recorded straight away.
+func (c *PeerReviewUseCase) Override(ctx context.Context, request *model.OverridePeerScoreRequest) (*model.PeerResultResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, review, err := c.findPeerReview(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if *request.Score > assignment.MaxScore {
+		return nil, util.ErrScoreOutOfRange
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == actor.ID {
+		return nil, util.ErrPermissionDenied
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+		return nil, err
+	}
+
+	result := &entity.PeerResult{
+		AssignmentID:   assignment.ID,
+		UserID:         user.ID,
+		Score:          request.Score,
+		Override:       request.Score,
+		OverrideBy:     &actor.ID,
+		OverrideReason: request.Reason,
+	}
+	if err = c.PeerResultRepository.SetOverride(ctx, result); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to override peer review score")
+		return nil, util.ErrInternalDefault
+	}
+	if review.FinalizedAt != nil {
+		if _, err = c.ScoreUseCase.recordGrade(ctx, assignment, user, actor, *request.Score, request.Reason, request.SourceIP); err != nil {
+			return nil, err
+		}
+	}
+	return converter.NewPeerResultResponse(result, user.Email), nil
+}
+
+// ListResults shows staff the results of the students they teach.
+func (c *PeerReviewUseCase) ListResults(ctx context.Context, request *model.ListAssignmentPeerReviewRequest) (*model.ListPeerResultResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	userIDs, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	results, total, err := c.PeerResultRepository.FindByAssignment(ctx, assignment.ID, userIDs, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find peer review results")
+		return nil, util.ErrInternalDefault
+	}
+
+	emails := map[primitive.ObjectID]string{}
+	response := &model.ListPeerResultResponse{
+		Results: make([]model.PeerResultResponse, 0, len(results)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range results {
+		response.Results = append(response.Results, *converter.NewPeerResultResponse(&results[i], c.email(ctx, emails, results[i].UserID)))
+	}
+	return response, nil
+}
+
+// Advance hands out the reviews of assignments that are due and finalizes
+// those whose reviews are due. It returns how many peer reviews it moved on.
+func (c *PeerReviewUseCase) Advance(ctx context.Context) (int, error) {
+	reviews, err := c.PeerReviewRepository.FindUnfinalized(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	advanced := 0
+	for i := range reviews {
+		review := &reviews[i]
+		if review.AllocatedAt != nil && now.Before(review.ReviewDueAt) {
+			continue
+		}
+		assignment, err := c.AssignmentRepository.FindByID(ctx, review.AssignmentID)
+		if err != nil {
+			return advanced, err
+		}
+		if assignment == nil {
+			continue
+		}
+		if review.AllocatedAt == nil {
+			if assignment.DueAt == nil || now.Before(*assignment.DueAt) {
+				continue
+			}
+			if _, err = c.allocate(ctx, assignment, review); err != nil && !errors.Is(err, util.ErrPeerReviewStarted) {
+				return advanced, err
+			}
+		} else if _, err = c.finalize(ctx, assignment, review); err != nil {
+			return advanced, err
+		}
+		advanced++
+	}
+	return advanced, nil
+}
+
+// content returns the work a review is of.
+func (c *PeerReviewUseCase) content(ctx context.Context, task *entity.PeerReviewTask) (string, error) {
+	submission, err := c.PeerSubmissionRepository.FindByID(ctx, task.SubmissionID)
+	if err != nil {
+		return "", util.ErrInternalDefault
+	}
+	if submission == nil {
+		return "", nil
+	}
+	return submission.Content, nil
+}
+
+func (c *PeerReviewUseCase) email(ctx context.Context, emails map[primitive.ObjectID]string, userID primitive.ObjectID) string {
+	email, ok := emails[userID]
+	if !ok {
+		if user, err := c.UserRepository.FindByID(ctx, userID); err == nil && user != nil {
+			email = user.Email
+		}
+		emails[userID] = email
+	}
+	return email
+}
+
+func (c *PeerReviewUseCase) findTask(ctx context.Context, id string) (*entity.PeerReviewTask, error) {
+	taskID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	task, err := c.PeerReviewTaskRepository.FindByID(ctx, taskID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if task == nil {
+		return nil, util.ErrPeerReviewTaskNotFound
+	}
+	return task, nil
+}
+
+func (c *PeerReviewUseCase) findPeerReview(ctx context.Context, assignmentID string) (*entity.Assignment, *entity.PeerReview, error) {
+	assignment, err := c.findAssignment(ctx, assignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	review, err := c.PeerReviewRepository.FindByAssignment(ctx, assignment.ID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	if review == nil {
+		return nil, nil, util.ErrPeerReviewNotFound
+	}
+	return assignment, review, nil
+}
+
+// findManagedPeerReview finds the peer review for an action only the staff of
+// the assignment's course may take.
+func (c *PeerReviewUseCase) findManagedPeerReview(ctx context.Context, request *model.PeerReviewActionRequest) (*entity.Assignment, *entity.PeerReview, error) {
+	assignment, review, err := c.findPeerReview(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, nil, err
+	}
+	return assignment, review, nil
+}
+
+func (c *PeerReviewUseCase) findAssignment(ctx context.Context, id string) (*entity.Assignment, error) {
+	assignmentID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	return assignment, nil
+}
+
+func (c *PeerReviewUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ProgressUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	ProgressRepository     *repository.ProgressRepository
+	CourseRepository       *repository.CourseRepository
+	ModuleRepository       *repository.ModuleRepository
+	AssignmentRepository   *repository.AssignmentRepository
+	EnrollmentRepository   *repository.EnrollmentRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	UserRepository         *repository.UserRepository
+}
+
+func NewProgressUseCase(logger *logrus.Logger, validate *validator.Validate,
+	progressRepository *repository.ProgressRepository, courseRepository *repository.CourseRepository,
+	moduleRepository *repository.ModuleRepository, assignmentRepository *repository.AssignmentRepository,
+	enrollmentRepository *repository.EnrollmentRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	userRepository *repository.UserRepository) *ProgressUseCase {
+	return &ProgressUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		ProgressRepository:     progressRepository,
+		CourseRepository:       courseRepository,
+		ModuleRepository:       moduleRepository,
+		AssignmentRepository:   assignmentRepository,
+		EnrollmentRepository:   enrollmentRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		UserRepository:         userRepository,
+	}
+}
+
+// Record moves the student's progress on an assignment forward. Assignments
+// outside any module have no progress to track. Failures are logged rather than
+// returned because progress is derived from data that is already saved.
+func (c *ProgressUseCase) Record(ctx context.Context, userID primitive.ObjectID, assignment *entity.Assignment, status string, score int) {
+	if assignment.ModuleID == nil || assignment.CourseID == nil {
+		return
+	}
+	err := c.ProgressRepository.RecordItem(ctx, userID, *assignment.CourseID, *assignment.ModuleID, assignment.ID, status, score)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"user_id":       userID.Hex(),
+			"assignment_id": assignment.ID.Hex(),
+			"status":        status,
+			util.LogError:   err,
+		}).Error("Failed to record progress")
+	}
+}
+
+// Start marks an assignment as started for the caller, who must be actively
+// enrolled in its course.
+func (c *ProgressUseCase) Start(ctx context.Context, request *model.StartAssignmentRequest) (*model.ProgressItemResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	assignmentID, err := primitive.ObjectIDFromHex(request.AssignmentID)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	if assignment.CourseID != nil {
+		enrollment, err := c.EnrollmentRepository.FindByCourseAndUser(ctx, *assignment.CourseID, user.ID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if enrollment == nil || enrollment.Status != entity.EnrollmentActive {
+			return nil, util.ErrNotEnrolled
+		}
+	}
+
+	c.Record(ctx, user.ID, assignment, entity.ProgressStarted, 0)
+	return &model.ProgressItemResponse{
+		AssignmentID: assignment.ID.Hex(),
+		Title:        assignment.Title,
+		Status:       entity.ProgressStarted,
+	}, nil
+}
+
+// Course returns one student's progress through every module of a course. Staff
+// may name a student they teach; students always get their own.
+func (c *ProgressUseCase) Course(ctx context.Context, request *model.CourseProgressRequest) (*model.CourseProgressResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.findCourse(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	staff := entity.IsStaffRole(request.ActorRole)
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if request.StudentEmail != "" && request.StudentEmail != request.ActorEmail {
+		if !staff {
+			return nil, util.ErrPermissionDenied
+		}
+		actor := user
+		if user, err = c.findUser(ctx, request.StudentEmail); err != nil {
+			return nil, err
+		}
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	if _, err = requireCourseAccess(ctx, c.EnrollmentRepository, course.ID, user.ID, staff); err != nil {
+		return nil, err
+	}
+
+	modules, assignments, err := c.outline(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+	progress, err := c.ProgressRepository.FindByCourse(ctx, course.ID, []primitive.ObjectID{user.ID})
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find progress")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.CourseProgressResponse{
+		CourseID: course.ID.Hex(),
+		Email:    user.Email,
+		Modules:  make([]model.ModuleProgressResponse, 0, len(modules)),
+	}
+	for _, module := range moduleProgress(modules, assignments, progress, true) {
+		response.Completed += module.Completed
+		response.Total += module.Total
+		response.LastActivityAt = converter.LatestTime(response.LastActivityAt, module.LastActivityAt)
+		response.Modules = append(response.Modules, module)
+	}
+	response.Percent = converter.Percent(response.Completed, response.Total)
+	return response, nil
+}
+
+// Matrix shows staff every enrolled student they teach against every module,
+// one page of students at a time.
+func (c *ProgressUseCase) Matrix(ctx context.Context, request *model.ProgressMatrixRequest) (*model.ProgressMatrixResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	course, err := c.findCourse(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	modules, assignments, err := c.outline(ctx, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollments, total, err := c.EnrollmentRepository.FindByCourse(ctx, course.ID, scope, request.Status, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to list enrollments")
+		return nil, util.ErrInternalDefault
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(enrollments))
+	for _, enrollment := range enrollments {
+		userIDs = append(userIDs, enrollment.UserID)
+	}
+	progress, err := c.ProgressRepository.FindByCourse(ctx, course.ID, userIDs)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find progress")
+		return nil, util.ErrInternalDefault
+	}
+	byUser := make(map[primitive.ObjectID][]entity.ModuleProgress, len(userIDs))
+	for _, item := range progress {
+		byUser[item.UserID] = append(byUser[item.UserID], item)
+	}
+
+	response := &model.ProgressMatrixResponse{
+		Columns: make([]model.ProgressMatrixColumn, 0, len(modules)),
+		Rows:    make([]model.ProgressMatrixRow, 0, len(enrollments)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for _, module := range modules {
+		response.Columns = append(response.Columns, model.ProgressMatrixColumn{
+			ModuleID: module.ID.Hex(),
+			Title:    module.Title,
+			Total:    len(assignments[module.ID]),
+		})
+	}
+	for _, userID := range userIDs {
+		user, err := c.UserRepository.FindByID(ctx, userID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		row := model.ProgressMatrixRow{UserID: userID.Hex()}
+		if user != nil {
+			row.Email = user.Email
+			row.Name = user.Name
+		}
+		completed, all := 0, 0
+		for _, module := range moduleProgress(modules, assignments, byUser[userID], false) {
+			completed += module.Completed
+			all += module.Total
+			row.LastActivityAt = converter.LatestTime(row.LastActivityAt, module.LastActivityAt)
+			row.Modules = append(row.Modules, module)
+		}
+		row.Percent = converter.Percent(completed, all)
+		response.Rows = append(response.Rows, row)
+	}
+	return response, nil
+}
+
+// outline returns the course's modules in order and their assignments by module.
+func (c *ProgressUseCase) outline(ctx context.Context, courseID primitive.ObjectID) ([]entity.Module, map[primitive.ObjectID][]entity.Assignment, error) {
+	modules, err := c.ModuleRepository.FindByCourse(ctx, courseID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	assignments, err := c.AssignmentRepository.FindByCourse(ctx, courseID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	byModule := make(map[primitive.ObjectID][]entity.Assignment, len(modules))
+	for _, assignment := range assignments {
+		if assignment.ModuleID != nil {
+			byModule[*assignment.ModuleID] = append(byModule[*assignment.ModuleID], assignment)
+		}
+	}
+	return modules, byModule, nil
+}
+
+func moduleProgress(modules []entity.Module, assignments map[primitive.ObjectID][]entity.Assignment,
+	progress []entity.ModuleProgress, withItems bool) []model.ModuleProgressResponse {
+	byModule := make(map[primitive.ObjectID]*entity.ModuleProgress, len(progress))
+	for i := range progress {
+		byModule[progress[i].ModuleID] = &progress[i]
+	}
+	responses := make([]model.ModuleProgressResponse, 0, len(modules))
+	for i := range modules {
+		module := &modules[i]
+		responses = append(responses, *converter.NewModuleProgressResponse(module, assignments[module.ID], byModule[module.ID], withItems))
+	}
+	return responses
+}
+
+func (c *ProgressUseCase) findCourse(ctx context.Context, id string) (*entity.Course, error) {
+	courseID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	course, err := c.CourseRepository.FindByID(ctx, courseID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if course == nil {
+		return nil, util.ErrCourseNotFound
+	}
+	return course, nil
+}
+
+func (c *ProgressUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,1345 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"regexp"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/questionfile"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// quizGracePeriod is how long after an attempt's time is up answers are still
+// taken, so a submission sent at the last second is not lost in transit. Exam
+// attempts get none: their session ends exactly at its end time.
+const quizGracePeriod = 30 * time.Second
+
+type QuizUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	QuestionBankRepository *repository.QuestionBankRepository
+	QuestionRepository     *repository.QuestionRepository
+	QuizRepository         *repository.QuizRepository
+	QuizAttemptRepository  *repository.QuizAttemptRepository
+	ExamEventRepository    *repository.ExamEventRepository
+	AssignmentRepository   *repository.AssignmentRepository
+	CourseRepository       *repository.CourseRepository
+	EnrollmentRepository   *repository.EnrollmentRepository
+	SubmissionRepository   *repository.SubmissionRepository
+	CohortRepository       *repository.CohortRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	UserRepository         *repository.UserRepository
+	ScoreUseCase           *ScoreUseCase
+}
+
+func NewQuizUseCase(logger *logrus.Logger, validate *validator.Validate,
+	questionBankRepository *repository.QuestionBankRepository, questionRepository *repository.QuestionRepository,
+	quizRepository *repository.QuizRepository, quizAttemptRepository *repository.QuizAttemptRepository,
+	examEventRepository *repository.ExamEventRepository, assignmentRepository *repository.AssignmentRepository, courseRepository *repository.CourseRepository,
+	enrollmentRepository *repository.EnrollmentRepository, submissionRepository *repository.SubmissionRepository,
+	cohortRepository *repository.CohortRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	userRepository *repository.UserRepository, scoreUseCase *ScoreUseCase) *QuizUseCase {
+	return &QuizUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		QuestionBankRepository: questionBankRepository,
+		QuestionRepository:     questionRepository,
+		QuizRepository:         quizRepository,
+		QuizAttemptRepository:  quizAttemptRepository,
+		ExamEventRepository:    examEventRepository,
+		AssignmentRepository:   assignmentRepository,
+		CourseRepository:       courseRepository,
+		EnrollmentRepository:   enrollmentRepository,
+		SubmissionRepository:   submissionRepository,
+		CohortRepository:       cohortRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		UserRepository:         userRepository,
+		ScoreUseCase:           scoreUseCase,
+	}
+}
+
+func (c *QuizUseCase) CreateBank(ctx context.Context, request *model.CreateQuestionBankRequest) (*model.QuestionBankResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	courseID, err := c.courseID(ctx, request.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	bank := &entity.QuestionBank{
+		Name:      request.Name,
+		CourseID:  courseID,
+		CreatedBy: actor.ID,
+	}
+	if err = c.QuestionBankRepository.Create(ctx, bank); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create question bank")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewQuestionBankResponse(bank, 0), nil
+}
+
+func (c *QuizUseCase) UpdateBank(ctx context.Context, request *model.UpdateQuestionBankRequest) (*model.QuestionBankResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	bank, err := c.findBank(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if bank.CourseID, err = c.courseID(ctx, request.CourseID); err != nil {
+		return nil, err
+	}
+	bank.Name = request.Name
+	if err = c.QuestionBankRepository.Update(ctx, bank); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update question bank")
+		return nil, util.ErrInternalDefault
+	}
+	return c.bankResponse(ctx, bank)
+}
+
+func (c *QuizUseCase) ListBanks(ctx context.Context, request *model.ListQuestionBankRequest) (*model.ListQuestionBankResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	var courseID *primitive.ObjectID
+	if request.CourseID != "" {
+		id, err := primitive.ObjectIDFromHex(request.CourseID)
+		if err != nil {
+			return nil, util.ErrInvalidID
+		}
+		courseID = &id
+	}
+	banks, total, err := c.QuestionBankRepository.FindAll(ctx, courseID, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find question banks")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListQuestionBankResponse{
+		Banks:  make([]model.QuestionBankResponse, 0, len(banks)),
+		Paging: converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range banks {
+		bank, err := c.bankResponse(ctx, &banks[i])
+		if err != nil {
+			return nil, err
+		}
+		response.Banks = append(response.Banks, *bank)
+	}
+	return response, nil
+}
+
+func (c *QuizUseCase) GetBank(ctx context.Context, id string) (*model.QuestionBankResponse, error) {
+	bank, err := c.findBank(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return c.bankResponse(ctx, bank)
+}
+
+func (c *QuizUseCase) ListQuestions(ctx context.Context, request *model.ListQuestionRequest) (*model.ListQuestionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	bank, err := c.findBank(ctx, request.BankID)
+	if err != nil {
+		return nil, err
+	}
+	questions, total, err := c.QuestionRepository.FindByBank(ctx, bank.ID, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find questions")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListQuestionResponse{
+		Questions: make([]model.QuestionResponse, 0, len(questions)),
+		Paging:    converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range questions {
+		response.Questions = append(response.Questions, *converter.NewQuestionResponse(&questions[i]))
+	}
+	return response, nil
+}
+
+func (c *QuizUseCase) CreateQuestion(ctx context.Context, request *model.SaveQuestionRequest) (*model.QuestionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	bank, err := c.findBank(ctx, request.BankID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	question := &entity.Question{BankID: bank.ID, CreatedBy: actor.ID}
+	if err = setQuestion(question, request); err != nil {
+		return nil, err
+	}
+	if err = c.QuestionRepository.Create(ctx, question); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create question")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewQuestionResponse(question), nil
+}
+
+// UpdateQuestion changes the question for quizzes started from now on; attempts
+// already started keep the copy they were given.
+func (c *QuizUseCase) UpdateQuestion(ctx context.Context, request *model.SaveQuestionRequest) (*model.QuestionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	question, err := c.findQuestion(ctx, request.BankID, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err = setQuestion(question, request); err != nil {
+		return nil, err
+	}
+	if err = c.QuestionRepository.Update(ctx, question); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update question")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewQuestionResponse(question), nil
+}
+
+func (c *QuizUseCase) DeleteQuestion(ctx context.Context, bankID, id string) error {
+	question, err := c.findQuestion(ctx, bankID, id)
+	if err != nil {
+		return err
+	}
+	deleted, err := c.QuestionRepository.Delete(ctx, question.ID)
+	if err != nil {
+		return util.ErrInternalDefault
+	}
+	if !deleted {
+		return util.ErrQuestionNotFound
+	}
+	return nil
+}
+
+// setQuestion copies the request onto the question and checks that the answer
+// key fits the question type. Choices are given ids a, b, c... in order.
+func setQuestion(question *entity.Question, request *model.SaveQuestionRequest) error {
+	question.Type = request.Type
+	question.Prompt = request.Prompt
+	question.Points = request.Points
+	if question.Points == 0 {
+		question.Points = 1
+	}
+	question.Choices = nil
+	question.Answer = 0
+	question.Tolerance = 0
+	question.Parameters = nil
+	question.Formula = ""
+	question.Patterns = nil
+	question.CaseSensitive = false
+
+	switch request.Type {
+	case entity.QuestionMultipleChoice, entity.QuestionMultipleAnswer:
+		correct := 0
+		for i, choice := range request.Choices {
+			question.Choices = append(question.Choices, entity.QuestionChoice{
+				ID:      string(rune('a' + i)),
+				Text:    choice.Text,
+				Correct: choice.Correct,
+			})
+			if choice.Correct {
+				correct++
+			}
+		}
+		if len(question.Choices) < 2 || correct == 0 || (request.Type == entity.QuestionMultipleChoice && correct != 1) {
+			return util.ErrInvalidQuestion
+		}
+	case entity.QuestionNumeric:
+		if request.Answer == nil && request.Formula == "" {
+			return util.ErrInvalidQuestion
+		}
+		if request.Formula == "" {
+			question.Answer = *request.Answer
+		}
+		question.Tolerance = request.Tolerance
+	case entity.QuestionShortText:
+		if len(request.Patterns) == 0 {
+			return util.ErrInvalidQuestion
+		}
+		question.Patterns = request.Patterns
+		question.CaseSensitive = request.CaseSensitive
+		for _, pattern := range question.Patterns {
+			if _, err := question.AnswerPattern(pattern); err != nil {
+				return util.ErrInvalidAnswerPattern
+			}
+		}
+	}
+	return setParameters(question, request)
+}
+
+// parameterName is what a parameter may be called, so it can be written as a
+// {{name}} placeholder and used in a formula.
+var parameterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// setParameters copies the question's parameters and checks that its
+// placeholders and formula only use those. Parameters make a numeric question
+// take its answer from the formula, which is tried with every parameter at its
+// minimum to catch formulas that cannot give a number.
+func setParameters(question *entity.Question, request *model.SaveQuestionRequest) error {
+	names := map[string]bool{}
+	for _, parameter := range request.Parameters {
+		step := parameter.Step
+		if step == 0 {
+			step = 1
+		}
+		if !parameterName.MatchString(parameter.Name) || entity.IsFormulaName(parameter.Name) || names[parameter.Name] ||
+			parameter.Max < parameter.Min || (parameter.Max > parameter.Min && step > parameter.Max-parameter.Min) ||
+			(parameter.Max-parameter.Min)/step > 1_000_000 {
+			return util.ErrInvalidParameter
+		}
+		names[parameter.Name] = true
+		question.Parameters = append(question.Parameters, entity.QuestionParameter{
+			Name: parameter.Name,
+			Min:  parameter.Min,
+			Max:  parameter.Max,
+			Step: step,
+		})
+	}
+	for _, name := range question.Placeholders() {
+		if !names[name] {
+			return util.ErrUnknownPlaceholder
+		}
+	}
+
+	if question.Type != entity.QuestionNumeric {
+		if request.Formula != "" {
+			return util.ErrInvalidFormula
+		}
+		return nil
+	}
+	if (request.Formula == "") != (len(question.Parameters) == 0) {
+		return util.ErrInvalidFormula
+	}
+	if request.Formula == "" {
+		return nil
+	}
+	formula, err := entity.ParseFormula(request.Formula)
+	if err != nil {
+		return util.ErrInvalidFormula
+	}
+	for name := range formula.Variables() {
+		if !names[name] {
+			return util.ErrInvalidFormula
+		}
+	}
+	values := map[string]float64{}
+	for _, parameter := range question.Parameters {
+		values[parameter.Name] = parameter.Min
+	}
+	if _, err = formula.Eval(values); err != nil {
+		return util.ErrInvalidFormula
+	}
+	question.Formula = request.Formula
+	return nil
+}
+
+// PreviewQuestion shows staff the variant of a question a seed gives, with the
+// parameter values and the answer, so they can check its ranges and formula.
+func (c *QuizUseCase) PreviewQuestion(ctx context.Context, request *model.PreviewQuestionRequest) (*model.QuestionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	question, err := c.findQuestion(ctx, request.BankID, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewQuestionVariantResponse(question, request.Seed), nil
+}
+
+// questionImportMaxItems caps how many items one question file may hold.
+const questionImportMaxItems = 1000
+
+// ImportQuestions adds the questions of a GIFT or QTI file to the bank. The
+// format is worked out from the file when not given. Every item is checked
+// before anything is written; items of a kind the quiz engine has no type for
+// are reported and left out, and nothing is imported when any other item is
+// invalid.
+func (c *QuizUseCase) ImportQuestions(ctx context.Context, request *model.ImportQuestionsRequest) (*model.ImportQuestionsResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	bank, err := c.findBank(ctx, request.BankID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	format := request.Format
+	if format == "" {
+		format = "gift"
+		if trimmed := bytes.TrimSpace(bytes.TrimPrefix(request.Data, []byte("\ufeff"))); bytes.HasPrefix(trimmed, []byte("PK")) || bytes.HasPrefix(trimmed, []byte("<")) {
+			format = "qti"
+		}
+	}
+	var items []questionfile.ImportedQuestion
+	if format == "qti" {
+		if items, err = questionfile.ParseQTI(request.Data); err != nil {
+			return nil, util.ErrInvalidQuestionFile
+		}
+	} else {
+		items = questionfile.ParseGIFT(request.Data)
+	}
+	if len(items) == 0 {
+		return nil, util.ErrInvalidQuestionFile
+	}
+	if len(items) > questionImportMaxItems {
+		return nil, util.ErrQuestionFileTooLarge
+	}
+
+	response := &model.ImportQuestionsResponse{
+		Format:    format,
+		Total:     len(items),
+		Questions: make([]model.ImportedQuestionResponse, 0, len(items)),
+	}
+	questions := make([]*entity.Question, len(items))
+	for i, item := range items {
+		result := model.ImportedQuestionResponse{
+			Index:   item.Index,
+			Title:   item.Title,
+			Kind:    item.Kind,
+			Status:  model.QuestionImportUnsupported,
+			Problem: item.Problem,
+		}
+		if item.Question != nil {
+			item.Question.ActorEmail = request.ActorEmail
+			item.Question.BankID = request.BankID
+			question := &entity.Question{BankID: bank.ID, CreatedBy: actor.ID}
+			err := c.Validate.Struct(item.Question)
+			if err == nil {
+				err = setQuestion(question, item.Question)
+			}
+			if err != nil {
+				result.Status = model.QuestionImportInvalid
+				result.Problem = err.Error()
+			} else {
+				questions[i] = question
+			}
+		}
+		switch result.Status {
+		case model.QuestionImportUnsupported:
+			response.Unsupported++
+		case model.QuestionImportInvalid:
+			response.Invalid++
+		}
+		response.Questions = append(response.Questions, result)
+	}
+	if response.Invalid > 0 {
+		return response, util.ErrInvalidQuestionFile
+	}
+
+	for i, question := range questions {
+		if question == nil {
+			continue
+		}
+		if err = c.QuestionRepository.Create(ctx, question); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"bank_id":     bank.ID.Hex(),
+				"imported":    response.Imported,
+				util.LogError: err,
+			}).Error("Failed to import question")
+			return nil, util.ErrInternalDefault
+		}
+		response.Questions[i].Status = model.QuestionImportImported
+		response.Questions[i].QuestionID = question.ID.Hex()
+		response.Imported++
+	}
+	return response, nil
+}
+
+// QuestionExport is a bank ready to be written as GIFT or QTI. Skipped counts
+// the questions the format cannot hold, which the file lists with the reason.
+type QuestionExport struct {
+	Filename    string
+	ContentType string
+	Skipped     int
+	questions   []entity.Question
+	format      string
+}
+
+func (e *QuestionExport) Write(w io.Writer) error {
+	if e.format == "qti" {
+		return questionfile.WriteQTI(w, e.questions)
+	}
+	return questionfile.WriteGIFT(w, e.questions)
+}
+
+func (c *QuizUseCase) ExportQuestions(ctx context.Context, request *model.ExportQuestionsRequest) (*QuestionExport, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	bank, err := c.findBank(ctx, request.BankID)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := c.QuestionRepository.FindAllByBank(ctx, bank.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find questions")
+		return nil, util.ErrInternalDefault
+	}
+
+	export := &QuestionExport{
+		Filename:    fmt.Sprintf("question-bank-%s.gift.txt", bank.ID.Hex()),
+		ContentType: "text/plain; charset=utf-8",
+		questions:   questions,
+		format:      request.Format,
+	}
+	if request.Format == "qti" {
+		export.Filename = fmt.Sprintf("question-bank-%s-qti.zip", bank.ID.Hex())
+		export.ContentType = "application/zip"
+	}
+	for i := range questions {
+		if questionfile.QuestionExportProblem(&questions[i]) != "" {
+			export.Skipped++
+		}
+	}
+	return export, nil
+}
+
+func (c *QuizUseCase) GetQuiz(ctx context.Context, assignmentID string, staff bool) (*model.QuizResponse, error) {
+	_, quiz, err := c.findQuiz(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewQuizResponse(quiz, staff), nil
+}
+
+// SaveQuiz attaches a quiz to the assignment, or changes the one it has, and
+// makes the assignment auto-graded. An exam needs a time limit, which its
+// attempts are held to exactly.
+func (c *QuizUseCase) SaveQuiz(ctx context.Context, request *model.SaveQuizRequest) (*model.QuizResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, err
+	}
+
+	quiz := &entity.Quiz{
+		AssignmentID:   assignment.ID,
+		Sections:       make([]entity.QuizSection, 0, len(request.Sections)),
+		TimeLimit:      request.TimeLimit,
+		ShuffleChoices: request.ShuffleChoices,
+		CreatedBy:      actor.ID,
+	}
+	if request.Exam != nil {
+		if request.TimeLimit == 0 {
+			return nil, util.ErrExamNeedsTimeLimit
+		}
+		quiz.Exam = &entity.QuizExam{AccessCode: request.Exam.AccessCode}
+		for _, network := range request.Exam.AllowedNetworks {
+			ipNet, err := util.ParseNetwork(network)
+			if err != nil {
+				return nil, util.ErrInvalidNetwork
+			}
+			quiz.Exam.AllowedNetworks = append(quiz.Exam.AllowedNetworks, ipNet.String())
+		}
+	}
+	for _, section := range request.Sections {
+		bank, err := c.findBank(ctx, section.BankID)
+		if err != nil {
+			return nil, err
+		}
+		count, err := c.QuestionRepository.CountByBank(ctx, bank.ID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if int64(section.Count) > count {
+			return nil, util.ErrInvalidQuizSection
+		}
+		quiz.Sections = append(quiz.Sections, entity.QuizSection{BankID: bank.ID, Count: section.Count})
+	}
+
+	if err = c.QuizRepository.Save(ctx, quiz); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to save quiz")
+		return nil, util.ErrInternalDefault
+	}
+	if assignment.Grading != entity.GradingQuiz {
+		if err = c.AssignmentRepository.SetGrading(ctx, assignment.ID, entity.GradingQuiz); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				util.LogError:   err,
+			}).Error("Failed to make assignment a quiz")
+			return nil, util.ErrInternalDefault
+		}
+	}
+	return converter.NewQuizResponse(quiz, true), nil
+}
+
+// StartAttempt returns the student's attempt in progress, or draws the questions
+// for a new one when the assignment is open and attempts are left. An exam
+// asks for its access code and allowed network either way.
+func (c *QuizUseCase) StartAttempt(ctx context.Context, request *model.StartQuizAttemptRequest) (*model.QuizAttemptResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, quiz, err := c.findQuiz(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.CourseID != nil {
+		enrollment, err := c.EnrollmentRepository.FindByCourseAndUser(ctx, *assignment.CourseID, user.ID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if enrollment == nil || enrollment.Status != entity.EnrollmentActive {
+			return nil, util.ErrNotEnrolled
+		}
+	}
+	if quiz.Exam != nil {
+		if subtle.ConstantTimeCompare([]byte(request.AccessCode), []byte(quiz.Exam.AccessCode)) != 1 {
+			return nil, util.ErrInvalidAccessCode
+		}
+		if !util.IPAllowed(request.SourceIP, quiz.Exam.AllowedNetworks) {
+			return nil, util.ErrNetworkNotAllowed
+		}
+	}
+
+	attempt, err := c.QuizAttemptRepository.FindInProgress(ctx, quiz.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if attempt != nil {
+		if !isExpired(attempt, time.Now()) {
+			if err = c.trackIP(ctx, attempt, request.SourceIP); err != nil {
+				return nil, err
+			}
+			return converter.NewQuizAttemptResponse(attempt, "", false), nil
+		}
+		if _, err = c.finish(ctx, attempt, assignment, user, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	loc, err := courseLocation(ctx, c.CourseRepository, assignment.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	extension, err := c.ScoreUseCase.extension(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	_, closesAt := assignment.DeadlinesFor(extension)
+	if !assignment.IsOpenUntil(now, closesAt) {
+		return nil, util.ErrAssignmentClosed
+	}
+	submissions, err := c.SubmissionRepository.FindByUserAndAssignment(ctx, user.ID, assignment.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment.AttemptsLeft(len(submissions)) == 0 {
+		return nil, util.ErrAttemptLimitReached
+	}
+
+	questions, err := c.draw(ctx, quiz)
+	if err != nil {
+		return nil, err
+	}
+	started, err := c.QuizAttemptRepository.CountByQuizAndUser(ctx, quiz.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	attempt = &entity.QuizAttempt{
+		QuizID:       quiz.ID,
+		AssignmentID: assignment.ID,
+		UserID:       user.ID,
+		Status:       entity.QuizAttemptInProgress,
+		Questions:    questions,
+		Seed:         entity.VariantSeed(quiz.ID, user.ID, int(started)+1),
+		Answers:      []entity.QuizAnswer{},
+		StartedAt:    now,
+		ExpiresAt:    closesAt,
+	}
+	for _, question := range questions {
+		attempt.Possible += question.Points
+	}
+	if quiz.TimeLimit > 0 {
+		expiresAt := now.Add(time.Duration(quiz.TimeLimit) * time.Minute)
+		if closesAt == nil || expiresAt.Before(*closesAt) {
+			attempt.ExpiresAt = &expiresAt
+		}
+	}
+	if quiz.Exam != nil {
+		attempt.Exam = &entity.QuizAttemptExam{
+			IP:              request.SourceIP,
+			LastIP:          request.SourceIP,
+			AllowedNetworks: quiz.Exam.AllowedNetworks,
+		}
+	}
+	if err = c.QuizAttemptRepository.Create(ctx, attempt); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			// A concurrent request started the attempt first.
+			if attempt, err = c.QuizAttemptRepository.FindInProgress(ctx, quiz.ID, user.ID); err == nil && attempt != nil {
+				return converter.NewQuizAttemptResponse(attempt, "", false), nil
+			}
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to start quiz attempt")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewQuizAttemptResponse(attempt, "", false), nil
+}
+
+// draw picks each section's questions from its bank and shuffles their choices
+// when the quiz asks for it.
+func (c *QuizUseCase) draw(ctx context.Context, quiz *entity.Quiz) ([]entity.Question, error) {
+	questions := []entity.Question{}
+	for _, section := range quiz.Sections {
+		drawn, err := c.QuestionRepository.Sample(ctx, section.BankID, section.Count)
+		if err != nil {
+			c.Log.WithFields(logrus.Fields{
+				"quiz_id":     quiz.ID.Hex(),
+				util.LogError: err,
+			}).Error("Failed to draw quiz questions")
+			return nil, util.ErrInternalDefault
+		}
+		if len(drawn) < section.Count {
+			return nil, util.ErrInvalidQuizSection
+		}
+		questions = append(questions, drawn...)
+	}
+	if quiz.ShuffleChoices {
+		for i := range questions {
+			choices := questions[i].Choices
+			rand.Shuffle(len(choices), func(a, b int) { choices[a], choices[b] = choices[b], choices[a] })
+		}
+	}
+	return questions, nil
+}
+
+// GetAttempt shows an attempt to the student who took it, or to staff teaching
+// the student, who also see the answer key.
+func (c *QuizUseCase) GetAttempt(ctx context.Context, request *model.GetQuizAttemptRequest) (*model.QuizAttemptResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	attempt, err := c.findAttempt(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.UserID != actor.ID {
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, attempt.UserID); err != nil {
+			return nil, err
+		}
+	}
+	if attempt.Status == entity.QuizAttemptInProgress && isExpired(attempt, time.Now()) {
+		if attempt, err = c.finishExpired(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+
+	if attempt.UserID == actor.ID {
+		return converter.NewQuizAttemptResponse(attempt, "", false), nil
+	}
+	email := ""
+	if user, err := c.UserRepository.FindByID(ctx, attempt.UserID); err == nil && user != nil {
+		email = user.Email
+	}
+	return converter.NewQuizAttemptResponse(attempt, email, true), nil
+}
+
+// SaveAnswers stores answers while the attempt is running. Answers replace any
+// given earlier to the same questions.
+func (c *QuizUseCase) SaveAnswers(ctx context.Context, request *model.SaveQuizAnswersRequest) (*model.QuizAttemptResponse, error) {
+	attempt, err := c.answer(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := c.QuizAttemptRepository.SaveAnswers(ctx, attempt)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to save quiz answers")
+		return nil, util.ErrInternalDefault
+	}
+	if !saved {
+		return nil, util.ErrQuizAttemptClosed
+	}
+	return converter.NewQuizAttemptResponse(attempt, "", false), nil
+}
+
+// Submit stores any last answers, then grades the attempt and records its score.
+func (c *QuizUseCase) Submit(ctx context.Context, request *model.SaveQuizAnswersRequest) (*model.QuizAttemptResponse, error) {
+	attempt, err := c.answer(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, attempt.AssignmentID)
+	if err != nil || assignment == nil {
+		return nil, util.ErrInternalDefault
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if attempt, err = c.finish(ctx, attempt, assignment, user, request.SourceIP); err != nil {
+		return nil, err
+	}
+	return converter.NewQuizAttemptResponse(attempt, "", false), nil
+}
+
+// answer loads the caller's running attempt and merges the request's answers
+// into it. An attempt whose time is up is graded with what it had instead.
+func (c *QuizUseCase) answer(ctx context.Context, request *model.SaveQuizAnswersRequest) (*entity.QuizAttempt, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	attempt, err := c.findAttempt(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.UserID != user.ID {
+		return nil, util.ErrQuizAttemptNotFound
+	}
+	if attempt.Status != entity.QuizAttemptInProgress {
+		return nil, util.ErrQuizAttemptClosed
+	}
+	if isExpired(attempt, time.Now()) {
+		if _, err = c.finishExpired(ctx, attempt); err != nil {
+			return nil, err
+		}
+		return nil, util.ErrQuizAttemptClosed
+	}
+	if err = c.trackIP(ctx, attempt, request.SourceIP); err != nil {
+		return nil, err
+	}
+
+	for _, item := range request.Answers {
+		questionID, err := primitive.ObjectIDFromHex(item.QuestionID)
+		if err != nil || !attempt.HasQuestion(questionID) {
+			return nil, util.ErrInvalidQuizAnswer
+		}
+		answer := entity.QuizAnswer{
+			QuestionID: questionID,
+			ChoiceIDs:  item.ChoiceIDs,
+			Number:     item.Number,
+			Text:       item.Text,
+		}
+		if existing := attempt.Answer(questionID); existing != nil {
+			*existing = answer
+		} else {
+			attempt.Answers = append(attempt.Answers, answer)
+		}
+	}
+	return attempt, nil
+}
+
+// finish grades the attempt, marks it submitted and records its score as a new
+// submission of the assignment. When the attempt was finished concurrently the
+// stored result is returned instead. The attempt's result stands even when the
+// score cannot be recorded, e.g. because the assignment closed meanwhile; staff
+// can still record it by hand.
+func (c *QuizUseCase) finish(ctx context.Context, attempt *entity.QuizAttempt, assignment *entity.Assignment, user *entity.User, sourceIP string) (*entity.QuizAttempt, error) {
+	submittedAt := time.Now()
+	if attempt.ExpiresAt != nil && attempt.ExpiresAt.Before(submittedAt) {
+		submittedAt = *attempt.ExpiresAt
+	}
+	attempt.Grade(assignment.MaxScore)
+	attempt.Status = entity.QuizAttemptSubmitted
+	attempt.SubmittedAt = &submittedAt
+
+	finished, err := c.QuizAttemptRepository.Finish(ctx, attempt)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"attempt_id":  attempt.ID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to finish quiz attempt")
+		return nil, util.ErrInternalDefault
+	}
+	if !finished {
+		return c.findAttempt(ctx, attempt.ID.Hex())
+	}
+
+	response, err := c.ScoreUseCase.RecordScore(ctx, &model.RecordScoreRequest{
+		UserEmail:    user.Email,
+		AssignmentID: assignment.ID.Hex(),
+		Score:        attempt.Score,
+		PayloadRef:   "quiz_attempt:" + attempt.ID.Hex(),
+		Grader:       entity.GradingQuiz,
+		SourceIP:     sourceIP,
+		AutoGraded:   true,
+		SubmittedAt:  &submittedAt,
+	})
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"attempt_id":  attempt.ID.Hex(),
+			util.LogError: err,
+		}).Warn("Failed to record quiz attempt score")
+		return attempt, nil
+	}
+	attempt.Attempt = response.Attempt
+	if err = c.QuizAttemptRepository.SetAttempt(ctx, attempt.ID, response.Attempt); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"attempt_id":  attempt.ID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to link quiz attempt to its submission")
+	}
+	return attempt, nil
+}
+
+func (c *QuizUseCase) finishExpired(ctx context.Context, attempt *entity.QuizAttempt) (*entity.QuizAttempt, error) {
+	assignment, err := c.AssignmentRepository.FindByID(ctx, attempt.AssignmentID)
+	if err != nil || assignment == nil {
+		return nil, util.ErrInternalDefault
+	}
+	user, err := c.UserRepository.FindByID(ctx, attempt.UserID)
+	if err != nil || user == nil {
+		return nil, util.ErrInternalDefault
+	}
+	return c.finish(ctx, attempt, assignment, user, "")
+}
+
+// FinishExpired grades the attempts whose time ran out without being submitted
+// and returns how many it finished.
+func (c *QuizUseCase) FinishExpired(ctx context.Context) (int, error) {
+	attempts, err := c.QuizAttemptRepository.FindExpired(ctx, time.Now().Add(-quizGracePeriod), 100)
+	if err != nil {
+		return 0, err
+	}
+	finished := 0
+	for i := range attempts {
+		if _, err = c.finishExpired(ctx, &attempts[i]); err != nil {
+			return finished, err
+		}
+		finished++
+	}
+	return finished, nil
+}
+
+// ListAttempts shows staff the attempts of the students they teach, optionally
+// only those of one student.
+func (c *QuizUseCase) ListAttempts(ctx context.Context, request *model.ListQuizAttemptRequest) (*model.ListQuizAttemptResponse, error) {
+	attempts, emails, paging, err := c.listAttempts(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	response := &model.ListQuizAttemptResponse{
+		Attempts: make([]model.QuizAttemptResponse, 0, len(attempts)),
+		Paging:   paging,
+	}
+	for i := range attempts {
+		response.Attempts = append(response.Attempts, *converter.NewQuizAttemptResponse(&attempts[i], emails[attempts[i].UserID], false))
+	}
+	return response, nil
+}
+
+// listAttempts finds the page of attempts ListAttempts shows, with the emails
+// of the students who made them.
+func (c *QuizUseCase) listAttempts(ctx context.Context, request *model.ListQuizAttemptRequest) ([]entity.QuizAttempt, map[primitive.ObjectID]string, *model.PaginationMetadata, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, nil, nil, util.ErrInvalidPaging
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var userIDs []primitive.ObjectID
+	if request.Email != "" {
+		user, err := c.findUser(ctx, request.Email)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+			return nil, nil, nil, err
+		}
+		userIDs = []primitive.ObjectID{user.ID}
+	} else if userIDs, err = studentScope(ctx, c.CohortMemberRepository, actor); err != nil {
+		return nil, nil, nil, err
+	}
+
+	attempts, total, err := c.QuizAttemptRepository.FindByAssignment(ctx, assignment.ID, userIDs, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find quiz attempts")
+		return nil, nil, nil, util.ErrInternalDefault
+	}
+	emails := map[primitive.ObjectID]string{}
+	for _, attempt := range attempts {
+		if _, ok := emails[attempt.UserID]; ok {
+			continue
+		}
+		email := ""
+		if user, err := c.UserRepository.FindByID(ctx, attempt.UserID); err == nil && user != nil {
+			email = user.Email
+		}
+		emails[attempt.UserID] = email
+	}
+	return attempts, emails, converter.NewPaginationMetadata(request.Page, request.Limit, total), nil
+}
+
+// RecordEvents adds the integrity events the exam client reports to the
+// timeline of the caller's running exam attempt.
+func (c *QuizUseCase) RecordEvents(ctx context.Context, request *model.RecordExamEventsRequest) (*model.RecordExamEventsResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	attempt, err := c.findAttempt(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if attempt.UserID != user.ID {
+		return nil, util.ErrQuizAttemptNotFound
+	}
+	if attempt.Exam == nil {
+		return nil, util.ErrNotAnExamAttempt
+	}
+	if attempt.Status != entity.QuizAttemptInProgress || isExpired(attempt, time.Now()) {
+		return nil, util.ErrQuizAttemptClosed
+	}
+	if err = c.trackIP(ctx, attempt, request.SourceIP); err != nil {
+		return nil, err
+	}
+
+	now := util.NowInWIB()
+	events := make([]entity.ExamEvent, 0, len(request.Events))
+	for _, item := range request.Events {
+		events = append(events, entity.ExamEvent{
+			AttemptID:    attempt.ID,
+			AssignmentID: attempt.AssignmentID,
+			UserID:       attempt.UserID,
+			Type:         item.Type,
+			Detail:       item.Detail,
+			IP:           request.SourceIP,
+			ClientAt:     item.At,
+			ReceivedAt:   now,
+		})
+	}
+	if err = c.ExamEventRepository.CreateMany(ctx, events); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to record exam events")
+		return nil, util.ErrInternalDefault
+	}
+	return &model.RecordExamEventsResponse{Recorded: len(events)}, nil
+}
+
+// ListEvents shows staff teaching the student an exam attempt's timeline.
+func (c *QuizUseCase) ListEvents(ctx context.Context, request *model.GetQuizAttemptRequest) (*model.ExamTimelineResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	attempt, err := c.findAttempt(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, attempt.UserID); err != nil {
+		return nil, err
+	}
+	if attempt.Exam == nil {
+		return nil, util.ErrNotAnExamAttempt
+	}
+
+	events, err := c.ExamEventRepository.FindByAttempt(ctx, attempt.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find exam events")
+		return nil, util.ErrInternalDefault
+	}
+	response := &model.ExamTimelineResponse{
+		AttemptID: attempt.ID.Hex(),
+		IP:        attempt.Exam.IP,
+		Events:    make([]model.ExamEventResponse, 0, len(events)),
+	}
+	if user, err := c.UserRepository.FindByID(ctx, attempt.UserID); err == nil && user != nil {
+		response.Email = user.Email
+	}
+	for i := range events {
+		response.Events = append(response.Events, *converter.NewExamEventResponse(&events[i]))
+	}
+	return response, nil
+}
+
+// ExamSummary shows staff the attempts of the students they teach, optionally
+// only those of one student, with how many integrity events of each type they
+// had.
+func (c *QuizUseCase) ExamSummary(ctx context.Context, request *model.ListQuizAttemptRequest) (*model.ExamSummaryResponse, error) {
+	attempts, emails, paging, err := c.listAttempts(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	attemptIDs := make([]primitive.ObjectID, 0, len(attempts))
+	for _, attempt := range attempts {
+		attemptIDs = append(attemptIDs, attempt.ID)
+	}
+	counts, err := c.ExamEventRepository.CountByAttempts(ctx, attemptIDs)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to count exam events")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ExamSummaryResponse{
+		Attempts: make([]model.ExamAttemptSummary, 0, len(attempts)),
+		Paging:   paging,
+	}
+	for i := range attempts {
+		response.Attempts = append(response.Attempts, *converter.NewExamAttemptSummary(&attempts[i], emails[attempts[i].UserID], counts[attempts[i].ID]))
+	}
+	return response, nil
+}
+
+// trackIP holds an exam attempt to its allowed networks and puts an ip_changed
+// event on its timeline when it is used from another address than last time.
+func (c *QuizUseCase) trackIP(ctx context.Context, attempt *entity.QuizAttempt, ip string) error {
+	if attempt.Exam == nil {
+		return nil
+	}
+	if !util.IPAllowed(ip, attempt.Exam.AllowedNetworks) {
+		return util.ErrNetworkNotAllowed
+	}
+	if ip == "" || ip == attempt.Exam.LastIP {
+		return nil
+	}
+	event := entity.ExamEvent{
+		AttemptID:    attempt.ID,
+		AssignmentID: attempt.AssignmentID,
+		UserID:       attempt.UserID,
+		Type:         entity.ExamEventIPChanged,
+		Detail:       "from " + attempt.Exam.LastIP,
+		IP:           ip,
+		ReceivedAt:   util.NowInWIB(),
+	}
+	if err := c.ExamEventRepository.CreateMany(ctx, []entity.ExamEvent{event}); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"attempt_id":  attempt.ID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to record exam address change")
+		return util.ErrInternalDefault
+	}
+	if err := c.QuizAttemptRepository.SetLastIP(ctx, attempt.ID, ip); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"attempt_id":  attempt.ID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to record exam address")
+		return util.ErrInternalDefault
+	}
+	attempt.Exam.LastIP = ip
+	return nil
+}
+
+func isExpired(attempt *entity.QuizAttempt, now time.Time) bool {
+	if attempt.ExpiresAt == nil {
+		return false
+	}
+	if attempt.Exam != nil {
+		return now.After(*attempt.ExpiresAt)
+	}
+	return now.After(attempt.ExpiresAt.Add(quizGracePeriod))
+}
+
+func (c *QuizUseCase) bankResponse(ctx context.Context, bank *entity.QuestionBank) (*model.QuestionBankResponse, error) {
+	count, err := c.QuestionRepository.CountByBank(ctx, bank.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewQuestionBankResponse(bank, count), nil
+}
+
+// courseID checks that an optional course exists.
+func (c *QuizUseCase) courseID(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	courseID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	course, err := c.CourseRepository.FindByID(ctx, courseID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if course == nil {
+		return nil, util.ErrCourseNotFound
+	}
+	return &course.ID, nil
+}
+
+func (c *QuizUseCase) findBank(ctx context.Context, id string) (*entity.QuestionBank, error) {
+	bankID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	bank, err := c.QuestionBankRepository.FindByID(ctx, bankID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if bank == nil {
+		return nil, util.ErrQuestionBankNotFound
+	}
+	return bank, nil
+}
+
+func (c *QuizUseCase) findQuestion(ctx context.Context, bankID, id string) (*entity.Question, error) {
+	bank, err := c.findBank(ctx, bankID)
+	if err != nil {
+		return nil, err
+	}
+	questionID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	question, err := c.QuestionRepository.FindByID(ctx, questionID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if question == nil || question.BankID != bank.ID {
+		return nil, util.ErrQuestionNotFound
+	}
+	return question, nil
+}
+
+func (c *QuizUseCase) findAssignment(ctx context.Context, id string) (*entity.Assignment, error) {
+	assignmentID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	return assignment, nil
+}
+
+func (c *QuizUseCase) findQuiz(ctx context.Context, assignmentID string) (*entity.Assignment, *entity.Quiz, error) {
+	assignment, err := c.findAssignment(ctx, assignmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	quiz, err := c.QuizRepository.FindByAssignment(ctx, assignment.ID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	if quiz == nil {
+		return nil, nil, util.ErrQuizNotFound
+	}
+	return assignment, quiz, nil
+}
+
+func (c *QuizUseCase) findAttempt(ctx context.Context, id string) (*entity.QuizAttempt, error) {
+	attemptID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	attempt, err := c.QuizAttemptRepository.FindByID(ctx, attemptID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if attempt == nil {
+		return nil, util.ErrQuizAttemptNotFound
+	}
+	return attempt, nil
+}
+
+func (c *QuizUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,446 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RegradeUseCase struct {
+	Log                      *logrus.Logger
+	Validate                 *validator.Validate
+	RegradeRequestRepository *repository.RegradeRequestRepository
+	AssignmentRepository     *repository.AssignmentRepository
+	ScoreRepository          *repository.ScoreRepository
+	ScoreLedgerRepository    *repository.ScoreLedgerRepository
+	CohortMemberRepository   *repository.CohortMemberRepository
+	UserRepository           *repository.UserRepository
+	ScoreUseCase             *ScoreUseCase
+	NotificationUseCase      *NotificationUseCase
+}
+
+func NewRegradeUseCase(logger *logrus.Logger, validate *validator.Validate,
+	regradeRequestRepository *repository.RegradeRequestRepository, assignmentRepository *repository.AssignmentRepository,
+	scoreRepository *repository.ScoreRepository, scoreLedgerRepository *repository.ScoreLedgerRepository,
+	cohortMemberRepository *repository.CohortMemberRepository, userRepository *repository.UserRepository,
+	scoreUseCase *ScoreUseCase, notificationUseCase *NotificationUseCase) *RegradeUseCase {
+	return &RegradeUseCase{
+		Log:                      logger,
+		Validate:                 validate,
+		RegradeRequestRepository: regradeRequestRepository,
+		AssignmentRepository:     assignmentRepository,
+		ScoreRepository:          scoreRepository,
+		ScoreLedgerRepository:    scoreLedgerRepository,
+		CohortMemberRepository:   cohortMemberRepository,
+		UserRepository:           userRepository,
+		ScoreUseCase:             scoreUseCase,
+		NotificationUseCase:      notificationUseCase,
+	}
+}
+
+// Create opens a regrade request against the student's current score on the
+// assignment. The assignment must have been graded, the request must come
+// within the assignment's regrade window of the last grade or submission, and
+// the student may only have one request pending per assignment. Corrections,
+// accepted regrades included, do not open the window again.
+func (c *RegradeUseCase) Create(ctx context.Context, request *model.CreateRegradeRequest) (*model.RegradeRequestResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	score, err := c.ScoreRepository.FindByUserAndAssignment(ctx, user.ID, assignment.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if score == nil {
+		return nil, util.ErrNotGraded
+	}
+	graded, err := c.ScoreLedgerRepository.FindLatest(ctx, user.ID, assignment.ID, entity.ScoreChangeGrade, entity.ScoreChangeSubmission)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find last grade in score ledger")
+		return nil, util.ErrInternalDefault
+	}
+	gradedAt := score.CreatedAt
+	if graded != nil {
+		gradedAt = graded.CreatedAt
+	}
+	if util.NowInWIB().After(gradedAt.Add(assignment.RegradeWindow())) {
+		return nil, util.ErrRegradeWindowClosed
+	}
+
+	regrade := &entity.RegradeRequest{
+		AssignmentID:  assignment.ID,
+		CourseID:      assignment.CourseID,
+		UserID:        user.ID,
+		Justification: request.Justification,
+		Status:        entity.RegradeOpen,
+		Pending:       true,
+		Score:         score.Score,
+	}
+	if err = c.RegradeRequestRepository.Create(ctx, regrade); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, util.ErrRegradePending
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create regrade request")
+		return nil, util.ErrInternalDefault
+	}
+	c.notifyStaff(ctx, user, "Regrade requested",
+		fmt.Sprintf("%s asked for a regrade of %q.", user.Email, assignment.Title),
+		entity.CohortRoleInstructor, entity.CohortRoleTA)
+	return converter.NewRegradeRequestResponse(regrade, user.Email, false), nil
+}
+
+// List shows students their own requests and staff the queue of the students
+// they teach, by default only the pending ones.
+func (c *RegradeUseCase) List(ctx context.Context, request *model.ListRegradeRequest) (*model.ListRegradeRequestResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	staff := entity.IsStaffRole(actor.GetRole())
+	var userIDs []primitive.ObjectID
+	if !staff {
+		userIDs = []primitive.ObjectID{actor.ID}
+	} else if userIDs, err = studentScope(ctx, c.CohortMemberRepository, actor); err != nil {
+		return nil, err
+	}
+	status := request.Status
+	if status == "" && staff {
+		status = "pending"
+	}
+	var statuses []string
+	switch status {
+	case "":
+	case "pending":
+		statuses = []string{entity.RegradeOpen, entity.RegradeEscalated}
+	default:
+		statuses = []string{status}
+	}
+	var assignmentID *primitive.ObjectID
+	if request.AssignmentID != "" {
+		id, err := primitive.ObjectIDFromHex(request.AssignmentID)
+		if err != nil {
+			return nil, util.ErrInvalidID
+		}
+		assignmentID = &id
+	}
+
+	requests, total, err := c.RegradeRequestRepository.FindAll(ctx, statuses, assignmentID, userIDs, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find regrade requests")
+		return nil, util.ErrInternalDefault
+	}
+
+	emails := map[primitive.ObjectID]string{}
+	response := &model.ListRegradeRequestResponse{
+		Requests: make([]model.RegradeRequestResponse, 0, len(requests)),
+		Paging:   converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range requests {
+		email, ok := emails[requests[i].UserID]
+		if !ok {
+			if user, err := c.UserRepository.FindByID(ctx, requests[i].UserID); err == nil && user != nil {
+				email = user.Email
+			}
+			emails[requests[i].UserID] = email
+		}
+		response.Requests = append(response.Requests, *converter.NewRegradeRequestResponse(&requests[i], email, staff))
+	}
+	return response, nil
+}
+
+func (c *RegradeUseCase) Get(ctx context.Context, request *model.GetRegradeRequest) (*model.RegradeRequestResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	regrade, user, err := c.findRequest(ctx, actor, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewRegradeRequestResponse(regrade, user.Email, actor.ID != user.ID), nil
+}
+
+// Accept resolves the request with the score the student should end up at. A
+// score different from the current one is recorded as a correction in the
+// score ledger.
+func (c *RegradeUseCase) Accept(ctx context.Context, request *model.ResolveRegradeRequest) (*model.RegradeRequestResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	if request.NewScore == nil {
+		return nil, util.ErrInvalidCorrection
+	}
+	regrade, user, actor, assignment, err := c.resolvable(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if *request.NewScore > assignment.MaxScore {
+		return nil, util.ErrScoreOutOfRange
+	}
+	score, err := c.ScoreRepository.FindByUserAndAssignment(ctx, user.ID, assignment.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+
+	previous := regrade.Status
+	c.resolve(regrade, actor, entity.RegradeAccepted, request.Response)
+	regrade.NewScore = request.NewScore
+	if err = c.transition(ctx, regrade, previous); err != nil {
+		return nil, err
+	}
+	if score == nil || score.Score != *request.NewScore {
+		_, err = c.ScoreUseCase.Correct(ctx, &model.ScoreCorrectionRequest{
+			ActorEmail:   actor.Email,
+			SourceIP:     request.SourceIP,
+			UserEmail:    user.Email,
+			AssignmentID: assignment.ID.Hex(),
+			NewScore:     request.NewScore,
+			Reason:       "Regrade request " + regrade.ID.Hex() + " accepted",
+		})
+		if err != nil {
+			// Put the request back in the queue, so it is not left accepted
+			// without its score.
+			accepted := *regrade
+			regrade.Status, regrade.Pending, regrade.NewScore = previous, true, nil
+			regrade.Response, regrade.ResolvedBy, regrade.ResolvedEmail, regrade.ResolvedAt = "", nil, "", nil
+			if _, revertErr := c.RegradeRequestRepository.Transition(ctx, regrade, accepted.Status); revertErr != nil {
+				c.Log.WithFields(logrus.Fields{
+					"regrade_request_id": regrade.ID.Hex(),
+					util.LogError:        revertErr,
+				}).Error("Failed to reopen regrade request")
+			}
+			return nil, err
+		}
+	}
+	_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategoryScore, "Regrade request accepted",
+		fmt.Sprintf("Your regrade request for %q was accepted; your score is now %d/%d.", assignment.Title, *request.NewScore, assignment.MaxScore))
+	return converter.NewRegradeRequestResponse(regrade, user.Email, true), nil
+}
+
+// Reject resolves the request without changing the score. The student is told
+// why.
+func (c *RegradeUseCase) Reject(ctx context.Context, request *model.ResolveRegradeRequest) (*model.RegradeRequestResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	if request.Response == "" || request.NewScore != nil {
+		return nil, util.ErrInvalidRegradeResolution
+	}
+	regrade, user, actor, assignment, err := c.resolvable(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	previous := regrade.Status
+	c.resolve(regrade, actor, entity.RegradeRejected, request.Response)
+	if err = c.transition(ctx, regrade, previous); err != nil {
+		return nil, err
+	}
+	_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategoryScore, "Regrade request rejected",
+		fmt.Sprintf("Your regrade request for %q was rejected: %s", assignment.Title, request.Response))
+	return converter.NewRegradeRequestResponse(regrade, user.Email, true), nil
+}
+
+// Escalate hands an open request to the instructors of the student's cohort,
+// e.g. when a TA disagrees with the original grade but may not overrule it.
+func (c *RegradeUseCase) Escalate(ctx context.Context, request *model.EscalateRegradeRequest) (*model.RegradeRequestResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	regrade, user, err := c.findRequest(ctx, actor, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.ID == user.ID {
+		return nil, util.ErrPermissionDenied
+	}
+	if regrade.Status != entity.RegradeOpen {
+		return nil, util.ErrRegradeResolved
+	}
+	assignment, err := c.findAssignment(ctx, regrade.AssignmentID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	now := util.NowInWIB()
+	regrade.Status = entity.RegradeEscalated
+	regrade.EscalatedBy = &actor.ID
+	regrade.EscalatedAt = &now
+	regrade.EscalateNote = request.Note
+	if err = c.transition(ctx, regrade, entity.RegradeOpen); err != nil {
+		return nil, err
+	}
+	_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategoryScore, "Regrade request escalated",
+		fmt.Sprintf("Your regrade request for %q was passed on to an instructor.", assignment.Title))
+	c.notifyStaff(ctx, user, "Regrade request escalated",
+		fmt.Sprintf("%s escalated %s's regrade request for %q.", actor.Email, user.Email, assignment.Title),
+		entity.CohortRoleInstructor)
+	return converter.NewRegradeRequestResponse(regrade, user.Email, true), nil
+}
+
+// resolvable loads a pending request for staff who may resolve it. Escalated
+// requests are left to instructors and admins.
+func (c *RegradeUseCase) resolvable(ctx context.Context, request *model.ResolveRegradeRequest) (*entity.RegradeRequest, *entity.User, *entity.User, *entity.Assignment, error) {
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	regrade, user, err := c.findRequest(ctx, actor, request.ID)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if actor.ID == user.ID {
+		return nil, nil, nil, nil, util.ErrPermissionDenied
+	}
+	if !regrade.Pending {
+		return nil, nil, nil, nil, util.ErrRegradeResolved
+	}
+	if regrade.Status == entity.RegradeEscalated && actor.GetRole() == entity.RoleTA {
+		return nil, nil, nil, nil, util.ErrRegradeEscalated
+	}
+	assignment, err := c.findAssignment(ctx, regrade.AssignmentID.Hex())
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return regrade, user, actor, assignment, nil
+}
+
+func (c *RegradeUseCase) resolve(regrade *entity.RegradeRequest, actor *entity.User, status, response string) {
+	now := util.NowInWIB()
+	regrade.Status = status
+	regrade.Pending = false
+	regrade.Response = response
+	regrade.ResolvedBy = &actor.ID
+	regrade.ResolvedEmail = actor.Email
+	regrade.ResolvedAt = &now
+}
+
+func (c *RegradeUseCase) transition(ctx context.Context, regrade *entity.RegradeRequest, from string) error {
+	ok, err := c.RegradeRequestRepository.Transition(ctx, regrade, from)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"regrade_request_id": regrade.ID.Hex(),
+			"status":             regrade.Status,
+			util.LogError:        err,
+		}).Error("Failed to update regrade request")
+		return util.ErrInternalDefault
+	}
+	if !ok {
+		return util.ErrRegradeResolved
+	}
+	return nil
+}
+
+// notifyStaff tells the staff with the given roles in the student's cohort.
+// Students without a cohort are only in the admins' queue.
+func (c *RegradeUseCase) notifyStaff(ctx context.Context, student *entity.User, title, body string, roles ...string) {
+	cohortID, err := c.CohortMemberRepository.FindStudentCohortID(ctx, student.ID)
+	if err != nil || cohortID == nil {
+		return
+	}
+	staffIDs, err := c.CohortMemberRepository.FindStaffIDs(ctx, *cohortID, roles...)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"cohort_id":   cohortID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to find cohort staff")
+		return
+	}
+	for _, id := range staffIDs {
+		_ = c.NotificationUseCase.Notify(ctx, id, entity.NotificationCategoryScore, title, body)
+	}
+}
+
+// findRequest loads a request the actor may see: their own, or one of a
+// student they teach.
+func (c *RegradeUseCase) findRequest(ctx context.Context, actor *entity.User, id string) (*entity.RegradeRequest, *entity.User, error) {
+	requestID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, nil, util.ErrInvalidID
+	}
+	regrade, err := c.RegradeRequestRepository.FindByID(ctx, requestID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	if regrade == nil {
+		return nil, nil, util.ErrRegradeRequestNotFound
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, regrade.UserID); err != nil {
+		return nil, nil, util.ErrRegradeRequestNotFound
+	}
+	user := actor
+	if regrade.UserID != actor.ID {
+		if user, err = c.UserRepository.FindByID(ctx, regrade.UserID); err != nil {
+			return nil, nil, util.ErrInternalDefault
+		}
+		if user == nil {
+			return nil, nil, util.ErrUserNotFound
+		}
+	}
+	return regrade, user, nil
+}
+
+func (c *RegradeUseCase) findAssignment(ctx context.Context, id string) (*entity.Assignment, error) {
+	assignmentID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	return assignment, nil
+}
+
+func (c *RegradeUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,520 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RubricUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	RubricRepository       *repository.RubricRepository
+	RubricGradeRepository  *repository.RubricGradeRepository
+	AssignmentRepository   *repository.AssignmentRepository
+	CourseRepository       *repository.CourseRepository
+	CohortRepository       *repository.CohortRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	UserRepository         *repository.UserRepository
+	ScoreUseCase           *ScoreUseCase
+}
+
+func NewRubricUseCase(logger *logrus.Logger, validate *validator.Validate,
+	rubricRepository *repository.RubricRepository, rubricGradeRepository *repository.RubricGradeRepository,
+	assignmentRepository *repository.AssignmentRepository, courseRepository *repository.CourseRepository,
+	cohortRepository *repository.CohortRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	userRepository *repository.UserRepository, scoreUseCase *ScoreUseCase) *RubricUseCase {
+	return &RubricUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		RubricRepository:       rubricRepository,
+		RubricGradeRepository:  rubricGradeRepository,
+		AssignmentRepository:   assignmentRepository,
+		CourseRepository:       courseRepository,
+		CohortRepository:       cohortRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		UserRepository:         userRepository,
+		ScoreUseCase:           scoreUseCase,
+	}
+}
+
+func (c *RubricUseCase) Create(ctx context.Context, request *model.SaveRubricRequest) (*model.RubricResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	rubric := &entity.Rubric{CreatedBy: actor.ID}
+	if err = c.setRubric(ctx, actor, rubric, request); err != nil {
+		return nil, err
+	}
+	if err = c.RubricRepository.Create(ctx, rubric); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to create rubric")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewRubricResponse(rubric), nil
+}
+
+// Update changes the rubric for every assignment graded with it. Grades given
+// before keep the breakdown they were given with until they are graded again.
+// Only its creator, the staff of its course and admins may change it.
+func (c *RubricUseCase) Update(ctx context.Context, request *model.SaveRubricRequest) (*model.RubricResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	rubric, err := c.findRubric(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if rubric.CreatedBy != actor.ID && actor.GetRole() != entity.RoleAdmin {
+		if rubric.CourseID == nil {
+			return nil, util.ErrPermissionDenied
+		}
+		if err = requireCourseStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, *rubric.CourseID); err != nil {
+			return nil, err
+		}
+	}
+	if err = c.setRubric(ctx, actor, rubric, request); err != nil {
+		return nil, err
+	}
+	if err = c.RubricRepository.Update(ctx, rubric); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update rubric")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewRubricResponse(rubric), nil
+}
+
+// setRubric copies the request into the rubric. Criteria and levels sent
+// without an id get a new one; ids must be unique within the rubric and within
+// each criterion. A rubric can only be tied to a course the actor teaches.
+func (c *RubricUseCase) setRubric(ctx context.Context, actor *entity.User, rubric *entity.Rubric, request *model.SaveRubricRequest) error {
+	courseID, err := c.courseID(ctx, request.CourseID)
+	if err != nil {
+		return err
+	}
+	if courseID != nil && !sameObjectID(courseID, rubric.CourseID) {
+		if err = requireCourseStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, *courseID); err != nil {
+			return err
+		}
+	}
+	rubric.CourseID = courseID
+	rubric.Title = request.Title
+	rubric.Description = request.Description
+	rubric.Criteria = make([]entity.RubricCriterion, 0, len(request.Criteria))
+
+	criterionIDs := map[string]bool{}
+	for _, criterion := range request.Criteria {
+		item := entity.RubricCriterion{
+			ID:          criterion.ID,
+			Title:       criterion.Title,
+			Description: criterion.Description,
+			Levels:      make([]entity.RubricLevel, 0, len(criterion.Levels)),
+		}
+		if item.ID == "" {
+			item.ID = primitive.NewObjectID().Hex()
+		}
+		if criterionIDs[item.ID] {
+			return util.ErrInvalidRubric
+		}
+		criterionIDs[item.ID] = true
+
+		levelIDs := map[string]bool{}
+		for _, level := range criterion.Levels {
+			id := level.ID
+			if id == "" {
+				id = primitive.NewObjectID().Hex()
+			}
+			if levelIDs[id] {
+				return util.ErrInvalidRubric
+			}
+			levelIDs[id] = true
+			item.Levels = append(item.Levels, entity.RubricLevel{
+				ID:          id,
+				Title:       level.Title,
+				Description: level.Description,
+				Points:      level.Points,
+			})
+		}
+		rubric.Criteria = append(rubric.Criteria, item)
+	}
+	if rubric.MaxPoints() == 0 {
+		return util.ErrInvalidRubric
+	}
+	return nil
+}
+
+func (c *RubricUseCase) List(ctx context.Context, request *model.ListRubricRequest) (*model.ListRubricResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	var courseID *primitive.ObjectID
+	if request.CourseID != "" {
+		id, err := primitive.ObjectIDFromHex(request.CourseID)
+		if err != nil {
+			return nil, util.ErrInvalidID
+		}
+		courseID = &id
+	}
+	rubrics, total, err := c.RubricRepository.FindAll(ctx, courseID, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find rubrics")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListRubricResponse{
+		Rubrics: make([]model.RubricResponse, 0, len(rubrics)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range rubrics {
+		response.Rubrics = append(response.Rubrics, *converter.NewRubricResponse(&rubrics[i]))
+	}
+	return response, nil
+}
+
+func (c *RubricUseCase) Get(ctx context.Context, id string) (*model.RubricResponse, error) {
+	rubric, err := c.findRubric(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewRubricResponse(rubric), nil
+}
+
+// Attach has the assignment graded with the rubric. Students can no longer
+// report their own scores for it. A rubric tied to a course only grades that
+// course's assignments.
+func (c *RubricUseCase) Attach(ctx context.Context, request *model.AttachRubricRequest) (*model.RubricResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, err
+	}
+	rubric, err := c.findRubric(ctx, request.RubricID)
+	if err != nil {
+		return nil, err
+	}
+	if rubric.CourseID != nil && !sameObjectID(rubric.CourseID, assignment.CourseID) {
+		return nil, util.ErrRubricOtherCourse
+	}
+	if err = c.AssignmentRepository.SetRubric(ctx, assignment.ID, rubric.ID); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to attach rubric")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewRubricResponse(rubric), nil
+}
+
+// GetForAssignment returns the rubric the assignment is graded with, so students
+// can see what they will be graded on.
+func (c *RubricUseCase) GetForAssignment(ctx context.Context, assignmentID string) (*model.RubricResponse, error) {
+	_, rubric, err := c.findAssignmentRubric(ctx, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	return converter.NewRubricResponse(rubric), nil
+}
+
+// Grade marks a student's work with the assignment's rubric. Every criterion
+// needs a level; the points earned are scaled to the assignment's max score and
+// recorded as the student's score, replacing any earlier rubric grade.
+func (c *RubricUseCase) Grade(ctx context.Context, request *model.GradeRubricRequest) (*model.RubricGradeResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, rubric, err := c.findAssignmentRubric(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == actor.ID {
+		return nil, util.ErrPermissionDenied
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+		return nil, err
+	}
+
+	criteria, earned, err := gradeWithRubric(rubric, request.Criteria)
+	if err != nil {
+		return nil, err
+	}
+	grade := &entity.RubricGrade{
+		AssignmentID: assignment.ID,
+		RubricID:     rubric.ID,
+		UserID:       user.ID,
+		Criteria:     criteria,
+		Comment:      request.Comment,
+		Earned:       earned,
+		Possible:     rubric.MaxPoints(),
+		GraderID:     actor.ID,
+		GraderEmail:  actor.Email,
+		GradedAt:     util.NowInWIB(),
+	}
+	grade.Score = int(math.Round(float64(grade.Earned) / float64(grade.Possible) * float64(assignment.MaxScore)))
+
+	if err = c.RubricGradeRepository.Save(ctx, grade); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to save rubric grade")
+		return nil, util.ErrInternalDefault
+	}
+	if _, err = c.ScoreUseCase.recordGrade(ctx, assignment, user, actor, grade.Score, "Rubric grade", request.SourceIP); err != nil {
+		return nil, err
+	}
+	// On a group assignment the grade is the whole group's.
+	group, mates, err := c.ScoreUseCase.groupMates(ctx, assignment, user)
+	if err != nil {
+		return nil, err
+	}
+	for _, mate := range mates {
+		copied := *grade
+		copied.ID = primitive.NilObjectID
+		copied.UserID = mate.ID
+		if err = c.RubricGradeRepository.Save(ctx, &copied); err != nil {
+			c.Log.WithFields(logrus.Fields{
+				util.LogRequest: request,
+				util.LogError:   err,
+			}).Error("Failed to save rubric grade")
+			return nil, util.ErrInternalDefault
+		}
+		reason := fmt.Sprintf("Rubric grade for group %s", group.Name)
+		if _, err = c.ScoreUseCase.recordGrade(ctx, assignment, &mate, actor, grade.Score, reason, request.SourceIP); err != nil {
+			return nil, err
+		}
+	}
+	return converter.NewRubricGradeResponse(grade, user.Email), nil
+}
+
+// gradeWithRubric picks the selected level of every criterion of the rubric
+// and totals their points. Each criterion needs exactly one valid level.
+func gradeWithRubric(rubric *entity.Rubric, selections []model.RubricSelection) ([]entity.RubricGradeCriterion, int, error) {
+	selected := make(map[string]model.RubricSelection, len(selections))
+	for _, selection := range selections {
+		if _, ok := selected[selection.CriterionID]; ok {
+			return nil, 0, util.ErrIncompleteRubricGrade
+		}
+		selected[selection.CriterionID] = selection
+	}
+	if len(selected) != len(rubric.Criteria) {
+		return nil, 0, util.ErrIncompleteRubricGrade
+	}
+	criteria := make([]entity.RubricGradeCriterion, 0, len(rubric.Criteria))
+	earned := 0
+	for i := range rubric.Criteria {
+		criterion := &rubric.Criteria[i]
+		selection, ok := selected[criterion.ID]
+		if !ok {
+			return nil, 0, util.ErrIncompleteRubricGrade
+		}
+		level := criterion.Level(selection.LevelID)
+		if level == nil {
+			return nil, 0, util.ErrIncompleteRubricGrade
+		}
+		criteria = append(criteria, entity.RubricGradeCriterion{
+			CriterionID: criterion.ID,
+			Title:       criterion.Title,
+			LevelID:     level.ID,
+			Level:       level.Title,
+			Points:      level.Points,
+			MaxPoints:   criterion.MaxPoints(),
+			Comment:     selection.Comment,
+		})
+		earned += level.Points
+	}
+	return criteria, earned, nil
+}
+
+// GetGrade returns the caller's own rubric grade with its breakdown, or a
+// student's when staff who teach them name them.
+func (c *RubricUseCase) GetGrade(ctx context.Context, request *model.GetRubricGradeRequest) (*model.RubricGradeResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if request.Email != "" && request.Email != user.Email {
+		actor := user
+		if user, err = c.findUser(ctx, request.Email); err != nil {
+			return nil, err
+		}
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	grade, err := c.RubricGradeRepository.FindByAssignmentAndUser(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if grade == nil {
+		return nil, util.ErrRubricGradeNotFound
+	}
+	return converter.NewRubricGradeResponse(grade, user.Email), nil
+}
+
+// ListGrades shows staff the rubric grades of the students they teach.
+func (c *RubricUseCase) ListGrades(ctx context.Context, request *model.ListRubricGradeRequest) (*model.ListRubricGradeResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	userIDs, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	grades, total, err := c.RubricGradeRepository.FindByAssignment(ctx, assignment.ID, userIDs, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find rubric grades")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.ListRubricGradeResponse{
+		Grades: make([]model.RubricGradeResponse, 0, len(grades)),
+		Paging: converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range grades {
+		email := ""
+		if user, err := c.UserRepository.FindByID(ctx, grades[i].UserID); err == nil && user != nil {
+			email = user.Email
+		}
+		response.Grades = append(response.Grades, *converter.NewRubricGradeResponse(&grades[i], email))
+	}
+	return response, nil
+}
+
+// courseID checks that an optional course exists.
+func (c *RubricUseCase) courseID(ctx context.Context, id string) (*primitive.ObjectID, error) {
+	if id == "" {
+		return nil, nil
+	}
+	courseID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	course, err := c.CourseRepository.FindByID(ctx, courseID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if course == nil {
+		return nil, util.ErrCourseNotFound
+	}
+	return &course.ID, nil
+}
+
+func (c *RubricUseCase) findRubric(ctx context.Context, id string) (*entity.Rubric, error) {
+	rubricID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	rubric, err := c.RubricRepository.FindByID(ctx, rubricID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if rubric == nil {
+		return nil, util.ErrRubricNotFound
+	}
+	return rubric, nil
+}
+
+func (c *RubricUseCase) findAssignment(ctx context.Context, id string) (*entity.Assignment, error) {
+	assignmentID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	return assignment, nil
+}
+
+func (c *RubricUseCase) findAssignmentRubric(ctx context.Context, id string) (*entity.Assignment, *entity.Rubric, error) {
+	assignment, err := c.findAssignment(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if assignment.Grading != entity.GradingRubric || assignment.RubricID == nil {
+		return nil, nil, util.ErrAssignmentHasNoRubric
+	}
+	rubric, err := c.findRubric(ctx, assignment.RubricID.Hex())
+	if err != nil {
+		return nil, nil, err
+	}
+	return assignment, rubric, nil
+}
+
+func (c *RubricUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,1109 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ScoreUseCase struct {
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	ScoreRepository        *repository.ScoreRepository
+	ScoreLedgerRepository  *repository.ScoreLedgerRepository
+	SubmissionRepository   *repository.SubmissionRepository
+	AssignmentRepository   *repository.AssignmentRepository
+	CourseRepository       *repository.CourseRepository
+	ExtensionRepository    *repository.ExtensionRepository
+	EnrollmentRepository   *repository.EnrollmentRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	GroupRepository        *repository.GroupRepository
+	GroupMemberRepository  *repository.GroupMemberRepository
+	UserRepository         *repository.UserRepository
+	NotificationUseCase    *NotificationUseCase
+	ProgressUseCase        *ProgressUseCase
+}
+
+func NewScoreUseCase(logger *logrus.Logger, validate *validator.Validate,
+	scoreRepository *repository.ScoreRepository, scoreLedgerRepository *repository.ScoreLedgerRepository,
+	submissionRepository *repository.SubmissionRepository, assignmentRepository *repository.AssignmentRepository,
+	courseRepository *repository.CourseRepository, extensionRepository *repository.ExtensionRepository,
+	enrollmentRepository *repository.EnrollmentRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	groupRepository *repository.GroupRepository, groupMemberRepository *repository.GroupMemberRepository,
+	userRepository *repository.UserRepository, notificationUseCase *NotificationUseCase,
+	progressUseCase *ProgressUseCase) *ScoreUseCase {
+	return &ScoreUseCase{
+		Log:                    logger,
+		Validate:               validate,
+		ScoreRepository:        scoreRepository,
+		ScoreLedgerRepository:  scoreLedgerRepository,
+		SubmissionRepository:   submissionRepository,
+		AssignmentRepository:   assignmentRepository,
+		CourseRepository:       courseRepository,
+		ExtensionRepository:    extensionRepository,
+		EnrollmentRepository:   enrollmentRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		GroupRepository:        groupRepository,
+		GroupMemberRepository:  groupMemberRepository,
+		UserRepository:         userRepository,
+		NotificationUseCase:    notificationUseCase,
+		ProgressUseCase:        progressUseCase,
+	}
+}
+
+// RecordScore is the single path through which graded submissions change a score.
+// For a group assignment the submission counts for every member of the
+// submitter's group enrolled in the course: each gets a copy as their own next
+// attempt, made with the submitter's deadlines and late penalty.
+func (c *ScoreUseCase) RecordScore(ctx context.Context, request *model.RecordScoreRequest) (*model.UpdateScoreResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrScoreOutOfRange
+	}
+	assignmentID, err := primitive.ObjectIDFromHex(request.AssignmentID)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	user, err := c.findUser(ctx, request.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	actor := user
+	if request.ActorEmail != "" && request.ActorEmail != user.Email {
+		if actor, err = c.findUser(ctx, request.ActorEmail); err != nil {
+			return nil, err
+		}
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	if assignment.IsAutoGraded() && !request.AutoGraded && actor.ID == user.ID {
+		return nil, util.ErrAutoGradedAssignment
+	}
+	if request.Score > assignment.MaxScore {
+		return nil, util.ErrScoreOutOfRange
+	}
+	loc, err := courseLocation(ctx, c.CourseRepository, assignment.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	extension, err := c.extension(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(loc)
+	if request.SubmittedAt != nil {
+		now = request.SubmittedAt.In(loc)
+	}
+	dueAt, closesAt := assignment.DeadlinesFor(extension)
+	if !assignment.IsOpenUntil(now, closesAt) {
+		return nil, util.ErrAssignmentClosed
+	}
+	adjusted, daysLate := assignment.ApplyLatePolicy(request.Score, dueAt, now, loc)
+	reason := request.Reason
+	if reason == "" && daysLate > 0 {
+		reason = fmt.Sprintf("%d day(s) late, %d point penalty", daysLate, request.Score-adjusted)
+	}
+	if assignment.CourseID != nil {
+		enrollment, err := c.EnrollmentRepository.FindByCourseAndUser(ctx, *assignment.CourseID, user.ID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if enrollment == nil || enrollment.Status != entity.EnrollmentActive {
+			return nil, util.ErrNotEnrolled
+		}
+	}
+	group, mates, err := c.groupMates(ctx, assignment, user)
+	if err != nil {
+		return nil, err
+	}
+
+	grader := request.Grader
+	if grader == "" {
+		grader = actor.Email
+	}
+	var submission *entity.Submission
+	var score *entity.Score
+	attempts := 0
+	err = c.ScoreRepository.WithTransaction(ctx, func(ctx context.Context) error {
+		for i, member := range append([]entity.User{*user}, mates...) {
+			submissions, err := c.SubmissionRepository.FindByUserAndAssignment(ctx, member.ID, assignment.ID)
+			if err != nil {
+				return err
+			}
+			// Only the submitter is held to the attempt limit.
+			if i == 0 && assignment.AttemptsLeft(len(submissions)) == 0 {
+				return util.ErrAttemptLimitReached
+			}
+
+			copied := &entity.Submission{
+				UserID:       member.ID,
+				AssignmentID: assignment.ID,
+				Attempt:      len(submissions) + 1,
+				PayloadRef:   request.PayloadRef,
+				RawScore:     request.Score,
+				Score:        adjusted,
+				DaysLate:     daysLate,
+				Grader:       grader,
+				SubmittedAt:  now,
+			}
+			entry := &entity.ScoreLedgerEntry{
+				UserID:       member.ID,
+				AssignmentID: assignment.ID,
+				Kind:         entity.ScoreChangeSubmission,
+				ActorID:      actor.ID,
+				ActorEmail:   actor.Email,
+				Reason:       reason,
+				SourceIP:     request.SourceIP,
+			}
+			if group != nil {
+				copied.GroupID = &group.ID
+				if i > 0 {
+					entry.Reason = strings.TrimSuffix(fmt.Sprintf("submitted by %s for group %s; %s", user.Email, group.Name, reason), "; ")
+				}
+			}
+			if err = c.SubmissionRepository.Create(ctx, copied); err != nil {
+				return err
+			}
+			submissions = append(submissions, *copied)
+			entry.SubmissionID = &copied.ID
+
+			effective, _ := assignment.EffectiveScore(submissions)
+			changed, err := c.applyChange(ctx, entry, func(score *entity.Score) {
+				score.CourseID = assignment.CourseID
+				score.SubmissionScore = effective
+				score.Attempts = len(submissions)
+			})
+			if err != nil {
+				return err
+			}
+			if i == 0 {
+				submission, score, attempts = copied, changed, len(submissions)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var customErr util.CustomError
+		if errors.As(err, &customErr) {
+			return nil, customErr
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			// Another submission took this attempt number first.
+			return nil, util.ErrSubmissionConflict
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to record submission score")
+		return nil, util.ErrInternalDefault
+	}
+	// A score the student reports for themselves completes the item; one recorded
+	// by anybody else or by an auto-grader also grades it.
+	status := entity.ProgressCompleted
+	if actor.ID != user.ID || request.AutoGraded {
+		status = entity.ProgressGraded
+	}
+	c.ProgressUseCase.Record(ctx, user.ID, assignment, status, score.Score)
+	body := fmt.Sprintf("Attempt %d for %q scored %d/%d.", submission.Attempt, assignment.Title, submission.Score, assignment.MaxScore)
+	if daysLate > 0 {
+		body += fmt.Sprintf(" It was %s, so the raw score of %d was reduced.", reason, submission.RawScore)
+	}
+	_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategoryScore, "Score recorded", body)
+	for _, mate := range mates {
+		c.ProgressUseCase.Record(ctx, mate.ID, assignment, entity.ProgressGraded, submission.Score)
+		_ = c.NotificationUseCase.Notify(ctx, mate.ID, entity.NotificationCategoryScore, "Group score recorded",
+			fmt.Sprintf("%s submitted %q for group %s, scoring %d/%d.", user.Email, assignment.Title, group.Name, submission.Score, assignment.MaxScore))
+	}
+
+	total, err := c.ScoreRepository.SumByUser(ctx, user.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	response := &model.UpdateScoreResponse{
+		Email:        user.Email,
+		AssignmentID: assignment.ID.Hex(),
+		Attempt:      submission.Attempt,
+		AttemptsLeft: assignment.AttemptsLeft(attempts),
+		RawScore:     submission.RawScore,
+		DaysLate:     submission.DaysLate,
+		Score:        score.Score,
+		TotalScore:   total,
+	}
+	if group != nil {
+		response.Group = group.Name
+	}
+	return response, nil
+}
+
+// Correct applies a compensating ledger entry. Scores are never edited in place;
+// staff either give a delta or the value the score should end up at.
+func (c *ScoreUseCase) Correct(ctx context.Context, request *model.ScoreCorrectionRequest) (*model.ScoreLedgerEntryResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	if (request.Delta == nil) == (request.NewScore == nil) {
+		return nil, util.ErrInvalidCorrection
+	}
+	assignmentID, err := primitive.ObjectIDFromHex(request.AssignmentID)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	user, err := c.findUser(ctx, request.UserEmail)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+		return nil, err
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+
+	entry := &entity.ScoreLedgerEntry{
+		UserID:       user.ID,
+		AssignmentID: assignment.ID,
+		Kind:         entity.ScoreChangeCorrection,
+		ActorID:      actor.ID,
+		ActorEmail:   actor.Email,
+		Reason:       request.Reason,
+		SourceIP:     request.SourceIP,
+	}
+	err = c.ScoreRepository.WithTransaction(ctx, func(ctx context.Context) error {
+		_, err := c.applyChange(ctx, entry, func(score *entity.Score) {
+			if request.Delta != nil {
+				score.Adjustment += *request.Delta
+			} else {
+				score.Adjustment += *request.NewScore - score.Score
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if entry.Delta == 0 {
+			return util.ErrInvalidCorrection
+		}
+		if entry.NewScore < 0 || entry.NewScore > assignment.MaxScore {
+			return util.ErrScoreOutOfRange
+		}
+		return nil
+	})
+	if err != nil {
+		var customErr util.CustomError
+		if errors.As(err, &customErr) {
+			return nil, customErr
+		}
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to apply score correction")
+		return nil, util.ErrInternalDefault
+	}
+
+	c.ProgressUseCase.Record(ctx, user.ID, assignment, entity.ProgressGraded, entry.NewScore)
+	_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategoryScore, "Score corrected",
+		fmt.Sprintf("Your score for %q changed from %d to %d: %s", assignment.Title, entry.OldScore, entry.NewScore, entry.Reason))
+	return converter.NewScoreLedgerEntryResponse(entry, user.Email), nil
+}
+
+// recordGrade sets the student's score for the assignment to a grade staff gave
+// the work as a whole, e.g. with a rubric. It takes no attempt and no late
+// penalty; corrections made before stay on top of it. A grade worked out by the
+// service itself, e.g. from peer reviews, has no actor; the ledger names the
+// assignment's grading instead.
+func (c *ScoreUseCase) recordGrade(ctx context.Context, assignment *entity.Assignment, user, actor *entity.User,
+	grade int, reason, sourceIP string) (*entity.ScoreLedgerEntry, error) {
+	if grade < 0 || grade > assignment.MaxScore {
+		return nil, util.ErrScoreOutOfRange
+	}
+	if assignment.CourseID != nil {
+		enrollment, err := c.EnrollmentRepository.FindByCourseAndUser(ctx, *assignment.CourseID, user.ID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if enrollment == nil || enrollment.Status != entity.EnrollmentActive {
+			return nil, util.ErrNotEnrolled
+		}
+	}
+
+	entry := &entity.ScoreLedgerEntry{
+		UserID:       user.ID,
+		AssignmentID: assignment.ID,
+		Kind:         entity.ScoreChangeGrade,
+		ActorEmail:   assignment.Grading,
+		Reason:       reason,
+		SourceIP:     sourceIP,
+	}
+	if actor != nil {
+		entry.ActorID, entry.ActorEmail = actor.ID, actor.Email
+	}
+	err := c.ScoreRepository.WithTransaction(ctx, func(ctx context.Context) error {
+		_, err := c.applyChange(ctx, entry, func(score *entity.Score) {
+			score.CourseID = assignment.CourseID
+			score.SubmissionScore = grade
+		})
+		return err
+	})
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":         user.Email,
+			"assignment_id": assignment.ID.Hex(),
+			util.LogError:   err,
+		}).Error("Failed to record grade")
+		return nil, util.ErrInternalDefault
+	}
+
+	c.ProgressUseCase.Record(ctx, user.ID, assignment, entity.ProgressGraded, entry.NewScore)
+	_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategoryScore, "Work graded",
+		fmt.Sprintf("%q was graded %d/%d.", assignment.Title, entry.NewScore, assignment.MaxScore))
+	return entry, nil
+}
+
+// History lists ledger entries newest first. Staff other than admins only see
+// the students of the cohorts they teach.
+func (c *ScoreUseCase) History(ctx context.Context, request *model.ScoreHistoryRequest) (*model.ScoreHistoryResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	var userIDs []primitive.ObjectID
+	var assignmentID *primitive.ObjectID
+	if request.UserEmail != "" {
+		user, err := c.findUser(ctx, request.UserEmail)
+		if err != nil {
+			return nil, err
+		}
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+			return nil, err
+		}
+		userIDs = []primitive.ObjectID{user.ID}
+	} else if userIDs, err = studentScope(ctx, c.CohortMemberRepository, actor); err != nil {
+		return nil, err
+	}
+	if request.AssignmentID != "" {
+		id, err := primitive.ObjectIDFromHex(request.AssignmentID)
+		if err != nil {
+			return nil, util.ErrInvalidID
+		}
+		assignmentID = &id
+	}
+
+	entries, total, err := c.ScoreLedgerRepository.FindHistory(ctx, userIDs, assignmentID, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find score history")
+		return nil, util.ErrInternalDefault
+	}
+
+	emails := map[primitive.ObjectID]string{}
+	response := &model.ScoreHistoryResponse{
+		Entries: make([]model.ScoreLedgerEntryResponse, 0, len(entries)),
+		Paging:  converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range entries {
+		email, ok := emails[entries[i].UserID]
+		if !ok {
+			if user, err := c.UserRepository.FindByID(ctx, entries[i].UserID); err == nil && user != nil {
+				email = user.Email
+			}
+			emails[entries[i].UserID] = email
+		}
+		response.Entries = append(response.Entries, *converter.NewScoreLedgerEntryResponse(&entries[i], email))
+	}
+	return response, nil
+}
+
+// applyChange lets project move the submission score, adjustment or attempts of
+// the projection, then appends the resulting delta to the ledger and saves the
+// projection. It must run inside ScoreRepository.WithTransaction so the two
+// writes land together. Changes that leave the score as it was are not recorded
+// in the ledger.
+func (c *ScoreUseCase) applyChange(ctx context.Context, entry *entity.ScoreLedgerEntry, project func(score *entity.Score)) (*entity.Score, error) {
+	score, err := c.ScoreRepository.FindByUserAndAssignment(ctx, entry.UserID, entry.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if score == nil {
+		score = &entity.Score{UserID: entry.UserID, AssignmentID: entry.AssignmentID}
+	}
+
+	entry.OldScore = score.Score
+	project(score)
+	score.Score = score.SubmissionScore + score.Adjustment
+	entry.NewScore = score.Score
+	entry.Delta = entry.NewScore - entry.OldScore
+
+	if entry.Delta != 0 {
+		if err = c.ScoreLedgerRepository.Append(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+	if err = c.ScoreRepository.SaveProjection(ctx, score); err != nil {
+		return nil, err
+	}
+	return score, nil
+}
+
+// ListSubmissions returns the caller's attempts, or a student's attempts when the
+// caller teaches the student and names them.
+func (c *ScoreUseCase) ListSubmissions(ctx context.Context, request *model.ListSubmissionRequest) (*model.ListSubmissionResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignmentID, err := primitive.ObjectIDFromHex(request.AssignmentID)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	user, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if request.StudentEmail != "" && request.StudentEmail != request.ActorEmail {
+		if !entity.IsStaffRole(request.ActorRole) {
+			return nil, util.ErrPermissionDenied
+		}
+		actor := user
+		if user, err = c.findUser(ctx, request.StudentEmail); err != nil {
+			return nil, err
+		}
+		if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+
+	submissions, err := c.SubmissionRepository.FindByUserAndAssignment(ctx, user.ID, assignment.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find submissions")
+		return nil, util.ErrInternalDefault
+	}
+	score, err := c.ScoreRepository.FindByUserAndAssignment(ctx, user.ID, assignment.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+
+	loc, err := courseLocation(ctx, c.CourseRepository, assignment.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	extension, err := c.extension(ctx, assignment.ID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	dueAt, closesAt := assignment.DeadlinesFor(extension)
+
+	_, countedAttempt := assignment.EffectiveScore(submissions)
+	response := &model.ListSubmissionResponse{
+		Email:        user.Email,
+		AssignmentID: assignment.ID.Hex(),
+		ScorePolicy:  assignment.ScorePolicy,
+		DueAt:        converter.InLocation(dueAt, loc),
+		ClosesAt:     converter.InLocation(closesAt, loc),
+		AttemptsLeft: assignment.AttemptsLeft(len(submissions)),
+		Submissions:  make([]model.SubmissionResponse, 0, len(submissions)),
+	}
+	if score != nil {
+		response.Score = score.Score
+	}
+	for i := range submissions {
+		response.Submissions = append(response.Submissions, *converter.NewSubmissionResponse(&submissions[i], countedAttempt))
+	}
+	group, mates, err := c.groupMates(ctx, assignment, user)
+	if err != nil {
+		return nil, err
+	}
+	if group != nil {
+		response.Group = &model.GroupSummary{ID: group.ID.Hex(), Name: group.Name, Members: []string{user.Email}}
+		for _, mate := range mates {
+			response.Group.Members = append(response.Group.Members, mate.Email)
+		}
+	}
+	return response, nil
+}
+
+// MigrateLegacyScores copies the old single User.Score into a "legacy" assignment
+// as its first and only attempt, so it keeps counting towards the total. Running
+// it again skips users that were already migrated.
+func (c *ScoreUseCase) MigrateLegacyScores(ctx context.Context) (int, error) {
+	users, err := c.UserRepository.FindWithLegacyScore(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	legacy, err := c.AssignmentRepository.FindLegacy(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if legacy == nil {
+		maxScore := 100
+		for _, user := range users {
+			maxScore = max(maxScore, user.Score)
+		}
+		legacy = &entity.Assignment{
+			Title:       "Lab assignment",
+			Description: "Score recorded before assignments were introduced.",
+			MaxScore:    maxScore,
+			MaxAttempts: 1,
+			ScorePolicy: entity.ScorePolicyBest,
+			Legacy:      true,
+		}
+		if err = c.AssignmentRepository.Create(ctx, legacy); err != nil {
+			return 0, err
+		}
+	}
+
+	migrated := 0
+	for _, user := range users {
+		submissions, err := c.SubmissionRepository.FindByUserAndAssignment(ctx, user.ID, legacy.ID)
+		if err != nil {
+			return migrated, err
+		}
+		if len(submissions) > 0 {
+			continue
+		}
+
+		err = c.ScoreRepository.WithTransaction(ctx, func(ctx context.Context) error {
+			submission := &entity.Submission{
+				UserID:       user.ID,
+				AssignmentID: legacy.ID,
+				Attempt:      1,
+				RawScore:     user.Score,
+				Score:        user.Score,
+				Grader:       "migration",
+				SubmittedAt:  user.CreatedAt,
+			}
+			if user.UpdatedAt != nil {
+				submission.SubmittedAt = *user.UpdatedAt
+			}
+			if err := c.SubmissionRepository.Create(ctx, submission); err != nil {
+				return err
+			}
+			entry := &entity.ScoreLedgerEntry{
+				UserID:       user.ID,
+				AssignmentID: legacy.ID,
+				Kind:         entity.ScoreChangeMigration,
+				ActorEmail:   "migration",
+				Reason:       "legacy single score",
+				SubmissionID: &submission.ID,
+			}
+			_, err := c.applyChange(ctx, entry, func(score *entity.Score) {
+				score.SubmissionScore = user.Score
+				score.Attempts = 1
+			})
+			return err
+		})
+		if err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// BackfillLedger opens the ledger for scores written before it existed, so that
+// every projection equals the sum of its ledger entries.
+func (c *ScoreUseCase) BackfillLedger(ctx context.Context) (int, error) {
+	scores, err := c.ScoreRepository.FindAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	backfilled := 0
+	for i := range scores {
+		score := &scores[i]
+		count, err := c.ScoreLedgerRepository.CountByUserAndAssignment(ctx, score.UserID, score.AssignmentID)
+		if err != nil {
+			return backfilled, err
+		}
+		if count > 0 || score.Score == 0 {
+			continue
+		}
+
+		err = c.ScoreRepository.WithTransaction(ctx, func(ctx context.Context) error {
+			entry := &entity.ScoreLedgerEntry{
+				UserID:       score.UserID,
+				AssignmentID: score.AssignmentID,
+				Kind:         entity.ScoreChangeMigration,
+				Delta:        score.Score,
+				OldScore:     0,
+				NewScore:     score.Score,
+				ActorEmail:   "migration",
+				Reason:       "opening balance",
+			}
+			if err := c.ScoreLedgerRepository.Append(ctx, entry); err != nil {
+				return err
+			}
+			score.SubmissionScore = score.Score
+			score.Adjustment = 0
+			return c.ScoreRepository.SaveProjection(ctx, score)
+		})
+		if err != nil {
+			return backfilled, err
+		}
+		backfilled++
+	}
+	return backfilled, nil
+}
+
+// ImportGrades checks a CSV with email, assignment, score and optional comment
+// columns against the course's enrollments and assignments. Without Confirm it
+// only reports what every row would do. With Confirm it applies every change in
+// one transaction as a correction made by the importer, and a single invalid row
+// rejects the whole file.
+func (c *ScoreUseCase) ImportGrades(ctx context.Context, request *model.GradeImportRequest) (*model.GradeImportResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	courseID, err := primitive.ObjectIDFromHex(request.CourseID)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	course, err := c.CourseRepository.FindByID(ctx, courseID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if course == nil {
+		return nil, util.ErrCourseNotFound
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := parseGradeImport(request.CSV)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > gradeImportMaxRows {
+		return nil, util.ErrGradeImportTooLarge
+	}
+
+	check, err := c.newGradeImportCheck(ctx, course.ID, actor, rows)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"course_id":   course.ID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to check grade import")
+		return nil, util.ErrInternalDefault
+	}
+	response := &model.GradeImportResponse{
+		Total: len(rows),
+		Rows:  make([]model.GradeImportRowResponse, 0, len(rows)),
+	}
+	var changes []gradeImportChange
+	for _, row := range rows {
+		result, change := check.row(row)
+		switch result.Status {
+		case model.GradeImportValid:
+			response.Valid++
+			change.result = len(response.Rows)
+			changes = append(changes, *change)
+		case model.GradeImportUnchanged:
+			response.Unchanged++
+		default:
+			response.Invalid++
+		}
+		response.Rows = append(response.Rows, *result)
+	}
+	if !request.Confirm {
+		return response, nil
+	}
+	if response.Invalid > 0 {
+		return response, util.ErrGradeImportRejected
+	}
+
+	entries := make([]*entity.ScoreLedgerEntry, len(changes))
+	err = c.ScoreRepository.WithTransaction(ctx, func(ctx context.Context) error {
+		for i, change := range changes {
+			reason := "Grade import"
+			if change.comment != "" {
+				reason += ": " + change.comment
+			}
+			entries[i] = &entity.ScoreLedgerEntry{
+				UserID:       change.user.ID,
+				AssignmentID: change.assignment.ID,
+				Kind:         entity.ScoreChangeCorrection,
+				ActorID:      actor.ID,
+				ActorEmail:   actor.Email,
+				Reason:       reason,
+				SourceIP:     request.SourceIP,
+			}
+			_, err := c.applyChange(ctx, entries[i], func(score *entity.Score) {
+				score.CourseID = change.assignment.CourseID
+				score.Adjustment += change.score - score.Score
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"course_id":   course.ID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to apply grade import")
+		return nil, util.ErrInternalDefault
+	}
+
+	response.Applied = true
+	for i, change := range changes {
+		entry := entries[i]
+		result := &response.Rows[change.result]
+		result.Status = model.GradeImportApplied
+		result.OldScore = &entry.OldScore
+		c.ProgressUseCase.Record(ctx, change.user.ID, change.assignment, entity.ProgressGraded, entry.NewScore)
+		_ = c.NotificationUseCase.Notify(ctx, change.user.ID, entity.NotificationCategoryScore, "Score updated",
+			fmt.Sprintf("Your score for %q changed from %d to %d.", change.assignment.Title, entry.OldScore, entry.NewScore))
+	}
+	return response, nil
+}
+
+// gradeImportMaxRows keeps an import well inside what one transaction can write.
+const gradeImportMaxRows = 5000
+
+type gradeImportRow struct {
+	line       int
+	email      string
+	assignment string
+	score      string
+	comment    string
+}
+
+type gradeImportChange struct {
+	result     int
+	user       *entity.User
+	assignment *entity.Assignment
+	score      int
+	comment    string
+}
+
+type gradeImportKey struct {
+	userID, assignmentID primitive.ObjectID
+}
+
+// gradeImportCheck holds everything the rows of one import are checked against,
+// loaded with a handful of queries rather than a few per row.
+type gradeImportCheck struct {
+	users       map[string]*entity.User
+	enrolled    map[primitive.ObjectID]bool
+	scope       map[primitive.ObjectID]bool
+	byID        map[string]*entity.Assignment
+	byTitle     map[string][]*entity.Assignment
+	scores      map[gradeImportKey]int
+	seen        map[gradeImportKey]int
+	allStudents bool
+}
+
+func (c *ScoreUseCase) newGradeImportCheck(ctx context.Context, courseID primitive.ObjectID, actor *entity.User, rows []gradeImportRow) (*gradeImportCheck, error) {
+	check := &gradeImportCheck{
+		users:    map[string]*entity.User{},
+		enrolled: map[primitive.ObjectID]bool{},
+		scope:    map[primitive.ObjectID]bool{},
+		byID:     map[string]*entity.Assignment{},
+		byTitle:  map[string][]*entity.Assignment{},
+		scores:   map[gradeImportKey]int{},
+		seen:     map[gradeImportKey]int{},
+	}
+
+	assignments, err := c.AssignmentRepository.FindByCourse(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	assignmentIDs := make([]primitive.ObjectID, 0, len(assignments))
+	for i := range assignments {
+		assignment := &assignments[i]
+		check.byID[assignment.ID.Hex()] = assignment
+		title := strings.ToLower(strings.TrimSpace(assignment.Title))
+		check.byTitle[title] = append(check.byTitle[title], assignment)
+		assignmentIDs = append(assignmentIDs, assignment.ID)
+	}
+
+	emails := make([]string, 0, len(rows))
+	for _, row := range rows {
+		emails = append(emails, row.email)
+	}
+	users, err := c.UserRepository.FindByEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(users))
+	for i := range users {
+		check.users[users[i].Email] = &users[i]
+		userIDs = append(userIDs, users[i].ID)
+	}
+	if len(userIDs) == 0 {
+		return check, nil
+	}
+
+	enrollments, _, err := c.EnrollmentRepository.FindByCourse(ctx, courseID, userIDs, entity.EnrollmentActive, 1, len(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, enrollment := range enrollments {
+		check.enrolled[enrollment.UserID] = true
+	}
+	scope, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	check.allStudents = scope == nil
+	for _, id := range scope {
+		check.scope[id] = true
+	}
+	scores, err := c.ScoreRepository.FindByUsersAndAssignments(ctx, userIDs, assignmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, score := range scores {
+		check.scores[gradeImportKey{score.UserID, score.AssignmentID}] = score.Score
+	}
+	return check, nil
+}
+
+// row reports what importing the row would do, and returns the change to make
+// when it is valid and moves the score.
+func (check *gradeImportCheck) row(row gradeImportRow) (*model.GradeImportRowResponse, *gradeImportChange) {
+	result := &model.GradeImportRowResponse{
+		Row:        row.line,
+		Email:      row.email,
+		Assignment: row.assignment,
+		Comment:    row.comment,
+	}
+	var problems []string
+
+	user := check.users[row.email]
+	switch {
+	case user == nil:
+		problems = append(problems, model.GradeImportUnknownEmail)
+	case !check.enrolled[user.ID]:
+		problems = append(problems, model.GradeImportNotEnrolled)
+	case !check.allStudents && !check.scope[user.ID]:
+		problems = append(problems, model.GradeImportNotInCohort)
+	}
+
+	assignment := check.byID[row.assignment]
+	if assignment == nil {
+		switch matches := check.byTitle[strings.ToLower(row.assignment)]; len(matches) {
+		case 0:
+			problems = append(problems, model.GradeImportUnknownAssignment)
+		case 1:
+			assignment = matches[0]
+		default:
+			problems = append(problems, model.GradeImportAmbiguousAssignment)
+		}
+	}
+	if assignment != nil {
+		result.AssignmentID = assignment.ID.Hex()
+	}
+
+	score, err := strconv.Atoi(row.score)
+	if err != nil {
+		problems = append(problems, model.GradeImportInvalidScore)
+	} else {
+		result.Score = &score
+		if assignment != nil && (score < 0 || score > assignment.MaxScore) {
+			problems = append(problems, model.GradeImportOutOfRange)
+		}
+	}
+	if len(row.comment) > 500 {
+		problems = append(problems, model.GradeImportCommentTooLong)
+	}
+
+	old := 0
+	if user != nil && assignment != nil {
+		key := gradeImportKey{user.ID, assignment.ID}
+		if first, ok := check.seen[key]; ok {
+			problems = append(problems, model.GradeImportDuplicate)
+			result.DuplicateOf = first
+		} else {
+			check.seen[key] = row.line
+		}
+		old = check.scores[key]
+		result.OldScore = &old
+	}
+
+	switch {
+	case len(problems) > 0:
+		result.Status = model.GradeImportInvalid
+		result.Problems = problems
+		return result, nil
+	case score == old:
+		result.Status = model.GradeImportUnchanged
+		return result, nil
+	}
+	result.Status = model.GradeImportValid
+	return result, &gradeImportChange{user: user, assignment: assignment, score: score, comment: row.comment}
+}
+
+// parseGradeImport reads the rows of a grade import. Columns are found by their
+// header, in any order and case; blank lines are skipped.
+func parseGradeImport(data []byte) ([]gradeImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, util.ErrInvalidGradeImport
+	}
+	columns := map[string]int{"comment": -1}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"email", "assignment", "score"} {
+		if _, ok := columns[name]; !ok {
+			return nil, util.ErrInvalidGradeImport
+		}
+	}
+
+	rows := []gradeImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, util.ErrInvalidGradeImport
+		}
+		field := func(name string) string {
+			if i := columns[name]; i >= 0 && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := gradeImportRow{
+			email:      strings.ToLower(field("email")),
+			assignment: field("assignment"),
+			score:      field("score"),
+			comment:    field("comment"),
+		}
+		if row.email == "" && row.assignment == "" && row.score == "" && row.comment == "" {
+			continue
+		}
+		row.line, _ = reader.FieldPos(0)
+		rows = append(rows, row)
+	}
+}
+
+// groupMates returns the student's group for a group assignment and the other
+// members enrolled in its course. The group is nil for individual assignments
+// and for students without a group, who then work alone.
+func (c *ScoreUseCase) groupMates(ctx context.Context, assignment *entity.Assignment, user *entity.User) (*entity.Group, []entity.User, error) {
+	if !assignment.GroupMode || c.GroupMemberRepository == nil {
+		return nil, nil, nil
+	}
+	member, err := studentGroup(ctx, c.CohortMemberRepository, c.GroupMemberRepository, user.ID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	if member == nil {
+		return nil, nil, nil
+	}
+	group, err := c.GroupRepository.FindByID(ctx, member.GroupID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	if group == nil {
+		return nil, nil, nil
+	}
+	userIDs, err := c.GroupMemberRepository.FindUserIDs(ctx, group.ID)
+	if err != nil {
+		return nil, nil, util.ErrInternalDefault
+	}
+	mates := []entity.User{}
+	for _, userID := range userIDs {
+		if userID == user.ID {
+			continue
+		}
+		if assignment.CourseID != nil {
+			enrollment, err := c.EnrollmentRepository.FindByCourseAndUser(ctx, *assignment.CourseID, userID)
+			if err != nil {
+				return nil, nil, util.ErrInternalDefault
+			}
+			if enrollment == nil || enrollment.Status != entity.EnrollmentActive {
+				continue
+			}
+		}
+		mate, err := c.UserRepository.FindByID(ctx, userID)
+		if err != nil {
+			return nil, nil, util.ErrInternalDefault
+		}
+		if mate != nil {
+			mates = append(mates, *mate)
+		}
+	}
+	return group, mates, nil
+}
+
+// extension returns the extension that applies to the student, either their own
+// or their cohort's.
+func (c *ScoreUseCase) extension(ctx context.Context, assignmentID, userID primitive.ObjectID) (*entity.Extension, error) {
+	cohortID, err := c.CohortMemberRepository.FindStudentCohortID(ctx, userID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	extensions, err := c.ExtensionRepository.FindForUser(ctx, assignmentID, userID, cohortID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	return entity.PickExtension(extensions, userID), nil
+}
+
+func (c *ScoreUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
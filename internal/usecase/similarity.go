@@ -0,0 +1,336 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+	"github.com/Erwanph/be-wan-central-lab/internal/model"
+	"github.com/Erwanph/be-wan-central-lab/internal/model/converter"
+	"github.com/Erwanph/be-wan-central-lab/internal/repository"
+	"github.com/Erwanph/be-wan-central-lab/internal/util"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	similarityLease = 30 * time.Minute
+	similarityTries = 3
+	// maxSimilarityPairs and maxSimilarityRegions keep a report of a large
+	// class with much shared code within a document's size.
+	maxSimilarityPairs   = 500
+	maxSimilarityRegions = 50
+)
+
+type SimilarityUseCase struct {
+	Log                        *logrus.Logger
+	Validate                   *validator.Validate
+	SimilarityReportRepository *repository.SimilarityReportRepository
+	CodeSubmissionRepository   *repository.CodeSubmissionRepository
+	AssignmentRepository       *repository.AssignmentRepository
+	CourseRepository           *repository.CourseRepository
+	CohortRepository           *repository.CohortRepository
+	CohortMemberRepository     *repository.CohortMemberRepository
+	UserRepository             *repository.UserRepository
+}
+
+func NewSimilarityUseCase(logger *logrus.Logger, validate *validator.Validate,
+	similarityReportRepository *repository.SimilarityReportRepository, codeSubmissionRepository *repository.CodeSubmissionRepository,
+	assignmentRepository *repository.AssignmentRepository, courseRepository *repository.CourseRepository,
+	cohortRepository *repository.CohortRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	userRepository *repository.UserRepository) *SimilarityUseCase {
+	return &SimilarityUseCase{
+		Log:                        logger,
+		Validate:                   validate,
+		SimilarityReportRepository: similarityReportRepository,
+		CodeSubmissionRepository:   codeSubmissionRepository,
+		AssignmentRepository:       assignmentRepository,
+		CourseRepository:           courseRepository,
+		CohortRepository:           cohortRepository,
+		CohortMemberRepository:     cohortMemberRepository,
+		UserRepository:             userRepository,
+	}
+}
+
+// SaveAllowList replaces the starter code the assignment's checks ignore. It
+// is taken into account from the next check on.
+func (c *SimilarityUseCase) SaveAllowList(ctx context.Context, request *model.SaveAllowListRequest) (*model.SimilarityReportResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, err
+	}
+	report := &entity.SimilarityReport{
+		AssignmentID: assignment.ID,
+		AllowList:    make([]entity.StarterCode, 0, len(request.StarterCode)),
+	}
+	for _, starter := range request.StarterCode {
+		report.AllowList = append(report.AllowList, entity.StarterCode{Name: starter.Name, Source: starter.Source})
+	}
+	if err = c.SimilarityReportRepository.SaveAllowList(ctx, report); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"assignment_id": request.AssignmentID,
+			util.LogError:   err,
+		}).Error("Failed to save similarity allow-list")
+		return nil, util.ErrInternalDefault
+	}
+	return c.response(ctx, report, request.ActorEmail)
+}
+
+// Check queues a check of the latest code submission of every student of the
+// assignment against each other's. It replaces the previous report once the
+// similarity job has run it.
+func (c *SimilarityUseCase) Check(ctx context.Context, request *model.CheckSimilarityRequest) (*model.SimilarityReportResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := c.findUser(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireAssignmentStaff(ctx, c.CourseRepository, c.CohortRepository, c.CohortMemberRepository, actor, assignment); err != nil {
+		return nil, err
+	}
+	report := &entity.SimilarityReport{
+		AssignmentID: assignment.ID,
+		Threshold:    request.Threshold,
+		RequestedBy:  &actor.ID,
+	}
+	if report.Threshold == 0 {
+		report.Threshold = entity.DefaultSimilarityThreshold
+	}
+	if err = c.SimilarityReportRepository.Queue(ctx, report); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to queue similarity check")
+		return nil, util.ErrInternalDefault
+	}
+	return c.response(ctx, report, request.ActorEmail)
+}
+
+// GetReport shows the latest check. Instructors only see pairs involving a
+// student they teach, and not who the other student is when they do not teach
+// them.
+func (c *SimilarityUseCase) GetReport(ctx context.Context, request *model.GetSimilarityReportRequest) (*model.SimilarityReportResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	assignment, err := c.findAssignment(ctx, request.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	report, err := c.SimilarityReportRepository.FindByAssignment(ctx, assignment.ID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if report == nil {
+		return nil, util.ErrSimilarityReportNotFound
+	}
+	return c.response(ctx, report, request.ActorEmail)
+}
+
+func (c *SimilarityUseCase) response(ctx context.Context, report *entity.SimilarityReport, actorEmail string) (*model.SimilarityReportResponse, error) {
+	actor, err := c.findUser(ctx, actorEmail)
+	if err != nil {
+		return nil, err
+	}
+	userIDs, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	visible := map[primitive.ObjectID]bool{}
+	for _, userID := range userIDs {
+		visible[userID] = true
+	}
+
+	response := converter.NewSimilarityReportResponse(report)
+	emails := map[primitive.ObjectID]string{}
+	email := func(userID primitive.ObjectID) string {
+		if userIDs != nil && !visible[userID] {
+			return ""
+		}
+		if _, ok := emails[userID]; !ok {
+			if user, err := c.UserRepository.FindByID(ctx, userID); err == nil && user != nil {
+				emails[userID] = user.Email
+			}
+		}
+		return emails[userID]
+	}
+	for i := range report.Pairs {
+		pair := &report.Pairs[i]
+		if userIDs != nil && !visible[pair.UserA] && !visible[pair.UserB] {
+			continue
+		}
+		response.Pairs = append(response.Pairs, *converter.NewSimilarityPairResponse(pair, email(pair.UserA), email(pair.UserB)))
+	}
+	return response, nil
+}
+
+// CheckQueued runs queued checks until there are none left or limit were run,
+// and returns how many it ran. Several API processes can run it at once; each
+// check is claimed by one of them.
+func (c *SimilarityUseCase) CheckQueued(ctx context.Context, limit int) (int, error) {
+	if failed, err := c.SimilarityReportRepository.FailStale(ctx, time.Now(), similarityTries); err != nil {
+		return 0, err
+	} else if failed > 0 {
+		c.Log.WithField("count", failed).Warn("Gave up similarity checks")
+	}
+	checked := 0
+	for checked < limit {
+		now := time.Now()
+		report, err := c.SimilarityReportRepository.Claim(ctx, now, now.Add(similarityLease), similarityTries)
+		if err != nil {
+			return checked, err
+		}
+		if report == nil {
+			return checked, nil
+		}
+		if err = c.check(ctx, report); err != nil {
+			return checked, err
+		}
+		checked++
+	}
+	return checked, nil
+}
+
+// check fingerprints every student's latest submission and compares each pair
+// written in the same language, leaving out code found in the starter code.
+// Pairs at or above the threshold are kept, most similar first.
+func (c *SimilarityUseCase) check(ctx context.Context, report *entity.SimilarityReport) error {
+	submissions, err := c.CodeSubmissionRepository.FindLatestSources(ctx, report.AssignmentID)
+	if err != nil {
+		return err
+	}
+	prints := make([]*util.SourceFingerprints, len(submissions))
+	ignore := map[string]map[uint64]bool{}
+	for i := range submissions {
+		language := submissions[i].Language
+		prints[i] = util.FingerprintSource(language, submissions[i].Source)
+		if ignore[language] == nil {
+			ignore[language] = map[uint64]bool{}
+			for _, starter := range report.AllowList {
+				for _, fingerprint := range util.FingerprintSource(language, starter.Source).Fingerprints {
+					ignore[language][fingerprint.Hash] = true
+				}
+			}
+		}
+	}
+
+	report.Pairs = []entity.SimilarityPair{}
+	report.Compared = 0
+	for i := range submissions {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		for j := i + 1; j < len(submissions); j++ {
+			a, b := &submissions[i], &submissions[j]
+			if a.Language != b.Language {
+				continue
+			}
+			report.Compared++
+			match := util.CompareSources(prints[i], prints[j], ignore[a.Language])
+			if match.Similarity()*100 < float64(report.Threshold) {
+				continue
+			}
+			pair := entity.SimilarityPair{
+				UserA:       a.UserID,
+				UserB:       b.UserID,
+				SubmissionA: a.ID,
+				SubmissionB: b.ID,
+				Similarity:  roundSimilarity(match.Similarity()),
+				CoverageA:   roundSimilarity(match.CoverageA),
+				CoverageB:   roundSimilarity(match.CoverageB),
+				Regions:     make([]entity.SimilarityRegion, 0, min(len(match.Regions), maxSimilarityRegions)),
+			}
+			for _, region := range match.Regions[:min(len(match.Regions), maxSimilarityRegions)] {
+				pair.Regions = append(pair.Regions, entity.SimilarityRegion{
+					StartA: region.StartA,
+					EndA:   region.EndA,
+					StartB: region.StartB,
+					EndB:   region.EndB,
+				})
+			}
+			report.Pairs = append(report.Pairs, pair)
+		}
+	}
+	sort.SliceStable(report.Pairs, func(i, j int) bool { return report.Pairs[i].Similarity > report.Pairs[j].Similarity })
+	if len(report.Pairs) > maxSimilarityPairs {
+		report.Pairs = report.Pairs[:maxSimilarityPairs]
+	}
+
+	now := util.NowInWIB()
+	report.Status = entity.SimilarityDone
+	report.Submissions = len(submissions)
+	report.Error = ""
+	report.FinishedAt = &now
+	finished, err := c.SimilarityReportRepository.Finish(ctx, report)
+	if err != nil {
+		return err
+	}
+	if !finished {
+		c.Log.WithField("assignment_id", report.AssignmentID.Hex()).Warn("Similarity check was superseded before it finished")
+		return nil
+	}
+	c.Log.WithFields(logrus.Fields{
+		"assignment_id": report.AssignmentID.Hex(),
+		"submissions":   report.Submissions,
+		"pairs":         len(report.Pairs),
+	}).Info("Checked code submissions for similarity")
+	return nil
+}
+
+func roundSimilarity(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+// findAssignment finds an assignment graded by running code, the only kind
+// with code submissions to compare.
+func (c *SimilarityUseCase) findAssignment(ctx context.Context, id string) (*entity.Assignment, error) {
+	assignmentID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	assignment, err := c.AssignmentRepository.FindByID(ctx, assignmentID)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if assignment == nil {
+		return nil, util.ErrAssignmentNotFound
+	}
+	if assignment.Grading != entity.GradingCode {
+		return nil, util.ErrNotCodeAssignment
+	}
+	return assignment, nil
+}
+
+func (c *SimilarityUseCase) findUser(ctx context.Context, email string) (*entity.User, error) {
+	user, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"email":       email,
+			util.LogError: err,
+		}).Error("Failed to find user by Email in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
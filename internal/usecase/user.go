@@ -15,23 +15,39 @@ import (
 	"github.com/golang-jwt/jwt"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserUseCase struct {
-	Log            *logrus.Logger
-	Validate       *validator.Validate
-	UserRepository *repository.UserRepository
-	Config         *viper.Viper
+	Log                    *logrus.Logger
+	Validate               *validator.Validate
+	UserRepository         *repository.UserRepository
+	MailEventRepository    *repository.MailEventRepository
+	ScoreRepository        *repository.ScoreRepository
+	CourseGradeRepository  *repository.CourseGradeRepository
+	CohortRepository       *repository.CohortRepository
+	CohortMemberRepository *repository.CohortMemberRepository
+	NotificationUseCase    *NotificationUseCase
+	Config                 *viper.Viper
 }
 
 func NewUserUseCase(logger *logrus.Logger, validate *validator.Validate,
-	userRepository *repository.UserRepository, config *viper.Viper) *UserUseCase {
+	userRepository *repository.UserRepository, mailEventRepository *repository.MailEventRepository,
+	scoreRepository *repository.ScoreRepository, courseGradeRepository *repository.CourseGradeRepository,
+	cohortRepository *repository.CohortRepository, cohortMemberRepository *repository.CohortMemberRepository,
+	notificationUseCase *NotificationUseCase, config *viper.Viper) *UserUseCase {
 	return &UserUseCase{
-		Log:            logger,
-		Validate:       validate,
-		UserRepository: userRepository,
-		Config:         config,
+		Log:                    logger,
+		Validate:               validate,
+		UserRepository:         userRepository,
+		MailEventRepository:    mailEventRepository,
+		ScoreRepository:        scoreRepository,
+		CourseGradeRepository:  courseGradeRepository,
+		CohortRepository:       cohortRepository,
+		CohortMemberRepository: cohortMemberRepository,
+		NotificationUseCase:    notificationUseCase,
+		Config:                 config,
 	}
 }
 func (c *UserUseCase) Create(ctx context.Context, request *model.RegisterRequest) (*model.RegisterResponse, error) {
@@ -94,43 +110,9 @@ func (c *UserUseCase) Create(ctx context.Context, request *model.RegisterRequest
 		return nil, err
 	}
 
-	user.Score = 0
-
 	return converter.NewRegisterResponse(user), nil
 }
 
-func (c *UserUseCase) UpdateScore(ctx context.Context, email string, score int) (*model.UpdateScoreResponse, error) {
-	if !util.IsValidEmail(email) {
-		return nil, util.ErrInvalidEmail
-	}
-	user, err := c.UserRepository.FindByEmail(ctx, email)
-	if err != nil {
-		return nil, err
-	}
-	if user == nil {
-		return nil, util.ErrInternalDefault
-	}
-	if user.Score > 0 {
-		return nil, errors.New("assignment already worked")
-	}
-	user.Score += score // Increment existing score
-
-	err = c.UserRepository.UpdateScore(ctx, user)
-	if err != nil {
-		c.Log.WithFields(logrus.Fields{
-			"email": email,
-			"score": score,
-			"error": err,
-		}).Error("Failed to update user score")
-		return nil, err
-	}
-
-	return &model.UpdateScoreResponse{
-		Email: user.Email,
-		Score: user.Score,
-	}, nil
-}
-
 func (c *UserUseCase) VerifyOTPRegister(ctx context.Context, request *model.RequestVerifyEmailUsingOtp) (*model.ResponseVerifyEmailUsingOtp, error) {
 	user, err := c.UserRepository.FindByEmail(ctx, request.Email)
 	if err != nil || user == nil {
@@ -219,7 +201,26 @@ func (c *UserUseCase) GetProfiles(ctx context.Context, request *model.RequestGet
 	if err != nil {
 		return nil, err
 	}
-	return converter.NewGetUserResponse(user), err
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	total, err := c.ScoreRepository.SumByUser(ctx, user.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to sum user scores")
+		return nil, util.ErrInternalDefault
+	}
+	grades, err := c.CourseGradeRepository.FindByUser(ctx, user.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to find course grades")
+		return nil, util.ErrInternalDefault
+	}
+	return converter.NewGetUserResponse(user, total, grades), nil
 }
 func (c *UserUseCase) UpdateProfiles(ctx context.Context, request *model.RequestUpdateProfile) (*model.ResponseUpdateProfile, error) {
 	err := c.Validate.Struct(request)
@@ -291,7 +292,193 @@ func (c *UserUseCase) UpdateProfiles(ctx context.Context, request *model.Request
 	if err != nil {
 		return nil, err
 	}
+	if request.NewPassword != "" {
+		_ = c.NotificationUseCase.Notify(ctx, user.ID, entity.NotificationCategorySecurity,
+			"Password changed", "Your password was changed. If this wasn't you, reset your password and contact the lab staff.")
+	}
 	updatedUser := converter.NewUpdateUserResponse(user)
 	updatedUser.NewJWTToken = new_jwt_token
 	return updatedUser, nil
 }
+
+// AdminListUsers lists everybody for admins and only the students of their
+// cohorts for instructors and TAs.
+func (c *UserUseCase) AdminListUsers(ctx context.Context, request *model.AdminListUserRequest) (*model.AdminListUserResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.ErrInvalidPaging
+	}
+	actor, err := c.findActor(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := studentScope(ctx, c.CohortMemberRepository, actor)
+	if err != nil {
+		return nil, err
+	}
+	users, total, err := c.UserRepository.FindAll(ctx, scope, request.Search, request.Undeliverable, request.Page, request.Limit)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to list users")
+		return nil, util.ErrInternalDefault
+	}
+
+	response := &model.AdminListUserResponse{
+		Users:  make([]model.AdminUserResponse, 0, len(users)),
+		Paging: converter.NewPaginationMetadata(request.Page, request.Limit, total),
+	}
+	for i := range users {
+		user, err := c.adminUserResponse(ctx, &users[i])
+		if err != nil {
+			return nil, err
+		}
+		response.Users = append(response.Users, *user)
+	}
+	return response, nil
+}
+
+func (c *UserUseCase) AdminGetUser(ctx context.Context, actorEmail, id string) (*model.AdminUserResponse, error) {
+	user, err := c.findStudentForActor(ctx, actorEmail, id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := c.MailEventRepository.FindRecentByEmail(ctx, user.Email, 10)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"user_id":     id,
+			util.LogError: err,
+		}).Error("Failed to find mail events")
+		return nil, util.ErrInternalDefault
+	}
+
+	response, err := c.adminUserResponse(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		response.RecentMailEvents = append(response.RecentMailEvents, *converter.NewMailEventResponse(&events[i]))
+	}
+	return response, nil
+}
+
+// AdminResetEmailStatus lets staff re-enable mail once the student confirms their
+// mailbox works again without changing the address.
+func (c *UserUseCase) AdminResetEmailStatus(ctx context.Context, actorEmail, id string) (*model.AdminUserResponse, error) {
+	user, err := c.findStudentForActor(ctx, actorEmail, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.UserRepository.ClearEmailUndeliverable(ctx, user.ID); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"user_id":     id,
+			util.LogError: err,
+		}).Error("Failed to reset email status")
+		return nil, util.ErrInternalDefault
+	}
+	user.EmailUndeliverable = false
+	user.EmailUndeliverableReason = ""
+	user.EmailUndeliverableAt = nil
+	return c.adminUserResponse(ctx, user)
+}
+
+// AdminSetRole lets an admin make a user a student, instructor, TA or admin.
+// The role applies from the user's next request.
+func (c *UserUseCase) AdminSetRole(ctx context.Context, request *model.AdminSetRoleRequest) (*model.AdminUserResponse, error) {
+	if err := c.Validate.Struct(request); err != nil {
+		return nil, util.NewCustomError(err)
+	}
+	actor, err := c.findActor(ctx, request.ActorEmail)
+	if err != nil {
+		return nil, err
+	}
+	if actor.GetRole() != entity.RoleAdmin {
+		return nil, util.ErrPermissionDenied
+	}
+	user, err := c.findUserByHexID(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == actor.ID && request.Role != entity.RoleAdmin {
+		return nil, util.ErrOwnRole
+	}
+	if err = c.UserRepository.UpdateRole(ctx, user.ID, request.Role); err != nil {
+		c.Log.WithFields(logrus.Fields{
+			util.LogRequest: request,
+			util.LogError:   err,
+		}).Error("Failed to update user role")
+		return nil, util.ErrInternalDefault
+	}
+	user.Role = request.Role
+	return c.adminUserResponse(ctx, user)
+}
+
+// adminUserResponse adds the cohorts the user belongs to.
+func (c *UserUseCase) adminUserResponse(ctx context.Context, user *entity.User) (*model.AdminUserResponse, error) {
+	response := converter.NewAdminUserResponse(user)
+	members, err := c.CohortMemberRepository.FindByUser(ctx, user.ID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"user_id":     user.ID.Hex(),
+			util.LogError: err,
+		}).Error("Failed to find cohort memberships")
+		return nil, util.ErrInternalDefault
+	}
+	response.Cohorts = make([]model.UserCohortResponse, 0, len(members))
+	for i := range members {
+		cohort, err := c.CohortRepository.FindByID(ctx, members[i].CohortID)
+		if err != nil {
+			return nil, util.ErrInternalDefault
+		}
+		if cohort != nil {
+			response.Cohorts = append(response.Cohorts, *converter.NewUserCohortResponse(cohort, &members[i]))
+		}
+	}
+	return response, nil
+}
+
+// findStudentForActor finds the user and checks that the actor teaches them.
+func (c *UserUseCase) findStudentForActor(ctx context.Context, actorEmail, id string) (*entity.User, error) {
+	actor, err := c.findActor(ctx, actorEmail)
+	if err != nil {
+		return nil, err
+	}
+	user, err := c.findUserByHexID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = requireStudentAccess(ctx, c.CohortMemberRepository, actor, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (c *UserUseCase) findActor(ctx context.Context, email string) (*entity.User, error) {
+	actor, err := c.UserRepository.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, util.ErrInternalDefault
+	}
+	if actor == nil {
+		return nil, util.ErrInvalidCredential
+	}
+	return actor, nil
+}
+
+func (c *UserUseCase) findUserByHexID(ctx context.Context, id string) (*entity.User, error) {
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, util.ErrInvalidID
+	}
+	user, err := c.UserRepository.FindByID(ctx, userID)
+	if err != nil {
+		c.Log.WithFields(logrus.Fields{
+			"user_id":     id,
+			util.LogError: err,
+		}).Error("Failed to find user by ID in database")
+		return nil, util.ErrInternalDefault
+	}
+	if user == nil {
+		return nil, util.ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// joinCodeAlphabet leaves out characters that are easy to misread aloud or on a
+// projector (0/O, 1/I/L).
+const joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+func GenerateJoinCode() (string, error) {
+	code := make([]byte, 8)
+	max := big.NewInt(int64(len(joinCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = joinCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
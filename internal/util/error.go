@@ -55,4 +55,145 @@ var (
 		Code: http.StatusForbidden,
 		Err:  errors.New("permission denied"),
 	}
+
+	ErrUserNotFound  = CustomError{http.StatusNotFound, errors.New("user not found")}
+	ErrOwnRole       = CustomError{http.StatusBadRequest, errors.New("admins cannot take away their own admin role")}
+	ErrInvalidID     = CustomError{http.StatusBadRequest, errors.New("invalid id format")}
+	ErrInvalidPaging = CustomError{http.StatusBadRequest, errors.New("invalid page or limit")}
+
+	//notification error
+	ErrNotificationNotFound          = CustomError{http.StatusNotFound, errors.New("notification not found")}
+	ErrInvalidTimezone               = CustomError{http.StatusBadRequest, errors.New("invalid timezone, use an IANA name such as Asia/Jakarta")}
+	ErrInvalidNotificationPreference = CustomError{http.StatusBadRequest, errors.New("unknown notification category or delivery option")}
+
+	//mail error
+	ErrEmailUndeliverable      = CustomError{http.StatusUnprocessableEntity, errors.New("email address is undeliverable, please update your email")}
+	ErrInvalidMailReport       = CustomError{http.StatusBadRequest, errors.New("message is not a delivery status or feedback report")}
+	ErrInvalidWebhookSignature = CustomError{http.StatusUnauthorized, errors.New("invalid webhook secret")}
+
+	//assignment error
+	ErrAssignmentNotFound      = CustomError{http.StatusNotFound, errors.New("assignment not found")}
+	ErrAssignmentClosed        = CustomError{http.StatusForbidden, errors.New("assignment is not open for submissions")}
+	ErrAttemptLimitReached     = CustomError{http.StatusConflict, errors.New("no attempts left for this assignment")}
+	ErrSubmissionConflict      = CustomError{http.StatusConflict, errors.New("another submission was recorded at the same time, please retry")}
+	ErrInvalidAssignmentWindow = CustomError{http.StatusBadRequest, errors.New("assignment must open before it is due and be due before it closes")}
+	ErrInvalidLatePolicy       = CustomError{http.StatusBadRequest, errors.New("percent_per_day needs a percent and linear_decay needs days")}
+	ErrExtensionNotFound       = CustomError{http.StatusNotFound, errors.New("extension not found")}
+	ErrScoreOutOfRange         = CustomError{http.StatusBadRequest, errors.New("score must be between 0 and the assignment max score")}
+
+	//course error
+	ErrCourseNotFound     = CustomError{http.StatusNotFound, errors.New("course not found")}
+	ErrCourseCodeTaken    = CustomError{http.StatusConflict, errors.New("course code is already used")}
+	ErrModuleNotFound     = CustomError{http.StatusNotFound, errors.New("module not found")}
+	ErrInvalidJoinCode    = CustomError{http.StatusNotFound, errors.New("join code is invalid or the course is not accepting enrollments")}
+	ErrNotEnrolled        = CustomError{http.StatusForbidden, errors.New("you are not enrolled in this course")}
+	ErrEnrollmentNotFound = CustomError{http.StatusNotFound, errors.New("enrollment not found")}
+
+	//cohort error
+	ErrCohortNotFound       = CustomError{http.StatusNotFound, errors.New("cohort not found")}
+	ErrCohortNameTaken      = CustomError{http.StatusConflict, errors.New("cohort name is already used")}
+	ErrCohortMemberNotFound = CustomError{http.StatusNotFound, errors.New("user is not a member of this cohort")}
+	ErrStudentNotInCohort   = CustomError{http.StatusForbidden, errors.New("student is not in any cohort you teach")}
+	ErrInvalidCohortMove    = CustomError{http.StatusBadRequest, errors.New("students must move to a different cohort")}
+
+	//group error
+	ErrGroupNotFound       = CustomError{http.StatusNotFound, errors.New("group not found")}
+	ErrGroupNameTaken      = CustomError{http.StatusConflict, errors.New("group name is already used in this cohort")}
+	ErrGroupFull           = CustomError{http.StatusConflict, errors.New("group is full")}
+	ErrGroupClosed         = CustomError{http.StatusForbidden, errors.New("group is not open for self-signup")}
+	ErrAlreadyInGroup      = CustomError{http.StatusConflict, errors.New("student is already in a group of this cohort")}
+	ErrGroupMemberNotFound = CustomError{http.StatusNotFound, errors.New("user is not a member of this group")}
+
+	//grade error
+	ErrGradingSchemeNotFound = CustomError{http.StatusNotFound, errors.New("course has no grading scheme")}
+	ErrInvalidGradeWeights   = CustomError{http.StatusBadRequest, errors.New("category weights must add up to 100")}
+	ErrDuplicateGradeKey     = CustomError{http.StatusBadRequest, errors.New("category keys and letters must be unique")}
+	ErrInvalidGradeCurve     = CustomError{http.StatusBadRequest, errors.New("linear curve needs a target_mean and cap curve a cap between 0 and 100")}
+
+	//score ledger error
+	ErrInvalidCorrection = CustomError{http.StatusBadRequest, errors.New("correction needs exactly one of delta or new_score and must change the score")}
+
+	//grade import error
+	ErrInvalidGradeImport  = CustomError{http.StatusBadRequest, errors.New("grade import must be a CSV file with email, assignment and score columns")}
+	ErrGradeImportTooLarge = CustomError{http.StatusRequestEntityTooLarge, errors.New("grade import has too many rows")}
+	ErrGradeImportRejected = CustomError{http.StatusUnprocessableEntity, errors.New("grade import has invalid rows, nothing was applied")}
+
+	//quiz error
+	ErrAutoGradedAssignment = CustomError{http.StatusForbidden, errors.New("scores for this assignment come from its auto-grader")}
+	ErrQuestionBankNotFound = CustomError{http.StatusNotFound, errors.New("question bank not found")}
+	ErrQuestionNotFound     = CustomError{http.StatusNotFound, errors.New("question not found")}
+	ErrInvalidQuestion      = CustomError{http.StatusBadRequest, errors.New("question is missing the answer key its type needs")}
+	ErrInvalidAnswerPattern = CustomError{http.StatusBadRequest, errors.New("short text answer patterns must be valid regular expressions")}
+	ErrInvalidParameter     = CustomError{http.StatusBadRequest, errors.New("question parameters need unique names and a step that fits between min and max")}
+	ErrInvalidFormula       = CustomError{http.StatusBadRequest, errors.New("answer formula must be a valid expression of the question's parameters")}
+	ErrInvalidQuestionFile  = CustomError{http.StatusBadRequest, errors.New("question file must be in GIFT or QTI 2.1 format")}
+	ErrQuestionFileTooLarge = CustomError{http.StatusRequestEntityTooLarge, errors.New("question file has too many questions")}
+	ErrUnknownPlaceholder   = CustomError{http.StatusBadRequest, errors.New("question uses a placeholder that is not one of its parameters")}
+	ErrQuizNotFound         = CustomError{http.StatusNotFound, errors.New("assignment has no quiz")}
+	ErrInvalidQuizSection   = CustomError{http.StatusBadRequest, errors.New("quiz section asks for more questions than its bank has")}
+	ErrQuizAttemptNotFound  = CustomError{http.StatusNotFound, errors.New("quiz attempt not found")}
+	ErrQuizAttemptClosed    = CustomError{http.StatusConflict, errors.New("quiz attempt has been submitted or its time is up")}
+	ErrInvalidQuizAnswer    = CustomError{http.StatusBadRequest, errors.New("answers must be for questions of this attempt")}
+	ErrExamNeedsTimeLimit   = CustomError{http.StatusBadRequest, errors.New("exams need a time limit")}
+	ErrInvalidNetwork       = CustomError{http.StatusBadRequest, errors.New("allowed networks must be IP addresses or CIDR ranges")}
+	ErrInvalidAccessCode    = CustomError{http.StatusForbidden, errors.New("exam access code is wrong")}
+	ErrNetworkNotAllowed    = CustomError{http.StatusForbidden, errors.New("exam cannot be taken from this network")}
+	ErrNotAnExamAttempt     = CustomError{http.StatusBadRequest, errors.New("quiz attempt is not part of an exam")}
+
+	//code grading error
+	ErrCodeGraderNotFound     = CustomError{http.StatusNotFound, errors.New("assignment has no code grader")}
+	ErrInvalidCodeGrader      = CustomError{http.StatusBadRequest, errors.New("code grader commands and file name must be set for custom languages")}
+	ErrCodeGraderTooSlow      = CustomError{http.StatusBadRequest, errors.New("code grader tests may take at most 10 minutes together")}
+	ErrCodeSubmissionNotFound = CustomError{http.StatusNotFound, errors.New("code submission not found")}
+	ErrSourceTooLarge         = CustomError{http.StatusRequestEntityTooLarge, errors.New("source file is too large")}
+	ErrInvalidSource          = CustomError{http.StatusBadRequest, errors.New("source file must be UTF-8 text")}
+	ErrCodeSubmissionPending  = CustomError{http.StatusTooManyRequests, errors.New("an earlier submission is still waiting to be graded")}
+
+	//lab client error
+	ErrUnsignedScore       = CustomError{http.StatusUnauthorized, errors.New("scores must be signed by the lab client")}
+	ErrInvalidSignature    = CustomError{http.StatusUnauthorized, errors.New("score signature is not valid for this key")}
+	ErrSignatureExpired    = CustomError{http.StatusUnauthorized, errors.New("score timestamp is too far from the server time")}
+	ErrReplayedSubmission  = CustomError{http.StatusConflict, errors.New("score nonce has already been used")}
+	ErrClientKeyNotFound   = CustomError{http.StatusNotFound, errors.New("client key not found")}
+	ErrInvalidClientKey    = CustomError{http.StatusBadRequest, errors.New("public key must be a base64 Ed25519 public key")}
+	ErrInvalidScorePayload = CustomError{http.StatusBadRequest, errors.New("payload reference must be a single line")}
+
+	//rubric error
+	ErrRubricNotFound        = CustomError{http.StatusNotFound, errors.New("rubric not found")}
+	ErrInvalidRubric         = CustomError{http.StatusBadRequest, errors.New("rubric criteria and their levels need unique ids and points worth grading")}
+	ErrAssignmentHasNoRubric = CustomError{http.StatusNotFound, errors.New("assignment is not graded with a rubric")}
+	ErrRubricOtherCourse     = CustomError{http.StatusBadRequest, errors.New("rubric belongs to another course")}
+	ErrRubricGradeNotFound   = CustomError{http.StatusNotFound, errors.New("rubric grade not found")}
+	ErrIncompleteRubricGrade = CustomError{http.StatusBadRequest, errors.New("rubric grade must pick one level of every criterion")}
+
+	//regrade error
+	ErrRegradeRequestNotFound   = CustomError{http.StatusNotFound, errors.New("regrade request not found")}
+	ErrNotGraded                = CustomError{http.StatusConflict, errors.New("assignment has not been graded yet")}
+	ErrRegradeWindowClosed      = CustomError{http.StatusForbidden, errors.New("regrade requests for this grade are no longer accepted")}
+	ErrRegradePending           = CustomError{http.StatusConflict, errors.New("a regrade request for this assignment is already pending")}
+	ErrRegradeResolved          = CustomError{http.StatusConflict, errors.New("regrade request was already resolved")}
+	ErrRegradeEscalated         = CustomError{http.StatusForbidden, errors.New("escalated regrade requests are resolved by instructors")}
+	ErrInvalidRegradeResolution = CustomError{http.StatusBadRequest, errors.New("rejecting a regrade request needs a response and no new score")}
+
+	//peer review error
+	ErrPeerReviewNotFound     = CustomError{http.StatusNotFound, errors.New("assignment is not peer reviewed")}
+	ErrInvalidPeerReview      = CustomError{http.StatusBadRequest, errors.New("peer review needs an assignment due date and reviews due after it")}
+	ErrPeerReviewStarted      = CustomError{http.StatusConflict, errors.New("peer reviews were already handed out")}
+	ErrPeerReviewNotStarted   = CustomError{http.StatusConflict, errors.New("peer reviews have not been handed out yet")}
+	ErrPeerReviewClosed       = CustomError{http.StatusConflict, errors.New("peer review scores were already finalized")}
+	ErrPeerReviewTaskNotFound = CustomError{http.StatusNotFound, errors.New("peer review not found")}
+	ErrPeerSubmissionNotFound = CustomError{http.StatusNotFound, errors.New("peer review submission not found")}
+
+	//similarity error
+	ErrNotCodeAssignment        = CustomError{http.StatusBadRequest, errors.New("similarity checks are only available for code graded assignments")}
+	ErrSimilarityReportNotFound = CustomError{http.StatusNotFound, errors.New("assignment has not been checked for similarity")}
 )
+
+// StatusCode returns the HTTP status carried by a CustomError, or 500 for any other error.
+func StatusCode(err error) int {
+	var customErr CustomError
+	if errors.As(err, &customErr) {
+		return customErr.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
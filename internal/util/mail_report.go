@@ -0,0 +1,172 @@
+package util
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/Erwanph/be-wan-central-lab/internal/entity"
+)
+
+var ErrNotMailReport = errors.New("message is not a delivery status or feedback report")
+
+// ParseMailReport extracts bounce and complaint events from a raw mail message.
+// It understands RFC 3464 delivery status notifications and RFC 5965 abuse
+// feedback reports; any other message returns ErrNotMailReport.
+func ParseMailReport(r io.Reader) ([]entity.MailEvent, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/report" || params["boundary"] == "" {
+		return nil, ErrNotMailReport
+	}
+
+	occurredAt := time.Now()
+	if date, err := msg.Header.Date(); err == nil {
+		occurredAt = date
+	}
+
+	var events []entity.MailEvent
+	var complaint *entity.MailEvent
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		body := io.Reader(part)
+		if strings.EqualFold(part.Header.Get("Content-Transfer-Encoding"), "base64") {
+			body = base64.NewDecoder(base64.StdEncoding, part)
+		}
+
+		switch partType {
+		case "message/delivery-status":
+			found, err := parseDeliveryStatus(body, occurredAt)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, found...)
+		case "message/feedback-report":
+			complaint, err = parseFeedbackReport(body, occurredAt)
+			if err != nil {
+				return nil, err
+			}
+		case "message/rfc822", "text/rfc822-headers":
+			// The original message tells us who was complained about when the
+			// feedback report itself omits Original-Rcpt-To.
+			if complaint != nil && complaint.Email == "" {
+				if original, err := mail.ReadMessage(body); err == nil {
+					if to, err := mail.ParseAddress(original.Header.Get("To")); err == nil {
+						complaint.Email = strings.ToLower(to.Address)
+					}
+				}
+			}
+		}
+	}
+
+	if complaint != nil && complaint.Email != "" {
+		events = append(events, *complaint)
+	}
+	if params["report-type"] != "delivery-status" && params["report-type"] != "feedback-report" && len(events) == 0 {
+		return nil, ErrNotMailReport
+	}
+	return events, nil
+}
+
+func parseDeliveryStatus(r io.Reader, occurredAt time.Time) ([]entity.MailEvent, error) {
+	reader := textproto.NewReader(bufio.NewReader(r))
+
+	// The first block holds per-message fields, the rest are one block per recipient.
+	perMessage, err := reader.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if arrival, err := mail.ParseDate(perMessage.Get("Arrival-Date")); err == nil {
+		occurredAt = arrival
+	}
+
+	var events []entity.MailEvent
+	for err != io.EOF {
+		var recipient textproto.MIMEHeader
+		recipient, err = reader.ReadMIMEHeader()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(recipient) == 0 {
+			continue
+		}
+
+		email := addressField(recipient.Get("Final-Recipient"))
+		if email == "" {
+			email = addressField(recipient.Get("Original-Recipient"))
+		}
+		status := strings.TrimSpace(recipient.Get("Status"))
+		action := strings.ToLower(strings.TrimSpace(recipient.Get("Action")))
+		if email == "" {
+			continue
+		}
+
+		var eventType string
+		switch {
+		case action == "failed" && strings.HasPrefix(status, "5"):
+			eventType = entity.MailEventHardBounce
+		case action == "failed" || action == "delayed":
+			eventType = entity.MailEventSoftBounce
+		default:
+			continue
+		}
+
+		events = append(events, entity.MailEvent{
+			Email:      email,
+			Type:       eventType,
+			Source:     entity.MailEventSourceMailbox,
+			Status:     status,
+			Diagnostic: strings.TrimSpace(recipient.Get("Diagnostic-Code")),
+			OccurredAt: occurredAt,
+		})
+	}
+	return events, nil
+}
+
+func parseFeedbackReport(r io.Reader, occurredAt time.Time) (*entity.MailEvent, error) {
+	reader := textproto.NewReader(bufio.NewReader(r))
+	fields, err := reader.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if arrival, err := mail.ParseDate(fields.Get("Arrival-Date")); err == nil {
+		occurredAt = arrival
+	}
+	return &entity.MailEvent{
+		Email:      addressField(fields.Get("Original-Rcpt-To")),
+		Type:       entity.MailEventComplaint,
+		Source:     entity.MailEventSourceMailbox,
+		Status:     strings.TrimSpace(fields.Get("Feedback-Type")),
+		Diagnostic: strings.TrimSpace(fields.Get("User-Agent")),
+		OccurredAt: occurredAt,
+	}, nil
+}
+
+// addressField turns "rfc822; Someone@Example.com" or "<someone@example.com>" into
+// a bare lower-case address.
+func addressField(value string) string {
+	if i := strings.Index(value, ";"); i >= 0 {
+		value = value[i+1:]
+	}
+	value = strings.Trim(strings.TrimSpace(value), "<>")
+	return strings.ToLower(value)
+}
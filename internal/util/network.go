@@ -0,0 +1,43 @@
+package util
+
+import (
+	"net"
+	"strings"
+)
+
+// ParseNetwork reads a CIDR range, or a single address as the range holding
+// only it.
+func ParseNetwork(network string) (*net.IPNet, error) {
+	network = strings.TrimSpace(network)
+	if !strings.Contains(network, "/") {
+		ip := net.ParseIP(network)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: network}
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(network)
+	return ipNet, err
+}
+
+// IPAllowed reports whether ip is in one of the networks. Any address is
+// allowed when there are no networks; networks that do not parse match nothing.
+func IPAllowed(ip string, networks []string) bool {
+	if len(networks) == 0 {
+		return true
+	}
+	address := net.ParseIP(ip)
+	if address == nil {
+		return false
+	}
+	for _, network := range networks {
+		if ipNet, err := ParseNetwork(network); err == nil && ipNet.Contains(address) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ScoreSigningMessage is what lab clients sign to submit a score: a version
+// line followed by the user's email, the assignment id, the score, the payload
+// reference, the nonce and the Unix timestamp, each on its own line.
+func ScoreSigningMessage(email, assignmentID string, score int, payloadRef, nonce string, timestamp int64) []byte {
+	return []byte(strings.Join([]string{
+		"score-v1",
+		email,
+		assignmentID,
+		strconv.Itoa(score),
+		payloadRef,
+		nonce,
+		strconv.FormatInt(timestamp, 10),
+	}, "\n"))
+}
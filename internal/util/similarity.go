@@ -0,0 +1,285 @@
+package util
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Winnowing parameters: fingerprints are hashes of SimilarityK consecutive
+// tokens, and one is kept out of every SimilarityWindow in a row. Any copied
+// run of at least SimilarityK+SimilarityWindow-1 tokens is guaranteed to share
+// a fingerprint.
+const (
+	SimilarityK      = 10
+	SimilarityWindow = 6
+)
+
+var similarityKeywords = map[string]map[string]bool{
+	"python": similarityWords(`False None True and as assert async await break class continue def del
+		elif else except finally for from global if import in is lambda nonlocal not or pass raise return
+		try while with yield print range len self`),
+	"c": similarityWords(`auto break case char const continue default do double else enum extern float
+		for goto if inline int long register restrict return short signed sizeof static struct switch
+		typedef union unsigned void volatile while bool include define printf scanf malloc free NULL`),
+}
+
+func init() {
+	cpp := similarityWords(`alignas alignof and bool catch class constexpr const_cast decltype delete
+		dynamic_cast explicit export false friend mutable namespace new noexcept not nullptr operator or
+		private protected public reinterpret_cast static_assert static_cast template this throw true try
+		typeid typename using virtual std cin cout endl string vector map set pair`)
+	for word := range similarityKeywords["c"] {
+		cpp[word] = true
+	}
+	similarityKeywords["cpp"] = cpp
+}
+
+func similarityWords(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// SourceToken is a normalized token of a program and the line it starts on.
+type SourceToken struct {
+	Text string
+	Line int
+}
+
+// TokenizeSource normalizes a program so that renaming things, changing
+// literals, reformatting and editing comments do not hide copying: comments
+// and whitespace are dropped, identifiers other than the language's keywords
+// become "id", numbers "num" and strings "str". Python programs use # comments
+// and every other language C-style ones.
+func TokenizeSource(language, source string) []SourceToken {
+	keywords := similarityKeywords[language]
+	if keywords == nil {
+		keywords = similarityKeywords["cpp"]
+	}
+	hashComments := language == "python"
+	text := []rune(strings.ReplaceAll(source, "\r\n", "\n"))
+
+	tokens := []SourceToken{}
+	line := 1
+	for i := 0; i < len(text); {
+		r := text[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case hashComments && r == '#', !hashComments && r == '/' && i+1 < len(text) && text[i+1] == '/':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case !hashComments && r == '/' && i+1 < len(text) && text[i+1] == '*':
+			i += 2
+			for i < len(text) && !(text[i] == '*' && i+1 < len(text) && text[i+1] == '/') {
+				if text[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+		case r == '"' || r == '\'':
+			start := line
+			quote := string(r)
+			if hashComments && i+2 < len(text) && text[i+1] == r && text[i+2] == r {
+				quote = strings.Repeat(quote, 3)
+			}
+			i += len(quote)
+			for i < len(text) {
+				if strings.HasPrefix(string(text[i:min(i+len(quote), len(text))]), quote) {
+					i += len(quote)
+					break
+				}
+				if text[i] == '\\' && i+1 < len(text) {
+					if text[i+1] == '\n' {
+						line++
+					}
+					i += 2
+					continue
+				}
+				if text[i] == '\n' {
+					// an unterminated string ends with its line
+					if len(quote) == 1 {
+						break
+					}
+					line++
+				}
+				i++
+			}
+			tokens = append(tokens, SourceToken{Text: "str", Line: start})
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(text) && unicode.IsDigit(text[i+1]):
+			for i < len(text) && (unicode.IsLetter(text[i]) || unicode.IsDigit(text[i]) || text[i] == '.' || text[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, SourceToken{Text: "num", Line: line})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(text) && (unicode.IsLetter(text[i]) || unicode.IsDigit(text[i]) || text[i] == '_') {
+				i++
+			}
+			word := string(text[start:i])
+			if !keywords[word] {
+				word = "id"
+			}
+			tokens = append(tokens, SourceToken{Text: word, Line: line})
+		default:
+			tokens = append(tokens, SourceToken{Text: string(r), Line: line})
+			i++
+		}
+	}
+	return tokens
+}
+
+// Fingerprint is the hash of the SimilarityK tokens starting at Start.
+type Fingerprint struct {
+	Hash  uint64
+	Start int
+}
+
+// Winnow fingerprints a tokenized program: of every SimilarityWindow
+// consecutive k-gram hashes it keeps the smallest, the rightmost one on ties,
+// recording each kept position once. Programs shorter than SimilarityK tokens
+// have no fingerprints.
+func Winnow(tokens []SourceToken) []Fingerprint {
+	if len(tokens) < SimilarityK {
+		return []Fingerprint{}
+	}
+	hashes := make([]uint64, len(tokens)-SimilarityK+1)
+	for i := range hashes {
+		h := fnv.New64a()
+		for _, token := range tokens[i : i+SimilarityK] {
+			h.Write([]byte(token.Text))
+			h.Write([]byte{0})
+		}
+		hashes[i] = h.Sum64()
+	}
+
+	fingerprints := []Fingerprint{}
+	window := min(SimilarityWindow, len(hashes))
+	last := -1
+	for end := window; end <= len(hashes); end++ {
+		smallest := end - window
+		for i := smallest + 1; i < end; i++ {
+			if hashes[i] <= hashes[smallest] {
+				smallest = i
+			}
+		}
+		if smallest != last {
+			fingerprints = append(fingerprints, Fingerprint{Hash: hashes[smallest], Start: smallest})
+			last = smallest
+		}
+	}
+	return fingerprints
+}
+
+// SourceFingerprints is a tokenized program with its fingerprints.
+type SourceFingerprints struct {
+	Tokens       []SourceToken
+	Fingerprints []Fingerprint
+}
+
+func FingerprintSource(language, source string) *SourceFingerprints {
+	tokens := TokenizeSource(language, source)
+	return &SourceFingerprints{Tokens: tokens, Fingerprints: Winnow(tokens)}
+}
+
+// SimilarityRegion is a run of code found in both programs, as line ranges.
+type SimilarityRegion struct {
+	StartA, EndA int
+	StartB, EndB int
+}
+
+// SimilarityMatch is how much of two programs they share: the fraction of
+// each one's fingerprints found in the other, and the regions they share.
+type SimilarityMatch struct {
+	CoverageA float64
+	CoverageB float64
+	Regions   []SimilarityRegion
+}
+
+// Similarity is the larger of the two coverages, so that a program copied
+// whole into a bigger one still stands out.
+func (m *SimilarityMatch) Similarity() float64 {
+	return max(m.CoverageA, m.CoverageB)
+}
+
+// CompareSources matches two fingerprinted programs. Fingerprints in ignore,
+// such as those of the starter code students were given, count for neither
+// program.
+func CompareSources(a, b *SourceFingerprints, ignore map[uint64]bool) *SimilarityMatch {
+	inA, inB := map[uint64]bool{}, map[uint64][]int{}
+	for _, fingerprint := range a.Fingerprints {
+		if !ignore[fingerprint.Hash] {
+			inA[fingerprint.Hash] = true
+		}
+	}
+	for _, fingerprint := range b.Fingerprints {
+		if !ignore[fingerprint.Hash] {
+			inB[fingerprint.Hash] = append(inB[fingerprint.Hash], fingerprint.Start)
+		}
+	}
+	shared := 0
+	for hash := range inA {
+		if inB[hash] != nil {
+			shared++
+		}
+	}
+	match := &SimilarityMatch{Regions: []SimilarityRegion{}}
+	if shared == 0 {
+		return match
+	}
+	match.CoverageA = float64(shared) / float64(len(inA))
+	match.CoverageB = float64(shared) / float64(len(inB))
+
+	// Grow token ranges along a, extending the current one while the next
+	// shared fingerprint continues it in both programs.
+	type run struct{ startA, endA, startB, endB int }
+	runs := []run{}
+	for _, fingerprint := range a.Fingerprints {
+		starts := inB[fingerprint.Hash]
+		if starts == nil || ignore[fingerprint.Hash] {
+			continue
+		}
+		if len(runs) > 0 {
+			current := &runs[len(runs)-1]
+			if fingerprint.Start <= current.endA+SimilarityWindow {
+				extended := false
+				for _, startB := range starts {
+					if startB > current.startB && startB <= current.endB+SimilarityWindow {
+						current.endA = fingerprint.Start + SimilarityK - 1
+						current.endB = max(current.endB, startB+SimilarityK-1)
+						extended = true
+						break
+					}
+				}
+				if extended {
+					continue
+				}
+			}
+		}
+		runs = append(runs, run{fingerprint.Start, fingerprint.Start + SimilarityK - 1, starts[0], starts[0] + SimilarityK - 1})
+	}
+	for _, r := range runs {
+		region := SimilarityRegion{
+			StartA: a.Tokens[r.startA].Line, EndA: a.Tokens[r.endA].Line,
+			StartB: b.Tokens[r.startB].Line, EndB: b.Tokens[r.endB].Line,
+		}
+		if n := len(match.Regions); n > 0 && match.Regions[n-1].StartA <= region.StartA && region.StartA <= match.Regions[n-1].EndA &&
+			match.Regions[n-1].StartB <= region.StartB && region.StartB <= match.Regions[n-1].EndB {
+			match.Regions[n-1].EndA = max(match.Regions[n-1].EndA, region.EndA)
+			match.Regions[n-1].EndB = max(match.Regions[n-1].EndB, region.EndB)
+			continue
+		}
+		match.Regions = append(match.Regions, region)
+	}
+	sort.SliceStable(match.Regions, func(i, j int) bool { return match.Regions[i].StartA < match.Regions[j].StartA })
+	return match
+}
@@ -0,0 +1,201 @@
+package util
+
+import (
+	"slices"
+	"testing"
+)
+
+const similarityProgram = `#include <stdio.h>
+
+int sum(int *values, int count) {
+	int total = 0;
+	for (int i = 0; i < count; i++) {
+		total += values[i];
+	}
+	return total;
+}
+
+int main(void) {
+	int values[] = {3, 1, 4, 1, 5};
+	printf("%d\n", sum(values, 5));
+	return 0;
+}
+`
+
+// similarityRenamed is similarityProgram with its names, literals, comments
+// and layout changed.
+const similarityRenamed = `#include <stdio.h>
+/* adds them up */
+int add_all(int *xs, int n) { int acc = 0;
+	for (int k = 0; k < n; k++) { acc += xs[k]; } // running total
+	return acc;
+}
+int main(void) {
+	int xs[] = {2, 7, 1, 8, 2};
+	printf("total: %d\n", add_all(xs, 5));
+	return 1;
+}
+`
+
+const similarityOther = `def fizzbuzz(limit):
+    for n in range(1, limit + 1):
+        if n % 15 == 0:
+            print("FizzBuzz")
+        elif n % 3 == 0:
+            print("Fizz")
+        elif n % 5 == 0:
+            print("Buzz")
+        else:
+            print(n)
+`
+
+func TestTokenizeSource(t *testing.T) {
+	tests := []struct {
+		language, source string
+		want             []string
+	}{
+		{"c", "int x = 42; // note", []string{"int", "id", "=", "num", ";"}},
+		{"c", "/* a\nb */ y = 'c' + \"d\\\"e\";", []string{"id", "=", "str", "+", "str", ";"}},
+		{"python", "# comment\nx = .5 + 1e3", []string{"id", "=", "num", "+", "num"}},
+		{"python", `s = """a "quoted" b"""`, []string{"id", "=", "str"}},
+		{"python", "s = 'open\nt", []string{"id", "=", "str", "id"}},
+		{"cpp", "std::cout << nullptr;", []string{"std", ":", ":", "cout", "<", "<", "nullptr", ";"}},
+		{"c", "std::cout;", []string{"id", ":", ":", "id", ";"}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, token := range TokenizeSource(tt.language, tt.source) {
+			got = append(got, token.Text)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("TokenizeSource(%q, %q) = %q, want %q", tt.language, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizeSourceLines(t *testing.T) {
+	tokens := TokenizeSource("python", "a = \"\"\"x\ny\"\"\"\nb\r\nc = 'p\\\nq'\nd")
+	lines := map[string][]int{}
+	for _, token := range tokens {
+		lines[token.Text] = append(lines[token.Text], token.Line)
+	}
+	if want := []int{1, 3, 4, 6}; !slices.Equal(lines["id"], want) {
+		t.Errorf("identifier lines = %v, want %v", lines["id"], want)
+	}
+	if want := []int{1, 4}; !slices.Equal(lines["str"], want) {
+		t.Errorf("string lines = %v, want %v", lines["str"], want)
+	}
+}
+
+func TestWinnow(t *testing.T) {
+	same := func(n int) []SourceToken {
+		tokens := make([]SourceToken, n)
+		for i := range tokens {
+			tokens[i] = SourceToken{Text: "id", Line: 1}
+		}
+		return tokens
+	}
+	tests := []struct {
+		name   string
+		tokens []SourceToken
+		starts []int
+	}{
+		{"shorter than k", same(SimilarityK - 1), []int{}},
+		{"exactly k", same(SimilarityK), []int{0}},
+		{"fewer hashes than a window", same(SimilarityK + 2), []int{2}},
+		// Every hash ties, so each window keeps its rightmost position.
+		{"ties keep the rightmost", same(SimilarityK + SimilarityWindow + 2), []int{5, 6, 7, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starts := []int{}
+			for _, fingerprint := range Winnow(tt.tokens) {
+				starts = append(starts, fingerprint.Start)
+			}
+			if !slices.Equal(starts, tt.starts) {
+				t.Errorf("fingerprint starts = %v, want %v", starts, tt.starts)
+			}
+		})
+	}
+}
+
+func TestWinnowCoversEveryWindow(t *testing.T) {
+	tokens := TokenizeSource("c", similarityProgram)
+	fingerprints := Winnow(tokens)
+	hashes := len(tokens) - SimilarityK + 1
+	for i := 1; i < len(fingerprints); i++ {
+		if fingerprints[i].Start <= fingerprints[i-1].Start {
+			t.Fatalf("fingerprint starts not increasing: %d after %d", fingerprints[i].Start, fingerprints[i-1].Start)
+		}
+	}
+	for end := SimilarityWindow; end <= hashes; end++ {
+		covered := slices.ContainsFunc(fingerprints, func(f Fingerprint) bool {
+			return f.Start >= end-SimilarityWindow && f.Start < end
+		})
+		if !covered {
+			t.Errorf("window [%d, %d) has no fingerprint", end-SimilarityWindow, end)
+		}
+	}
+}
+
+func TestCompareSources(t *testing.T) {
+	program := FingerprintSource("c", similarityProgram)
+	renamed := FingerprintSource("c", similarityRenamed)
+	other := FingerprintSource("python", similarityOther)
+	// The program with a function of the student's own added after it. Every
+	// window of the program is one of the bigger program, so all of its
+	// fingerprints are found.
+	bigger := FingerprintSource("c", similarityProgram+"\nint unused(int a, int b) {\n\tif (a > b) {\n\t\treturn a - b;\n\t}\n\twhile (b > 0) {\n\t\tb--;\n\t}\n\treturn b * a;\n}\n")
+
+	tests := []struct {
+		name      string
+		a, b      *SourceFingerprints
+		ignore    map[uint64]bool
+		coverageA float64
+		coverageB float64 // -1 when only below coverageA
+		regions   int
+	}{
+		{"identical", program, program, nil, 1, 1, 1},
+		{"renamed and reformatted", program, renamed, nil, 1, 1, 1},
+		{"unrelated", program, other, nil, 0, 0, 0},
+		{"all starter code", program, renamed, fingerprintSet(program), 0, 0, 0},
+		{"copied into a bigger program", program, bigger, nil, 1, -1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := CompareSources(tt.a, tt.b, tt.ignore)
+			if match.CoverageA != tt.coverageA {
+				t.Errorf("CoverageA = %v, want %v", match.CoverageA, tt.coverageA)
+			}
+			if tt.coverageB < 0 {
+				if match.CoverageB <= 0 || match.CoverageB >= match.CoverageA {
+					t.Errorf("CoverageB = %v, want between 0 and %v", match.CoverageB, match.CoverageA)
+				}
+			} else if match.CoverageB != tt.coverageB {
+				t.Errorf("CoverageB = %v, want %v", match.CoverageB, tt.coverageB)
+			}
+			if match.Similarity() != max(match.CoverageA, match.CoverageB) {
+				t.Errorf("Similarity() = %v, want the larger coverage", match.Similarity())
+			}
+			if len(match.Regions) != tt.regions {
+				t.Errorf("got %d regions %+v, want %d", len(match.Regions), match.Regions, tt.regions)
+			}
+		})
+	}
+
+	match := CompareSources(program, bigger, nil)
+	if len(match.Regions) == 1 {
+		region := match.Regions[0]
+		if region.StartA != region.StartB || region.EndA != region.EndB || region.EndB > 15 {
+			t.Errorf("region = %+v, want it within the pasted code", region)
+		}
+	}
+}
+
+func fingerprintSet(source *SourceFingerprints) map[uint64]bool {
+	set := map[uint64]bool{}
+	for _, fingerprint := range source.Fingerprints {
+		set[fingerprint.Hash] = true
+	}
+	return set
+}
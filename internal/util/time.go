@@ -2,10 +2,36 @@ package util
 
 import "time"
 
+const DefaultTimezone = "Asia/Jakarta"
+
 func NowInWIB() time.Time {
-	location, err := time.LoadLocation("Asia/Jakarta")
+	location, err := time.LoadLocation(DefaultTimezone)
 	if err != nil {
 		return time.Now()
 	}
 	return time.Now().In(location)
 }
+
+// LoadLocation resolves an IANA timezone name, falling back to WIB when the name
+// is empty or unknown so callers always get a usable location.
+func LoadLocation(timezone string) *time.Location {
+	if timezone != "" {
+		if location, err := time.LoadLocation(timezone); err == nil {
+			return location
+		}
+	}
+	location, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+func NowIn(timezone string) time.Time {
+	return time.Now().In(LoadLocation(timezone))
+}
+
+func IsValidTimezone(timezone string) bool {
+	_, err := time.LoadLocation(timezone)
+	return timezone != "" && err == nil
+}
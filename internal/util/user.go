@@ -104,13 +104,17 @@ func VerifyOTPRegister(hashedOTP, inputOTP string) bool {
 	return err == nil
 }
 func SendOTPRegister(email, otp string, viperConfig *viper.Viper) error {
+	subject := "Email Registration Confirmation"
+	body := fmt.Sprintf("Thanks for your registration. Please put your OTP for verification process:\n\nOTP: %s\n\nThis OTP is valid for 15 minutes.", otp)
+	return SendMail(email, subject, body, viperConfig)
+}
+
+func SendMail(email, subject, body string, viperConfig *viper.Viper) error {
 	smtpHost := viperConfig.GetString("SMTP_HOST")
 	smtpPort := viperConfig.GetString("SMTP_PORT")
 	smtpUser := viperConfig.GetString("SMTP_USER")
 	smtpPass := viperConfig.GetString("SMTP_PASS")
 
-	subject := "Email Registration Confirmation"
-	body := fmt.Sprintf("Thanks for your registration. Please put your OTP for verification process:\n\nOTP: %s\n\nThis OTP is valid for 15 minutes.", otp)
 	msg := []byte("To: " + email + "\r\n" +
 		"Subject: " + subject + "\r\n" +
 		"\r\n" +
@@ -0,0 +1,132 @@
+package util
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// XLSXWriter streams a workbook with a single sheet. The sheet is the last part
+// of the archive, so rows go straight to the underlying writer and memory use
+// does not grow with the number of rows.
+type XLSXWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+	row   int
+}
+
+func NewXLSXWriter(w io.Writer, sheetName string) (*XLSXWriter, error) {
+	archive := zip.NewWriter(w)
+	if runes := []rune(sheetName); len(runes) > 31 {
+		sheetName = string(runes[:31])
+	}
+	parts := []struct{ path, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, xmlEscape(sheetName))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.WriteString(sheet, xlsxSheetStart); err != nil {
+		return nil, err
+	}
+	return &XLSXWriter{zip: archive, sheet: sheet}, nil
+}
+
+// WriteRow appends a row. Strings become text cells, integers and floats number
+// cells, and nil an empty cell.
+func (x *XLSXWriter) WriteRow(cells []any) error {
+	x.row++
+	if _, err := fmt.Fprintf(x.sheet, `<row r="%d">`, x.row); err != nil {
+		return err
+	}
+	for i, cell := range cells {
+		ref := XLSXColumn(i) + strconv.Itoa(x.row)
+		var err error
+		switch value := cell.(type) {
+		case nil:
+			continue
+		case string:
+			_, err = fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(value))
+		case int:
+			_, err = fmt.Fprintf(x.sheet, `<c r="%s"><v>%d</v></c>`, ref, value)
+		case float64:
+			_, err = fmt.Fprintf(x.sheet, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(value, 'f', -1, 64))
+		default:
+			_, err = fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, xmlEscape(fmt.Sprint(value)))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(x.sheet, `</row>`)
+	return err
+}
+
+// Flush pushes the rows written so far to the underlying writer.
+func (x *XLSXWriter) Flush() error {
+	return x.zip.Flush()
+}
+
+// Close ends the sheet and writes the archive's central directory.
+func (x *XLSXWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// XLSXColumn turns a zero-based column index into its letters: 0 is A, 26 is AA.
+func XLSXColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func xmlEscape(text string) string {
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}